@@ -21,6 +21,13 @@ import (
 // RunConfig holds config for the server
 type RunConfig struct {
 	Server        *runtime.ServerConfig
+	Grpc          *runtime.GrpcConfig
+	Audit         *runtime.AuditConfig
+	Tracing       *runtime.TracingConfig
+	Egress        *runtime.EgressConfig
+	Upstream      *runtime.UpstreamConfig
+	Webhook       *runtime.WebhookConfig
+	Metrics       *runtime.MetricsConfig
 	KubernetesCfg *k8s.KubernetesCfg
 }
 
@@ -28,6 +35,13 @@ type RunConfig struct {
 func NewRunConfig() *RunConfig {
 	return &RunConfig{
 		Server:        runtime.NewServerConfig(),
+		Grpc:          runtime.NewGrpcConfig(),
+		Audit:         runtime.NewAuditConfig(),
+		Tracing:       runtime.NewTracingConfig(),
+		Egress:        runtime.NewEgressConfig(),
+		Upstream:      runtime.NewUpstreamConfig(),
+		Webhook:       runtime.NewWebhookConfig(),
+		Metrics:       runtime.NewMetricsConfig(),
 		KubernetesCfg: k8s.NewKubernetesCfg(),
 	}
 }
@@ -36,6 +50,12 @@ func NewRunConfig() *RunConfig {
 func (cfg *RunConfig) Validate() []error {
 	var errs []error
 	errs = append(errs, cfg.Server.Validate()...)
+	errs = append(errs, cfg.Grpc.Validate()...)
+	errs = append(errs, cfg.Tracing.Validate()...)
+	errs = append(errs, cfg.Egress.Validate()...)
+	errs = append(errs, cfg.Upstream.Validate()...)
+	errs = append(errs, cfg.Webhook.Validate()...)
+	errs = append(errs, cfg.Metrics.Validate()...)
 	errs = append(errs, cfg.KubernetesCfg.Validate()...)
 	return errs
 }
@@ -14,12 +14,18 @@ package main
 
 import (
 	"context"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"marketplace-service/cmd/config"
 	"marketplace-service/pkg/server"
+	"marketplace-service/pkg/tracing"
 	"marketplace-service/pkg/zlog"
 )
 
+const tracingFlushTimeout = 5 * time.Second
+
 func main() {
 	defer zlog.Sync()
 	// 创建http server、各种资源操作的配置对象，目前只实现了k8s的配置对象
@@ -28,7 +34,20 @@ func main() {
 		zlog.Fatalf("Failed to Validate RunConfig: %v", errs)
 	}
 
-	ctx := context.TODO()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	shutdownTracing, err := tracing.Init(ctx, runOptions.Tracing)
+	if err != nil {
+		zlog.Fatalf("Failed to init tracing: %v", err)
+	}
+	defer func() {
+		// flush spans of the last requests
+		flushCtx, cancel := context.WithTimeout(context.Background(), tracingFlushTimeout)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			zlog.Warnf("Failed to flush spans: %v", err)
+		}
+	}()
 	marketplaceServer, err := server.NewServer(runOptions, ctx)
 	if err != nil {
 		zlog.Fatalf("Failed to NewServer: %v", err)
@@ -13,10 +13,15 @@
 package v1beta1
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
 
 	"github.com/emicklei/go-restful/v3"
+	"sigs.k8s.io/yaml"
 
+	"marketplace-service/pkg/audit"
 	"marketplace-service/pkg/constant"
 	"marketplace-service/pkg/helm"
 	helmModel "marketplace-service/pkg/models/helm"
@@ -26,15 +31,27 @@ import (
 	"marketplace-service/pkg/zlog"
 )
 
+const (
+	mimeYAML = httputil.MimeYAML
+	// repoFileSizeLimit largest repositories.yaml accepted for import
+	repoFileSizeLimit = 4 << 20
+)
+
 // Handler helm handler that contains all the helm operations
 type Handler struct {
 	HelmHandler helm.Operation
+	Auditor     *audit.Auditor
 }
 
 func newHandler(handler helm.Operation) *Handler {
 	return &Handler{HelmHandler: handler}
 }
 
+// operation helm operations bound to the context of the request
+func (h *Handler) operation(request *restful.Request) helm.Operation {
+	return h.HelmHandler.WithContext(request.Request.Context())
+}
+
 func (h *Handler) listHelmRepo(request *restful.Request, response *restful.Response) {
 	query := param.ParseQueryParameter(request)
 	repo := request.QueryParameter(param.Repository)
@@ -42,21 +59,21 @@ func (h *Handler) listHelmRepo(request *restful.Request, response *restful.Respo
 		_ = response.WriteHeaderAndEntity(http.StatusBadRequest, httputil.GetDefaultClientFailureResponseJson())
 		return
 	}
-	result, status := h.HelmHandler.ListRepo(query, repo)
-	_ = response.WriteHeaderAndEntity(status, result)
+	result, status := h.operation(request).ListRepo(query, repo)
+	_ = response.WriteHeaderAndEntity(status, httputil.Scrubbed{Value: result})
 }
 
 func (h *Handler) getHelmRepo(request *restful.Request, response *restful.Response) {
 	repoName := util.EscapeSpecialChars(request.PathParameter(param.Repository))
-	result, status := h.HelmHandler.GetRepo(repoName)
-	_ = response.WriteHeaderAndEntity(status, result)
+	result, status := h.operation(request).GetRepo(repoName)
+	_ = response.WriteHeaderAndEntity(status, httputil.Scrubbed{Value: result})
 }
 
 func (h *Handler) createHelmRepo(request *restful.Request, response *restful.Response) {
 	repoEntry := &helmModel.SafeRepoEntry{}
 	err := request.ReadEntity(repoEntry)
 	if err != nil {
-		zlog.Warnf("invalid input, %v", err)
+		zlog.FromContext(request.Request.Context()).Warnf("invalid input, %v", err)
 		_ = response.WriteHeaderAndEntity(http.StatusBadRequest, httputil.ResponseJson{
 			Code: constant.ClientError,
 			Msg:  "please provide proper input",
@@ -64,8 +81,24 @@ func (h *Handler) createHelmRepo(request *restful.Request, response *restful.Res
 		return
 	}
 	sanitizeRepoEntry(repoEntry)
-	result, status := h.HelmHandler.CreateRepo(repoEntry)
-	_ = response.WriteHeaderAndEntity(status, result)
+	result, status := h.operation(request).CreateRepo(repoEntry)
+	_ = response.WriteHeaderAndEntity(status, httputil.Scrubbed{Value: result})
+}
+
+func (h *Handler) testHelmRepo(request *restful.Request, response *restful.Response) {
+	repoEntry := &helmModel.SafeRepoEntry{}
+	err := request.ReadEntity(repoEntry)
+	if err != nil {
+		zlog.FromContext(request.Request.Context()).Warnf("invalid input, %v", err)
+		_ = response.WriteHeaderAndEntity(http.StatusBadRequest, httputil.ResponseJson{
+			Code: constant.ClientError,
+			Msg:  "please provide proper input",
+		})
+		return
+	}
+	sanitizeRepoEntry(repoEntry)
+	result, status := h.operation(request).TestRepo(repoEntry)
+	_ = response.WriteHeaderAndEntity(status, httputil.Scrubbed{Value: result})
 }
 
 func sanitizeRepoEntry(entry *helmModel.SafeRepoEntry) {
@@ -78,7 +111,7 @@ func (h *Handler) updateHelmRepo(request *restful.Request, response *restful.Res
 	err := request.ReadEntity(repoEntry)
 	repoEntry.Name = request.PathParameter(param.Repository)
 	if err != nil {
-		zlog.Warnf("invalid input, %v", err)
+		zlog.FromContext(request.Request.Context()).Warnf("invalid input, %v", err)
 		_ = response.WriteHeaderAndEntity(http.StatusBadRequest, httputil.ResponseJson{
 			Code: constant.ClientError,
 			Msg:  "proper input is needed",
@@ -86,30 +119,73 @@ func (h *Handler) updateHelmRepo(request *restful.Request, response *restful.Res
 		return
 	}
 	sanitizeRepoEntry(repoEntry)
-	result, status := h.HelmHandler.UpdateRepo(repoEntry)
-	_ = response.WriteHeaderAndEntity(status, result)
+	result, status := h.operation(request).UpdateRepo(repoEntry)
+	_ = response.WriteHeaderAndEntity(status, httputil.Scrubbed{Value: result})
 }
 
 func (h *Handler) deleteHelmRepo(request *restful.Request, response *restful.Response) {
 	repoName := util.EscapeSpecialChars(request.PathParameter(param.Repository))
-	result, status := h.HelmHandler.DeleteRepo(repoName)
+	result, status := h.operation(request).DeleteRepo(repoName)
 	_ = response.WriteHeaderAndEntity(status, result)
 }
 
 func (h *Handler) syncAllHelmRepo(request *restful.Request, response *restful.Response) {
-	result, status := h.HelmHandler.SyncAllRepos()
+	result, status := h.operation(request).SyncAllRepos()
 	_ = response.WriteHeaderAndEntity(status, result)
 }
 
+func (h *Handler) rotateRepoCredentials(request *restful.Request, response *restful.Response) {
+	result, status := h.operation(request).RotateRepoCredentials()
+	_ = response.WriteHeaderAndEntity(status, result)
+}
+
+func (h *Handler) exportHelmRepos(request *restful.Request, response *restful.Response) {
+	result, status := h.operation(request).ExportRepos(request.QueryParameter(param.Credentials))
+	if status != http.StatusOK {
+		_ = response.WriteHeaderAndEntity(status, result)
+		return
+	}
+	data, err := yaml.Marshal(result.Data)
+	if err != nil {
+		zlog.FromContext(request.Request.Context()).Errorf("marshal repositories file failed, %v", err)
+		_ = response.WriteHeaderAndEntity(http.StatusInternalServerError,
+			httputil.GetDefaultServerFailureResponseJson())
+		return
+	}
+	response.AddHeader(restful.HEADER_ContentType, mimeYAML)
+	response.AddHeader("Content-Disposition", "attachment; filename=repositories.yaml")
+	response.WriteHeader(http.StatusOK)
+	_, _ = response.Write(data)
+}
+
+func (h *Handler) importHelmRepos(request *restful.Request, response *restful.Response) {
+	data, err := io.ReadAll(io.LimitReader(request.Request.Body, repoFileSizeLimit+1))
+	if err != nil || len(data) > repoFileSizeLimit {
+		zlog.FromContext(request.Request.Context()).Warnf("invalid repositories file, %v", err)
+		_ = response.WriteHeaderAndEntity(http.StatusBadRequest, httputil.ResponseJson{
+			Code: constant.ClientError,
+			Msg:  "please provide a repositories.yaml of at most 4MiB",
+		})
+		return
+	}
+	dryRun, err := strconv.ParseBool(request.QueryParameter(param.DryRun))
+	if err != nil && request.QueryParameter(param.DryRun) != "" {
+		_ = response.WriteHeaderAndEntity(http.StatusBadRequest, httputil.GetDefaultClientFailureResponseJson())
+		return
+	}
+	result, status := h.operation(request).ImportRepos(data, dryRun)
+	_ = response.WriteHeaderAndEntity(status, httputil.Scrubbed{Value: result})
+}
+
 func (h *Handler) syncHelmRepo(request *restful.Request, response *restful.Response) {
 	repoName := util.EscapeSpecialChars(request.PathParameter(param.Repository))
-	result, status := h.HelmHandler.SyncRepo(repoName)
+	result, status := h.operation(request).SyncRepo(repoName)
 	_ = response.WriteHeaderAndEntity(status, result)
 }
 
 func (h *Handler) getRepoSyncStatus(request *restful.Request, response *restful.Response) {
 	repoName := util.EscapeSpecialChars(request.PathParameter(param.Repository))
-	result, status := h.HelmHandler.GetRepoSyncStatus(repoName)
+	result, status := h.operation(request).GetRepoSyncStatus(repoName)
 	_ = response.WriteHeaderAndEntity(status, result)
 }
 
@@ -126,7 +202,7 @@ func (h *Handler) getLatestCharts(request *restful.Request, response *restful.Re
 		return
 	}
 
-	result, status := h.HelmHandler.GetLatestCharts(searchParam)
+	result, status := h.operation(request).GetLatestCharts(searchParam)
 	_ = response.WriteHeaderAndEntity(status, result)
 }
 
@@ -134,7 +210,7 @@ func (h *Handler) getChartsWithOfficialTags(request *restful.Request, response *
 	tags := util.SanitizeArray(request.QueryParameters(param.Tag))
 	query := param.ParseQueryParameter(request)
 
-	result, status := h.HelmHandler.GetChartsWithOfficialTags(tags, query)
+	result, status := h.operation(request).GetChartsWithOfficialTags(tags, query)
 	_ = response.WriteHeaderAndEntity(status, result)
 }
 
@@ -145,32 +221,32 @@ func (h *Handler) uploadChart(request *restful.Request, response *restful.Respon
 		return
 	}
 	defer formFile.Close()
-	result, status := h.HelmHandler.UploadChart(formFile, fileHeader)
+	result, status := h.operation(request).UploadChart(formFile, fileHeader)
 	_ = response.WriteHeaderAndEntity(status, result)
 }
 
 func (h *Handler) countChart(request *restful.Request, response *restful.Response) {
-	result, status := h.HelmHandler.CountCharts()
+	result, status := h.operation(request).CountCharts()
 	_ = response.WriteHeaderAndEntity(status, result)
 }
 
 func (h *Handler) deleteChart(request *restful.Request, response *restful.Response) {
 	chartName := util.EscapeSpecialChars(request.PathParameter(param.Chart))
-	result, status := h.HelmHandler.DeleteChart(chartName)
+	result, status := h.operation(request).DeleteChart(chartName)
 	_ = response.WriteHeaderAndEntity(status, result)
 }
 
 func (h *Handler) deleteChartVersions(request *restful.Request, response *restful.Response) {
 	chartName := util.EscapeSpecialChars(request.PathParameter(param.Chart))
 	version := util.EscapeSpecialChars(request.PathParameter(param.Version))
-	result, status := h.HelmHandler.DeleteChartVersion(chartName, version)
+	result, status := h.operation(request).DeleteChartVersion(chartName, version)
 	_ = response.WriteHeaderAndEntity(status, result)
 }
 
 func (h *Handler) getChartVersions(request *restful.Request, response *restful.Response) {
 	repoName := util.EscapeSpecialChars(request.PathParameter(param.Repository))
 	chart := util.EscapeSpecialChars(request.PathParameter(param.Chart))
-	result, status := h.HelmHandler.GetChartVersions(repoName, chart)
+	result, status := h.operation(request).GetChartVersions(repoName, chart)
 	_ = response.WriteHeaderAndEntity(status, result)
 }
 
@@ -178,7 +254,7 @@ func (h *Handler) getChartVersion(request *restful.Request, response *restful.Re
 	repoName := util.EscapeSpecialChars(request.PathParameter(param.Repository))
 	chart := util.EscapeSpecialChars(request.PathParameter(param.Chart))
 	version := util.EscapeSpecialChars(request.PathParameter(param.Version))
-	result, status := h.HelmHandler.GetChartVersion(repoName, chart, version)
+	result, status := h.operation(request).GetChartVersion(repoName, chart, version)
 	_ = response.WriteHeaderAndEntity(status, result)
 }
 
@@ -187,6 +263,183 @@ func (h *Handler) getChartFiles(request *restful.Request, response *restful.Resp
 	chart := util.EscapeSpecialChars(request.PathParameter(param.Chart))
 	version := util.EscapeSpecialChars(request.PathParameter(param.Version))
 	fileType := util.EscapeSpecialChars(request.QueryParameter(param.FileType))
-	result, status := h.HelmHandler.GetChartFiles(repoName, chart, version, fileType)
+	result, status := h.operation(request).GetChartFiles(repoName, chart, version, fileType)
 	_ = response.WriteHeaderAndEntity(status, result)
 }
+
+func (h *Handler) checkChartCRDs(request *restful.Request, response *restful.Response) {
+	repoName := util.EscapeSpecialChars(request.PathParameter(param.Repository))
+	chart := util.EscapeSpecialChars(request.PathParameter(param.Chart))
+	version := util.EscapeSpecialChars(request.PathParameter(param.Version))
+	result, status := h.operation(request).CheckChartCRDs(repoName, chart, version)
+	_ = response.WriteHeaderAndEntity(status, result)
+}
+
+func (h *Handler) checkChartCompatibility(request *restful.Request, response *restful.Response) {
+	repoName := util.EscapeSpecialChars(request.PathParameter(param.Repository))
+	chart := util.EscapeSpecialChars(request.PathParameter(param.Chart))
+	version := util.EscapeSpecialChars(request.PathParameter(param.Version))
+	result, status := h.operation(request).CheckChartCompatibility(repoName, chart, version)
+	_ = response.WriteHeaderAndEntity(status, result)
+}
+
+func (h *Handler) checkChartDeprecations(request *restful.Request, response *restful.Response) {
+	repoName := util.EscapeSpecialChars(request.PathParameter(param.Repository))
+	chart := util.EscapeSpecialChars(request.PathParameter(param.Chart))
+	version := util.EscapeSpecialChars(request.PathParameter(param.Version))
+	targetVersion := util.EscapeSpecialChars(request.QueryParameter(param.TargetVersion))
+	result, status := h.operation(request).CheckChartDeprecations(repoName, chart, version, targetVersion)
+	_ = response.WriteHeaderAndEntity(status, result)
+}
+
+func (h *Handler) estimateChartResources(request *restful.Request, response *restful.Response) {
+	repoName := util.EscapeSpecialChars(request.PathParameter(param.Repository))
+	chart := util.EscapeSpecialChars(request.PathParameter(param.Chart))
+	version := util.EscapeSpecialChars(request.PathParameter(param.Version))
+	estimateRequest := &helmModel.ResourceEstimateRequest{}
+	if err := readOptionalEntity(request, estimateRequest); err != nil {
+		zlog.FromContext(request.Request.Context()).Warnf("invalid input, %v", err)
+		_ = response.WriteHeaderAndEntity(http.StatusBadRequest, httputil.ResponseJson{
+			Code: constant.ClientError,
+			Msg:  "please provide proper input",
+		})
+		return
+	}
+	result, status := h.operation(request).EstimateChartResources(repoName, chart, version, estimateRequest)
+	_ = response.WriteHeaderAndEntity(status, result)
+}
+
+func (h *Handler) createReleaseSnapshots(request *restful.Request, response *restful.Response) {
+	namespace := util.EscapeSpecialChars(request.PathParameter(param.Namespace))
+	release := util.EscapeSpecialChars(request.PathParameter(param.Release))
+	snapshotRequest := &helmModel.SnapshotRequest{}
+	if err := readOptionalEntity(request, snapshotRequest); err != nil {
+		zlog.FromContext(request.Request.Context()).Warnf("invalid input, %v", err)
+		_ = response.WriteHeaderAndEntity(http.StatusBadRequest, httputil.ResponseJson{
+			Code: constant.ClientError,
+			Msg:  "please provide proper input",
+		})
+		return
+	}
+	snapshotRequest.VolumeSnapshotClassName = util.EscapeSpecialChars(snapshotRequest.VolumeSnapshotClassName)
+	result, status := h.operation(request).CreateReleaseSnapshots(namespace, release, snapshotRequest)
+	_ = response.WriteHeaderAndEntity(status, result)
+}
+
+func (h *Handler) listReleaseSnapshots(request *restful.Request, response *restful.Response) {
+	namespace := util.EscapeSpecialChars(request.PathParameter(param.Namespace))
+	release := util.EscapeSpecialChars(request.PathParameter(param.Release))
+	revision := 0
+	if revisionParam := request.QueryParameter(param.Revision); revisionParam != "" {
+		var err error
+		revision, err = strconv.Atoi(revisionParam)
+		if err != nil || revision < 0 {
+			_ = response.WriteHeaderAndEntity(http.StatusBadRequest, httputil.GetDefaultClientFailureResponseJson())
+			return
+		}
+	}
+	result, status := h.operation(request).ListReleaseSnapshots(namespace, release, revision)
+	_ = response.WriteHeaderAndEntity(status, result)
+}
+
+func (h *Handler) restoreReleaseSnapshots(request *restful.Request, response *restful.Response) {
+	namespace := util.EscapeSpecialChars(request.PathParameter(param.Namespace))
+	release := util.EscapeSpecialChars(request.PathParameter(param.Release))
+	revision, err := strconv.Atoi(request.PathParameter(param.Revision))
+	if err != nil || revision <= 0 {
+		_ = response.WriteHeaderAndEntity(http.StatusBadRequest, httputil.GetDefaultClientFailureResponseJson())
+		return
+	}
+	restoreRequest := &helmModel.SnapshotRestoreRequest{}
+	if err = readOptionalEntity(request, restoreRequest); err != nil {
+		zlog.FromContext(request.Request.Context()).Warnf("invalid input, %v", err)
+		_ = response.WriteHeaderAndEntity(http.StatusBadRequest, httputil.ResponseJson{
+			Code: constant.ClientError,
+			Msg:  "please provide proper input",
+		})
+		return
+	}
+	result, status := h.operation(request).RestoreReleaseSnapshots(namespace, release, revision, restoreRequest)
+	_ = response.WriteHeaderAndEntity(status, result)
+}
+
+// readOptionalEntity read request body into entity, an empty body keeps the entity defaults
+func readOptionalEntity(request *restful.Request, entity interface{}) error {
+	if request.Request.Body == nil || request.Request.ContentLength == 0 {
+		return nil
+	}
+	err := request.ReadEntity(entity)
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	return err
+}
+
+func (h *Handler) listAuditEvents(request *restful.Request, response *restful.Response) {
+	if h.Auditor == nil {
+		_ = response.WriteHeaderAndEntity(http.StatusNotFound, httputil.ResponseJson{
+			Code: constant.ResourceNotFound,
+			Msg:  "audit is not enabled",
+		})
+		return
+	}
+	query := &audit.Query{
+		User:   request.QueryParameter(param.User),
+		Action: request.QueryParameter(param.Action),
+		Repo:   util.EscapeSpecialChars(request.QueryParameter(param.Repository)),
+		Chart:  util.EscapeSpecialChars(request.QueryParameter(param.Chart)),
+	}
+	if limit := request.QueryParameter(param.Limit); limit != "" {
+		var err error
+		if query.Limit, err = strconv.Atoi(limit); err != nil || query.Limit < 0 {
+			_ = response.WriteHeaderAndEntity(http.StatusBadRequest, httputil.GetDefaultClientFailureResponseJson())
+			return
+		}
+	}
+	_ = response.WriteHeaderAndEntity(http.StatusOK, httputil.Scrubbed{Value: httputil.ResponseJson{
+		Code: constant.Success,
+		Msg:  "success",
+		Data: h.Auditor.Recent(query),
+	}})
+}
+
+func (h *Handler) listLogLevels(_ *restful.Request, response *restful.Response) {
+	_ = response.WriteHeaderAndEntity(http.StatusOK, httputil.ResponseJson{
+		Code: constant.Success,
+		Msg:  "success",
+		Data: zlog.Levels(),
+	})
+}
+
+func (h *Handler) updateLogLevel(request *restful.Request, response *restful.Response) {
+	setting := &zlog.LevelSetting{}
+	if err := request.ReadEntity(setting); err != nil {
+		zlog.FromContext(request.Request.Context()).Warnf("invalid input, %v", err)
+		_ = response.WriteHeaderAndEntity(http.StatusBadRequest, httputil.GetDefaultClientFailureResponseJson())
+		return
+	}
+	setting.Package = request.PathParameter(param.Package)
+	if err := zlog.SetLevel(setting.Package, setting.Level); err != nil {
+		_ = response.WriteHeaderAndEntity(http.StatusBadRequest, httputil.ResponseJson{
+			Code: constant.ClientError,
+			Msg:  err.Error(),
+		})
+		return
+	}
+	zlog.FromContext(request.Request.Context()).Infof("log level of %s set to %s", setting.Package, setting.Level)
+	h.listLogLevels(request, response)
+}
+
+func (h *Handler) resetLogLevel(request *restful.Request, response *restful.Response) {
+	pkg := request.PathParameter(param.Package)
+	if pkg == zlog.DefaultPackage {
+		_ = response.WriteHeaderAndEntity(http.StatusBadRequest, httputil.ResponseJson{
+			Code: constant.ClientError,
+			Msg:  "the default level is reset by the log config",
+		})
+		return
+	}
+	zlog.ResetLevel(pkg)
+	zlog.FromContext(request.Request.Context()).Infof("log level of %s reset", pkg)
+	h.listLogLevels(request, response)
+}
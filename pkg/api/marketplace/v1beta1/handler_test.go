@@ -30,7 +30,7 @@ func TestHelloHandler(t *testing.T) {
 	config := &rest.Config{
 		Host: "https://fake-kubernetes-server",
 	}
-	_ = BindMarketPlaceRoute(marketplaceServiceWebService, config)
+	_ = BindMarketPlaceRoute(marketplaceServiceWebService, config, nil)
 	container.Add(marketplaceServiceWebService)
 
 	// 创建一个模拟请求
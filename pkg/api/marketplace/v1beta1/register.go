@@ -20,23 +20,31 @@ import (
 	"github.com/emicklei/go-restful/v3"
 	"k8s.io/client-go/rest"
 
+	"marketplace-service/pkg/audit"
+	"marketplace-service/pkg/auth"
 	"marketplace-service/pkg/helm"
+	helmModel "marketplace-service/pkg/models/helm"
 	"marketplace-service/pkg/server/param"
 	"marketplace-service/pkg/zlog"
 )
 
 // BindMarketPlaceRoute bind webservice route to container
-func BindMarketPlaceRoute(webService *restful.WebService, kubeConfig *rest.Config) *Handler {
+func BindMarketPlaceRoute(webService *restful.WebService, kubeConfig *rest.Config, auditor *audit.Auditor) *Handler {
 	operation, err := helm.NewHelmOperation(kubeConfig)
 	if err != nil {
 		zlog.Fatalf("helm handler init failed, err: %v", err)
 	}
 	handler := newHandler(operation)
+	handler.Auditor = auditor
+	handler.HelmHandler.LabelRepoReferences()
 	_, _ = handler.HelmHandler.SyncAllRepos()
 
 	bindHelmChartsRoute(webService, handler)
 	bindHelmRepoGetRoutes(webService, handler)
 	bindHelmRepoPostRoutes(webService, handler)
+	bindReleaseSnapshotRoutes(webService, handler)
+	bindAuditRoutes(webService, handler)
+	bindLogLevelRoutes(webService, handler)
 	return handler
 }
 
@@ -51,6 +59,7 @@ func bindHelmChartsRoute(webService *restful.WebService, handler *Handler) {
 		Param(webService.QueryParameter(param.Page, "page").Required(false).
 			DataFormat("page=%d").DefaultValue("page=1")).
 		Param(webService.QueryParameter(param.Limit, "limit").Required(false)).
+		Metadata(auth.MetadataKey, auth.NewAttributes(auth.ResourceHelmCharts, auth.VerbList)).
 		To(handler.getLatestCharts))
 
 	webService.Route(webService.GET("/helm-charts/official-tags").
@@ -59,27 +68,35 @@ func bindHelmChartsRoute(webService *restful.WebService, handler *Handler) {
 		Param(webService.QueryParameter(param.Page, "page").Required(false).
 			DataFormat("page=%d").DefaultValue("page=1")).
 		Param(webService.QueryParameter(param.Limit, "limit").Required(false)).
+		Metadata(auth.MetadataKey, auth.NewAttributes(auth.ResourceHelmCharts, auth.VerbList)).
 		To(handler.getChartsWithOfficialTags))
 
 	webService.Route(webService.GET("/helm-charts/count").
 		Doc("number of helm charts in local registry").
+		Metadata(auth.MetadataKey, auth.NewAttributes(auth.ResourceHelmCharts, auth.VerbList)).
 		To(handler.countChart))
 
 	webService.Route(webService.POST("/helm-charts").
 		Doc("upload helm chart").
 		Param(webService.MultiPartFormParameter(param.Chart, "helm chart").Required(true)).
 		Consumes("multipart/form-data").
+		Metadata(auth.MetadataKey, auth.NewAttributes(auth.ResourceHelmCharts, auth.VerbCreate)).
+		Metadata(audit.MetadataKey, audit.ActionChartUpload).
 		To(handler.uploadChart))
 
 	webService.Route(webService.DELETE("/helm-charts/{chart}").
 		Doc("delete helm chart versions").
 		Param(webService.PathParameter(param.Chart, "deleted chart name").Required(true)).
+		Metadata(auth.MetadataKey, auth.NewAttributes(auth.ResourceHelmCharts, auth.VerbDelete).WithName(param.Chart)).
+		Metadata(audit.MetadataKey, audit.ActionChartDelete).
 		To(handler.deleteChart))
 
 	webService.Route(webService.DELETE("/helm-charts/{chart}/versions/{version}").
 		Doc("delete helm chart version").
 		Param(webService.PathParameter(param.Chart, "deleted chart name").Required(true)).
 		Param(webService.PathParameter(param.Version, "deleted chart version").Required(true)).
+		Metadata(auth.MetadataKey, auth.NewAttributes(auth.ResourceHelmCharts, auth.VerbDelete).WithName(param.Chart)).
+		Metadata(audit.MetadataKey, audit.ActionChartVersionDelete).
 		To(handler.deleteChartVersions))
 }
 
@@ -89,25 +106,45 @@ func bindHelmRepoGetRoutes(webService *restful.WebService, handler *Handler) {
 		Param(webService.QueryParameter(param.Page, "page").Required(false).
 			DataFormat("page=%d").DefaultValue("page=1")).
 		Param(webService.QueryParameter(param.Limit, "limit").Required(false)).
+		Metadata(auth.MetadataKey, auth.NewAttributes(auth.ResourceHelmChartRepositories, auth.VerbList)).
 		To(handler.listHelmRepo))
 
+	webService.Route(webService.GET("/helm-repos/export").
+		Doc("export all helm repos as a helm repositories.yaml").
+		Produces(mimeYAML).
+		Param(webService.QueryParameter(param.Credentials,
+			"none, plain or encrypted, plain needs update on helmchartrepositories/credentials as well").
+			Required(false).DefaultValue(helmModel.RepoExportCredentialsNone)).
+		Metadata(auth.MetadataKey, auth.NewAttributes(auth.ResourceHelmChartRepositories, auth.VerbGet).
+			WithSubresource("export").
+			WithCondition(param.Credentials, helmModel.RepoExportCredentialsPlain,
+				auth.NewAttributes(auth.ResourceHelmChartRepositories, auth.VerbUpdate).
+					WithSubresource("credentials"))).
+		Metadata(audit.MetadataKey, audit.ActionRepoExport).
+		To(handler.exportHelmRepos))
+
 	webService.Route(webService.GET("/helm-repos/{repo}").
 		Doc("get specific helm repos").
 		Param(webService.PathParameter(param.Repository, "helm repo name").Required(true)).
 		Param(webService.QueryParameter(param.Page, "page").Required(false).
 			DataFormat("page=%d").DefaultValue("page=1")).
 		Param(webService.QueryParameter(param.Limit, "limit").Required(false)).
+		Metadata(auth.MetadataKey, auth.NewAttributes(auth.ResourceHelmChartRepositories, auth.VerbGet).
+			WithName(param.Repository)).
 		To(handler.getHelmRepo))
 
 	webService.Route(webService.GET("/helm-repos/{repo}/sync").
 		Doc("get repo sync status").
 		Param(webService.PathParameter(param.Repository, "helm repo name").Required(true)).
+		Metadata(auth.MetadataKey, auth.NewAttributes(auth.ResourceHelmChartRepositories, auth.VerbGet).
+			WithName(param.Repository).WithSubresource("sync")).
 		To(handler.getRepoSyncStatus))
 
 	webService.Route(webService.GET("/helm-repos/{repo}/charts/{chart}").
 		Doc("get chart all versions").
 		Param(webService.PathParameter(param.Repository, "helm repo name").Required(true)).
 		Param(webService.PathParameter(param.Chart, "helm chart name").Required(true)).
+		Metadata(auth.MetadataKey, auth.NewAttributes(auth.ResourceHelmCharts, auth.VerbGet).WithName(param.Chart)).
 		To(handler.getChartVersions))
 
 	webService.Route(webService.GET("/helm-repos/{repo}/charts/{chart}/versions/{version}").
@@ -115,6 +152,7 @@ func bindHelmRepoGetRoutes(webService *restful.WebService, handler *Handler) {
 		Param(webService.PathParameter(param.Repository, "helm repo name").Required(true)).
 		Param(webService.PathParameter(param.Chart, "helm chart name").Required(true)).
 		Param(webService.PathParameter(param.Version, "helm chart version").Required(true)).
+		Metadata(auth.MetadataKey, auth.NewAttributes(auth.ResourceHelmCharts, auth.VerbGet).WithName(param.Chart)).
 		To(handler.getChartVersion))
 
 	webService.Route(webService.GET("/helm-repos/{repo}/charts/{chart}/versions/{version}/files").
@@ -123,30 +161,167 @@ func bindHelmRepoGetRoutes(webService *restful.WebService, handler *Handler) {
 		Param(webService.PathParameter(param.Chart, "helm chart name").Required(true)).
 		Param(webService.PathParameter(param.Version, "helm chart version").Required(true)).
 		Param(webService.QueryParameter(param.FileType, "file type").Required(false)).
+		Metadata(auth.MetadataKey, auth.NewAttributes(auth.ResourceHelmCharts, auth.VerbGet).WithName(param.Chart)).
 		To(handler.getChartFiles))
+
+	webService.Route(webService.GET("/helm-repos/{repo}/charts/{chart}/versions/{version}/crd-preflight").
+		Doc("check chart CRDs against definitions installed in cluster").
+		Param(webService.PathParameter(param.Repository, "helm repo name").Required(true)).
+		Param(webService.PathParameter(param.Chart, "helm chart name").Required(true)).
+		Param(webService.PathParameter(param.Version, "helm chart version").Required(true)).
+		Metadata(auth.MetadataKey, auth.NewAttributes(auth.ResourceHelmCharts, auth.VerbGet).WithName(param.Chart)).
+		To(handler.checkChartCRDs))
+
+	webService.Route(webService.GET("/helm-repos/{repo}/charts/{chart}/versions/{version}/compatibility").
+		Doc("check chart kubeVersion and api versions against cluster").
+		Param(webService.PathParameter(param.Repository, "helm repo name").Required(true)).
+		Param(webService.PathParameter(param.Chart, "helm chart name").Required(true)).
+		Param(webService.PathParameter(param.Version, "helm chart version").Required(true)).
+		Metadata(auth.MetadataKey, auth.NewAttributes(auth.ResourceHelmCharts, auth.VerbGet).WithName(param.Chart)).
+		To(handler.checkChartCompatibility))
+
+	webService.Route(webService.GET("/helm-repos/{repo}/charts/{chart}/versions/{version}/deprecations").
+		Doc("check chart templates for deprecated kubernetes api versions").
+		Param(webService.PathParameter(param.Repository, "helm repo name").Required(true)).
+		Param(webService.PathParameter(param.Chart, "helm chart name").Required(true)).
+		Param(webService.PathParameter(param.Version, "helm chart version").Required(true)).
+		Param(webService.QueryParameter(param.TargetVersion, "target kubernetes version").Required(false)).
+		Metadata(auth.MetadataKey, auth.NewAttributes(auth.ResourceHelmCharts, auth.VerbGet).WithName(param.Chart)).
+		To(handler.checkChartDeprecations))
+
+	webService.Route(webService.POST("/helm-repos/{repo}/charts/{chart}/versions/{version}/resource-estimate").
+		Doc("estimate cpu, memory and storage the chart takes with given values").
+		Param(webService.PathParameter(param.Repository, "helm repo name").Required(true)).
+		Param(webService.PathParameter(param.Chart, "helm chart name").Required(true)).
+		Param(webService.PathParameter(param.Version, "helm chart version").Required(true)).
+		Metadata(auth.MetadataKey, auth.NewAttributes(auth.ResourceHelmCharts, auth.VerbGet).WithName(param.Chart)).
+		To(handler.estimateChartResources))
 }
 
 func bindHelmRepoPostRoutes(webService *restful.WebService, handler *Handler) {
 	webService.Route(webService.POST("/helm-repos").
 		Doc("create helm repo").
+		Metadata(auth.MetadataKey, auth.NewAttributes(auth.ResourceHelmChartRepositories, auth.VerbCreate)).
+		Metadata(audit.MetadataKey, audit.ActionRepoCreate).
 		To(handler.createHelmRepo))
 
+	webService.Route(webService.POST("/helm-repos/test").
+		Doc("test connecting to a helm repo step by step without creating it").
+		Metadata(auth.MetadataKey, auth.NewAttributes(auth.ResourceHelmChartRepositories, auth.VerbCreate).
+			WithSubresource("test")).
+		To(handler.testHelmRepo))
+
 	webService.Route(webService.PUT("/helm-repos/{repo}").
 		Doc("update helm repo").
 		Param(webService.PathParameter(param.Repository, "helm repo name").Required(true)).
+		Metadata(auth.MetadataKey, auth.NewAttributes(auth.ResourceHelmChartRepositories, auth.VerbUpdate).
+			WithName(param.Repository)).
+		Metadata(audit.MetadataKey, audit.ActionRepoUpdate).
 		To(handler.updateHelmRepo))
 
 	webService.Route(webService.DELETE("/helm-repos/{repo}").
 		Doc("delete helm repo").
 		Param(webService.PathParameter(param.Repository, "helm repo name").Required(true)).
+		Metadata(auth.MetadataKey, auth.NewAttributes(auth.ResourceHelmChartRepositories, auth.VerbDelete).
+			WithName(param.Repository)).
+		Metadata(audit.MetadataKey, audit.ActionRepoDelete).
 		To(handler.deleteHelmRepo))
 
 	webService.Route(webService.POST("/helm-repos/sync").
 		Doc("sync all repos").
+		Metadata(auth.MetadataKey, auth.NewAttributes(auth.ResourceHelmChartRepositories, auth.VerbUpdate).
+			WithSubresource("sync")).
+		Metadata(audit.MetadataKey, audit.ActionRepoSync).
 		To(handler.syncAllHelmRepo))
 
+	webService.Route(webService.POST("/helm-repos/credentials/rotate").
+		Doc("encrypt repo credentials with the current symmetric key, the previous key must stay configured").
+		Metadata(auth.MetadataKey, auth.NewAttributes(auth.ResourceHelmChartRepositories, auth.VerbUpdate).
+			WithSubresource("credentials")).
+		Metadata(audit.MetadataKey, audit.ActionRepoCredentialsRotate).
+		To(handler.rotateRepoCredentials))
+
+	webService.Route(webService.POST("/helm-repos/import").
+		Doc("create or update repos from a helm repositories.yaml, dryRun only validates the entries").
+		Consumes(mimeYAML, "application/yaml", "text/yaml", restful.MIME_JSON).
+		Param(webService.QueryParameter(param.DryRun, "validate without creating or updating").Required(false).
+			DataType("boolean")).
+		Metadata(auth.MetadataKey, auth.NewAttributes(auth.ResourceHelmChartRepositories, auth.VerbCreate).
+			WithSubresource("import").
+			WithRequired(auth.NewAttributes(auth.ResourceHelmChartRepositories, auth.VerbUpdate))).
+		Metadata(audit.MetadataKey, audit.ActionRepoImport).
+		To(handler.importHelmRepos))
+
 	webService.Route(webService.POST("/helm-repos/{repo}/sync").
 		Doc("sync repo").
 		Param(webService.PathParameter(param.Repository, "helm repo name").Required(true)).
+		Metadata(auth.MetadataKey, auth.NewAttributes(auth.ResourceHelmChartRepositories, auth.VerbUpdate).
+			WithName(param.Repository).WithSubresource("sync")).
+		Metadata(audit.MetadataKey, audit.ActionRepoSync).
 		To(handler.syncHelmRepo))
 }
+
+func bindReleaseSnapshotRoutes(webService *restful.WebService, handler *Handler) {
+	webService.Route(webService.POST("/releases/{namespace}/{release}/snapshots").
+		Doc("create volume snapshots for release persistent volume claims").
+		Param(webService.PathParameter(param.Namespace, "release namespace").Required(true)).
+		Param(webService.PathParameter(param.Release, "release name").Required(true)).
+		Metadata(auth.MetadataKey, auth.NewAttributes(auth.ResourceReleaseSnapshots, auth.VerbCreate).
+			WithName(param.Release).WithNamespace(param.Namespace)).
+		Metadata(audit.MetadataKey, audit.ActionSnapshotCreate).
+		To(handler.createReleaseSnapshots))
+
+	webService.Route(webService.GET("/releases/{namespace}/{release}/snapshots").
+		Doc("list volume snapshots of release").
+		Param(webService.PathParameter(param.Namespace, "release namespace").Required(true)).
+		Param(webService.PathParameter(param.Release, "release name").Required(true)).
+		Param(webService.QueryParameter(param.Revision, "release revision").Required(false)).
+		Metadata(auth.MetadataKey, auth.NewAttributes(auth.ResourceReleaseSnapshots, auth.VerbList).
+			WithName(param.Release).WithNamespace(param.Namespace)).
+		Metadata(audit.MetadataKey, audit.ActionSnapshotList).
+		To(handler.listReleaseSnapshots))
+
+	webService.Route(webService.POST("/releases/{namespace}/{release}/snapshots/{revision}/restore").
+		Doc("restore release persistent volume claims from volume snapshots").
+		Param(webService.PathParameter(param.Namespace, "release namespace").Required(true)).
+		Param(webService.PathParameter(param.Release, "release name").Required(true)).
+		Param(webService.PathParameter(param.Revision, "release revision").Required(true)).
+		Metadata(auth.MetadataKey, auth.NewAttributes(auth.ResourceReleaseSnapshots, auth.VerbUpdate).
+			WithName(param.Release).WithNamespace(param.Namespace).WithSubresource("restore")).
+		Metadata(audit.MetadataKey, audit.ActionSnapshotRestore).
+		To(handler.restoreReleaseSnapshots))
+}
+
+func bindAuditRoutes(webService *restful.WebService, handler *Handler) {
+	webService.Route(webService.GET("/audit-events").
+		Doc("list recent audit records of repository and chart changes, newest first").
+		Param(webService.QueryParameter(param.User, "user name").Required(false)).
+		Param(webService.QueryParameter(param.Action, "audited action").Required(false)).
+		Param(webService.QueryParameter(param.Repository, "helm repo name").Required(false)).
+		Param(webService.QueryParameter(param.Chart, "helm chart name").Required(false)).
+		Param(webService.QueryParameter(param.Limit, "limit").Required(false)).
+		Metadata(auth.MetadataKey, auth.NewAttributes(auth.ResourceAuditEvents, auth.VerbList)).
+		To(handler.listAuditEvents))
+}
+
+func bindLogLevelRoutes(webService *restful.WebService, handler *Handler) {
+	webService.Route(webService.GET("/log-levels").
+		Doc("list the configured log level and the levels of packages overriding it").
+		Metadata(auth.MetadataKey, auth.NewAttributes(auth.ResourceLogLevels, auth.VerbList)).
+		To(handler.listLogLevels))
+
+	webService.Route(webService.PUT("/log-levels/{package}").
+		Doc("set the log level of a package at runtime, default sets the configured level until the config reloads").
+		Param(webService.PathParameter(param.Package, "package name, e.g. helm").Required(true)).
+		Reads(zlog.LevelSetting{}).
+		Metadata(auth.MetadataKey, auth.NewAttributes(auth.ResourceLogLevels, auth.VerbUpdate).WithName(param.Package)).
+		Metadata(audit.MetadataKey, audit.ActionLogLevelUpdate).
+		To(handler.updateLogLevel))
+
+	webService.Route(webService.DELETE("/log-levels/{package}").
+		Doc("reset the log level of a package to the configured level").
+		Param(webService.PathParameter(param.Package, "package name").Required(true)).
+		Metadata(auth.MetadataKey, auth.NewAttributes(auth.ResourceLogLevels, auth.VerbDelete).WithName(param.Package)).
+		Metadata(audit.MetadataKey, audit.ActionLogLevelReset).
+		To(handler.resetLogLevel))
+}
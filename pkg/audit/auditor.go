@@ -0,0 +1,176 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+
+	"marketplace-service/pkg/auth"
+	"marketplace-service/pkg/server/param"
+	"marketplace-service/pkg/utils/redact"
+	"marketplace-service/pkg/zlog"
+)
+
+const (
+	// summaryBodyLimit request bodies larger than it are not summarized
+	summaryBodyLimit = 64 << 10
+
+	forwardedForHeader = "X-Forwarded-For"
+)
+
+// Auditor writes records to the sinks and keeps the recent ones for queries
+type Auditor struct {
+	sinks []Sink
+
+	mutex      sync.Mutex
+	recentSize int
+	recent     []Record
+}
+
+// NewAuditor keep recentSize records in memory besides writing them to the sinks
+func NewAuditor(recentSize int, sinks ...Sink) *Auditor {
+	return &Auditor{sinks: sinks, recentSize: recentSize, recent: make([]Record, 0, recentSize)}
+}
+
+// Record write the record to all sinks, failing sinks are logged and skipped
+func (a *Auditor) Record(record *Record) {
+	for _, sink := range a.sinks {
+		if err := sink.Write(record); err != nil {
+			zlog.Errorf("write audit record %s of %s failed, %v", record.Action, record.User, err)
+		}
+	}
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.recentSize <= 0 {
+		return
+	}
+	if len(a.recent) == a.recentSize {
+		a.recent = append(a.recent[:0], a.recent[1:]...)
+	}
+	a.recent = append(a.recent, *record)
+}
+
+// Recent matching records kept in memory, newest first
+func (a *Auditor) Recent(query *Query) []Record {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	result := make([]Record, 0)
+	for i := len(a.recent) - 1; i >= 0; i-- {
+		if query.Limit > 0 && len(result) >= query.Limit {
+			break
+		}
+		if query.matches(&a.recent[i]) {
+			result = append(result, a.recent[i])
+		}
+	}
+	return result
+}
+
+// Filter go-restful filter recording routes with an audited action in their metadata.
+// It runs before the auth filter so denied attempts are recorded as well
+func (a *Auditor) Filter(request *restful.Request, response *restful.Response, chain *restful.FilterChain) {
+	if request.SelectedRoute() == nil {
+		chain.ProcessFilter(request, response)
+		return
+	}
+	action, ok := request.SelectedRoute().Metadata()[MetadataKey].(string)
+	if !ok {
+		chain.ProcessFilter(request, response)
+		return
+	}
+	summary := readRequestSummary(request.Request)
+	chain.ProcessFilter(request, response)
+
+	record := &Record{
+		Timestamp:  time.Now().UTC(),
+		User:       anonymousUser,
+		SourceIP:   sourceIP(request.Request),
+		Action:     action,
+		Target:     getTarget(request, summary),
+		StatusCode: response.StatusCode(),
+		Request:    summary,
+	}
+	record.Outcome = outcomeOf(record.StatusCode)
+	if user, exist := auth.UserFrom(request); exist {
+		record.User = user.Username
+		record.Groups = user.Groups
+	}
+	if multipartForm := request.Request.MultipartForm; multipartForm != nil {
+		for _, fileHeader := range multipartForm.File[param.Chart] {
+			record.Request = map[string]interface{}{"filename": fileHeader.Filename, "size": fileHeader.Size}
+		}
+	}
+	if forwardedFor := request.Request.Header.Get(forwardedForHeader); forwardedFor != "" {
+		if record.Request == nil {
+			record.Request = make(map[string]interface{})
+		}
+		record.Request["forwardedFor"] = forwardedFor
+	}
+	a.Record(record)
+}
+
+// readRequestSummary decode a json body into a redacted summary and restore the body for the handler
+func readRequestSummary(request *http.Request) map[string]interface{} {
+	mediaType, _, err := mime.ParseMediaType(request.Header.Get("Content-Type"))
+	if err != nil || mediaType != restful.MIME_JSON || request.Body == nil {
+		return nil
+	}
+	body, err := io.ReadAll(io.LimitReader(request.Body, summaryBodyLimit+1))
+	request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), request.Body), request.Body}
+	if err != nil || len(body) > summaryBodyLimit {
+		return nil
+	}
+	fields := make(map[string]interface{})
+	if err = json.Unmarshal(body, &fields); err != nil {
+		return nil
+	}
+	redact.Value(fields)
+	return fields
+}
+
+func getTarget(request *restful.Request, summary map[string]interface{}) Target {
+	target := Target{
+		Repo:      request.PathParameter(param.Repository),
+		Chart:     request.PathParameter(param.Chart),
+		Version:   request.PathParameter(param.Version),
+		Package:   request.PathParameter(param.Package),
+		Namespace: request.PathParameter(param.Namespace),
+		Release:   request.PathParameter(param.Release),
+		Revision:  request.PathParameter(param.Revision),
+	}
+	if name, ok := summary[param.Name].(string); ok && target.Repo == "" {
+		target.Repo = name
+	}
+	return target
+}
+
+func sourceIP(request *http.Request) string {
+	host, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		return strings.TrimSpace(request.RemoteAddr)
+	}
+	return host
+}
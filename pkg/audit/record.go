@@ -0,0 +1,107 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package audit
+
+import (
+	"net/http"
+	"time"
+)
+
+// audited actions
+const (
+	ActionRepoCreate            = "repo.create"
+	ActionRepoUpdate            = "repo.update"
+	ActionRepoDelete            = "repo.delete"
+	ActionRepoSync              = "repo.sync"
+	ActionRepoCredentialsRotate = "repo.credentials.rotate"
+	ActionRepoExport            = "repo.export"
+	ActionRepoImport            = "repo.import"
+	ActionChartUpload           = "chart.upload"
+	ActionChartDelete           = "chart.delete"
+	ActionChartVersionDelete    = "chart.version.delete"
+	ActionLogLevelUpdate        = "loglevel.update"
+	ActionLogLevelReset         = "loglevel.reset"
+	ActionSnapshotCreate        = "release.snapshot.create"
+	ActionSnapshotList          = "release.snapshot.list"
+	ActionSnapshotRestore       = "release.snapshot.restore"
+)
+
+// outcomes of audited actions
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+	// OutcomeDenied the caller is not authenticated or not authorized
+	OutcomeDenied = "denied"
+)
+
+const (
+	// MetadataKey route metadata key holding the audited action of the route
+	MetadataKey = "marketplace.openfuyao.com/audit"
+
+	anonymousUser = "system:anonymous"
+)
+
+// Record an audited action
+type Record struct {
+	Timestamp time.Time `json:"timestamp"`
+	User      string    `json:"user"`
+	Groups    []string  `json:"groups,omitempty"`
+	SourceIP  string    `json:"sourceIP"`
+	Action    string    `json:"action"`
+	Target    Target    `json:"target"`
+	Outcome   string    `json:"outcome"`
+	// StatusCode http status of the response
+	StatusCode int `json:"statusCode"`
+	// Request redacted summary of the request
+	Request map[string]interface{} `json:"request,omitempty"`
+}
+
+// Target object the action applies to
+type Target struct {
+	Repo    string `json:"repo,omitempty"`
+	Chart   string `json:"chart,omitempty"`
+	Version string `json:"version,omitempty"`
+	// Package logging package whose level is changed
+	Package string `json:"package,omitempty"`
+	// Namespace, Release and Revision release whose volumes are snapshotted or restored
+	Namespace string `json:"namespace,omitempty"`
+	Release   string `json:"release,omitempty"`
+	Revision  string `json:"revision,omitempty"`
+}
+
+// Query filters of recent records, empty fields match all
+type Query struct {
+	User   string
+	Action string
+	Repo   string
+	Chart  string
+	Limit  int
+}
+
+func (q *Query) matches(record *Record) bool {
+	return (q.User == "" || record.User == q.User) &&
+		(q.Action == "" || record.Action == q.Action) &&
+		(q.Repo == "" || record.Target.Repo == q.Repo) &&
+		(q.Chart == "" || record.Target.Chart == q.Chart)
+}
+
+func outcomeOf(statusCode int) string {
+	switch {
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return OutcomeDenied
+	case statusCode >= http.StatusBadRequest:
+		return OutcomeFailure
+	default:
+		return OutcomeSuccess
+	}
+}
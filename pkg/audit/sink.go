@@ -0,0 +1,121 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"marketplace-service/pkg/constant"
+	"marketplace-service/pkg/zlog"
+)
+
+const (
+	eventSource        = "marketplace-service"
+	eventTimeout       = 5 * time.Second
+	auditedServiceName = "marketplace-service"
+)
+
+// Sink destination of audit records
+type Sink interface {
+	Write(record *Record) error
+}
+
+// writerSink write records as json lines, the writer is usually a rotated audit log file
+type writerSink struct {
+	sync.Mutex
+	encoder *json.Encoder
+}
+
+// NewWriterSink write records as json lines to the writer
+func NewWriterSink(writer io.Writer) Sink {
+	return &writerSink{encoder: json.NewEncoder(writer)}
+}
+
+func (s *writerSink) Write(record *Record) error {
+	s.Lock()
+	defer s.Unlock()
+	return s.encoder.Encode(record)
+}
+
+// eventSink report records as kubernetes events of the marketplace service, events are created in background
+// so the api server latency does not add to the audited request
+type eventSink struct {
+	client    kubernetes.Interface
+	namespace string
+}
+
+// NewEventSink report records as kubernetes events
+func NewEventSink(client kubernetes.Interface) Sink {
+	return &eventSink{client: client, namespace: constant.MarketplaceServiceDefaultNamespace}
+}
+
+func (s *eventSink) Write(record *Record) error {
+	eventType := v1.EventTypeNormal
+	if record.Outcome != OutcomeSuccess {
+		eventType = v1.EventTypeWarning
+	}
+	now := metav1.NewTime(record.Timestamp)
+	event := &v1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: auditedServiceName + "-audit-",
+			Namespace:    s.namespace,
+		},
+		InvolvedObject: v1.ObjectReference{
+			APIVersion: "v1",
+			Kind:       "Service",
+			Name:       auditedServiceName,
+			Namespace:  s.namespace,
+		},
+		Reason:         record.Action,
+		Message:        eventMessage(record),
+		Type:           eventType,
+		Source:         v1.EventSource{Component: eventSource},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), eventTimeout)
+		defer cancel()
+		if _, err := s.client.CoreV1().Events(s.namespace).Create(ctx, event, metav1.CreateOptions{}); err != nil {
+			zlog.Errorf("create audit event %s of %s failed, %v", record.Action, record.User, err)
+		}
+	}()
+	return nil
+}
+
+func eventMessage(record *Record) string {
+	target := record.Target.Repo
+	if record.Target.Chart != "" {
+		target = fmt.Sprintf("chart %s", record.Target.Chart)
+		if record.Target.Version != "" {
+			target = fmt.Sprintf("%s version %s", target, record.Target.Version)
+		}
+		if record.Target.Repo != "" {
+			target = fmt.Sprintf("%s in repo %s", target, record.Target.Repo)
+		}
+	} else if target != "" {
+		target = fmt.Sprintf("repo %s", target)
+	}
+	return fmt.Sprintf("%s %s %s from %s: %s (%d)", record.User, record.Action, target, record.SourceIP,
+		record.Outcome, record.StatusCode)
+}
@@ -0,0 +1,168 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	authorizationHeader = "Authorization"
+	bearerPrefix        = "Bearer "
+
+	// oauthUserInfoPath userinfo endpoint of the oauth server, next to its authorize and token endpoints
+	oauthUserInfoPath = "/oauth2/oauth/userinfo"
+
+	authenticationCacheTTL = time.Minute
+	userInfoBodyLimit      = 1 << 20
+)
+
+// Authenticator identify the caller of a request.
+// A nil user without error means the request carries no credentials the authenticator accepts
+type Authenticator interface {
+	Authenticate(request *http.Request) (*UserInfo, error)
+}
+
+// x509Authenticator accept client certificates verified by the tls config of the server
+type x509Authenticator struct{}
+
+func (x509Authenticator) Authenticate(request *http.Request) (*UserInfo, error) {
+	if request.TLS == nil || len(request.TLS.VerifiedChains) == 0 || len(request.TLS.VerifiedChains[0]) == 0 {
+		return nil, nil
+	}
+	subject := request.TLS.VerifiedChains[0][0].Subject
+	if subject.CommonName == "" {
+		return nil, errors.New("client certificate without common name")
+	}
+	return &UserInfo{Username: subject.CommonName, Groups: subject.Organization}, nil
+}
+
+// tokenReviewAuthenticator accept bearer tokens kubernetes authenticates
+type tokenReviewAuthenticator struct {
+	client kubernetes.Interface
+	cache  *ttlCache[*UserInfo]
+}
+
+func newTokenReviewAuthenticator(client kubernetes.Interface) *tokenReviewAuthenticator {
+	return &tokenReviewAuthenticator{client: client, cache: newTTLCache[*UserInfo](authenticationCacheTTL)}
+}
+
+func (a *tokenReviewAuthenticator) Authenticate(request *http.Request) (*UserInfo, error) {
+	token := bearerToken(request)
+	if token == "" {
+		return nil, nil
+	}
+	if user, ok := a.cache.get(token); ok {
+		return user, nil
+	}
+	review, err := a.client.AuthenticationV1().TokenReviews().Create(request.Context(),
+		&authenticationv1.TokenReview{Spec: authenticationv1.TokenReviewSpec{Token: token}}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("token review failed: %w", err)
+	}
+	if !review.Status.Authenticated {
+		return nil, nil
+	}
+	user := &UserInfo{
+		Username: review.Status.User.Username,
+		UID:      review.Status.User.UID,
+		Groups:   review.Status.User.Groups,
+	}
+	if len(review.Status.User.Extra) > 0 {
+		user.Extra = make(map[string][]string, len(review.Status.User.Extra))
+		for key, value := range review.Status.User.Extra {
+			user.Extra[key] = value
+		}
+	}
+	a.cache.set(token, user)
+	return user, nil
+}
+
+// oauthAuthenticator accept bearer tokens issued by the openFuyao oauth server
+type oauthAuthenticator struct {
+	userInfoURL string
+	client      *http.Client
+	cache       *ttlCache[*UserInfo]
+}
+
+type oauthUserInfo struct {
+	Subject           string   `json:"sub"`
+	Name              string   `json:"name"`
+	PreferredUsername string   `json:"preferred_username"`
+	Groups            []string `json:"groups"`
+}
+
+func newOAuthAuthenticator(serverHost string, client *http.Client) *oauthAuthenticator {
+	return &oauthAuthenticator{
+		userInfoURL: strings.TrimSuffix(serverHost, "/") + oauthUserInfoPath,
+		client:      client,
+		cache:       newTTLCache[*UserInfo](authenticationCacheTTL),
+	}
+}
+
+func (a *oauthAuthenticator) Authenticate(request *http.Request) (*UserInfo, error) {
+	token := bearerToken(request)
+	if token == "" {
+		return nil, nil
+	}
+	if user, ok := a.cache.get(token); ok {
+		return user, nil
+	}
+	userInfoRequest, err := http.NewRequestWithContext(request.Context(), http.MethodGet, a.userInfoURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	userInfoRequest.Header.Set(authorizationHeader, bearerPrefix+token)
+	response, err := a.client.Do(userInfoRequest)
+	if err != nil {
+		return nil, fmt.Errorf("oauth userinfo request failed: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusUnauthorized || response.StatusCode == http.StatusForbidden {
+		return nil, nil
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oauth userinfo returned status %d", response.StatusCode)
+	}
+	userInfo := &oauthUserInfo{}
+	if err = json.NewDecoder(io.LimitReader(response.Body, userInfoBodyLimit)).Decode(userInfo); err != nil {
+		return nil, fmt.Errorf("invalid oauth userinfo: %w", err)
+	}
+	user := &UserInfo{Username: userInfo.PreferredUsername, UID: userInfo.Subject, Groups: userInfo.Groups}
+	if user.Username == "" {
+		user.Username = userInfo.Name
+	}
+	if user.Username == "" {
+		return nil, errors.New("oauth userinfo without user name")
+	}
+	a.cache.set(token, user)
+	return user, nil
+}
+
+func bearerToken(request *http.Request) string {
+	header := request.Header.Get(authorizationHeader)
+	if len(header) <= len(bearerPrefix) || !strings.EqualFold(header[:len(bearerPrefix)], bearerPrefix) {
+		return ""
+	}
+	return strings.TrimSpace(header[len(bearerPrefix):])
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const authorizationCacheTTL = 10 * time.Second
+
+// Authorizer decide whether the user may act on the resource
+type Authorizer interface {
+	Authorize(ctx context.Context, user *UserInfo, attributes *ResourceAttributes) (bool, string, error)
+}
+
+type authorizationDecision struct {
+	allowed bool
+	reason  string
+}
+
+// subjectAccessReviewAuthorizer authorize with kubernetes rbac on the virtual marketplace resources
+type subjectAccessReviewAuthorizer struct {
+	client kubernetes.Interface
+	cache  *ttlCache[authorizationDecision]
+}
+
+func newSubjectAccessReviewAuthorizer(client kubernetes.Interface) *subjectAccessReviewAuthorizer {
+	return &subjectAccessReviewAuthorizer{
+		client: client,
+		cache:  newTTLCache[authorizationDecision](authorizationCacheTTL),
+	}
+}
+
+func (a *subjectAccessReviewAuthorizer) Authorize(ctx context.Context, user *UserInfo,
+	attributes *ResourceAttributes) (bool, string, error) {
+	key := fmt.Sprintf("%s|%s|%s|%s|%+v", user.Username, user.UID, strings.Join(user.Groups, ","),
+		user.Extra, *attributes)
+	if decision, ok := a.cache.get(key); ok {
+		return decision.allowed, decision.reason, nil
+	}
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			UID:    user.UID,
+			Groups: user.Groups,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Group:       Group,
+				Resource:    attributes.Resource,
+				Subresource: attributes.Subresource,
+				Verb:        attributes.Verb,
+				Name:        attributes.Name,
+				Namespace:   attributes.Namespace,
+			},
+		},
+	}
+	if len(user.Extra) > 0 {
+		review.Spec.Extra = make(map[string]authorizationv1.ExtraValue, len(user.Extra))
+		for extraKey, value := range user.Extra {
+			review.Spec.Extra[extraKey] = value
+		}
+	}
+	result, err := a.client.AuthorizationV1().SubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+	if err != nil {
+		return false, "", fmt.Errorf("subject access review failed: %w", err)
+	}
+	decision := authorizationDecision{allowed: result.Status.Allowed && !result.Status.Denied,
+		reason: result.Status.Reason}
+	a.cache.set(key, decision)
+	return decision.allowed, decision.reason, nil
+}
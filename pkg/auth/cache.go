@@ -0,0 +1,64 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// maxCacheEntries entries kept before the cache is reset
+const maxCacheEntries = 4096
+
+// ttlCache caches authentication and authorization results, keys are hashed so tokens are not kept
+type ttlCache[V any] struct {
+	sync.Mutex
+	ttl     time.Duration
+	entries map[string]cacheEntry[V]
+}
+
+type cacheEntry[V any] struct {
+	value  V
+	expiry time.Time
+}
+
+func newTTLCache[V any](ttl time.Duration) *ttlCache[V] {
+	return &ttlCache[V]{ttl: ttl, entries: make(map[string]cacheEntry[V])}
+}
+
+func (c *ttlCache[V]) get(key string) (V, bool) {
+	c.Lock()
+	defer c.Unlock()
+	entry, ok := c.entries[hashKey(key)]
+	if !ok || time.Now().After(entry.expiry) {
+		var empty V
+		return empty, false
+	}
+	return entry.value, true
+}
+
+func (c *ttlCache[V]) set(key string, value V) {
+	c.Lock()
+	defer c.Unlock()
+	if len(c.entries) >= maxCacheEntries {
+		c.entries = make(map[string]cacheEntry[V])
+	}
+	c.entries[hashKey(key)] = cacheEntry[V]{value: value, expiry: time.Now().Add(c.ttl)}
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,115 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package auth
+
+import (
+	"crypto/tls"
+	"net/http"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+	"k8s.io/client-go/kubernetes"
+
+	"marketplace-service/pkg/constant"
+	"marketplace-service/pkg/utils/httputil"
+	"marketplace-service/pkg/zlog"
+)
+
+// Filter go-restful filter authenticating callers and authorizing them on the attributes of the route
+type Filter struct {
+	authenticators []Authenticator
+	authorizer     Authorizer
+}
+
+// NewFilter authenticate with client certificates, kubernetes TokenReview and the oauth server if configured,
+// authorize with SubjectAccessReview
+func NewFilter(client kubernetes.Interface, oauthServerHost string, insecureSkipVerify bool) *Filter {
+	authenticators := []Authenticator{x509Authenticator{}, newTokenReviewAuthenticator(client)}
+	if oauthServerHost != "" {
+		oauthClient := &http.Client{
+			Timeout: time.Second * constant.DefaultHttpRequestSeconds,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{
+				MinVersion:         tls.VersionTLS12,
+				InsecureSkipVerify: insecureSkipVerify,
+			}},
+		}
+		authenticators = append(authenticators, newOAuthAuthenticator(oauthServerHost, oauthClient))
+	}
+	return &Filter{authenticators: authenticators, authorizer: newSubjectAccessReviewAuthorizer(client)}
+}
+
+// Filter reject unauthenticated requests with 401 and unauthorized ones with 403
+func (f *Filter) Filter(request *restful.Request, response *restful.Response, chain *restful.FilterChain) {
+	if request.SelectedRoute() == nil {
+		// no route matched, the not found response is written already
+		chain.ProcessFilter(request, response)
+		return
+	}
+	user := f.authenticate(request.Request)
+	if user == nil {
+		response.Header().Set("WWW-Authenticate", `Bearer realm="marketplace-service"`)
+		_ = response.WriteHeaderAndEntity(http.StatusUnauthorized, httputil.ResponseJson{
+			Code: constant.Unauthorized,
+			Msg:  "authentication required",
+		})
+		return
+	}
+	request.SetAttribute(userAttribute, user)
+	request.Request = request.Request.WithContext(zlog.NewContext(request.Request.Context(),
+		zlog.UserKey, user.Username))
+	log := zlog.FromContext(request.Request.Context())
+	attributes, ok := request.SelectedRoute().Metadata()[MetadataKey].(Attributes)
+	if !ok {
+		log.Warnf("route %s %s has no authorization attributes, denied", request.Request.Method,
+			request.SelectedRoutePath())
+		writeForbidden(response)
+		return
+	}
+	for _, resourceAttributes := range attributes.resourceAttributes(request) {
+		allowed, reason, err := f.authorizer.Authorize(request.Request.Context(), user, resourceAttributes)
+		if err != nil {
+			log.Errorf("authorize user %s failed, %v", user.Username, err)
+			_ = response.WriteHeaderAndEntity(http.StatusInternalServerError,
+				httputil.GetDefaultServerFailureResponseJson())
+			return
+		}
+		if !allowed {
+			log.Infof("user %s is not allowed to %s %s %s, %s", user.Username, resourceAttributes.Verb,
+				resourceAttributes.Resource, resourceAttributes.Name, reason)
+			writeForbidden(response)
+			return
+		}
+	}
+	chain.ProcessFilter(request, response)
+}
+
+func (f *Filter) authenticate(request *http.Request) *UserInfo {
+	for _, authenticator := range f.authenticators {
+		user, err := authenticator.Authenticate(request)
+		if err != nil {
+			zlog.Warnf("authenticate request from %s failed, %v", request.RemoteAddr, err)
+			continue
+		}
+		if user != nil {
+			return user
+		}
+	}
+	return nil
+}
+
+func writeForbidden(response *restful.Response) {
+	_ = response.WriteHeaderAndEntity(http.StatusForbidden, httputil.ResponseJson{
+		Code: constant.Forbidden,
+		Msg:  "access denied",
+	})
+}
@@ -0,0 +1,172 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// mockAuthClientset accepts token "admin" and "viewer", only admin may update, delete or act on credentials
+func mockAuthClientset() *fake.Clientset {
+	clientset := fake.NewSimpleClientset()
+	clientset.PrependReactor("create", "tokenreviews",
+		func(action k8stesting.Action) (bool, runtime.Object, error) {
+			review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+			if review.Spec.Token == "admin" || review.Spec.Token == "viewer" {
+				review.Status.Authenticated = true
+				review.Status.User = authenticationv1.UserInfo{Username: review.Spec.Token}
+			}
+			return true, review, nil
+		})
+	clientset.PrependReactor("create", "subjectaccessreviews",
+		func(action k8stesting.Action) (bool, runtime.Object, error) {
+			review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+			attributes := review.Spec.ResourceAttributes
+			review.Status.Allowed = attributes.Group == Group && (review.Spec.User == "admin" ||
+				(attributes.Verb != VerbDelete && attributes.Verb != VerbUpdate && attributes.Subresource != "credentials"))
+			return true, review, nil
+		})
+	return clientset
+}
+
+func newTestContainer(filter *Filter, user *string) *restful.Container {
+	webService := new(restful.WebService).Produces(restful.MIME_JSON)
+	webService.Route(webService.DELETE("/helm-repos/{repo}").
+		Metadata(MetadataKey, NewAttributes(ResourceHelmChartRepositories, VerbDelete).WithName("repo")).
+		To(func(request *restful.Request, response *restful.Response) {
+			if info, ok := UserFrom(request); ok {
+				*user = info.Username
+			}
+			response.WriteHeader(http.StatusOK)
+		}))
+	webService.Route(webService.GET("/helm-repos/export").
+		Metadata(MetadataKey, NewAttributes(ResourceHelmChartRepositories, VerbGet).WithSubresource("export").
+			WithCondition("credentials", "plain",
+				NewAttributes(ResourceHelmChartRepositories, VerbUpdate).WithSubresource("credentials"))).
+		To(func(request *restful.Request, response *restful.Response) {
+			if info, ok := UserFrom(request); ok {
+				*user = info.Username
+			}
+			response.WriteHeader(http.StatusOK)
+		}))
+	webService.Route(webService.POST("/helm-repos/import").
+		Metadata(MetadataKey, NewAttributes(ResourceHelmChartRepositories, VerbCreate).WithSubresource("import").
+			WithRequired(NewAttributes(ResourceHelmChartRepositories, VerbUpdate))).
+		To(func(request *restful.Request, response *restful.Response) {
+			if info, ok := UserFrom(request); ok {
+				*user = info.Username
+			}
+			response.WriteHeader(http.StatusOK)
+		}))
+	webService.Route(webService.GET("/unprotected").
+		To(func(request *restful.Request, response *restful.Response) {
+			response.WriteHeader(http.StatusOK)
+		}))
+	container := restful.NewContainer()
+	container.Filter(filter.Filter)
+	container.Add(webService)
+	return container
+}
+
+func TestFilter_Filter(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		wantStatus int
+	}{
+		{"TestFilter_Filter_no_credentials", http.MethodDelete, "/helm-repos/local", "", http.StatusUnauthorized},
+		{"TestFilter_Filter_invalid_token", http.MethodDelete, "/helm-repos/local", "guest", http.StatusUnauthorized},
+		{"TestFilter_Filter_forbidden", http.MethodDelete, "/helm-repos/local", "viewer", http.StatusForbidden},
+		{"TestFilter_Filter_allowed", http.MethodDelete, "/helm-repos/local", "admin", http.StatusOK},
+		{"TestFilter_Filter_no_attributes", http.MethodGet, "/unprotected", "admin", http.StatusForbidden},
+		{"TestFilter_Filter_condition_unmet", http.MethodGet, "/helm-repos/export?credentials=none", "viewer",
+			http.StatusOK},
+		{"TestFilter_Filter_condition_forbidden", http.MethodGet, "/helm-repos/export?credentials=plain", "viewer",
+			http.StatusForbidden},
+		{"TestFilter_Filter_condition_allowed", http.MethodGet, "/helm-repos/export?credentials=plain", "admin",
+			http.StatusOK},
+		{"TestFilter_Filter_required_forbidden", http.MethodPost, "/helm-repos/import", "viewer",
+			http.StatusForbidden},
+		{"TestFilter_Filter_required_allowed", http.MethodPost, "/helm-repos/import", "admin", http.StatusOK},
+		{"TestFilter_Filter_no_route", http.MethodGet, "/not-found", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var user string
+			container := newTestContainer(NewFilter(mockAuthClientset(), "", false), &user)
+			request := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.token != "" {
+				request.Header.Set(authorizationHeader, bearerPrefix+tt.token)
+			}
+			recorder := httptest.NewRecorder()
+			container.ServeHTTP(recorder, request)
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("Filter() status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK && user != tt.token {
+				t.Errorf("Filter() user = %s, want %s", user, tt.token)
+			}
+		})
+	}
+}
+
+func Test_x509Authenticator_Authenticate(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{
+		Subject: pkix.Name{CommonName: "installer", Organization: []string{"system:masters"}},
+	}}}}
+	user, err := x509Authenticator{}.Authenticate(request)
+	if err != nil || user == nil || user.Username != "installer" || user.Groups[0] != "system:masters" {
+		t.Errorf("Authenticate() = %v, %v", user, err)
+	}
+}
+
+func Test_oauthAuthenticator_Authenticate(t *testing.T) {
+	oauthServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != oauthUserInfoPath || r.Header.Get(authorizationHeader) != "Bearer valid" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(oauthUserInfo{Subject: "1", PreferredUsername: "alice",
+			Groups: []string{"devs"}})
+	}))
+	defer oauthServer.Close()
+	authenticator := newOAuthAuthenticator(oauthServer.URL+"/", oauthServer.Client())
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set(authorizationHeader, "Bearer valid")
+	user, err := authenticator.Authenticate(request)
+	if err != nil || user == nil || user.Username != "alice" || user.UID != "1" {
+		t.Errorf("Authenticate() = %v, %v", user, err)
+	}
+
+	request.Header.Set(authorizationHeader, "Bearer expired")
+	if user, err = authenticator.Authenticate(request); err != nil || user != nil {
+		t.Errorf("Authenticate() with invalid token = %v, %v", user, err)
+	}
+}
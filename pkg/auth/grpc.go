@@ -0,0 +1,118 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"marketplace-service/pkg/zlog"
+)
+
+// grpcPublicPrefix methods of the grpc health and reflection services, probes call them without credentials
+const grpcPublicPrefix = "/grpc."
+
+// UnaryServerInterceptor the grpc counterpart of Filter, methods maps full method names to their attributes.
+// Methods without attributes are denied
+func (f *Filter) UnaryServerInterceptor(methods map[string]Attributes) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := f.authorizeMethod(ctx, info.FullMethod, methods)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor the grpc stream counterpart of Filter
+func (f *Filter) StreamServerInterceptor(methods map[string]Attributes) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) error {
+		ctx, err := f.authorizeMethod(stream.Context(), info.FullMethod, methods)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// authorizeMethod context of the call tagged with the authenticated user, an Unauthenticated or
+// PermissionDenied status if the caller may not call the method
+func (f *Filter) authorizeMethod(ctx context.Context, method string,
+	methods map[string]Attributes) (context.Context, error) {
+	if strings.HasPrefix(method, grpcPublicPrefix) {
+		return ctx, nil
+	}
+	user := f.authenticate(grpcRequest(ctx))
+	if user == nil {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+	ctx = zlog.NewContext(ctx, zlog.UserKey, user.Username)
+	log := zlog.FromContext(ctx)
+	attributes, ok := methods[method]
+	if !ok {
+		log.Warnf("grpc method %s has no authorization attributes, denied", method)
+		return nil, status.Error(codes.PermissionDenied, "access denied")
+	}
+	for _, resourceAttributes := range attributes.methodAttributes() {
+		allowed, reason, err := f.authorizer.Authorize(ctx, user, resourceAttributes)
+		if err != nil {
+			log.Errorf("authorize user %s failed, %v", user.Username, err)
+			return nil, status.Error(codes.Internal, "authorization failed")
+		}
+		if !allowed {
+			log.Infof("user %s is not allowed to %s %s, %s", user.Username, resourceAttributes.Verb,
+				resourceAttributes.Resource, reason)
+			return nil, status.Error(codes.PermissionDenied, "access denied")
+		}
+	}
+	return ctx, nil
+}
+
+// grpcRequest http form of a grpc call, the authenticators read its bearer token and client certificates
+func grpcRequest(ctx context.Context) *http.Request {
+	request := (&http.Request{Header: http.Header{}}).WithContext(ctx)
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, value := range md.Get(authorizationHeader) {
+			request.Header.Add(authorizationHeader, value)
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if p.Addr != nil {
+			request.RemoteAddr = p.Addr.String()
+		}
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			request.TLS = &info.State
+		}
+	}
+	return request
+}
+
+// authenticatedStream server stream whose context carries the authenticated user
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
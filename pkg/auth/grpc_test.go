@@ -0,0 +1,99 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var testMethods = map[string]Attributes{
+	"/rpc.ChartManager/ListRepositories": NewAttributes(ResourceHelmChartRepositories, VerbList),
+	"/rpc.ChartManager/WatchCatalog": NewAttributes(ResourceHelmCharts, VerbList).
+		WithRequired(NewAttributes(ResourceHelmChartRepositories, VerbList).WithSubresource("credentials")),
+}
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func grpcContext(token string) context.Context {
+	if token == "" {
+		return context.Background()
+	}
+	return metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs("authorization", bearerPrefix+token))
+}
+
+func TestFilter_UnaryServerInterceptor(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		token    string
+		wantCode codes.Code
+	}{
+		{"no_credentials", "/rpc.ChartManager/ListRepositories", "", codes.Unauthenticated},
+		{"invalid_token", "/rpc.ChartManager/ListRepositories", "guest", codes.Unauthenticated},
+		{"allowed", "/rpc.ChartManager/ListRepositories", "viewer", codes.OK},
+		{"no_attributes", "/rpc.ChartManager/GetChartFiles", "admin", codes.PermissionDenied},
+		{"required_forbidden", "/rpc.ChartManager/WatchCatalog", "viewer", codes.PermissionDenied},
+		{"required_allowed", "/rpc.ChartManager/WatchCatalog", "admin", codes.OK},
+		{"health", "/grpc.health.v1.Health/Check", "", codes.OK},
+	}
+	interceptor := NewFilter(mockAuthClientset(), "", false).UnaryServerInterceptor(testMethods)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var called bool
+			_, err := interceptor(grpcContext(tt.token), nil, &grpc.UnaryServerInfo{FullMethod: tt.method},
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					called = true
+					return nil, nil
+				})
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("UnaryServerInterceptor() code = %v, want %v", status.Code(err), tt.wantCode)
+			}
+			if called != (tt.wantCode == codes.OK) {
+				t.Errorf("UnaryServerInterceptor() handler called %v", called)
+			}
+		})
+	}
+}
+
+func TestFilter_StreamServerInterceptor(t *testing.T) {
+	interceptor := NewFilter(mockAuthClientset(), "", false).StreamServerInterceptor(testMethods)
+	info := &grpc.StreamServerInfo{FullMethod: "/rpc.ChartManager/WatchCatalog", IsServerStream: true}
+	var called bool
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		called = stream.Context() != nil
+		return nil
+	}
+
+	err := interceptor(nil, &testServerStream{ctx: grpcContext("viewer")}, info, handler)
+	if status.Code(err) != codes.PermissionDenied || called {
+		t.Fatalf("StreamServerInterceptor() = %v, handler called %v", err, called)
+	}
+	err = interceptor(nil, &testServerStream{ctx: grpcContext("admin")}, info, handler)
+	if err != nil || !called {
+		t.Errorf("StreamServerInterceptor() = %v, handler called %v", err, called)
+	}
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package auth
+
+import (
+	"slices"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// Group virtual api group the marketplace resources are authorized in
+const Group = "marketplace.openfuyao.com"
+
+// virtual resources of the marketplace
+const (
+	ResourceHelmCharts            = "helmcharts"
+	ResourceHelmChartRepositories = "helmchartrepositories"
+	ResourceReleaseSnapshots      = "releasesnapshots"
+	ResourceAuditEvents           = "auditevents"
+	ResourceLogLevels             = "loglevels"
+)
+
+// verbs of the virtual resources, the same as kubernetes
+const (
+	VerbGet    = "get"
+	VerbList   = "list"
+	VerbCreate = "create"
+	VerbUpdate = "update"
+	VerbDelete = "delete"
+)
+
+const (
+	// MetadataKey route metadata key holding the Attributes of the route
+	MetadataKey = Group + "/authorization"
+
+	userAttribute = Group + "/user"
+)
+
+// UserInfo authenticated caller
+type UserInfo struct {
+	Username string
+	UID      string
+	Groups   []string
+	Extra    map[string][]string
+}
+
+// Attributes authorization attributes of a route, name and namespace are read from the path parameters
+type Attributes struct {
+	Resource           string
+	Subresource        string
+	Verb               string
+	NameParameter      string
+	NamespaceParameter string
+	// Conditions attributes a request must be authorized on as well when it has a query parameter value
+	Conditions []Condition
+}
+
+// Condition attributes required of requests whose query parameter has the value, e.g. an export with
+// plaintext credentials. A condition without parameter is met by every request
+type Condition struct {
+	Parameter  string
+	Value      string
+	Attributes Attributes
+}
+
+// ResourceAttributes authorization attributes of a request
+type ResourceAttributes struct {
+	Resource    string
+	Subresource string
+	Verb        string
+	Name        string
+	Namespace   string
+}
+
+// UserFrom authenticated user of the request, set by the auth filter
+func UserFrom(request *restful.Request) (*UserInfo, bool) {
+	user, ok := request.Attribute(userAttribute).(*UserInfo)
+	return user, ok
+}
+
+// NewAttributes authorization attributes for the verb on the resource
+func NewAttributes(resource, verb string) Attributes {
+	return Attributes{Resource: resource, Verb: verb}
+}
+
+// WithName read the resource name from the path parameter
+func (a Attributes) WithName(parameter string) Attributes {
+	a.NameParameter = parameter
+	return a
+}
+
+// WithNamespace read the namespace from the path parameter
+func (a Attributes) WithNamespace(parameter string) Attributes {
+	a.NamespaceParameter = parameter
+	return a
+}
+
+// WithSubresource authorize on the subresource
+func (a Attributes) WithSubresource(subresource string) Attributes {
+	a.Subresource = subresource
+	return a
+}
+
+// WithCondition authorize requests whose query parameter has the value on required as well
+func (a Attributes) WithCondition(parameter, value string, required Attributes) Attributes {
+	a.Conditions = append(slices.Clip(a.Conditions), Condition{Parameter: parameter, Value: value,
+		Attributes: required})
+	return a
+}
+
+// WithRequired authorize every request on required as well, e.g. an import updating existing resources
+func (a Attributes) WithRequired(required Attributes) Attributes {
+	return a.WithCondition("", "", required)
+}
+
+// methodAttributes authorization attributes of a grpc method, those of the conditions without parameter
+// included. Grpc requests have neither path nor query parameters
+func (a Attributes) methodAttributes() []*ResourceAttributes {
+	result := []*ResourceAttributes{{Resource: a.Resource, Subresource: a.Subresource, Verb: a.Verb}}
+	for _, condition := range a.Conditions {
+		if condition.Parameter == "" {
+			result = append(result, condition.Attributes.methodAttributes()...)
+		}
+	}
+	return result
+}
+
+// resourceAttributes authorization attributes of the request, those of the conditions it meets included
+func (a Attributes) resourceAttributes(request *restful.Request) []*ResourceAttributes {
+	resourceAttributes := &ResourceAttributes{
+		Resource:    a.Resource,
+		Subresource: a.Subresource,
+		Verb:        a.Verb,
+	}
+	if a.NameParameter != "" {
+		resourceAttributes.Name = request.PathParameter(a.NameParameter)
+	}
+	if a.NamespaceParameter != "" {
+		resourceAttributes.Namespace = request.PathParameter(a.NamespaceParameter)
+	}
+	result := []*ResourceAttributes{resourceAttributes}
+	for _, condition := range a.Conditions {
+		if condition.Parameter == "" || request.QueryParameter(condition.Parameter) == condition.Value {
+			result = append(result, condition.Attributes.resourceAttributes(request)...)
+		}
+	}
+	return result
+}
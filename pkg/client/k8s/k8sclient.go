@@ -19,6 +19,8 @@ import (
 	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+
+	"marketplace-service/pkg/tracing"
 )
 
 // Client kubernetes client
@@ -44,6 +46,7 @@ func NewKubernetesClient(cfg *KubernetesCfg) (Client, error) {
 
 	cfg.KubeConfig.QPS = cfg.QPS
 	cfg.KubeConfig.Burst = cfg.Burst
+	tracing.WrapConfig(cfg.KubeConfig)
 
 	k8sInterface, err := kubernetes.NewForConfig(cfg.KubeConfig)
 	if err != nil {
@@ -39,6 +39,8 @@ const (
 	FileCreated            = 201
 	NoContent              = 204
 	ClientError            = 400
+	Unauthorized           = 401
+	Forbidden              = 403
 	ExceedChartUploadLimit = 4001
 	ResourceNotFound       = 404
 	ServerError            = 500
@@ -47,7 +49,10 @@ const (
 // marketplace-service k8s component
 const (
 	MarketplaceServiceConfigmap = "marketplace-service-config"
+	SymmetricKeySecret          = "marketplace-service-secret-key"
 	SecretFieldSymmetricKey     = "marketplace-service-symmetric-key"
+	// SecretFieldPreviousSymmetricKey key replaced during a rotation, opens secrets until they are rewrapped
+	SecretFieldPreviousSymmetricKey = "marketplace-service-previous-symmetric-key"
 )
 
 // helm chart keyword constant
@@ -80,4 +85,6 @@ const (
 	LocalHarborCAPath      = "/ssl/local-harbor/ca.pem"
 	LocalHarborTLSCertPath = "/ssl/local-harbor/server.crt"
 	LocalHarborTLSKeyPath  = "/ssl/local-harbor/server.key"
+	WebhookTLSCertPath     = "/ssl/webhook/tls.crt"
+	WebhookTLSKeyPath      = "/ssl/webhook/tls.key"
 )
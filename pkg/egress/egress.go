@@ -0,0 +1,250 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+// Package egress restricts the destinations of repository and chart downloads, so user supplied urls
+// cannot reach the kubernetes api server, cloud metadata endpoints or other internal services
+package egress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+const (
+	dialTimeout   = 30 * time.Second
+	dialKeepAlive = 30 * time.Second
+)
+
+// DefaultSchemes schemes of repository and chart urls allowed by default
+var DefaultSchemes = []string{"http", "https", "oci"}
+
+// blockedCIDRs loopback, link-local (cloud metadata), unspecified and multicast ranges, unreachable unless
+// allowed explicitly
+var blockedCIDRs = []string{
+	"127.0.0.0/8", "::1/128",
+	"169.254.0.0/16", "fe80::/10", "fd00:ec2::254/128",
+	"0.0.0.0/8", "::/128",
+	"224.0.0.0/4", "ff00::/8",
+}
+
+// ViolationError a url or address rejected by the policy
+type ViolationError struct {
+	Target string
+	Reason string
+}
+
+func (e *ViolationError) Error() string {
+	return fmt.Sprintf("%s is not allowed: %s", e.Target, e.Reason)
+}
+
+// IsViolation whether err is caused by the egress policy
+func IsViolation(err error) bool {
+	var violation *ViolationError
+	return errors.As(err, &violation)
+}
+
+// Policy allowed schemes and address ranges of outgoing repository requests
+type Policy struct {
+	schemes map[string]bool
+	// allow when not empty only these ranges are reachable, they take precedence over the blocked ranges
+	allow []*net.IPNet
+	deny  []*net.IPNet
+	// blocked ranges denied unless allowed explicitly
+	blocked []*net.IPNet
+}
+
+// NewPolicy policy of the allowed schemes and cidrs, deny wins over allow. The kubernetes api server
+// service ip is blocked along with the loopback and link-local ranges
+func NewPolicy(schemes, allow, deny []string) (*Policy, error) {
+	p := &Policy{schemes: make(map[string]bool)}
+	for _, scheme := range schemes {
+		p.schemes[strings.ToLower(strings.TrimSpace(scheme))] = true
+	}
+	var err error
+	if p.allow, err = parseCIDRs(allow); err != nil {
+		return nil, err
+	}
+	if p.deny, err = parseCIDRs(deny); err != nil {
+		return nil, err
+	}
+	blocked := blockedCIDRs
+	if apiServer := net.ParseIP(os.Getenv("KUBERNETES_SERVICE_HOST")); apiServer != nil {
+		blocked = append(append([]string{}, blocked...), apiServer.String()+hostMask(apiServer))
+	}
+	if p.blocked, err = parseCIDRs(blocked); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func hostMask(ip net.IP) string {
+	if ip.To4() != nil {
+		return "/32"
+	}
+	return "/128"
+}
+
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	result := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cidr %s, %w", cidr, err)
+		}
+		result = append(result, network)
+	}
+	return result, nil
+}
+
+func contains(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckIP whether ip may be connected to
+func (p *Policy) CheckIP(ip net.IP) error {
+	if ipv4 := ip.To4(); ipv4 != nil {
+		ip = ipv4
+	}
+	switch {
+	case contains(p.deny, ip):
+		return &ViolationError{Target: ip.String(), Reason: "address is in a denied range"}
+	case contains(p.allow, ip):
+		return nil
+	case len(p.allow) > 0:
+		return &ViolationError{Target: ip.String(), Reason: "address is not in an allowed range"}
+	case contains(p.blocked, ip):
+		return &ViolationError{Target: ip.String(), Reason: "loopback, link-local and api server addresses are blocked"}
+	default:
+		return nil
+	}
+}
+
+// CheckURL whether rawURL has an allowed scheme and all addresses of its host are allowed. The addresses are
+// checked again when connecting, since dns answers may change in between
+func (p *Policy) CheckURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return &ViolationError{Target: rawURL, Reason: "url is malformed"}
+	}
+	if !p.schemes[strings.ToLower(u.Scheme)] {
+		return &ViolationError{Target: rawURL, Reason: fmt.Sprintf("scheme %q is not allowed", u.Scheme)}
+	}
+	host := u.Hostname()
+	if host == "" {
+		return &ViolationError{Target: rawURL, Reason: "host is missing"}
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return p.check(rawURL, ip)
+	}
+	addresses, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		// unresolvable hosts fail when connecting
+		return nil
+	}
+	for _, address := range addresses {
+		if err = p.check(rawURL, address.IP); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Policy) check(rawURL string, ip net.IP) error {
+	if err := p.CheckIP(ip); err != nil {
+		return &ViolationError{Target: rawURL, Reason: err.(*ViolationError).Reason}
+	}
+	return nil
+}
+
+// control checks the address actually connected to, after dns resolution, so rebinding a checked host
+// name to an internal address does not bypass the policy
+func (p *Policy) control(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return &ViolationError{Target: address, Reason: "address is not an ip"}
+	}
+	return p.CheckIP(ip)
+}
+
+var current atomic.Pointer[Policy]
+
+func init() {
+	policy, err := NewPolicy(DefaultSchemes, nil, nil)
+	if err != nil {
+		panic(err)
+	}
+	current.Store(policy)
+}
+
+// SetPolicy replace the policy applied to repository requests
+func SetPolicy(policy *Policy) {
+	current.Store(policy)
+}
+
+// CurrentPolicy policy applied to repository requests
+func CurrentPolicy() *Policy {
+	return current.Load()
+}
+
+// CheckURL check rawURL against the current policy
+func CheckURL(ctx context.Context, rawURL string) error {
+	return CurrentPolicy().CheckURL(ctx, rawURL)
+}
+
+// Transport set the dialer of transport to one enforcing the current policy on every connection, including
+// redirects. Requests sent through a proxy of transport have their url checked as well, the dialer only sees
+// the address of the proxy which connects to the target on behalf of the service
+func Transport(transport *http.Transport) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   dialTimeout,
+		KeepAlive: dialKeepAlive,
+		Control: func(network, address string, conn syscall.RawConn) error {
+			return CurrentPolicy().control(network, address, conn)
+		},
+	}
+	transport.DialContext = dialer.DialContext
+	if proxy := transport.Proxy; proxy != nil {
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			proxyURL, err := proxy(req)
+			if err != nil || proxyURL == nil {
+				return proxyURL, err
+			}
+			if err = CheckURL(req.Context(), req.URL.String()); err != nil {
+				return nil, err
+			}
+			return proxyURL, nil
+		}
+	}
+	return transport
+}
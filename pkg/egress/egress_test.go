@@ -0,0 +1,123 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package egress
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPolicy_CheckIP 测试地址段的放行与拦截
+func TestPolicy_CheckIP(t *testing.T) {
+	defaults, err := NewPolicy(DefaultSchemes, nil, []string{"10.96.0.0/12"})
+	assert.NoError(t, err)
+	allowList, err := NewPolicy(DefaultSchemes, []string{"127.0.0.1/32", "192.168.0.0/16"}, []string{"192.168.1.0/24"})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		policy  *Policy
+		ip      string
+		allowed bool
+	}{
+		{"public", defaults, "93.184.216.34", true},
+		{"cluster service", defaults, "10.240.0.1", true},
+		{"loopback", defaults, "127.0.0.1", false},
+		{"ipv6 loopback", defaults, "::1", false},
+		{"metadata", defaults, "169.254.169.254", false},
+		{"mapped metadata", defaults, "::ffff:169.254.169.254", false},
+		{"denied", defaults, "10.96.0.1", false},
+		{"allowed loopback", allowList, "127.0.0.1", true},
+		{"allowed range", allowList, "192.168.2.1", true},
+		{"deny wins", allowList, "192.168.1.1", false},
+		{"not allowed", allowList, "93.184.216.34", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.CheckIP(net.ParseIP(tt.ip))
+			assert.Equal(t, tt.allowed, err == nil)
+			if err != nil {
+				assert.True(t, IsViolation(err))
+			}
+		})
+	}
+
+	_, err = NewPolicy(DefaultSchemes, []string{"not-a-cidr"}, nil)
+	assert.Error(t, err)
+}
+
+// TestPolicy_CheckURL 测试url协议与地址校验
+func TestPolicy_CheckURL(t *testing.T) {
+	policy, err := NewPolicy([]string{"https", "oci"}, nil, nil)
+	assert.NoError(t, err)
+
+	assert.NoError(t, policy.CheckURL(context.Background(), "https://93.184.216.34/charts"))
+	assert.NoError(t, policy.CheckURL(context.Background(), "oci://93.184.216.34/charts/nginx"))
+	for _, rawURL := range []string{"http://93.184.216.34/charts", "file:///etc/passwd", "gopher://93.184.216.34",
+		"https://169.254.169.254/latest/meta-data", "https://[::1]:6443/api", "https:///index.yaml"} {
+		err = policy.CheckURL(context.Background(), rawURL)
+		assert.True(t, IsViolation(err), rawURL)
+	}
+}
+
+// TestTransport 测试连接时按实际解析地址拦截
+func TestTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	defer SetPolicy(CurrentPolicy())
+	client := &http.Client{Transport: Transport(&http.Transport{})}
+
+	_, err := client.Get(server.URL)
+	assert.True(t, IsViolation(err))
+
+	policy, err := NewPolicy(DefaultSchemes, []string{"127.0.0.0/8"}, nil)
+	assert.NoError(t, err)
+	SetPolicy(policy)
+	resp, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	_ = resp.Body.Close()
+}
+
+// TestTransport_proxy 测试经代理的请求按目标地址拦截
+func TestTransport_proxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer proxy.Close()
+	defer SetPolicy(CurrentPolicy())
+	policy, err := NewPolicy(DefaultSchemes, []string{"127.0.0.0/8", "203.0.113.0/24"}, nil)
+	assert.NoError(t, err)
+	SetPolicy(policy)
+	proxyURL, err := url.Parse(proxy.URL)
+	assert.NoError(t, err)
+	client := &http.Client{Transport: Transport(&http.Transport{Proxy: http.ProxyURL(proxyURL)})}
+
+	_, err = client.Get("http://169.254.169.254/latest/meta-data/")
+	assert.True(t, IsViolation(err))
+	resp, err := client.Get("http://203.0.113.10/index.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	_ = resp.Body.Close()
+	assert.Equal(t, []string{"http://203.0.113.10/index.yaml"}, proxied)
+}
@@ -33,6 +33,8 @@ func (e *TokenExpiredError) Error() string {
 // HttpResponseNotOKError is an error returned on http response with http status other than 200
 type HttpResponseNotOKError struct {
 	Message string
+	// StatusCode http status of the response, 0 if unknown
+	StatusCode int
 }
 
 func (e *HttpResponseNotOKError) Error() string {
@@ -57,3 +59,26 @@ type FieldNotFoundError struct {
 func (e *FieldNotFoundError) Error() string {
 	return e.Message
 }
+
+// ResourceNotFoundError is an error returned when repository, chart or chart version can't be found
+type ResourceNotFoundError struct {
+	Message string
+}
+
+func (e *ResourceNotFoundError) Error() string {
+	return e.Message
+}
+
+// ConnectionError is an error returned when remote repository can't be reached
+type ConnectionError struct {
+	Message string
+	Err     error
+}
+
+func (e *ConnectionError) Error() string {
+	return e.Message
+}
+
+func (e *ConnectionError) Unwrap() error {
+	return e.Err
+}
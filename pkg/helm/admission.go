@@ -0,0 +1,146 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package helm
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"marketplace-service/pkg/egress"
+	"marketplace-service/pkg/models/helm"
+	"marketplace-service/pkg/utils/k8sutil"
+)
+
+// ErrRepoProtected the official and local repositories are not allowed for modification
+var ErrRepoProtected = errors.New("repo not allowed for modification")
+
+/*
+ValidateRepoCR enforce the rules of CreateRepo, UpdateRepo and DeleteRepo on a repository cr written to the api
+server directly, e.g. by kubectl. repository is nil on deletion, old is nil on creation.
+Changes leaving the spec as it is, like the modification time patched by a sync, are always allowed.
+The protection of the official and local repositories is checked last and wraps ErrRepoProtected
+*/
+func (c *helmClient) ValidateRepoCR(repository, old *helm.HelmChartRepository) error {
+	if repository != nil && old != nil && reflect.DeepEqual(repository.Spec, old.Spec) {
+		return nil
+	}
+	if repository != nil {
+		if err := c.validateRepoSpec(repository); err != nil {
+			return err
+		}
+	}
+	for _, checked := range []*helm.HelmChartRepository{repository, old} {
+		if checked == nil {
+			continue
+		}
+		response, status, err := c.isRepoModificationAllowed(checked.Spec.DisplayName)
+		if err == nil {
+			continue
+		}
+		if status == http.StatusBadRequest {
+			return fmt.Errorf("%w, %s is managed by marketplace", ErrRepoProtected, checked.Spec.DisplayName)
+		}
+		return errors.New(response.Msg)
+	}
+	return nil
+}
+
+func (c *helmClient) validateRepoSpec(repository *helm.HelmChartRepository) error {
+	name, spec := repository.Name, repository.Spec
+	if valid, err := k8sutil.ResourceMetadataRegexValid(name); !valid || err != nil {
+		return fmt.Errorf("metadata.name %s must consist of lower case alphanumeric characters, '-' or '.',"+
+			" and must start and end with an alphanumeric character", name)
+	}
+	if spec.DisplayName == "" {
+		return errors.New("spec.displayName is required")
+	}
+	if strings.ToLower(spec.DisplayName) != name {
+		return fmt.Errorf("metadata.name %s must be spec.displayName %s in lower case", name, spec.DisplayName)
+	}
+	if err := egress.CheckURL(c.requestContext(), spec.URL); err != nil {
+		return fmt.Errorf("spec.url rejected, %v", err)
+	}
+	if response, valid := validateRepoConnection(&helm.SafeRepoEntry{Proxy: spec.Proxy}); !valid {
+		return errors.New(response.Msg)
+	}
+
+	repositories, err := c.listCustomRepo()
+	if err != nil {
+		return fmt.Errorf("list repositories failed, %v", err)
+	}
+	for i := range repositories {
+		if repositories[i].Name == name {
+			continue
+		}
+		if repositories[i].Spec.URL == spec.URL {
+			return fmt.Errorf("spec.url %s already used by repository %s", spec.URL,
+				repositories[i].Spec.DisplayName)
+		}
+		if strings.EqualFold(repositories[i].Spec.DisplayName, spec.DisplayName) {
+			return fmt.Errorf("spec.displayName %s already used by repository %s", spec.DisplayName,
+				repositories[i].Name)
+		}
+	}
+	return c.checkRepoCRReferences(spec)
+}
+
+// referenceCheck secret reference of a repository cr with the keys an external secret needs and the suffix of the
+// secret marketplace creates for it
+type referenceCheck struct {
+	ref    helm.SecretReference
+	keys   []string
+	suffix string
+}
+
+// checkRepoCRReferences whether the secrets and config map the repository references exist, existing objects
+// managed outside of marketplace need the keys marketplace reads and referenceLabel as well. Any other object
+// must be the one marketplace created for the repository
+func (c *helmClient) checkRepoCRReferences(spec helm.HelmChartRepositorySpec) error {
+	secrets := []referenceCheck{
+		{spec.BasicAuth, []string{mapKeyUsername, mapKeyPassword}, repoCRSecretBasicAuth},
+		{spec.TLS, []string{mapKeyTLSCrt, mapKeyTLSKey}, repoCRSecretTLS},
+	}
+	if spec.Headers != nil {
+		secrets = append(secrets, referenceCheck{ref: *spec.Headers, suffix: repoCRSecretHeaders})
+	}
+	if spec.Auth != nil {
+		secrets = append(secrets, referenceCheck{ref: spec.Auth.Secret, suffix: repoCRSecretAuth})
+	}
+	for _, secret := range secrets {
+		if secret.ref.Name == "" {
+			continue
+		}
+		var keys []string
+		if secret.ref.External {
+			keys = secret.keys
+		}
+		data, err := c.secretReferenceData(secret.ref, managedReferenceName(spec.DisplayName, secret.suffix))
+		if _, _, err = checkReference(referenceKindSecret, secret.ref.Name, data, err, keys...); err != nil {
+			return err
+		}
+	}
+	if spec.CA.Name != "" {
+		var keys []string
+		if spec.CA.External {
+			keys = []string{mapKeyCAKey}
+		}
+		data, err := c.configMapReferenceData(spec.CA, managedReferenceName(spec.DisplayName, repoCRConfigMapCA))
+		if _, _, err = checkReference(referenceKindConfigMap, spec.CA.Name, data, err, keys...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package helm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"marketplace-service/pkg/constant"
+	"marketplace-service/pkg/models/helm"
+)
+
+const mockAdmissionRepoURL = "https://charts.example.invalid"
+
+func mockAdmissionRepo(name, displayName, url string) *helm.HelmChartRepository {
+	return &helm.HelmChartRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       helm.HelmChartRepositorySpec{DisplayName: displayName, URL: url},
+	}
+}
+
+// Test_helmClient_ValidateRepoCR 测试准入校验直接写入的仓库cr
+func Test_helmClient_ValidateRepoCR(t *testing.T) {
+	c := &helmClient{clientset: mockClientset(), dynamicClient: mockDynamicClientWithRepoCR(mockRemoteRepoCR()),
+		ctx: context.Background()}
+	valid := mockAdmissionRepo("team", "Team", mockAdmissionRepoURL)
+	assert.NoError(t, c.ValidateRepoCR(valid, nil))
+
+	dangling := mockAdmissionRepo("team", "Team", mockAdmissionRepoURL)
+	dangling.Spec.BasicAuth = helm.SecretReference{Name: "absent", External: true}
+	foreignHeaders := mockAdmissionRepo("team", "Team", mockAdmissionRepoURL)
+	foreignHeaders.Spec.Headers = &helm.SecretReference{Name: localHarborSecret}
+	unlabeled := mockAdmissionRepo("team", "Team", mockAdmissionRepoURL)
+	unlabeled.Spec.CA = helm.ConfigMapReference{Name: constant.MarketplaceServiceConfigmap, External: true}
+	tests := []struct {
+		name       string
+		repository *helm.HelmChartRepository
+	}{
+		{"invalid name", mockAdmissionRepo("Team_A", "Team_A", mockAdmissionRepoURL)},
+		{"missing display name", mockAdmissionRepo("team", "", mockAdmissionRepoURL)},
+		{"name mismatch", mockAdmissionRepo("team", "other", mockAdmissionRepoURL)},
+		{"duplicate url", mockAdmissionRepo("team", "Team", mockRemoteRepoCRURL)},
+		{"dangling secret", dangling},
+		{"unlabeled config map", unlabeled},
+		{"foreign headers secret", foreignHeaders},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := c.ValidateRepoCR(tt.repository, nil)
+			assert.Error(t, err)
+			assert.False(t, errors.Is(err, ErrRepoProtected))
+		})
+	}
+
+	official := mockAdmissionRepo(mockOfficialRepoCRName, "openFuyao", mockAdmissionRepoURL)
+	assert.ErrorIs(t, c.ValidateRepoCR(official, nil), ErrRepoProtected)
+	assert.ErrorIs(t, c.ValidateRepoCR(nil, official), ErrRepoProtected)
+
+	relabelled := official.DeepCopy()
+	relabelled.Labels = map[string]string{"team": "platform"}
+	assert.NoError(t, c.ValidateRepoCR(relabelled, official))
+}
@@ -19,13 +19,32 @@ Package helm 实现了对helm组件的所有操作
 package helm
 
 import (
+	"context"
 	"sync"
 
 	"helm.sh/helm/v3/pkg/repo"
+
+	"marketplace-service/pkg/metrics"
 )
 
 var cachedData = NewChartCache()
 
+var (
+	chartCacheWarm     = make(chan struct{})
+	chartCacheWarmOnce sync.Once
+)
+
+// ChartCacheWarm closed once all repositories have been synchronized for the first time
+func ChartCacheWarm() <-chan struct{} {
+	return chartCacheWarm
+}
+
+func markChartCacheWarm() {
+	chartCacheWarmOnce.Do(func() {
+		close(chartCacheWarm)
+	})
+}
+
 // NewChartCache create new map for storing charts in every repository
 func NewChartCache() ChartCache {
 	return &cachedChart{
@@ -36,7 +55,7 @@ func NewChartCache() ChartCache {
 // ChartCache operations for manipulate cached charts
 type ChartCache interface {
 	SetChartCache(repoName string, indexFile *repo.IndexFile)
-	UpdateChartCache(repoEntry *repo.Entry) error
+	UpdateChartCache(ctx context.Context, repoEntry *repo.Entry) error
 	GetChartCacheFromAllRepo() map[string]map[string]repo.ChartVersions
 	GetChartCacheByRepo(repoName string) (indexEntries map[string]repo.ChartVersions, exists bool)
 	DeleteChartCache(repoName string)
@@ -47,21 +66,28 @@ type cachedChart struct {
 	repoChartCache map[string]map[string]repo.ChartVersions
 }
 
-func (c *cachedChart) UpdateChartCache(repoEntry *repo.Entry) error {
-	index, err := LoadRepoIndex(repoEntry)
+func (c *cachedChart) UpdateChartCache(ctx context.Context, repoEntry *repo.Entry) error {
+	index, err := LoadRepoIndex(ctx, repoEntry)
 	if err != nil {
 		return err
 	}
-	c.Lock()
-	defer c.Unlock()
-	c.repoChartCache[repoEntry.Name] = index.Entries
+	c.SetChartCache(repoEntry.Name, index)
 	return nil
 }
 
+// SetChartCache replace charts of the repository, watchers are notified of changed chart versions
 func (c *cachedChart) SetChartCache(repoName string, indexFile *repo.IndexFile) {
 	c.Lock()
 	defer c.Unlock()
+	oldEntries, loaded := c.repoChartCache[repoName]
 	c.repoChartCache[repoName] = indexFile.Entries
+	catalogWatcher.publish(chartCacheEvents(repoName, oldEntries, loaded, indexFile.Entries)...)
+	deprecationBadges.prune(repoName, indexFile.Entries)
+	versions := 0
+	for _, chartVersions := range indexFile.Entries {
+		versions += len(chartVersions)
+	}
+	metrics.SetCacheSize(repoName, len(indexFile.Entries), versions)
 }
 
 func (c *cachedChart) GetChartCacheFromAllRepo() map[string]map[string]repo.ChartVersions {
@@ -79,8 +105,14 @@ func (c *cachedChart) GetChartCacheByRepo(repoName string) (map[string]repo.Char
 	return nil, false
 }
 
+// DeleteChartCache remove charts of the repository, watchers are notified of removed chart versions
 func (c *cachedChart) DeleteChartCache(repoName string) {
-	c.RLock()
-	defer c.RUnlock()
+	c.Lock()
+	defer c.Unlock()
+	oldEntries, exist := c.repoChartCache[repoName]
 	delete(c.repoChartCache, repoName)
+	deprecationBadges.drop(repoName)
+	if exist {
+		catalogWatcher.publish(diffChartEntries(repoName, oldEntries, nil)...)
+	}
 }
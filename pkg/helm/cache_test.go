@@ -15,6 +15,7 @@
 package helm
 
 import (
+	"context"
 	"testing"
 
 	"github.com/agiledragon/gomonkey/v2"
@@ -41,14 +42,14 @@ func TestSetChartCache(t *testing.T) {
 // TestUpdateChartCache 测试缓存更新
 func TestUpdateChartCache(t *testing.T) {
 	// Mock LoadRepoIndex 返回测试数据
-	patches := gomonkey.ApplyFunc(LoadRepoIndex, func(_ *repo.Entry) (*repo.IndexFile, error) {
+	patches := gomonkey.ApplyFunc(LoadRepoIndex, func(_ context.Context, _ *repo.Entry) (*repo.IndexFile, error) {
 		return &repo.IndexFile{Entries: map[string]repo.ChartVersions{"test": {}}}, nil
 	})
 	defer patches.Reset()
 
 	cache := &cachedChart{repoChartCache: make(map[string]map[string]repo.ChartVersions)}
 	entry := &repo.Entry{Name: "test-repo"}
-	err := cache.UpdateChartCache(entry)
+	err := cache.UpdateChartCache(context.Background(), entry)
 
 	assert.NoError(t, err)
 	assert.Equal(t, map[string]repo.ChartVersions{"test": {}}, cache.repoChartCache["test-repo"])
@@ -56,13 +57,13 @@ func TestUpdateChartCache(t *testing.T) {
 
 // TestUpdateChartCache_Error 测试缓存更新失败
 func TestUpdateChartCache_Error(t *testing.T) {
-	patches := gomonkey.ApplyFunc(LoadRepoIndex, func(_ *repo.Entry) (*repo.IndexFile, error) {
+	patches := gomonkey.ApplyFunc(LoadRepoIndex, func(_ context.Context, _ *repo.Entry) (*repo.IndexFile, error) {
 		return nil, assert.AnError
 	})
 	defer patches.Reset()
 
 	cache := &cachedChart{}
-	err := cache.UpdateChartCache(&repo.Entry{})
+	err := cache.UpdateChartCache(context.Background(), &repo.Entry{})
 	assert.Error(t, err)
 }
 
@@ -40,10 +40,8 @@ import (
 )
 
 var (
-	officialHarborBearerToken = ""
-	splitTokenPartNum         = 2
-	ascendingOrder            = 1
-	descendingOrder           = -1
+	ascendingOrder  = 1
+	descendingOrder = -1
 )
 
 const (
@@ -60,13 +58,6 @@ const (
 	fileTypeTemplate = "template"
 	fileTypeAll      = ""
 
-	token           = "token"
-	authorization   = "Authorization"
-	wwwAuthenticate = "Www-Authenticate"
-	realmParam      = "realm"
-	serviceParam    = "service"
-	scopeParam      = "scope"
-
 	maxRetries = 3
 
 	// chart tgz max file size 2MB
@@ -118,27 +109,27 @@ func (c *helmClient) uploadChartCheck(formFile multipart.File,
 
 	config, err := util.GetMarketplaceServiceConfig(c.clientset)
 	if err != nil || config == nil {
-		zlog.Errorf("error getting marketplace-service config: %v", err)
+		c.log().Errorf("error getting marketplace-service config: %v", err)
 		return &httputil.ResponseJson{
 			Code: http.StatusInternalServerError,
 			Msg:  "error getting marketplace-service config"}, http.StatusInternalServerError
 	}
 	numbers, err := c.countChartNumbers()
 	if err != nil {
-		zlog.Errorf("error counting chart numbers: %v", err)
+		c.log().Errorf("error counting chart numbers: %v", err)
 		return &httputil.ResponseJson{
 			Code: http.StatusInternalServerError,
 			Msg:  "error counting chart numbers"}, http.StatusInternalServerError
 	}
 	chartLimit, err := strconv.ParseInt(config.ChartLimit, constant.BaseTen, constant.SixtyFourBits)
 	if err != nil {
-		zlog.Errorf("error parsing chart limit: %v", err)
+		c.log().Errorf("error parsing chart limit: %v", err)
 		return &httputil.ResponseJson{
 			Code: http.StatusInternalServerError,
 			Msg:  "error parsing chart limit"}, http.StatusInternalServerError
 	}
 	if numbers > chartLimit {
-		zlog.Errorf("exceeding chart upload limit %v", chartLimit)
+		c.log().Errorf("exceeding chart upload limit %v", chartLimit)
 		return &httputil.ResponseJson{
 			Code: constant.ExceedChartUploadLimit,
 			Msg:  fmt.Sprintf("reach chart upload limit %v", chartLimit)}, http.StatusBadRequest
@@ -156,7 +147,7 @@ func (c *helmClient) UploadChart(formFile multipart.File,
 	}
 	config, err := util.GetMarketplaceServiceConfig(c.clientset)
 	if err != nil {
-		zlog.Errorf("error getting marketplace service config: %v", err)
+		c.log().Errorf("error getting marketplace service config: %v", err)
 		return &httputil.ResponseJson{
 			Code: http.StatusInternalServerError,
 			Msg:  "error getting marketplace-service config"}, http.StatusInternalServerError
@@ -167,7 +158,7 @@ func (c *helmClient) UploadChart(formFile multipart.File,
 	}
 	password, exists, err := c.getLocalHarborPassword()
 	if err != nil || !exists {
-		zlog.Errorf("error getting local harbor password: %v", err)
+		c.log().Errorf("error getting local harbor password: %v", err)
 		return &httputil.ResponseJson{
 			Code: http.StatusInternalServerError,
 			Msg:  "error getting local harbor password"}, http.StatusInternalServerError
@@ -177,24 +168,24 @@ func (c *helmClient) UploadChart(formFile multipart.File,
 
 	_, repoEntry, err := c.getRepoEntryAndChartVersions("local")
 	if err != nil {
-		zlog.Errorf("error getting repo entry & chartVersions %v", err)
+		c.log().Errorf("error getting repo entry & chartVersions %v", err)
 		return &httputil.ResponseJson{
 			Code: http.StatusInternalServerError,
 			Msg:  "error getting repo entry & chartVersions"}, http.StatusInternalServerError
 	}
 	resp, body, err := getHttpRequestResponse(request, repoEntry)
 	if err != nil {
-		zlog.Errorf("error sending HTTP request: %v", err)
+		c.log().Errorf("error sending HTTP request: %v", err)
 		return httputil.GetResponseJson(constant.ServerError,
 			"error reaching registry", nil), http.StatusInternalServerError
 	}
-	zlog.Infof("status %v, status code %v, body %v", resp.Status, resp.StatusCode, string(body))
+	c.log().Infof("status %v, status code %v, body %v", resp.Status, resp.StatusCode, string(body))
 
 	if util.IsHTTPClientError(resp.StatusCode) {
 		return getUploadErrorGeneralResponse(), http.StatusBadRequest
 	}
 	if resp.StatusCode != http.StatusCreated {
-		zlog.Errorf(fmt.Sprintf("failed to upload file, status %s, status code %v, %v", resp.Status, resp.StatusCode,
+		c.log().Errorf(fmt.Sprintf("failed to upload file, status %s, status code %v, %v", resp.Status, resp.StatusCode,
 			string(body)))
 		return httputil.GetResponseJson(constant.ServerError, "failed to upload file", nil),
 			http.StatusInternalServerError
@@ -212,13 +203,13 @@ func (c *helmClient) buildUploadRequest(config *helm.MarketplaceServiceConfig, f
 	fileHeader *multipart.FileHeader) (*http.Request, *httputil.ResponseJson) {
 	_, err := (formFile).Seek(0, io.SeekStart)
 	if err != nil {
-		zlog.Errorf("reset file pointer to zero failed: %v", err)
+		c.log().Errorf("reset file pointer to zero failed: %v", err)
 		return nil, httputil.GetDefaultServerFailureResponseJson()
 	}
 	request, err := http.NewRequest(http.MethodPost,
 		getBuiltInHarborChartURI(config.LocalHarborHost, config.LocalHarborProject), nil)
 	if err != nil {
-		zlog.Errorf("error creating post request: %v", err)
+		c.log().Errorf("error creating post request: %v", err)
 		return nil, httputil.GetDefaultServerFailureResponseJson()
 	}
 
@@ -227,12 +218,12 @@ func (c *helmClient) buildUploadRequest(config *helm.MarketplaceServiceConfig, f
 	defer writer.Close()
 	part, err := writer.CreateFormFile(harborDefaultFormDataField, fileHeader.Filename)
 	if err != nil {
-		zlog.Errorf("error creating form file, %v", err)
+		c.log().Errorf("error creating form file, %v", err)
 		return nil, getUploadErrorGeneralResponse()
 	}
 	_, err = io.Copy(part, formFile)
 	if err != nil {
-		zlog.Errorf("error copy from file to writer part, %v", err)
+		c.log().Errorf("error copy from file to writer part, %v", err)
 		return nil, getUploadErrorGeneralResponse()
 	}
 	request.Header.Set("Content-Type", writer.FormDataContentType())
@@ -241,7 +232,8 @@ func (c *helmClient) buildUploadRequest(config *helm.MarketplaceServiceConfig, f
 }
 
 func (c *helmClient) getLocalHarborPassword() ([]byte, bool, error) {
-	secret, err := k8sutil.GetSecret(c.clientset, localHarborSecret, constant.MarketplaceServiceDefaultNamespace)
+	secret, err := k8sutil.GetSecret(c.requestContext(), c.clientset, localHarborSecret,
+		constant.MarketplaceServiceDefaultNamespace)
 	if err != nil {
 		return nil, false, err
 	}
@@ -274,12 +266,12 @@ func (c *helmClient) buildDeleteRequest(config *helm.MarketplaceServiceConfig, c
 
 	request, err := http.NewRequest(http.MethodDelete, deleteURL, nil)
 	if err != nil {
-		zlog.Errorf("error creating delete request: %v", err)
+		c.log().Errorf("error creating delete request: %v", err)
 		return nil, httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
 	}
 	password, exists, err := c.getLocalHarborPassword()
 	if err != nil || !exists {
-		zlog.Errorf("error getting local harbor password: %v", err)
+		c.log().Errorf("error getting local harbor password: %v", err)
 		return nil, getUploadErrorGeneralResponse(), http.StatusInternalServerError
 	}
 	request.SetBasicAuth(localHarborUser, string(password))
@@ -291,7 +283,7 @@ func (c *helmClient) buildDeleteRequest(config *helm.MarketplaceServiceConfig, c
 func (c *helmClient) DeleteChartVersion(chartName, version string) (*httputil.ResponseJson, int) {
 	config, err := util.GetMarketplaceServiceConfig(c.clientset)
 	if err != nil || config == nil {
-		zlog.Errorf("error getting marketplace-service config: %v", err)
+		c.log().Errorf("error getting marketplace-service config: %v", err)
 		return httputil.GetResponseJson(constant.ServerError, "error getting marketplace-service config", nil),
 			http.StatusInternalServerError
 	}
@@ -301,25 +293,25 @@ func (c *helmClient) DeleteChartVersion(chartName, version string) (*httputil.Re
 	}
 	_, repoEntry, err := c.getRepoEntryAndChartVersions("local")
 	if err != nil {
-		zlog.Errorf("error getting repo entry & chartVersions %v", err)
+		c.log().Errorf("error getting repo entry & chartVersions %v", err)
 		return &httputil.ResponseJson{
 			Code: http.StatusInternalServerError,
 			Msg:  "error getting repo entry & chartVersions"}, http.StatusInternalServerError
 	}
 	resp, body, err := getHttpRequestResponse(req, repoEntry)
 	if err != nil {
-		zlog.Errorf("error sending HTTP request: %v", err)
+		c.log().Errorf("error sending HTTP request: %v", err)
 		return httputil.GetResponseJson(constant.ServerError,
 			"error sending HTTP request", nil), http.StatusInternalServerError
 	}
 	if resp.StatusCode != http.StatusOK {
 		if resp.StatusCode == http.StatusNotFound {
-			zlog.Infof(string(body))
+			c.log().Infof(string(body))
 			return httputil.GetResponseJson(constant.ClientError,
 					fmt.Sprintf("failed to delete %s %s, no such file", chartName, version), nil),
 				http.StatusInternalServerError
 		}
-		zlog.Errorf(fmt.Sprintf("failed to delete file, %v", string(body)))
+		c.log().Errorf(fmt.Sprintf("failed to delete file, %v", string(body)))
 		return httputil.GetResponseJson(constant.ClientError, "failed to delete file", nil),
 			http.StatusInternalServerError
 	}
@@ -328,7 +320,7 @@ func (c *helmClient) DeleteChartVersion(chartName, version string) (*httputil.Re
 }
 
 func getHttpRequestResponse(req *http.Request, repoEntry *repo.Entry) (*http.Response, []byte, error) {
-	client, err := httputil.GetHarborHttpClientByRepo(repoEntry)
+	client, err := repoHTTPClient(repoEntry)
 	if err != nil {
 		zlog.Errorf("error create http client %v", err)
 		return nil, nil, err
@@ -365,16 +357,16 @@ func getUploadErrorGeneralResponse() *httputil.ResponseJson {
 func (c *helmClient) GetLatestCharts(searchParam *helm.ChartSearchParam) (*httputil.ResponseJson, int) {
 	chartList, err := c.getLatestChartsByRepos(searchParam.Repositories)
 	if err != nil {
-		zlog.Errorf("list chart failed, %v", err)
+		c.log().Errorf("list chart failed, %v", err)
 		return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
 	}
 	config, err := util.GetMarketplaceServiceConfig(c.clientset)
 	if err != nil {
-		zlog.Errorf("get marketplace service config failed, %v", err)
+		c.log().Errorf("get marketplace service config failed, %v", err)
 		return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
 	}
 	if !util.ContainsAll(config.MarketplaceScenes, searchParam.Scene) {
-		zlog.Warnf("check if scene is defined by marketplace service, marketplace config %v,"+
+		c.log().Warnf("check if scene is defined by marketplace service, marketplace config %v,"+
 			" search param %v", config.MarketplaceScenes, searchParam.Scene)
 		return httputil.GetDefaultClientFailureResponseJson(), http.StatusBadRequest
 	}
@@ -413,7 +405,7 @@ func (c *helmClient) getChartWithOfficialTag(tag string,
 	}
 	chartWithTag, err := c.filterOfficialChartWithTag(taggedCharts, config.OfficialHarborDisplayName)
 	if err != nil {
-		zlog.Errorf("filter official chart with tag %s , error %v", tag, err)
+		c.log().Errorf("filter official chart with tag %s , error %v", tag, err)
 		return nil, err
 	}
 	return &helm.ChartVersionResponseWithTag{
@@ -423,19 +415,10 @@ func (c *helmClient) getChartWithOfficialTag(tag string,
 }
 
 func (c *helmClient) handleTagRequest(tag string, config *helm.MarketplaceServiceConfig) *helm.OfficialTagsResponse {
+	// bearer tokens of the official harbor are requested on its token challenge by the repository client
 	officialTagsResponse, err := c.getOfficialTagWithToken(config.OfficialHarborHost+config.OfficialHarborTagsURL, tag)
 	if err != nil {
-		zlog.Errorf("request tag respond error %v", err)
-		var expiredError *marketplaceErrors.TokenExpiredError
-		if goErrors.As(err, &expiredError) {
-			if expiredError == nil {
-				return nil
-			}
-			err = c.updateOfficialHarborBearerToken(expiredError.Response)
-			if err != nil {
-				zlog.Errorf("update Official Harbor Bearer Token failed, %v", err)
-			}
-		}
+		c.log().Errorf("request tag respond error %v", err)
 	}
 	return officialTagsResponse
 }
@@ -446,10 +429,16 @@ func (c *helmClient) GetChartsWithOfficialTags(tags []string, query *param.Query
 		return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
 	}
 	result := make([]*helm.ChartVersionResponseWithTag, 0)
+	// the tags of a suspended official repository are not looked up
+	if repository, err := c.getCustomRepoByName(config.OfficialHarborDisplayName); err == nil &&
+		repository.Spec.Suspended {
+		c.log().Infof("official repository %s is suspended, skip tag lookups", config.OfficialHarborDisplayName)
+		tags = nil
+	}
 	for _, tag := range tags {
 		chartVersionResponseWithTag, err := c.getChartWithOfficialTag(tag, config)
 		if err != nil {
-			zlog.Errorln(err)
+			c.log().Error(err)
 		} else {
 			result = append(result, chartVersionResponseWithTag)
 		}
@@ -471,7 +460,7 @@ func (c *helmClient) filterOfficialChartWithTag(taggedCharts []string,
 	c.asyncUpdateRepository(repository, nil)
 	chartList, err := c.getLatestChartsByRepos([]string{officialHarborDisplayName})
 	if err != nil {
-		zlog.Errorf("list chart failed, %v", err)
+		c.log().Errorf("list chart failed, %v", err)
 		return nil, err
 	}
 	for _, taggedChart := range taggedCharts {
@@ -490,79 +479,6 @@ func decodeURLSafeBase64String(input string) (string, error) {
 	input = strings.ReplaceAll(input, ".", "=")
 	return util.DecodeBase64String(input)
 }
-func (c *helmClient) updateOfficialHarborBearerToken(tagRequestResponse *http.Response) error {
-	authenticate := tagRequestResponse.Header[wwwAuthenticate]
-	if len(authenticate) < 1 {
-		return &marketplaceErrors.FieldNotFoundError{Message: "field authenticate not found"}
-	}
-	realm, service, scope := getBearTokenHeadParameter(authenticate[0])
-	OfficialHarborBearerTokenRequestURL := getOfficialHarborBearerTokenRequestURL(realm, service, scope)
-	request, err := http.NewRequest(http.MethodGet, OfficialHarborBearerTokenRequestURL, nil)
-	if err != nil {
-		return err
-	}
-	_, repoEntry, err := c.getRepoEntryAndChartVersions("openfuyao")
-	if err != nil {
-		zlog.Errorf("error getting repo entry & chartVersions %v", err)
-		return err
-	}
-	response, body, err := getHttpRequestResponse(request, repoEntry)
-	if err != nil {
-		return err
-	}
-	if response.StatusCode != http.StatusOK {
-		return &marketplaceErrors.HttpResponseNotOKError{Message: string(body)}
-	}
-
-	if !json.Valid(body) {
-		return &marketplaceErrors.InvalidJsonHttpBodyError{Message: string(body)}
-	}
-	var data map[string]interface{}
-	err = json.Unmarshal(body, &data)
-	if err != nil {
-		return err
-	}
-	tokenField, ok := data[token]
-	if !ok {
-		return getTokenNotFoundError(body)
-	}
-	officialHarborBearerToken, ok = tokenField.(string)
-	if !ok {
-		return getTokenNotFoundError(body)
-	}
-	return nil
-}
-
-func getTokenNotFoundError(body []byte) error {
-	return &marketplaceErrors.FieldNotFoundError{
-		Message: fmt.Sprintf("failed to find field %s in response %s", token, string(body)),
-		Field:   token,
-	}
-}
-
-func getBearTokenHeadParameter(input string) (string, string, string) {
-	input = strings.TrimPrefix(input, "Bearer ")
-	parts := strings.Split(input, ",")
-	var realmString, serviceString, scopeString string
-
-	for _, part := range parts {
-		kv := strings.SplitN(part, "=", splitTokenPartNum)
-		if len(kv) == splitTokenPartNum {
-			key := strings.TrimSpace(kv[0])
-			value := strings.Trim(kv[1], `"`)
-			switch key {
-			case realmParam:
-				realmString = value
-			case serviceParam:
-				serviceString = value
-			case scopeParam:
-				scopeString = value
-			default:
-			}
-		}
-	}
-	return realmString, serviceString, scopeString
-}
 
 func (c *helmClient) getOfficialTagWithToken(harborHost, tagToBeListed string) (*helm.OfficialTagsResponse, error) {
 	officialHarborTagRequestURL := getOfficialHarborTagsRequestURL(harborHost, tagToBeListed)
@@ -570,10 +486,9 @@ func (c *helmClient) getOfficialTagWithToken(harborHost, tagToBeListed string) (
 	if err != nil {
 		return nil, err
 	}
-	request.Header.Set(authorization, officialHarborBearerToken)
 	_, repoEntry, err := c.getRepoEntryAndChartVersions("openfuyao")
 	if err != nil {
-		zlog.Errorf("error getting repo entry & chartVersions %v", err)
+		c.log().Errorf("error getting repo entry & chartVersions %v", err)
 		return nil, &marketplaceErrors.HttpResponseNotOKError{Message: "error getting repo entry & chartVersions"}
 	}
 	response, body, err := getHttpRequestResponse(request, repoEntry)
@@ -601,10 +516,6 @@ func getOfficialHarborTagsRequestURL(harborHost, tagToBeListed string) string {
 	return fmt.Sprintf("%s/%s%s", harborHost, tagToBeListed, "/tags/list")
 }
 
-func getOfficialHarborBearerTokenRequestURL(realm, service, scope string) string {
-	return fmt.Sprintf("%s?service=%s&scope=%s", realm, service, scope)
-}
-
 func filterChartVersionSlice(searchParam *helm.ChartSearchParam,
 	chartList []*helm.ChartVersionResponse) []*helm.ChartVersionResponse {
 	// Helper function to filter chartList based on a condition
@@ -747,9 +658,10 @@ func (c *helmClient) getLatestChartsByRepos(repositories []string) ([]*helm.Char
 	chartList := make([]*helm.ChartVersionResponse, 0)
 	repoCRList, err := c.listCustomRepo()
 	if err != nil {
-		zlog.Errorf("error list repositories %v", err)
+		c.log().Errorf("error list repositories %v", err)
 		return nil, err
 	}
+	repoCRList = activeRepositories(repoCRList)
 	if len(repositories) == 0 {
 		chartList = c.getLatestChartsFromAllRepositories(repoCRList)
 	} else {
@@ -790,9 +702,9 @@ func (c *helmClient) getLatestChartsFromRepository(repository helm.HelmChartRepo
 	result := make([]*helm.ChartVersionResponse, 0)
 	chartList, exist := cachedData.GetChartCacheByRepo(repository.Spec.DisplayName)
 	if !exist {
-		zlog.Errorf("repo cr %s exist, but cache is missing", repository.Spec.DisplayName)
+		c.log().Errorf("repo cr %s exist, but cache is missing", repository.Spec.DisplayName)
 	}
-	zlog.Debugf("getLatestChartsByRepos repository is: %s", repository.Spec.DisplayName)
+	c.log().Debugf("getLatestChartsByRepos repository is: %s", repository.Spec.DisplayName)
 	// go through every chart, get the latest version order by time
 	// the latest version contains all the keywords of this chart
 	// repo.ChartVersions has too many fields, only return fields we are interested
@@ -834,19 +746,19 @@ func (c *helmClient) GetChartVersion(repoName, chartName, version string) (*http
 	repository, err := c.getCustomRepoByName(repoName)
 	if err != nil {
 		if errors.IsNotFound(err) {
-			zlog.Errorf("repository %s not found", repoName)
+			c.log().Errorf("repository %s not found", repoName)
 			return &httputil.ResponseJson{
 				Code: constant.ResourceNotFound,
 				Msg:  "repository not found",
 			}, http.StatusNotFound
 		}
-		zlog.Errorf("error get repository %s, %v", repoName, err)
+		c.log().Errorf("error get repository %s, %v", repoName, err)
 		return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
 	}
 	indexEntries, exist := cachedData.GetChartCacheByRepo(repository.Spec.DisplayName)
 
 	if !exist {
-		zlog.Errorf("repo cr %s exist, but cache is missing", repository.Spec.DisplayName)
+		c.log().Errorf("repo cr %s exist, but cache is missing", repository.Spec.DisplayName)
 		return &httputil.ResponseJson{
 			Code: constant.ResourceNotFound,
 			Msg:  "please sync your repository",
@@ -857,6 +769,7 @@ func (c *helmClient) GetChartVersion(repoName, chartName, version string) (*http
 		if needShow(entry, version) {
 			chartInfo := helm.ChartVersionResponse{
 				Metadata: entry, Repo: repository.Spec.DisplayName, RepoUrl: repository.Spec.URL,
+				Deprecation: getCachedDeprecationBadge(repository.Spec.DisplayName, entry),
 			}
 			chartList = append(chartList, chartInfo)
 		}
@@ -872,12 +785,14 @@ func (c *helmClient) getRepoEntryAndChartVersions(repoName string) (map[string]r
 	*repo.Entry, error) {
 	indexEntries, exist := cachedData.GetChartCacheByRepo(repoName)
 	if !exist {
-		return nil, nil, fmt.Errorf("%s is no longer in the helm repository, please add repository first", repoName)
+		return nil, nil, &marketplaceErrors.ResourceNotFoundError{
+			Message: fmt.Sprintf("%s is no longer in the helm repository, please add repository first", repoName),
+		}
 	}
 	repository, err := c.getCustomRepoByName(repoName)
 	if err != nil {
 		if errors.IsNotFound(err) {
-			zlog.Errorf("repository %s not found", repoName)
+			c.log().Errorf("repository %s not found", repoName)
 			return nil, nil, err
 		}
 		return nil, nil, err
@@ -893,12 +808,12 @@ func (c *helmClient) getRepoEntryAndChartVersions(repoName string) (map[string]r
 func (c *helmClient) GetChartBytesByVersion(repoName, chartName, chartVersion string) (*bytes.Buffer, error) {
 	chartVersions, repoEntry, err := c.getRepoEntryAndChartVersions(repoName)
 	if err != nil {
-		zlog.Errorf("error get repo entry & chartVersions %v", err)
+		c.log().Errorf("error get repo entry & chartVersions %v", err)
 		return nil, err
 	}
 	for _, entry := range chartVersions[chartName] {
 		if entry.Version == chartVersion {
-			chartInfo, err := LoadChartBytes(entry.URLs[0], repoEntry)
+			chartInfo, err := LoadChartBytes(c.requestContext(), entry.URLs[0], repoEntry)
 			if err != nil {
 				return nil, err
 			} else {
@@ -906,19 +821,21 @@ func (c *helmClient) GetChartBytesByVersion(repoName, chartName, chartVersion st
 			}
 		}
 	}
-	return nil, fmt.Errorf("no chart information found: %s-%s-%s", repoName, chartName, chartVersion)
+	return nil, &marketplaceErrors.ResourceNotFoundError{
+		Message: fmt.Sprintf("no chart information found: %s-%s-%s", repoName, chartName, chartVersion),
+	}
 }
 
 // GetChartByVersion get chart from repoName repository with chartName and chartVersion
 func (c *helmClient) getChartByVersion(repoName, chartName, chartVersion string) (*chart.Chart, error) {
 	chartVersions, repoEntry, err := c.getRepoEntryAndChartVersions(repoName)
 	if err != nil {
-		zlog.Errorf("error get repo entry & chartVersions %v", err)
+		c.log().Errorf("error get repo entry & chartVersions %v", err)
 		return nil, err
 	}
 	for _, entry := range chartVersions[chartName] {
 		if entry.Version == chartVersion {
-			chartInfo, err := LoadChart(entry.URLs[0], repoEntry)
+			chartInfo, err := LoadChart(c.requestContext(), entry.URLs[0], repoEntry)
 			if err != nil {
 				return nil, err
 			} else {
@@ -926,12 +843,36 @@ func (c *helmClient) getChartByVersion(repoName, chartName, chartVersion string)
 			}
 		}
 	}
-	return nil, fmt.Errorf("no chart information found: %s-%s-%s", repoName, chartName, chartVersion)
+	return nil, &marketplaceErrors.ResourceNotFoundError{
+		Message: fmt.Sprintf("no chart information found: %s-%s-%s", repoName, chartName, chartVersion),
+	}
+}
+
+// chartLoadFailureResponse response of a chart version getChartByVersion failed to load, urls rejected by the
+// egress policy are client errors, a missing repository or chart version is not found, others are server errors
+func (c *helmClient) chartLoadFailureResponse(err error, repoName, chartName,
+	chartVersion string) (*httputil.ResponseJson, int) {
+	if response, blocked := egressViolationResponse(err); blocked {
+		return response, http.StatusBadRequest
+	}
+	var notFoundError *marketplaceErrors.ResourceNotFoundError
+	if goErrors.As(err, &notFoundError) || errors.IsNotFound(err) {
+		return &httputil.ResponseJson{
+			Code: constant.ResourceNotFound,
+			Msg: fmt.Sprintf("not found chart %s with version %s in repo %s, please check input or sync repository",
+				chartName, chartVersion, repoName),
+		}, http.StatusNotFound
+	}
+	c.log().Errorf("error loading chart %s-%s of repo %s, %v", chartName, chartVersion, repoName, err)
+	return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
 }
 
 func (c *helmClient) GetChartFiles(repoName, chartName, version, fileType string) (*httputil.ResponseJson, int) {
 	restfulResponse := httputil.GetDefaultSuccessResponseJson()
 	chartByVersion, err := c.getChartByVersion(repoName, chartName, version)
+	if response, blocked := egressViolationResponse(err); blocked {
+		return response, http.StatusBadRequest
+	}
 	if err != nil {
 		restfulResponse.Code = constant.ServerError
 		restfulResponse.Msg =
@@ -975,7 +916,7 @@ func (c *helmClient) getChartDetail(chart *chart.Chart) *helm.ChartDetailRespons
 		} else if strings.ToLower(file.Name) == chartFileName {
 			detail.Chart = string(file.Data)
 		} else {
-			zlog.Debugf("%s is not part of chart detail file list", file.Name)
+			c.log().Debugf("%s is not part of chart detail file list", file.Name)
 		}
 	}
 	return detail
@@ -1010,21 +951,21 @@ type TotalResponse struct {
 func (c *helmClient) CountCharts() (*httputil.ResponseJson, int) {
 	config, err := util.GetMarketplaceServiceConfig(c.clientset)
 	if err != nil || config == nil {
-		zlog.Errorf("error getting marketplace-service config: %v", err)
+		c.log().Errorf("error getting marketplace-service config: %v", err)
 		return &httputil.ResponseJson{
 			Code: http.StatusInternalServerError,
 			Msg:  "error getting marketplace-service config"}, http.StatusInternalServerError
 	}
 	chartLimit, err := strconv.ParseInt(config.ChartLimit, constant.BaseTen, constant.SixtyFourBits)
 	if err != nil {
-		zlog.Errorf("error parsing chart limit: %v", err)
+		c.log().Errorf("error parsing chart limit: %v", err)
 		return &httputil.ResponseJson{
 			Code: http.StatusInternalServerError,
 			Msg:  "error parsing chart limit"}, http.StatusInternalServerError
 	}
 	numbers, err := c.countChartNumbers()
 	if err != nil {
-		zlog.Error(err)
+		c.log().Error(err)
 		return httputil.GetResponseJson(constant.ServerError, "failed to count files", nil),
 			http.StatusInternalServerError
 	}
@@ -1057,7 +998,7 @@ func (c *helmClient) countChartNumbers() (int64, error) {
 	util.ClearByte(password)
 	_, repoEntry, err := c.getRepoEntryAndChartVersions("local")
 	if err != nil {
-		zlog.Errorf("error getting repo entry & chartVersions %v", err)
+		c.log().Errorf("error getting repo entry & chartVersions %v", err)
 		return 0, err
 	}
 	resp, body, err := getHttpRequestResponse(request, repoEntry)
@@ -1071,13 +1012,13 @@ func (c *helmClient) countChartNumbers() (int64, error) {
 	// Parse the JSON response
 	var charts []Chart
 	if err := json.Unmarshal(body, &charts); err != nil {
-		zlog.Errorf(fmt.Sprintf("failed to count files, %v", string(body)))
+		c.log().Errorf(fmt.Sprintf("failed to count files, %v", string(body)))
 	}
 
 	var totalCharts int64 = 0
 	for _, chartVersions := range charts {
 		totalCharts += int64(chartVersions.TotalVersions)
 	}
-	zlog.Infof("Total number of chart packages: %d\n", totalCharts)
+	c.log().Infof("Total number of chart packages: %d\n", totalCharts)
 	return totalCharts, err
 }
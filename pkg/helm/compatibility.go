@@ -0,0 +1,158 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package helm
+
+import (
+	"fmt"
+	"net/http"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/yaml"
+
+	"marketplace-service/pkg/constant"
+	"marketplace-service/pkg/models/helm"
+	"marketplace-service/pkg/utils/httputil"
+)
+
+var checkResultSeverity = map[string]int{
+	helm.CheckResultPass: 0,
+	helm.CheckResultWarn: 1,
+	helm.CheckResultFail: 2,
+}
+
+// CheckChartCompatibility check kubeVersion constraint and api versions used by the chart against the cluster
+func (c *helmClient) CheckChartCompatibility(repoName, chartName, chartVersion string) (*httputil.ResponseJson, int) {
+	chartByVersion, err := c.getChartByVersion(repoName, chartName, chartVersion)
+	if err != nil {
+		return c.chartLoadFailureResponse(err, repoName, chartName, chartVersion)
+	}
+	caps, err := c.getClusterCapabilities()
+	if err != nil {
+		c.log().Errorf("error getting cluster capabilities, %v", err)
+		return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
+	}
+	return &httputil.ResponseJson{
+		Code: constant.Success,
+		Msg:  "success",
+		Data: checkChartCompatibility(chartByVersion, caps),
+	}, http.StatusOK
+}
+
+func checkChartCompatibility(chrt *chart.Chart, caps *chartutil.Capabilities) *helm.CompatibilityReport {
+	report := &helm.CompatibilityReport{
+		Chart:       chrt.Metadata.Name,
+		Version:     chrt.Metadata.Version,
+		KubeVersion: caps.KubeVersion.Version,
+		Checks:      []helm.CompatibilityCheck{checkKubeVersion(chrt, caps)},
+	}
+	manifests, err := renderChartManifests(chrt, nil, caps)
+	if err != nil {
+		report.Checks = append(report.Checks, helm.CompatibilityCheck{
+			Type:   helm.CheckTypeRender,
+			Result: helm.CheckResultFail,
+			Reason: fmt.Sprintf("chart can not be rendered with default values: %v", err),
+		})
+	} else {
+		report.Checks = append(report.Checks, checkAPIVersions(manifests, caps)...)
+	}
+
+	report.Result = helm.CheckResultPass
+	for _, check := range report.Checks {
+		if checkResultSeverity[check.Result] > checkResultSeverity[report.Result] {
+			report.Result = check.Result
+		}
+	}
+	return report
+}
+
+func checkKubeVersion(chrt *chart.Chart, caps *chartutil.Capabilities) helm.CompatibilityCheck {
+	check := helm.CompatibilityCheck{Type: helm.CheckTypeKubeVersion}
+	constraint := chrt.Metadata.KubeVersion
+	switch {
+	case constraint == "":
+		check.Result = helm.CheckResultPass
+		check.Reason = "chart declares no kubeVersion constraint"
+	case chartutil.IsCompatibleRange(constraint, caps.KubeVersion.Version):
+		check.Result = helm.CheckResultPass
+		check.Reason = fmt.Sprintf("cluster version %s satisfies %s", caps.KubeVersion.Version, constraint)
+	default:
+		check.Result = helm.CheckResultFail
+		check.Reason = fmt.Sprintf("cluster version %s does not satisfy %s", caps.KubeVersion.Version, constraint)
+	}
+	return check
+}
+
+// checkAPIVersions every apiVersion/kind must be served by the cluster or defined by CRDs of the chart itself
+func checkAPIVersions(manifests []renderedManifest, caps *chartutil.Capabilities) []helm.CompatibilityCheck {
+	chartKinds := getChartDefinedKinds(manifests)
+	checks := make([]helm.CompatibilityCheck, 0)
+	checkIndex := make(map[string]int)
+	for _, manifest := range manifests {
+		key := manifest.APIVersion + "/" + manifest.Kind
+		if index, ok := checkIndex[key]; ok {
+			checks[index].Sources = appendUnique(checks[index].Sources, manifest.Source)
+			continue
+		}
+		check := helm.CompatibilityCheck{
+			Type:       helm.CheckTypeAPIVersion,
+			APIVersion: manifest.APIVersion,
+			Kind:       manifest.Kind,
+			Sources:    []string{manifest.Source},
+		}
+		switch {
+		case caps.APIVersions.Has(key):
+			check.Result = helm.CheckResultPass
+			check.Reason = "served by cluster"
+		case chartKinds[key]:
+			check.Result = helm.CheckResultWarn
+			check.Reason = "defined by CRD of the chart, available after the CRD is installed"
+		case caps.APIVersions.Has(manifest.APIVersion):
+			check.Result = helm.CheckResultFail
+			check.Reason = fmt.Sprintf("kind %s is not served in %s", manifest.Kind, manifest.APIVersion)
+		default:
+			check.Result = helm.CheckResultFail
+			check.Reason = fmt.Sprintf("api version %s is not served by cluster", manifest.APIVersion)
+		}
+		checkIndex[key] = len(checks)
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+func getChartDefinedKinds(manifests []renderedManifest) map[string]bool {
+	kinds := make(map[string]bool)
+	for _, manifest := range manifests {
+		if manifest.Kind != kindCustomResourceDefinition {
+			continue
+		}
+		crd := &apiextensionsv1.CustomResourceDefinition{}
+		if err := yaml.Unmarshal([]byte(manifest.Content), crd); err != nil {
+			continue
+		}
+		for _, crdVersion := range crd.Spec.Versions {
+			kinds[crd.Spec.Group+"/"+crdVersion.Name+"/"+crd.Spec.Names.Kind] = true
+		}
+	}
+	return kinds
+}
+
+func appendUnique(items []string, item string) []string {
+	for _, existing := range items {
+		if existing == item {
+			return items
+		}
+	}
+	return append(items, item)
+}
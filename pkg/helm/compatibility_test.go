@@ -0,0 +1,119 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package helm
+
+import (
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+	fakeDiscovery "k8s.io/client-go/discovery/fake"
+	clientSetFake "k8s.io/client-go/kubernetes/fake"
+
+	"marketplace-service/pkg/models/helm"
+)
+
+const mockCompatibilityTemplates = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Release.Name }}
+---
+apiVersion: policy/v1beta1
+kind: PodDisruptionBudget
+metadata:
+  name: {{ .Release.Name }}
+---
+apiVersion: example.com/v1beta1
+kind: Widget
+metadata:
+  name: {{ .Release.Name }}
+`
+
+func mockDiscoveryHelmClient() *helmClient {
+	clientset := clientSetFake.NewSimpleClientset()
+	discovery := clientset.Discovery().(*fakeDiscovery.FakeDiscovery)
+	discovery.FakedServerVersion = &version.Info{Major: "1", Minor: "28", GitVersion: "v1.28.3"}
+	discovery.Resources = []*metav1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{{Name: "pods", Kind: "Pod"}}},
+		{GroupVersion: "apps/v1", APIResources: []metav1.APIResource{{Name: "deployments", Kind: "Deployment"}}},
+		{GroupVersion: "policy/v1", APIResources: []metav1.APIResource{
+			{Name: "poddisruptionbudgets", Kind: "PodDisruptionBudget"}}},
+		{GroupVersion: "apiextensions.k8s.io/v1", APIResources: []metav1.APIResource{
+			{Name: "customresourcedefinitions", Kind: "CustomResourceDefinition"}}},
+	}
+	return &helmClient{clientset: clientset}
+}
+
+func mockCompatibilityChart(kubeVersion string) *chart.Chart {
+	return &chart.Chart{
+		Metadata: &chart.Metadata{Name: "widget", Version: "1.0.0", APIVersion: "v2", KubeVersion: kubeVersion},
+		Templates: []*chart.File{
+			{Name: "templates/all.yaml", Data: []byte(mockCompatibilityTemplates)},
+			{Name: "templates/NOTES.txt", Data: []byte("installed {{ .Release.Name }}")},
+		},
+		Files: []*chart.File{{Name: "crds/widgets.yaml", Data: []byte(mockChartCRD)}},
+	}
+}
+
+func Test_checkChartCompatibility(t *testing.T) {
+	tests := []struct {
+		name        string
+		kubeVersion string
+		want        map[string]string
+	}{
+		{
+			name:        "Test_checkChartCompatibility_kubeVersion_satisfied",
+			kubeVersion: ">=1.25.0-0",
+			want: map[string]string{
+				helm.CheckTypeKubeVersion:                          helm.CheckResultPass,
+				"apiextensions.k8s.io/v1/CustomResourceDefinition": helm.CheckResultPass,
+				"apps/v1/Deployment":                               helm.CheckResultPass,
+				"policy/v1beta1/PodDisruptionBudget":               helm.CheckResultFail,
+				"example.com/v1beta1/Widget":                       helm.CheckResultWarn,
+			},
+		},
+		{
+			name:        "Test_checkChartCompatibility_kubeVersion_not_satisfied",
+			kubeVersion: "<1.25.0-0",
+			want: map[string]string{
+				helm.CheckTypeKubeVersion: helm.CheckResultFail,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			caps, err := mockDiscoveryHelmClient().getClusterCapabilities()
+			if err != nil {
+				t.Fatalf("getClusterCapabilities() error = %v", err)
+			}
+			got := checkChartCompatibility(mockCompatibilityChart(tt.kubeVersion), caps)
+			if got.Result != helm.CheckResultFail || got.KubeVersion != "v1.28.3" {
+				t.Errorf("checkChartCompatibility() result = %s, kubeVersion = %s", got.Result, got.KubeVersion)
+			}
+			results := make(map[string]string)
+			for _, check := range got.Checks {
+				if check.Type == helm.CheckTypeKubeVersion {
+					results[check.Type] = check.Result
+				} else {
+					results[check.APIVersion+"/"+check.Kind] = check.Result
+				}
+			}
+			for key, want := range tt.want {
+				if results[key] != want {
+					t.Errorf("checkChartCompatibility() %s = %s, want %s", key, results[key], want)
+				}
+			}
+		})
+	}
+}
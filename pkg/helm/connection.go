@@ -0,0 +1,194 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package helm
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"helm.sh/helm/v3/pkg/repo"
+	v1 "k8s.io/api/core/v1"
+
+	"marketplace-service/pkg/constant"
+	"marketplace-service/pkg/models/helm"
+	"marketplace-service/pkg/upstream"
+	"marketplace-service/pkg/utils/httputil"
+)
+
+// repoConnectionMap proxy, headers and authentication of repositories by display name, repo.Entry has no
+// room for them. It is refreshed whenever a repository cr is converted to its entry
+var repoConnectionMap = sync.Map{}
+
+// repoConnection proxy, additional request headers and token, oauth2 or docker config authentication of a
+// repository
+type repoConnection struct {
+	proxy   helm.ProxyConfig
+	headers map[string]string
+	auth    *upstream.Auth
+}
+
+func storeRepoConnection(repoName string, connection *repoConnection) {
+	if connection == nil || (connection.proxy.URL == "" && len(connection.headers) == 0 && connection.auth == nil) {
+		repoConnectionMap.Delete(repoName)
+		return
+	}
+	repoConnectionMap.Store(repoName, connection)
+}
+
+func loadRepoConnection(repoName string) *repoConnection {
+	if connection, ok := repoConnectionMap.Load(repoName); ok {
+		return connection.(*repoConnection)
+	}
+	return nil
+}
+
+// safeRepoEntryConnection proxy, headers and authentication of a create or update request
+func safeRepoEntryConnection(repoEntry *helm.SafeRepoEntry) *repoConnection {
+	connection := &repoConnection{headers: repoEntry.Headers, auth: repoAuthEntryToAuth(repoEntry.Auth)}
+	if repoEntry.Proxy != nil {
+		connection.proxy = *repoEntry.Proxy
+	}
+	return connection
+}
+
+func repoAuthEntryToAuth(entry *helm.RepoAuthEntry) *upstream.Auth {
+	if entry == nil || entry.Type == "" {
+		return nil
+	}
+	return &upstream.Auth{
+		Type:         entry.Type,
+		Token:        string(entry.Token),
+		ClientID:     entry.ClientID,
+		ClientSecret: string(entry.ClientSecret),
+		TokenURL:     entry.TokenURL,
+		Scopes:       entry.Scopes,
+		DockerConfig: entry.DockerConfig,
+	}
+}
+
+// repoAuthSecretData secret data of the credentials of a create or update request
+func repoAuthSecretData(entry *helm.RepoAuthEntry) map[string][]byte {
+	switch entry.Type {
+	case upstream.AuthToken:
+		return map[string][]byte{mapKeyToken: entry.Token}
+	case upstream.AuthOAuth2:
+		return map[string][]byte{mapKeyClientID: []byte(entry.ClientID), mapKeyClientSecret: entry.ClientSecret}
+	case upstream.AuthDockerConfig:
+		return map[string][]byte{v1.DockerConfigJsonKey: entry.DockerConfig}
+	default:
+		return nil
+	}
+}
+
+// repositoryAuthToAuth authentication of a repository cr with the credentials of its secret
+func repositoryAuthToAuth(auth *helm.RepositoryAuth, data map[string][]byte) *upstream.Auth {
+	return &upstream.Auth{
+		Type:         auth.Type,
+		Token:        string(data[mapKeyToken]),
+		ClientID:     string(data[mapKeyClientID]),
+		ClientSecret: string(data[mapKeyClientSecret]),
+		TokenURL:     auth.TokenURL,
+		Scopes:       auth.Scopes,
+		DockerConfig: data[v1.DockerConfigJsonKey],
+	}
+}
+
+// validateRepoConnection response rejecting an invalid proxy or header of a create or update request
+func validateRepoConnection(repoEntry *helm.SafeRepoEntry) (*httputil.ResponseJson, bool) {
+	var err error
+	if repoEntry.Proxy != nil && repoEntry.Proxy.URL != "" {
+		err = upstream.ValidateProxy(repoEntry.Proxy.URL)
+	}
+	if err == nil {
+		err = upstream.ValidateHeaders(repoEntry.Headers)
+	}
+	if auth := repoAuthEntryToAuth(repoEntry.Auth); err == nil && auth != nil {
+		err = auth.Validate()
+	}
+	if err == nil {
+		return nil, true
+	}
+	return &httputil.ResponseJson{
+		Code: constant.ClientError,
+		Msg:  fmt.Sprintf("invalid repository connection settings, %v", err),
+	}, false
+}
+
+// repoHTTPClient shared client of the repository with retries and circuit breaker, rebuilt when the url,
+// tls, proxy, header or auth settings of the repository change
+func repoHTTPClient(repoEntry *repo.Entry) (*http.Client, error) {
+	return upstream.Client(repoEntry.Name, repoSettings(repoEntry, loadRepoConnection(repoEntry.Name)))
+}
+
+// repoSettings upstream settings of the repository, connection may be nil
+func repoSettings(repoEntry *repo.Entry, connection *repoConnection) upstream.Settings {
+	settings := upstream.Settings{
+		TLSKey: transportKey(repoEntry),
+		TLSConfig: func() (*tls.Config, error) {
+			return httputil.GetHarborHTTPConfig(repoEntry)
+		},
+	}
+	if connection != nil {
+		settings.ProxyURL = connection.proxy.URL
+		settings.NoProxy = connection.proxy.NoProxy
+		settings.Headers = connection.headers
+	}
+	settings.Auth = connectionAuth(repoEntry, connection)
+	return settings
+}
+
+// repoAuth authentication of the repository, its basic credentials answer token challenges
+func repoAuth(repoEntry *repo.Entry) *upstream.Auth {
+	return connectionAuth(repoEntry, loadRepoConnection(repoEntry.Name))
+}
+
+func connectionAuth(repoEntry *repo.Entry, connection *repoConnection) *upstream.Auth {
+	auth := &upstream.Auth{}
+	if connection != nil && connection.auth != nil {
+		*auth = *connection.auth
+	}
+	auth.Username = repoEntry.Username
+	auth.Password = repoEntry.Password
+	return auth
+}
+
+func transportKey(repoEntry *repo.Entry) string {
+	hash := sha256.New()
+	for _, part := range []string{repoEntry.URL, repoEntry.CertFile, repoEntry.KeyFile, repoEntry.CAFile,
+		strconv.FormatBool(repoEntry.InsecureSkipTLSverify)} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// authToRepoAuthEntry request form of the authentication of a repository, nil for basic auth only
+func authToRepoAuthEntry(auth *upstream.Auth) *helm.RepoAuthEntry {
+	if auth == nil || auth.Type == "" {
+		return nil
+	}
+	return &helm.RepoAuthEntry{
+		Type:         auth.Type,
+		Token:        []byte(auth.Token),
+		ClientID:     auth.ClientID,
+		ClientSecret: []byte(auth.ClientSecret),
+		TokenURL:     auth.TokenURL,
+		Scopes:       auth.Scopes,
+		DockerConfig: auth.DockerConfig,
+	}
+}
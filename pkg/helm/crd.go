@@ -0,0 +1,237 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package helm
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+	"sigs.k8s.io/yaml"
+
+	"marketplace-service/pkg/constant"
+	"marketplace-service/pkg/models/helm"
+	"marketplace-service/pkg/utils/httputil"
+)
+
+const (
+	kindCustomResourceDefinition = "CustomResourceDefinition"
+
+	helmReleaseNameAnnotation      = "meta.helm.sh/release-name"
+	helmReleaseNamespaceAnnotation = "meta.helm.sh/release-namespace"
+	helmChartLabel                 = "helm.sh/chart"
+	managedByLabel                 = "app.kubernetes.io/managed-by"
+
+	crdOwnerKindHelmRelease = "HelmRelease"
+)
+
+// CheckChartCRDs check every CRD shipped in the chart version against definitions installed in the cluster
+func (c *helmClient) CheckChartCRDs(repoName, chartName, chartVersion string) (*httputil.ResponseJson, int) {
+	chartByVersion, err := c.getChartByVersion(repoName, chartName, chartVersion)
+	if err != nil {
+		return c.chartLoadFailureResponse(err, repoName, chartName, chartVersion)
+	}
+	result, err := c.checkChartCRDs(chartByVersion)
+	if err != nil {
+		c.log().Errorf("error checking crds of chart %s-%s, %v", chartName, chartVersion, err)
+		return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
+	}
+	return &httputil.ResponseJson{
+		Code: constant.Success,
+		Msg:  "success",
+		Data: result,
+	}, http.StatusOK
+}
+
+func (c *helmClient) checkChartCRDs(chartByVersion *chart.Chart) (*helm.CRDPreflightResponse, error) {
+	result := &helm.CRDPreflightResponse{
+		Chart:   chartByVersion.Metadata.Name,
+		Version: chartByVersion.Metadata.Version,
+		CRDs:    make([]helm.CRDPreflightResult, 0),
+	}
+	for _, crdObject := range chartByVersion.CRDObjects() {
+		for _, manifest := range releaseutil.SplitManifests(string(crdObject.File.Data)) {
+			crd := &apiextensionsv1.CustomResourceDefinition{}
+			if err := yaml.Unmarshal([]byte(manifest), crd); err != nil {
+				c.log().Warnf("skip unparsable crd manifest %s, %v", crdObject.Filename, err)
+				continue
+			}
+			if crd.Kind != kindCustomResourceDefinition || crd.Name == "" {
+				continue
+			}
+			checkResult, err := c.checkCRD(crd, chartByVersion.Metadata)
+			if err != nil {
+				return nil, err
+			}
+			checkResult.File = crdObject.Filename
+			result.Conflict = result.Conflict || !checkResult.Compatible
+			result.CRDs = append(result.CRDs, *checkResult)
+		}
+	}
+	return result, nil
+}
+
+func (c *helmClient) checkCRD(crd *apiextensionsv1.CustomResourceDefinition,
+	metadata *chart.Metadata) (*helm.CRDPreflightResult, error) {
+	result := &helm.CRDPreflightResult{
+		Name:           crd.Name,
+		Group:          crd.Spec.Group,
+		Kind:           crd.Spec.Names.Kind,
+		Scope:          string(crd.Spec.Scope),
+		Versions:       servedVersions(crd),
+		StorageVersion: storageVersion(crd),
+		Compatible:     true,
+	}
+	installed, err := c.apiExtensionsClient.ApiextensionsV1().CustomResourceDefinitions().Get(c.requestContext(),
+		crd.Name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return result, nil
+		}
+		return nil, err
+	}
+
+	result.Exists = true
+	result.Owner = getCRDOwner(installed)
+	result.InstalledVersions = servedVersions(installed)
+	result.StoredVersions = installed.Status.StoredVersions
+	checkCRDCompatibility(result, crd, installed)
+	checkCRDDowngrade(result, installed, metadata)
+	return result, nil
+}
+
+// checkCRDCompatibility the api server refuses a definition dropping a version objects are still stored in
+func checkCRDCompatibility(result *helm.CRDPreflightResult, crd, installed *apiextensionsv1.CustomResourceDefinition) {
+	newVersions := make(map[string]bool)
+	for _, crdVersion := range crd.Spec.Versions {
+		newVersions[crdVersion.Name] = true
+	}
+	for _, storedVersion := range installed.Status.StoredVersions {
+		if !newVersions[storedVersion] {
+			result.Compatible = false
+			result.Warnings = append(result.Warnings,
+				fmt.Sprintf("stored version %s is removed, existing objects can not be read", storedVersion))
+		}
+	}
+	if installed.Spec.Scope != crd.Spec.Scope {
+		result.Compatible = false
+		result.Warnings = append(result.Warnings,
+			fmt.Sprintf("scope changes from %s to %s", installed.Spec.Scope, crd.Spec.Scope))
+	}
+	if installed.Spec.Names.Kind != crd.Spec.Names.Kind {
+		result.Compatible = false
+		result.Warnings = append(result.Warnings,
+			fmt.Sprintf("kind changes from %s to %s", installed.Spec.Names.Kind, crd.Spec.Names.Kind))
+	}
+	if result.Owner != nil && result.Owner.Kind != crdOwnerKindHelmRelease {
+		result.Warnings = append(result.Warnings,
+			fmt.Sprintf("definition is managed by %s %s", result.Owner.Kind, result.Owner.Name))
+	}
+}
+
+func checkCRDDowngrade(result *helm.CRDPreflightResult, installed *apiextensionsv1.CustomResourceDefinition,
+	metadata *chart.Metadata) {
+	newVersions := make(map[string]bool)
+	for _, crdVersion := range result.Versions {
+		newVersions[crdVersion] = true
+	}
+	for _, installedVersion := range result.InstalledVersions {
+		if !newVersions[installedVersion] {
+			result.Downgrade = true
+			result.Warnings = append(result.Warnings,
+				fmt.Sprintf("served version %s is removed", installedVersion))
+		}
+	}
+
+	installedStorage := storageVersion(installed)
+	if installedStorage != "" && result.StorageVersion != "" &&
+		version.CompareKubeAwareVersionStrings(result.StorageVersion, installedStorage) < 0 {
+		result.Downgrade = true
+		result.Warnings = append(result.Warnings,
+			fmt.Sprintf("storage version downgrades from %s to %s", installedStorage, result.StorageVersion))
+	}
+
+	if result.Owner == nil || result.Owner.Chart == "" || metadata == nil {
+		return
+	}
+	installedChartVersion, ok := strings.CutPrefix(result.Owner.Chart, metadata.Name+"-")
+	if !ok {
+		return
+	}
+	installedSemver, err := semver.NewVersion(installedChartVersion)
+	if err != nil {
+		return
+	}
+	chartSemver, err := semver.NewVersion(metadata.Version)
+	if err != nil {
+		return
+	}
+	if chartSemver.LessThan(installedSemver) {
+		result.Downgrade = true
+		result.Warnings = append(result.Warnings,
+			fmt.Sprintf("installed by chart version %s, newer than %s", installedChartVersion, metadata.Version))
+	}
+}
+
+// getCRDOwner helm records the release in annotations, operators usually set owner references or managed-by label
+func getCRDOwner(crd *apiextensionsv1.CustomResourceDefinition) *helm.CRDOwner {
+	if releaseName := crd.Annotations[helmReleaseNameAnnotation]; releaseName != "" {
+		return &helm.CRDOwner{
+			Kind:      crdOwnerKindHelmRelease,
+			Name:      releaseName,
+			Namespace: crd.Annotations[helmReleaseNamespaceAnnotation],
+			Chart:     crd.Labels[helmChartLabel],
+		}
+	}
+	for _, ownerReference := range crd.OwnerReferences {
+		return &helm.CRDOwner{
+			Kind:  ownerReference.Kind,
+			Name:  ownerReference.Name,
+			Chart: crd.Labels[helmChartLabel],
+		}
+	}
+	if managedBy := crd.Labels[managedByLabel]; managedBy != "" {
+		return &helm.CRDOwner{
+			Kind:  managedByLabel,
+			Name:  managedBy,
+			Chart: crd.Labels[helmChartLabel],
+		}
+	}
+	return nil
+}
+
+func servedVersions(crd *apiextensionsv1.CustomResourceDefinition) []string {
+	versions := make([]string, 0, len(crd.Spec.Versions))
+	for _, crdVersion := range crd.Spec.Versions {
+		if crdVersion.Served {
+			versions = append(versions, crdVersion.Name)
+		}
+	}
+	return versions
+}
+
+func storageVersion(crd *apiextensionsv1.CustomResourceDefinition) string {
+	for _, crdVersion := range crd.Spec.Versions {
+		if crdVersion.Storage {
+			return crdVersion.Name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package helm
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsFake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"marketplace-service/pkg/egress"
+	marketplaceErrors "marketplace-service/pkg/errors"
+)
+
+const mockChartCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  scope: Namespaced
+  names:
+    kind: Widget
+    plural: widgets
+  versions:
+  - name: v1beta1
+    served: true
+    storage: true
+`
+
+func mockInstalledCRD(storedVersions []string, versions ...string) *apiextensionsv1.CustomResourceDefinition {
+	crd := &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "widgets.example.com",
+			Annotations: map[string]string{
+				helmReleaseNameAnnotation:      "widget-operator",
+				helmReleaseNamespaceAnnotation: "operators",
+			},
+			Labels: map[string]string{helmChartLabel: "widget-2.0.0"},
+		},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: "example.com",
+			Scope: apiextensionsv1.NamespaceScoped,
+			Names: apiextensionsv1.CustomResourceDefinitionNames{Kind: "Widget", Plural: "widgets"},
+		},
+		Status: apiextensionsv1.CustomResourceDefinitionStatus{StoredVersions: storedVersions},
+	}
+	for i, crdVersion := range versions {
+		crd.Spec.Versions = append(crd.Spec.Versions, apiextensionsv1.CustomResourceDefinitionVersion{
+			Name:    crdVersion,
+			Served:  true,
+			Storage: i == 0,
+		})
+	}
+	return crd
+}
+
+func mockCRDChart(version string) *chart.Chart {
+	return &chart.Chart{
+		Metadata: &chart.Metadata{Name: "widget", Version: version},
+		Files:    []*chart.File{{Name: "crds/widgets.yaml", Data: []byte(mockChartCRD)}},
+	}
+}
+
+func Test_helmClient_checkChartCRDs(t *testing.T) {
+	tests := []struct {
+		name          string
+		installed     *apiextensionsv1.CustomResourceDefinition
+		chartVersion  string
+		wantExists    bool
+		wantCompat    bool
+		wantDowngrade bool
+	}{
+		{
+			name:         "Test_checkChartCRDs_not_installed",
+			chartVersion: "2.0.0",
+			wantCompat:   true,
+		},
+		{
+			name:         "Test_checkChartCRDs_same_version",
+			installed:    mockInstalledCRD([]string{"v1beta1"}, "v1beta1"),
+			chartVersion: "2.0.0",
+			wantExists:   true,
+			wantCompat:   true,
+		},
+		{
+			name:          "Test_checkChartCRDs_stored_version_removed",
+			installed:     mockInstalledCRD([]string{"v1beta1", "v1"}, "v1", "v1beta1"),
+			chartVersion:  "2.1.0",
+			wantExists:    true,
+			wantCompat:    false,
+			wantDowngrade: true,
+		},
+		{
+			name:          "Test_checkChartCRDs_chart_downgrade",
+			installed:     mockInstalledCRD([]string{"v1beta1"}, "v1beta1"),
+			chartVersion:  "1.9.0",
+			wantExists:    true,
+			wantCompat:    true,
+			wantDowngrade: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := apiextensionsFake.NewSimpleClientset()
+			if tt.installed != nil {
+				client = apiextensionsFake.NewSimpleClientset(tt.installed)
+			}
+			c := &helmClient{apiExtensionsClient: client}
+			got, err := c.checkChartCRDs(mockCRDChart(tt.chartVersion))
+			if err != nil {
+				t.Fatalf("checkChartCRDs() error = %v", err)
+			}
+			if len(got.CRDs) != 1 {
+				t.Fatalf("checkChartCRDs() got %d crds, want 1", len(got.CRDs))
+			}
+			result := got.CRDs[0]
+			if result.Exists != tt.wantExists || result.Compatible != tt.wantCompat ||
+				result.Downgrade != tt.wantDowngrade || got.Conflict == tt.wantCompat {
+				t.Errorf("checkChartCRDs() got = %+v", result)
+			}
+			if tt.wantExists && (result.Owner == nil || result.Owner.Name != "widget-operator") {
+				t.Errorf("checkChartCRDs() owner = %+v, want widget-operator", result.Owner)
+			}
+		})
+	}
+}
+
+// Test_helmClient_chartLoadFailureResponse 测试加载chart失败的响应码
+func Test_helmClient_chartLoadFailureResponse(t *testing.T) {
+	c := &helmClient{}
+	if _, status := c.CheckChartCRDs("absent", "widget", "1.0.0"); status != http.StatusNotFound {
+		t.Errorf("CheckChartCRDs() of absent repository status = %v, want %v", status, http.StatusNotFound)
+	}
+	violation := &marketplaceErrors.ConnectionError{Err: &egress.ViolationError{Target: "http://169.254.169.254"}}
+	if _, status := c.chartLoadFailureResponse(violation, "repo", "widget", "1.0.0"); status != http.StatusBadRequest {
+		t.Errorf("chartLoadFailureResponse() of egress violation status = %v, want %v", status,
+			http.StatusBadRequest)
+	}
+	if _, status := c.chartLoadFailureResponse(errors.New("boom"), "repo", "widget", "1.0.0"); status !=
+		http.StatusInternalServerError {
+		t.Errorf("chartLoadFailureResponse() status = %v, want %v", status, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,171 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package helm
+
+import (
+	"net/http"
+
+	v1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"marketplace-service/pkg/constant"
+	"marketplace-service/pkg/models/helm"
+	"marketplace-service/pkg/utils/envelope"
+	"marketplace-service/pkg/utils/httputil"
+	"marketplace-service/pkg/utils/k8sutil"
+)
+
+// keyring symmetric keys of the marketplace secret, without a configured key credentials are kept in plaintext
+func (c *helmClient) keyring() (*envelope.Keyring, error) {
+	secret, err := k8sutil.GetSecret(c.requestContext(), c.clientset, constant.SymmetricKeySecret,
+		constant.MarketplaceServiceDefaultNamespace)
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			c.log().Warnf("secret %s not found, repository credentials are stored unencrypted",
+				constant.SymmetricKeySecret)
+			return envelope.NewKeyring(nil), nil
+		}
+		return nil, err
+	}
+	return envelope.NewKeyring(secret.Data[constant.SecretFieldSymmetricKey],
+		secret.Data[constant.SecretFieldPreviousSymmetricKey]), nil
+}
+
+// sealSecret encrypt the data of a repository credential secret before it is written
+func (c *helmClient) sealSecret(secret *v1.Secret) error {
+	keyring, err := c.keyring()
+	if err != nil {
+		return err
+	}
+	return sealSecretData(keyring, secret)
+}
+
+func sealSecretData(keyring *envelope.Keyring, secret *v1.Secret) error {
+	data := make(map[string][]byte, len(secret.Data)+len(secret.StringData))
+	for field, value := range secret.Data {
+		data[field] = value
+	}
+	for field, value := range secret.StringData {
+		data[field] = []byte(value)
+	}
+	sealed, annotations, err := keyring.Seal(data)
+	if err != nil {
+		return err
+	}
+	secret.Data = sealed
+	secret.StringData = nil
+	if annotations != nil {
+		if secret.Annotations == nil {
+			secret.Annotations = make(map[string]string, len(annotations))
+		}
+		for key, value := range annotations {
+			secret.Annotations[key] = value
+		}
+	}
+	return nil
+}
+
+// openSecret decrypted data of a repository credential secret, plaintext secrets are returned as is
+func (c *helmClient) openSecret(secret *v1.Secret) (map[string][]byte, error) {
+	if !envelope.IsEncrypted(secret.Annotations) {
+		return secret.Data, nil
+	}
+	keyring, err := c.keyring()
+	if err != nil {
+		return nil, err
+	}
+	return keyring.Open(secret.Data, secret.Annotations)
+}
+
+// RotateRepoCredentials encrypt plaintext repository credentials and rewrap the data keys of encrypted ones
+// with the current symmetric key, the previous key must stay configured until it completes
+func (c *helmClient) RotateRepoCredentials() (*httputil.ResponseJson, int) {
+	keyring, err := c.keyring()
+	if err != nil {
+		c.log().Errorf("load symmetric key failed, %v", err)
+		return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
+	}
+	if !keyring.Enabled() {
+		return &httputil.ResponseJson{
+			Code: constant.ClientError,
+			Msg:  "symmetric key is not configured",
+		}, http.StatusBadRequest
+	}
+	repoList, err := c.listCustomRepo()
+	if err != nil {
+		c.log().Errorf("list custom helm repo failed, %v", err)
+		return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
+	}
+	result := &helm.CredentialRotationResponse{Failed: make([]string, 0)}
+	for _, repository := range repoList {
+		// existing secrets referenced by the repository are encrypted by their owners, if at all
+		var secretNames []string
+		for _, reference := range repoReferenceList(repository.Spec) {
+			if reference.kind == referenceKindSecret && !reference.external {
+				secretNames = append(secretNames, reference.name)
+			}
+		}
+		for _, secretName := range secretNames {
+			if err = c.rotateSecret(keyring, secretName, result); err != nil {
+				c.log().Errorf("rotate credentials %s of repo %s failed, %v", secretName,
+					repository.Spec.DisplayName, err)
+				result.Failed = append(result.Failed, repository.Spec.DisplayName)
+				break
+			}
+		}
+	}
+	c.log().Infof("repository credentials rotated, %d encrypted, %d rewrapped, %d unchanged, %d failed",
+		result.Encrypted, result.Rewrapped, result.Unchanged, len(result.Failed))
+	if len(result.Failed) > 0 {
+		return &httputil.ResponseJson{
+			Code: constant.ServerError,
+			Msg:  "some repository credentials are not rotated",
+			Data: result,
+		}, http.StatusInternalServerError
+	}
+	return &httputil.ResponseJson{Code: constant.Success, Msg: "success", Data: result}, http.StatusOK
+}
+
+func (c *helmClient) rotateSecret(keyring *envelope.Keyring, secretName string,
+	result *helm.CredentialRotationResponse) error {
+	secret, err := k8sutil.GetSecret(c.requestContext(), c.clientset, secretName,
+		constant.MarketplaceServiceDefaultNamespace)
+	if err != nil {
+		return err
+	}
+	var outcome *int
+	if !envelope.IsEncrypted(secret.Annotations) {
+		if err = sealSecretData(keyring, secret); err != nil {
+			return err
+		}
+		outcome = &result.Encrypted
+	} else {
+		annotations, rewrapped, err := keyring.Rewrap(secret.Annotations)
+		if err != nil {
+			return err
+		}
+		if !rewrapped {
+			result.Unchanged++
+			return nil
+		}
+		for key, value := range annotations {
+			secret.Annotations[key] = value
+		}
+		outcome = &result.Rewrapped
+	}
+	if _, err = k8sutil.UpdateSecret(c.requestContext(), c.clientset, secret); err != nil {
+		return err
+	}
+	*outcome++
+	return nil
+}
@@ -0,0 +1,329 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package helm
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/repo"
+
+	"marketplace-service/pkg/constant"
+	"marketplace-service/pkg/models/helm"
+	"marketplace-service/pkg/utils/httputil"
+)
+
+// deprecatedAPI an api version deprecated and removed at the given kubernetes minor versions
+type deprecatedAPI struct {
+	APIVersion   string
+	Kind         string
+	DeprecatedIn string
+	RemovedIn    string
+	Replacement  string
+}
+
+// deprecatedAPIs built-in table of deprecated api versions of kubernetes,
+// see https://kubernetes.io/docs/reference/using-api/deprecation-guide/
+var deprecatedAPIs = []deprecatedAPI{
+	{"extensions/v1beta1", "Deployment", "1.9", "1.16", "apps/v1"},
+	{"extensions/v1beta1", "DaemonSet", "1.9", "1.16", "apps/v1"},
+	{"extensions/v1beta1", "ReplicaSet", "1.9", "1.16", "apps/v1"},
+	{"extensions/v1beta1", "NetworkPolicy", "1.9", "1.16", "networking.k8s.io/v1"},
+	{"extensions/v1beta1", "PodSecurityPolicy", "1.10", "1.16", "policy/v1beta1"},
+	{"extensions/v1beta1", "Ingress", "1.14", "1.22", "networking.k8s.io/v1"},
+	{"apps/v1beta1", "Deployment", "1.9", "1.16", "apps/v1"},
+	{"apps/v1beta1", "StatefulSet", "1.9", "1.16", "apps/v1"},
+	{"apps/v1beta1", "ReplicaSet", "1.9", "1.16", "apps/v1"},
+	{"apps/v1beta2", "Deployment", "1.9", "1.16", "apps/v1"},
+	{"apps/v1beta2", "StatefulSet", "1.9", "1.16", "apps/v1"},
+	{"apps/v1beta2", "DaemonSet", "1.9", "1.16", "apps/v1"},
+	{"apps/v1beta2", "ReplicaSet", "1.9", "1.16", "apps/v1"},
+	{"networking.k8s.io/v1beta1", "Ingress", "1.19", "1.22", "networking.k8s.io/v1"},
+	{"networking.k8s.io/v1beta1", "IngressClass", "1.19", "1.22", "networking.k8s.io/v1"},
+	{"apiextensions.k8s.io/v1beta1", "CustomResourceDefinition", "1.16", "1.22", "apiextensions.k8s.io/v1"},
+	{"admissionregistration.k8s.io/v1beta1", "MutatingWebhookConfiguration", "1.16", "1.22",
+		"admissionregistration.k8s.io/v1"},
+	{"admissionregistration.k8s.io/v1beta1", "ValidatingWebhookConfiguration", "1.16", "1.22",
+		"admissionregistration.k8s.io/v1"},
+	{"apiregistration.k8s.io/v1beta1", "APIService", "1.19", "1.22", "apiregistration.k8s.io/v1"},
+	{"authentication.k8s.io/v1beta1", "TokenReview", "1.19", "1.22", "authentication.k8s.io/v1"},
+	{"authorization.k8s.io/v1beta1", "SubjectAccessReview", "1.19", "1.22", "authorization.k8s.io/v1"},
+	{"authorization.k8s.io/v1beta1", "LocalSubjectAccessReview", "1.19", "1.22", "authorization.k8s.io/v1"},
+	{"authorization.k8s.io/v1beta1", "SelfSubjectAccessReview", "1.19", "1.22", "authorization.k8s.io/v1"},
+	{"certificates.k8s.io/v1beta1", "CertificateSigningRequest", "1.19", "1.22", "certificates.k8s.io/v1"},
+	{"coordination.k8s.io/v1beta1", "Lease", "1.19", "1.22", "coordination.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", "ClusterRole", "1.17", "1.22", "rbac.authorization.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", "ClusterRoleBinding", "1.17", "1.22", "rbac.authorization.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", "Role", "1.17", "1.22", "rbac.authorization.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", "RoleBinding", "1.17", "1.22", "rbac.authorization.k8s.io/v1"},
+	{"scheduling.k8s.io/v1beta1", "PriorityClass", "1.14", "1.22", "scheduling.k8s.io/v1"},
+	{"storage.k8s.io/v1beta1", "CSIDriver", "1.19", "1.22", "storage.k8s.io/v1"},
+	{"storage.k8s.io/v1beta1", "CSINode", "1.17", "1.22", "storage.k8s.io/v1"},
+	{"storage.k8s.io/v1beta1", "StorageClass", "1.19", "1.22", "storage.k8s.io/v1"},
+	{"storage.k8s.io/v1beta1", "VolumeAttachment", "1.19", "1.22", "storage.k8s.io/v1"},
+	{"batch/v1beta1", "CronJob", "1.21", "1.25", "batch/v1"},
+	{"discovery.k8s.io/v1beta1", "EndpointSlice", "1.21", "1.25", "discovery.k8s.io/v1"},
+	{"events.k8s.io/v1beta1", "Event", "1.19", "1.25", "events.k8s.io/v1"},
+	{"autoscaling/v2beta1", "HorizontalPodAutoscaler", "1.22", "1.25", "autoscaling/v2"},
+	{"policy/v1beta1", "PodDisruptionBudget", "1.21", "1.25", "policy/v1"},
+	{"policy/v1beta1", "PodSecurityPolicy", "1.21", "1.25", ""},
+	{"node.k8s.io/v1beta1", "RuntimeClass", "1.20", "1.25", "node.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io/v1beta1", "FlowSchema", "1.23", "1.26", "flowcontrol.apiserver.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io/v1beta1", "PriorityLevelConfiguration", "1.23", "1.26",
+		"flowcontrol.apiserver.k8s.io/v1"},
+	{"autoscaling/v2beta2", "HorizontalPodAutoscaler", "1.23", "1.26", "autoscaling/v2"},
+	{"storage.k8s.io/v1beta1", "CSIStorageCapacity", "1.24", "1.27", "storage.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io/v1beta2", "FlowSchema", "1.26", "1.29", "flowcontrol.apiserver.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io/v1beta2", "PriorityLevelConfiguration", "1.26", "1.29",
+		"flowcontrol.apiserver.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io/v1beta3", "FlowSchema", "1.29", "1.32", "flowcontrol.apiserver.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io/v1beta3", "PriorityLevelConfiguration", "1.29", "1.32",
+		"flowcontrol.apiserver.k8s.io/v1"},
+}
+
+// deprecationBadges badges of checked chart versions with findings
+var deprecationBadges = &deprecationBadgeCache{badges: make(map[string]map[string]*helm.DeprecationBadge)}
+
+// deprecationBadgeCache badges by repository and chart/version/digest, a badge is kept as long as its chart
+// version is in the cached index of the repository
+type deprecationBadgeCache struct {
+	sync.RWMutex
+	badges map[string]map[string]*helm.DeprecationBadge
+}
+
+func (d *deprecationBadgeCache) store(repoName, key string, badge *helm.DeprecationBadge) {
+	d.Lock()
+	defer d.Unlock()
+	if badge == nil {
+		delete(d.badges[repoName], key)
+		return
+	}
+	if d.badges[repoName] == nil {
+		d.badges[repoName] = make(map[string]*helm.DeprecationBadge)
+	}
+	d.badges[repoName][key] = badge
+}
+
+func (d *deprecationBadgeCache) load(repoName, key string) *helm.DeprecationBadge {
+	d.RLock()
+	defer d.RUnlock()
+	return d.badges[repoName][key]
+}
+
+// prune drop badges of chart versions no longer in entries or published again with another digest
+func (d *deprecationBadgeCache) prune(repoName string, entries map[string]repo.ChartVersions) {
+	d.Lock()
+	defer d.Unlock()
+	if len(d.badges[repoName]) == 0 {
+		return
+	}
+	current := make(map[string]bool)
+	for _, chartVersions := range entries {
+		for _, chartVersion := range chartVersions {
+			if chartVersion.Metadata != nil {
+				current[getDeprecationBadgeKey(chartVersion.Name, chartVersion.Version, chartVersion.Digest)] = true
+			}
+		}
+	}
+	for key := range d.badges[repoName] {
+		if !current[key] {
+			delete(d.badges[repoName], key)
+		}
+	}
+}
+
+func (d *deprecationBadgeCache) drop(repoName string) {
+	d.Lock()
+	defer d.Unlock()
+	delete(d.badges, repoName)
+}
+
+// kubeMinorVersion major and minor of a kubernetes version
+type kubeMinorVersion struct {
+	major int
+	minor int
+}
+
+func (v kubeMinorVersion) less(other kubeMinorVersion) bool {
+	if v.major != other.major {
+		return v.major < other.major
+	}
+	return v.minor < other.minor
+}
+
+// parseKubeMinorVersion accept 1.25, v1.25 and v1.25.3 forms
+func parseKubeMinorVersion(kubeVersion string) (kubeMinorVersion, error) {
+	parts := strings.Split(strings.TrimPrefix(strings.TrimSpace(kubeVersion), "v"), ".")
+	if len(parts) < 2 {
+		return kubeMinorVersion{}, fmt.Errorf("invalid kubernetes version %s", kubeVersion)
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return kubeMinorVersion{}, fmt.Errorf("invalid kubernetes version %s", kubeVersion)
+	}
+	minor, err := strconv.Atoi(strings.TrimRight(parts[1], "+"))
+	if err != nil {
+		return kubeMinorVersion{}, fmt.Errorf("invalid kubernetes version %s", kubeVersion)
+	}
+	return kubeMinorVersion{major: major, minor: minor}, nil
+}
+
+// CheckChartDeprecations render the chart version offline and report manifests using api versions
+// deprecated or removed at targetVersion
+func (c *helmClient) CheckChartDeprecations(repoName, chartName, chartVersion,
+	targetVersion string) (*httputil.ResponseJson, int) {
+	var target *kubeMinorVersion
+	if targetVersion != "" {
+		parsed, err := parseKubeMinorVersion(targetVersion)
+		if err != nil {
+			return &httputil.ResponseJson{
+				Code: constant.ClientError,
+				Msg:  err.Error(),
+			}, http.StatusBadRequest
+		}
+		target = &parsed
+	}
+	chartByVersion, err := c.getChartByVersion(repoName, chartName, chartVersion)
+	if err != nil {
+		return c.chartLoadFailureResponse(err, repoName, chartName, chartVersion)
+	}
+	manifests, err := renderChartManifests(chartByVersion, nil, getTargetCapabilities(target))
+	if err != nil {
+		c.log().Warnf("error rendering chart %s-%s, %v", chartName, chartVersion, err)
+		return &httputil.ResponseJson{
+			Code: constant.ServerError,
+			Msg:  fmt.Sprintf("chart can not be rendered with default values: %v", err),
+		}, http.StatusInternalServerError
+	}
+
+	allFindings := findDeprecatedAPIs(manifests, nil)
+	if digest, exist := getCachedChartDigest(repoName, chartName, chartVersion); exist {
+		deprecationBadges.store(repoName, getDeprecationBadgeKey(chartName, chartVersion, digest),
+			getDeprecationBadge(allFindings))
+	}
+	report := &helm.DeprecationReport{
+		Chart:         chartByVersion.Metadata.Name,
+		Version:       chartByVersion.Metadata.Version,
+		TargetVersion: targetVersion,
+		Findings:      findDeprecatedAPIs(manifests, target),
+	}
+	return &httputil.ResponseJson{
+		Code: constant.Success,
+		Msg:  "success",
+		Data: report,
+	}, http.StatusOK
+}
+
+// getTargetCapabilities templates often switch api versions on .Capabilities.KubeVersion
+func getTargetCapabilities(target *kubeMinorVersion) *chartutil.Capabilities {
+	caps := chartutil.DefaultCapabilities.Copy()
+	if target != nil {
+		caps.KubeVersion = chartutil.KubeVersion{
+			Version: fmt.Sprintf("v%d.%d.0", target.major, target.minor),
+			Major:   strconv.Itoa(target.major),
+			Minor:   strconv.Itoa(target.minor),
+		}
+	}
+	return caps
+}
+
+// findDeprecatedAPIs nil target reports every known deprecation
+func findDeprecatedAPIs(manifests []renderedManifest, target *kubeMinorVersion) []helm.DeprecationFinding {
+	findings := make([]helm.DeprecationFinding, 0)
+	for _, manifest := range manifests {
+		for _, api := range deprecatedAPIs {
+			if api.APIVersion != manifest.APIVersion || api.Kind != manifest.Kind {
+				continue
+			}
+			status := getDeprecationStatus(api, target)
+			if status == "" {
+				continue
+			}
+			findings = append(findings, helm.DeprecationFinding{
+				Source:       manifest.Source,
+				Name:         manifest.Name,
+				APIVersion:   manifest.APIVersion,
+				Kind:         manifest.Kind,
+				Status:       status,
+				DeprecatedIn: api.DeprecatedIn,
+				RemovedIn:    api.RemovedIn,
+				Replacement:  api.Replacement,
+			})
+		}
+	}
+	return findings
+}
+
+func getDeprecationStatus(api deprecatedAPI, target *kubeMinorVersion) string {
+	if target == nil {
+		return helm.DeprecationStatusDeprecated
+	}
+	removedIn, err := parseKubeMinorVersion(api.RemovedIn)
+	if err == nil && !target.less(removedIn) {
+		return helm.DeprecationStatusRemoved
+	}
+	deprecatedIn, err := parseKubeMinorVersion(api.DeprecatedIn)
+	if err == nil && !target.less(deprecatedIn) {
+		return helm.DeprecationStatusDeprecated
+	}
+	return ""
+}
+
+// getDeprecationBadge nil for charts without findings
+func getDeprecationBadge(findings []helm.DeprecationFinding) *helm.DeprecationBadge {
+	if len(findings) == 0 {
+		return nil
+	}
+	badge := &helm.DeprecationBadge{}
+	var deprecatedIn, removedIn *kubeMinorVersion
+	for _, finding := range findings {
+		if parsed, err := parseKubeMinorVersion(finding.DeprecatedIn); err == nil &&
+			(deprecatedIn == nil || parsed.less(*deprecatedIn)) {
+			deprecatedIn = &parsed
+			badge.DeprecatedIn = finding.DeprecatedIn
+		}
+		if parsed, err := parseKubeMinorVersion(finding.RemovedIn); err == nil &&
+			(removedIn == nil || parsed.less(*removedIn)) {
+			removedIn = &parsed
+			badge.RemovedIn = finding.RemovedIn
+		}
+	}
+	return badge
+}
+
+func getDeprecationBadgeKey(chartName, chartVersion, digest string) string {
+	return chartName + "/" + chartVersion + "/" + digest
+}
+
+// getCachedChartDigest digest of the chart version in the cached index of the repository
+func getCachedChartDigest(repoName, chartName, chartVersion string) (string, bool) {
+	indexEntries, exist := cachedData.GetChartCacheByRepo(repoName)
+	if !exist {
+		return "", false
+	}
+	for _, entry := range indexEntries[chartName] {
+		if entry.Version == chartVersion {
+			return entry.Digest, true
+		}
+	}
+	return "", false
+}
+
+// getCachedDeprecationBadge badge of a chart version checked before, charts are not rendered when listing
+func getCachedDeprecationBadge(repoName string, entry *repo.ChartVersion) *helm.DeprecationBadge {
+	return deprecationBadges.load(repoName, getDeprecationBadgeKey(entry.Name, entry.Version, entry.Digest))
+}
@@ -0,0 +1,151 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package helm
+
+import (
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+
+	"marketplace-service/pkg/models/helm"
+)
+
+const mockDeprecationTemplates = `{{- if semverCompare ">=1.21-0" .Capabilities.KubeVersion.Version }}
+apiVersion: batch/v1
+{{- else }}
+apiVersion: batch/v1beta1
+{{- end }}
+kind: CronJob
+metadata:
+  name: {{ .Release.Name }}
+---
+apiVersion: policy/v1beta1
+kind: PodDisruptionBudget
+metadata:
+  name: {{ .Release.Name }}
+`
+
+func mockDeprecationChart() *chart.Chart {
+	return &chart.Chart{
+		Metadata:  &chart.Metadata{Name: "cron", Version: "1.0.0", APIVersion: "v2"},
+		Templates: []*chart.File{{Name: "templates/all.yaml", Data: []byte(mockDeprecationTemplates)}},
+	}
+}
+
+func Test_parseKubeMinorVersion(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    kubeMinorVersion
+		wantErr bool
+	}{
+		{input: "1.25", want: kubeMinorVersion{major: 1, minor: 25}},
+		{input: "v1.28.3", want: kubeMinorVersion{major: 1, minor: 28}},
+		{input: "1.27+", want: kubeMinorVersion{major: 1, minor: 27}},
+		{input: "latest", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseKubeMinorVersion(tt.input)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("parseKubeMinorVersion() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func Test_findDeprecatedAPIs(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		want   map[string]string
+	}{
+		{
+			name:   "Test_findDeprecatedAPIs_1.20",
+			target: "1.20",
+			want:   map[string]string{},
+		},
+		{
+			name:   "Test_findDeprecatedAPIs_1.21",
+			target: "1.21",
+			want:   map[string]string{"policy/v1beta1": helm.DeprecationStatusDeprecated},
+		},
+		{
+			name:   "Test_findDeprecatedAPIs_1.25",
+			target: "1.25",
+			want:   map[string]string{"policy/v1beta1": helm.DeprecationStatusRemoved},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, err := parseKubeMinorVersion(tt.target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			manifests, err := renderChartManifests(mockDeprecationChart(), nil, getTargetCapabilities(&target))
+			if err != nil {
+				t.Fatalf("renderChartManifests() error = %v", err)
+			}
+			got := findDeprecatedAPIs(manifests, &target)
+			if len(got) != len(tt.want) {
+				t.Fatalf("findDeprecatedAPIs() got %v, want %v", got, tt.want)
+			}
+			for _, finding := range got {
+				if tt.want[finding.APIVersion] != finding.Status {
+					t.Errorf("findDeprecatedAPIs() %s = %s, want %s", finding.APIVersion, finding.Status,
+						tt.want[finding.APIVersion])
+				}
+			}
+		})
+	}
+}
+
+func Test_getDeprecationBadge(t *testing.T) {
+	manifests, err := renderChartManifests(mockDeprecationChart(), nil, getTargetCapabilities(nil))
+	if err != nil {
+		t.Fatalf("renderChartManifests() error = %v", err)
+	}
+	got := getDeprecationBadge(findDeprecatedAPIs(manifests, nil))
+	if got.DeprecatedIn != "1.21" || got.RemovedIn != "1.25" {
+		t.Errorf("getDeprecationBadge() = %+v", got)
+	}
+	if clean := getDeprecationBadge(nil); clean != nil {
+		t.Errorf("getDeprecationBadge() = %+v, want no badge", clean)
+	}
+}
+
+func Test_deprecationBadgeCache(t *testing.T) {
+	cache := &deprecationBadgeCache{badges: make(map[string]map[string]*helm.DeprecationBadge)}
+	badge := &helm.DeprecationBadge{DeprecatedIn: "1.21", RemovedIn: "1.25"}
+	cache.store("bitnami", getDeprecationBadgeKey("mysql", "1.0.0", "sha-1"), badge)
+	cache.store("bitnami", getDeprecationBadgeKey("mysql", "2.0.0", "sha-2"), badge)
+	cache.store("bitnami", getDeprecationBadgeKey("mysql", "3.0.0", "sha-3"), nil)
+
+	cache.prune("bitnami", map[string]repo.ChartVersions{"mysql": {
+		{Metadata: &chart.Metadata{Name: "mysql", Version: "1.0.0"}, Digest: "sha-1"},
+		{Metadata: &chart.Metadata{Name: "mysql", Version: "2.0.0"}, Digest: "sha-2-republished"},
+	}})
+	if got := cache.load("bitnami", getDeprecationBadgeKey("mysql", "1.0.0", "sha-1")); got != badge {
+		t.Errorf("load() = %+v, want %+v", got, badge)
+	}
+	if got := cache.load("bitnami", getDeprecationBadgeKey("mysql", "2.0.0", "sha-2")); got != nil {
+		t.Errorf("load() of republished version = %+v, want no badge", got)
+	}
+	if len(cache.badges["bitnami"]) != 1 {
+		t.Errorf("badges = %+v, want only mysql 1.0.0", cache.badges["bitnami"])
+	}
+	cache.drop("bitnami")
+	if got := cache.load("bitnami", getDeprecationBadgeKey("mysql", "1.0.0", "sha-1")); got != nil {
+		t.Errorf("load() of deleted repository = %+v, want no badge", got)
+	}
+}
@@ -0,0 +1,272 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package helm
+
+import (
+	"fmt"
+	"net/http"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/yaml"
+
+	"marketplace-service/pkg/constant"
+	"marketplace-service/pkg/models/helm"
+	"marketplace-service/pkg/utils/httputil"
+	"marketplace-service/pkg/zlog"
+)
+
+const (
+	kindDeployment = "Deployment"
+	kindDaemonSet  = "DaemonSet"
+	kindJob        = "Job"
+
+	volumeClaimTemplateKind = "VolumeClaimTemplate"
+)
+
+var estimatedResources = []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory}
+
+// EstimateChartResources render the chart version with values and sum resources of its workloads
+func (c *helmClient) EstimateChartResources(repoName, chartName, chartVersion string,
+	estimateRequest *helm.ResourceEstimateRequest) (*httputil.ResponseJson, int) {
+	values, err := chartutil.ReadValues([]byte(estimateRequest.Values))
+	if err != nil {
+		return &httputil.ResponseJson{
+			Code: constant.ClientError,
+			Msg:  fmt.Sprintf("invalid values: %v", err),
+		}, http.StatusBadRequest
+	}
+	chartByVersion, err := c.getChartByVersion(repoName, chartName, chartVersion)
+	if err != nil {
+		return c.chartLoadFailureResponse(err, repoName, chartName, chartVersion)
+	}
+	manifests, err := renderChartManifests(chartByVersion, values, chartutil.DefaultCapabilities)
+	if err != nil {
+		c.log().Warnf("error rendering chart %s-%s, %v", chartName, chartVersion, err)
+		return &httputil.ResponseJson{
+			Code: constant.ClientError,
+			Msg:  fmt.Sprintf("chart can not be rendered with given values: %v", err),
+		}, http.StatusBadRequest
+	}
+	estimate := estimateResources(manifests)
+	estimate.Chart = chartByVersion.Metadata.Name
+	estimate.Version = chartByVersion.Metadata.Version
+	return &httputil.ResponseJson{
+		Code: constant.Success,
+		Msg:  "success",
+		Data: estimate,
+	}, http.StatusOK
+}
+
+// resourceEstimator accumulates resources while walking rendered manifests
+type resourceEstimator struct {
+	estimate      *helm.ResourceEstimate
+	totalRequests v1.ResourceList
+	totalLimits   v1.ResourceList
+	nodeRequests  v1.ResourceList
+	nodeLimits    v1.ResourceList
+	totalStorage  resource.Quantity
+}
+
+func estimateResources(manifests []renderedManifest) *helm.ResourceEstimate {
+	estimator := &resourceEstimator{
+		estimate: &helm.ResourceEstimate{
+			Workloads:                 make([]helm.WorkloadEstimate, 0),
+			Storage:                   make([]helm.StorageEstimate, 0),
+			ContainersWithoutRequests: make([]string, 0),
+		},
+		totalRequests: v1.ResourceList{},
+		totalLimits:   v1.ResourceList{},
+		nodeRequests:  v1.ResourceList{},
+		nodeLimits:    v1.ResourceList{},
+	}
+	for _, manifest := range manifests {
+		if err := estimator.addManifest(manifest); err != nil {
+			zlog.Warnf("skip unparsable %s %s in %s, %v", manifest.Kind, manifest.Name, manifest.Source, err)
+		}
+	}
+	estimator.estimate.Total = toResourceSummary(estimator.totalRequests, estimator.totalLimits)
+	estimator.estimate.PerNode = toResourceSummary(estimator.nodeRequests, estimator.nodeLimits)
+	estimator.estimate.TotalStorage = estimator.totalStorage.String()
+	return estimator.estimate
+}
+
+func (e *resourceEstimator) addManifest(manifest renderedManifest) error {
+	switch manifest.Kind {
+	case kindDeployment:
+		deployment := &appsv1.Deployment{}
+		if err := yaml.Unmarshal([]byte(manifest.Content), deployment); err != nil {
+			return err
+		}
+		e.addWorkload(manifest, replicasOrDefault(deployment.Spec.Replicas), false, &deployment.Spec.Template.Spec)
+	case kindStatefulSet:
+		statefulSet := &appsv1.StatefulSet{}
+		if err := yaml.Unmarshal([]byte(manifest.Content), statefulSet); err != nil {
+			return err
+		}
+		replicas := replicasOrDefault(statefulSet.Spec.Replicas)
+		e.addWorkload(manifest, replicas, false, &statefulSet.Spec.Template.Spec)
+		for i := range statefulSet.Spec.VolumeClaimTemplates {
+			template := &statefulSet.Spec.VolumeClaimTemplates[i]
+			e.addStorage(volumeClaimTemplateKind, template.Name, manifest.Source, &template.Spec, replicas)
+		}
+	case kindDaemonSet:
+		daemonSet := &appsv1.DaemonSet{}
+		if err := yaml.Unmarshal([]byte(manifest.Content), daemonSet); err != nil {
+			return err
+		}
+		e.addWorkload(manifest, 1, true, &daemonSet.Spec.Template.Spec)
+	case kindJob:
+		job := &batchv1.Job{}
+		if err := yaml.Unmarshal([]byte(manifest.Content), job); err != nil {
+			return err
+		}
+		e.addWorkload(manifest, replicasOrDefault(job.Spec.Parallelism), false, &job.Spec.Template.Spec)
+	case kindPersistentVolumeClaim:
+		pvc := &v1.PersistentVolumeClaim{}
+		if err := yaml.Unmarshal([]byte(manifest.Content), pvc); err != nil {
+			return err
+		}
+		e.addStorage(kindPersistentVolumeClaim, pvc.Name, manifest.Source, &pvc.Spec, 1)
+	default:
+	}
+	return nil
+}
+
+func (e *resourceEstimator) addWorkload(manifest renderedManifest, replicas int32, perNode bool,
+	podSpec *v1.PodSpec) {
+	podRequests, podLimits := getPodResources(podSpec)
+	totalRequests := multiplyResourceList(podRequests, replicas)
+	totalLimits := multiplyResourceList(podLimits, replicas)
+	if perNode {
+		addResourceList(e.nodeRequests, totalRequests)
+		addResourceList(e.nodeLimits, totalLimits)
+	} else {
+		addResourceList(e.totalRequests, totalRequests)
+		addResourceList(e.totalLimits, totalLimits)
+	}
+	e.estimate.Workloads = append(e.estimate.Workloads, helm.WorkloadEstimate{
+		Kind:     manifest.Kind,
+		Name:     manifest.Name,
+		Source:   manifest.Source,
+		Replicas: replicas,
+		PerNode:  perNode,
+		Pod:      toResourceSummary(podRequests, podLimits),
+		Total:    toResourceSummary(totalRequests, totalLimits),
+	})
+
+	containers := append(append([]v1.Container{}, podSpec.InitContainers...), podSpec.Containers...)
+	for _, container := range containers {
+		_, hasCPU := container.Resources.Requests[v1.ResourceCPU]
+		_, hasMemory := container.Resources.Requests[v1.ResourceMemory]
+		if !hasCPU || !hasMemory {
+			e.estimate.ContainersWithoutRequests = append(e.estimate.ContainersWithoutRequests,
+				fmt.Sprintf("%s/%s/%s", manifest.Kind, manifest.Name, container.Name))
+		}
+	}
+}
+
+func (e *resourceEstimator) addStorage(kind, name, source string, spec *v1.PersistentVolumeClaimSpec,
+	replicas int32) {
+	size := spec.Resources.Requests[v1.ResourceStorage]
+	total := size.DeepCopy()
+	total.Mul(int64(replicas))
+	e.totalStorage.Add(total)
+	storage := helm.StorageEstimate{
+		Kind:     kind,
+		Name:     name,
+		Source:   source,
+		Size:     size.String(),
+		Replicas: replicas,
+		Total:    total.String(),
+	}
+	if spec.StorageClassName != nil {
+		storage.StorageClass = *spec.StorageClassName
+	}
+	e.estimate.Storage = append(e.estimate.Storage, storage)
+}
+
+// getPodResources effective pod resources the scheduler accounts for,
+// the larger of the sum of containers and the largest init container
+func getPodResources(podSpec *v1.PodSpec) (v1.ResourceList, v1.ResourceList) {
+	requests, limits := v1.ResourceList{}, v1.ResourceList{}
+	for _, container := range podSpec.Containers {
+		addResourceList(requests, container.Resources.Requests)
+		addResourceList(limits, container.Resources.Limits)
+	}
+	for _, container := range podSpec.InitContainers {
+		maxResourceList(requests, container.Resources.Requests)
+		maxResourceList(limits, container.Resources.Limits)
+	}
+	return requests, limits
+}
+
+func addResourceList(total, added v1.ResourceList) {
+	for _, name := range estimatedResources {
+		quantity, ok := added[name]
+		if !ok {
+			continue
+		}
+		current := total[name]
+		current.Add(quantity)
+		total[name] = current
+	}
+}
+
+func maxResourceList(total, other v1.ResourceList) {
+	for _, name := range estimatedResources {
+		quantity, ok := other[name]
+		if !ok {
+			continue
+		}
+		if current, exist := total[name]; !exist || current.Cmp(quantity) < 0 {
+			total[name] = quantity.DeepCopy()
+		}
+	}
+}
+
+func multiplyResourceList(list v1.ResourceList, replicas int32) v1.ResourceList {
+	result := v1.ResourceList{}
+	for name, quantity := range list {
+		multiplied := quantity.DeepCopy()
+		multiplied.Mul(int64(replicas))
+		result[name] = multiplied
+	}
+	return result
+}
+
+func toResourceSummary(requests, limits v1.ResourceList) helm.ResourceSummary {
+	summary := helm.ResourceSummary{
+		Requests: make(map[string]string),
+		Limits:   make(map[string]string),
+	}
+	for _, name := range estimatedResources {
+		if quantity, ok := requests[name]; ok {
+			summary.Requests[string(name)] = quantity.String()
+		}
+		if quantity, ok := limits[name]; ok {
+			summary.Limits[string(name)] = quantity.String()
+		}
+	}
+	return summary
+}
+
+func replicasOrDefault(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
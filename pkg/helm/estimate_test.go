@@ -0,0 +1,116 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package helm
+
+import (
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+const mockEstimateTemplates = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: {{ .Values.replicas }}
+  template:
+    spec:
+      initContainers:
+      - name: init
+        resources:
+          requests: {cpu: "1", memory: 64Mi}
+      containers:
+      - name: web
+        resources:
+          requests: {cpu: 250m, memory: 256Mi}
+          limits: {cpu: 500m, memory: 512Mi}
+      - name: sidecar
+        resources:
+          requests: {cpu: 250m}
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: db
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: db
+        resources:
+          requests: {cpu: 100m, memory: 1Gi}
+  volumeClaimTemplates:
+  - metadata:
+      name: data
+    spec:
+      resources:
+        requests: {storage: 10Gi}
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: agent
+spec:
+  template:
+    spec:
+      containers:
+      - name: agent
+        resources:
+          requests: {cpu: 50m, memory: 32Mi}
+---
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: shared
+spec:
+  storageClassName: nfs
+  resources:
+    requests: {storage: 5Gi}
+`
+
+func Test_estimateResources(t *testing.T) {
+	chrt := &chart.Chart{
+		Metadata:  &chart.Metadata{Name: "app", Version: "1.0.0", APIVersion: "v2"},
+		Templates: []*chart.File{{Name: "templates/all.yaml", Data: []byte(mockEstimateTemplates)}},
+		Values:    map[string]interface{}{"replicas": 1},
+	}
+	values, err := chartutil.ReadValues([]byte("replicas: 3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifests, err := renderChartManifests(chrt, values, chartutil.DefaultCapabilities)
+	if err != nil {
+		t.Fatalf("renderChartManifests() error = %v", err)
+	}
+	got := estimateResources(manifests)
+
+	// web: 3 * max(500m, 1) cpu + 3 * 256Mi, db: 2 * 100m cpu + 2 * 1Gi
+	if got.Total.Requests["cpu"] != "3200m" || got.Total.Requests["memory"] != "2816Mi" {
+		t.Errorf("estimateResources() total requests = %v", got.Total.Requests)
+	}
+	if got.Total.Limits["cpu"] != "1500m" || got.Total.Limits["memory"] != "1536Mi" {
+		t.Errorf("estimateResources() total limits = %v", got.Total.Limits)
+	}
+	if got.PerNode.Requests["cpu"] != "50m" || got.PerNode.Requests["memory"] != "32Mi" {
+		t.Errorf("estimateResources() per node requests = %v", got.PerNode.Requests)
+	}
+	if len(got.Storage) != 2 || got.TotalStorage != "25Gi" {
+		t.Errorf("estimateResources() storage = %v, total %s", got.Storage, got.TotalStorage)
+	}
+	if len(got.ContainersWithoutRequests) != 1 || got.ContainersWithoutRequests[0] != "Deployment/web/sidecar" {
+		t.Errorf("estimateResources() containers without requests = %v", got.ContainersWithoutRequests)
+	}
+}
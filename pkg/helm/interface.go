@@ -14,8 +14,11 @@ package helm
 
 import (
 	"bytes"
+	"context"
 	"mime/multipart"
 
+	snapshotclient "github.com/kubernetes-csi/external-snapshotter/client/v4/clientset/versioned"
+	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -28,6 +31,8 @@ import (
 
 // Operation all operations for helm
 type Operation interface {
+	// WithContext operations bound to a request context, kubernetes and upstream calls continue its trace
+	WithContext(ctx context.Context) Operation
 	// 仓库操作
 	CreateRepo(repoEntry *helm.SafeRepoEntry) (*httputil.ResponseJson, int)
 	UpdateRepo(repoEntry *helm.SafeRepoEntry) (*httputil.ResponseJson, int)
@@ -37,6 +42,13 @@ type Operation interface {
 	SyncAllRepos() (*httputil.ResponseJson, int)
 	SyncRepo(repoName string) (*httputil.ResponseJson, int)
 	GetRepoSyncStatus(repoName string) (*httputil.ResponseJson, int)
+	RotateRepoCredentials() (*httputil.ResponseJson, int)
+	TestRepo(repoEntry *helm.SafeRepoEntry) (*httputil.ResponseJson, int)
+	ExportRepos(credentials string) (*httputil.ResponseJson, int)
+	ImportRepos(data []byte, dryRun bool) (*httputil.ResponseJson, int)
+	WatchRepoReferences(ctx context.Context)
+	LabelRepoReferences()
+	ValidateRepoCR(repository, old *helm.HelmChartRepository) error
 	// chart 操作
 	GetLatestCharts(searchParam *helm.ChartSearchParam) (*httputil.ResponseJson, int)
 	GetChartsWithOfficialTags(tags []string, query *param.Query) (*httputil.ResponseJson, int)
@@ -48,12 +60,25 @@ type Operation interface {
 	GetChartVersion(repoName, chartName, version string) (*httputil.ResponseJson, int)
 	GetChartFiles(repoName, chartName, version, fileType string) (*httputil.ResponseJson, int)
 	GetChartBytesByVersion(repoName, chartName, version string) (*bytes.Buffer, error)
+	CheckChartCRDs(repoName, chartName, version string) (*httputil.ResponseJson, int)
+	CheckChartCompatibility(repoName, chartName, version string) (*httputil.ResponseJson, int)
+	CheckChartDeprecations(repoName, chartName, version, targetVersion string) (*httputil.ResponseJson, int)
+	EstimateChartResources(repoName, chartName, version string,
+		request *helm.ResourceEstimateRequest) (*httputil.ResponseJson, int)
+	// release 卷快照操作
+	CreateReleaseSnapshots(namespace, release string, request *helm.SnapshotRequest) (*httputil.ResponseJson, int)
+	ListReleaseSnapshots(namespace, release string, revision int) (*httputil.ResponseJson, int)
+	RestoreReleaseSnapshots(namespace, release string, revision int,
+		request *helm.SnapshotRestoreRequest) (*httputil.ResponseJson, int)
 }
 
 type helmClient struct {
-	kubeConfig    *rest.Config
-	dynamicClient dynamic.Interface
-	clientset     kubernetes.Interface
+	kubeConfig          *rest.Config
+	dynamicClient       dynamic.Interface
+	clientset           kubernetes.Interface
+	snapshotClient      snapshotclient.Interface
+	apiExtensionsClient apiextensionsclient.Interface
+	ctx                 context.Context
 }
 
 // NewHelmOperation helm operation requires client set&dynamic client，for kubernetes resource operation
@@ -69,9 +94,53 @@ func NewHelmOperation(kubeConfig *rest.Config) (Operation, error) {
 		zlog.Errorf("error creating client set, err: %v", err)
 		return nil, err
 	}
+
+	snapshotClient, err := snapshotclient.NewForConfig(kubeConfig)
+	if err != nil {
+		zlog.Errorf("error creating snapshot client, err: %v", err)
+		return nil, err
+	}
+
+	apiExtensionsClient, err := apiextensionsclient.NewForConfig(kubeConfig)
+	if err != nil {
+		zlog.Errorf("error creating api extensions client, err: %v", err)
+		return nil, err
+	}
 	return &helmClient{
-		kubeConfig:    kubeConfig,
-		dynamicClient: dynamicClient,
-		clientset:     clientset,
+		kubeConfig:          kubeConfig,
+		dynamicClient:       dynamicClient,
+		clientset:           clientset,
+		snapshotClient:      snapshotClient,
+		apiExtensionsClient: apiExtensionsClient,
+		ctx:                 context.Background(),
 	}, nil
 }
+
+func (c *helmClient) WithContext(ctx context.Context) Operation {
+	return c.withContext(ctx)
+}
+
+func (c *helmClient) withContext(ctx context.Context) *helmClient {
+	client := *c
+	client.ctx = ctx
+	return &client
+}
+
+// requestContext context of the bound request, background if unbound
+func (c *helmClient) requestContext() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// detached client for work outliving the request, such as background synchronization. It keeps
+// the trace of the request but is not cancelled with it
+func (c *helmClient) detached() *helmClient {
+	return c.withContext(context.WithoutCancel(c.requestContext()))
+}
+
+// log logger tagged with the trace of the bound request
+func (c *helmClient) log() *zlog.Logger {
+	return zlog.FromContext(c.requestContext())
+}
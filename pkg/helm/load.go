@@ -14,13 +14,15 @@ package helm
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
-	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chart/loader"
 	"helm.sh/helm/v3/pkg/registry"
@@ -28,7 +30,11 @@ import (
 	"sigs.k8s.io/yaml"
 
 	"marketplace-service/pkg/constant"
-	"marketplace-service/pkg/utils/httputil"
+	"marketplace-service/pkg/egress"
+	marketplaceErrors "marketplace-service/pkg/errors"
+	"marketplace-service/pkg/metrics"
+	"marketplace-service/pkg/tracing"
+	"marketplace-service/pkg/utils/redact"
 	"marketplace-service/pkg/zlog"
 )
 
@@ -36,25 +42,34 @@ var (
 	timeLimit = 120
 )
 
+// span attributes of repository operations
+const (
+	repoAttributeKey = "marketplace.repo"
+	urlAttributeKey  = "marketplace.url"
+	sizeAttributeKey = "marketplace.size"
+)
+
 // LoadChart load helm chart tar file from repo url
-func LoadChart(chartUrl string, repoEntry *repo.Entry) (*chart.Chart, error) {
-	chartBytes, err := LoadChartBytes(chartUrl, repoEntry)
+func LoadChart(ctx context.Context, chartUrl string, repoEntry *repo.Entry) (*chart.Chart, error) {
+	chartBytes, err := LoadChartBytes(ctx, chartUrl, repoEntry)
 	if err != nil {
-		fmt.Println("Error loading chartStruct archive:", err)
 		return nil, err
 	}
+	_, span := tracing.Start(ctx, "LoadArchive",
+		trace.WithAttributes(attribute.Int(sizeAttributeKey, chartBytes.Len())))
 	chartStruct, err := loader.LoadArchive(chartBytes)
+	tracing.EndSpan(span, err)
 	if err != nil {
-		fmt.Println("Error loading chartStruct archive:", err)
+		zlog.FromContext(ctx).Errorf("error loading chart archive, %v", err)
 		return nil, err
 	}
 	return chartStruct, nil
 }
 
 // LoadChartBytes load helm chart tar file from repo url
-func LoadChartBytes(chartUrl string, repoEntry *repo.Entry) (*bytes.Buffer, error) {
+func LoadChartBytes(ctx context.Context, chartUrl string, repoEntry *repo.Entry) (*bytes.Buffer, error) {
 	if registry.IsOCI(chartUrl) {
-		return LoadOCI(chartUrl, repoEntry)
+		return LoadOCI(ctx, chartUrl, repoEntry)
 	}
 	if !(strings.HasPrefix(chartUrl, "https://") || strings.HasPrefix(chartUrl, "http://")) {
 		u := repoEntry.URL
@@ -64,7 +79,7 @@ func LoadChartBytes(chartUrl string, repoEntry *repo.Entry) (*bytes.Buffer, erro
 			chartUrl = fmt.Sprintf("%s%s", u, chartUrl)
 		}
 	}
-	resp, err := LoadData(chartUrl, repoEntry)
+	resp, err := LoadData(ctx, chartUrl, repoEntry)
 	if err != nil {
 		return nil, err
 	}
@@ -72,20 +87,15 @@ func LoadChartBytes(chartUrl string, repoEntry *repo.Entry) (*bytes.Buffer, erro
 }
 
 // LoadRepoIndex load repository index.yaml from url
-func LoadRepoIndex(repoEntry *repo.Entry) (*repo.IndexFile, error) {
-	u := repoEntry.URL
-	if !strings.HasSuffix(u, "/") {
-		u = fmt.Sprintf("%s/%s", u, "index.yaml")
-	} else {
-		u = fmt.Sprintf("%s%s", u, "index.yaml")
-	}
-
-	resp, err := LoadData(u, repoEntry)
+func LoadRepoIndex(ctx context.Context, repoEntry *repo.Entry) (*repo.IndexFile, error) {
+	resp, err := LoadData(ctx, repoIndexURL(repoEntry.URL), repoEntry)
 	if err != nil {
 		return nil, err
 	}
 
+	_, span := tracing.Start(ctx, "LoadIndex", trace.WithAttributes(attribute.Int(sizeAttributeKey, resp.Len())))
 	indexFile, err := loadIndex(resp.Bytes())
+	tracing.EndSpan(span, err)
 	if err != nil {
 		return nil, err
 	}
@@ -93,6 +103,14 @@ func LoadRepoIndex(repoEntry *repo.Entry) (*repo.IndexFile, error) {
 	return indexFile, nil
 }
 
+// repoIndexURL url of the index.yaml of the repository at u
+func repoIndexURL(u string) string {
+	if !strings.HasSuffix(u, "/") {
+		return fmt.Sprintf("%s/%s", u, "index.yaml")
+	}
+	return fmt.Sprintf("%s%s", u, "index.yaml")
+}
+
 func loadIndex(data []byte) (*repo.IndexFile, error) {
 	i := &repo.IndexFile{}
 	if err := yaml.Unmarshal(data, i); err != nil {
@@ -127,32 +145,36 @@ func newLimitedBuffer(maxSize int64) *limitedBuffer {
 	}
 }
 
-// LoadData load index.yaml from target url
-func LoadData(u string, repoEntry *repo.Entry) (*bytes.Buffer, error) {
-	req, err := http.NewRequest("GET", u, nil)
+// LoadData load index.yaml from target url, the trace context of ctx is propagated to the repository
+func LoadData(ctx context.Context, u string, repoEntry *repo.Entry) (result *bytes.Buffer, err error) {
+	ctx, span := tracing.Start(ctx, "LoadData", trace.WithAttributes(
+		attribute.String(repoAttributeKey, repoEntry.Name), attribute.String(urlAttributeKey, redact.String(u))))
+	defer func() {
+		tracing.EndSpan(span, err)
+	}()
+	log := zlog.FromContext(ctx)
+	if err = egress.CheckURL(ctx, u); err != nil {
+		log.Warnf("%v", err)
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 	if err != nil {
-		zlog.Errorf("error creating index.yaml request: %v", err)
+		log.Errorf("error creating index.yaml request: %v", err)
 		return nil, err
 	}
 	if repoEntry.Username != "" && repoEntry.Password != "" {
 		req.SetBasicAuth(repoEntry.Username, repoEntry.Password)
 	}
 
+	client, err := repoHTTPClient(repoEntry)
 	if err != nil {
-		zlog.Errorf("error get http config %v", err)
-	}
-	tlsClient, err := httputil.GetHarborHTTPConfig(repoEntry)
-	if err != nil {
-		zlog.Errorf("error get http config %v", err)
+		log.Errorf("error get http config %v", err)
+		return nil, err
 	}
-	client := &http.Client{Transport: &http.Transport{
-		TLSHandshakeTimeout: time.Duration(timeLimit) * time.Second,
-		TLSClientConfig:     tlsClient,
-	}}
 	resp, err := client.Do(req)
 	if err != nil {
-		zlog.Errorf("error making index.yaml request: %v", err)
-		return nil, err
+		log.Errorf("error making index.yaml request: %v", err)
+		return nil, &marketplaceErrors.ConnectionError{Message: err.Error(), Err: err}
 	}
 	defer resp.Body.Close()
 
@@ -160,29 +182,41 @@ func LoadData(u string, repoEntry *repo.Entry) (*bytes.Buffer, error) {
 	limitedBuf := newLimitedBuffer(maxSize)
 
 	_, err = io.Copy(limitedBuf, resp.Body)
+	metrics.AddRemoteFetchBytes(repoEntry.Name, limitedBuf.written)
+	span.SetAttributes(attribute.Int64(sizeAttributeKey, limitedBuf.written))
 	if err != nil {
-		zlog.Errorf("failed to read response body: %v", err)
+		log.Errorf("failed to read response body: %v", err)
 		return nil, errors.New(fmt.Sprintf(""))
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, errors.New(fmt.Sprintf("Error: received non-200 status code: %d", resp.StatusCode))
+		return nil, &marketplaceErrors.HttpResponseNotOKError{
+			Message:    fmt.Sprintf("Error: received non-200 status code: %d", resp.StatusCode),
+			StatusCode: resp.StatusCode,
+		}
 	}
 	return limitedBuf.buffer, nil
 }
 
-func LoadOCI(ref string, repoEntry *repo.Entry) (*bytes.Buffer, error) {
-	var opts []registry.ClientOption
-	if !repoEntry.InsecureSkipTLSverify {
-		tlsClient, err := httputil.GetHarborHTTPConfig(repoEntry)
-		if err != nil {
-			zlog.Errorf("error get http config %v", err)
-		}
-		opts = append(opts, registry.ClientOptHTTPClient(&http.Client{Transport: &http.Transport{
-			TLSHandshakeTimeout: time.Duration(timeLimit) * time.Second,
-			TLSClientConfig:     tlsClient,
-		}}))
+// LoadOCI pull chart from oci registry through the shared client of the repository, the trace context of ctx
+// is propagated to the registry
+func LoadOCI(ctx context.Context, ref string, repoEntry *repo.Entry) (result *bytes.Buffer, err error) {
+	ctx, span := tracing.Start(ctx, "LoadOCI", trace.WithAttributes(
+		attribute.String(repoAttributeKey, repoEntry.Name), attribute.String(urlAttributeKey, redact.String(ref))))
+	defer func() {
+		tracing.EndSpan(span, err)
+	}()
+	if err = egress.CheckURL(ctx, ref); err != nil {
+		return nil, err
 	}
+	shared, err := repoHTTPClient(repoEntry)
+	if err != nil {
+		return nil, err
+	}
+	opts := []registry.ClientOption{registry.ClientOptHTTPClient(&http.Client{
+		Timeout:   shared.Timeout,
+		Transport: &contextTransport{ctx: ctx, base: shared.Transport},
+	})}
 	if repoEntry.Username != "" && repoEntry.Password != "" {
 		opts = append(opts, registry.ClientOptBasicAuth(repoEntry.Username, repoEntry.Password))
 	}
@@ -190,9 +224,24 @@ func LoadOCI(ref string, repoEntry *repo.Entry) (*bytes.Buffer, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error creating OCI client: %w", err)
 	}
-	result, err := client.Pull(ref, registry.PullOptWithChart(true))
+	pulled, err := client.Pull(ref, registry.PullOptWithChart(true))
 	if err != nil {
 		return nil, fmt.Errorf("error pulling OCI chart: %w", err)
 	}
-	return bytes.NewBuffer(result.Chart.Data), nil
+	span.SetAttributes(attribute.Int(sizeAttributeKey, len(pulled.Chart.Data)))
+	return bytes.NewBuffer(pulled.Chart.Data), nil
+}
+
+// contextTransport the registry client does not take the context of the pull, contextTransport puts the
+// span of the pull into its requests so they join the trace
+type contextTransport struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+func (t *contextTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if !trace.SpanContextFromContext(request.Context()).IsValid() {
+		request = request.WithContext(trace.ContextWithSpan(request.Context(), trace.SpanFromContext(t.ctx)))
+	}
+	return t.base.RoundTrip(request)
 }
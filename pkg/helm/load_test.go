@@ -15,14 +15,37 @@
 package helm
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"helm.sh/helm/v3/pkg/repo"
+
+	"marketplace-service/pkg/egress"
+	"marketplace-service/pkg/models/helm"
+	"marketplace-service/pkg/upstream"
 )
 
+func init() {
+	// the mock repositories are httptest servers on loopback, blocked by the default egress policy
+	policy, err := egress.NewPolicy(egress.DefaultSchemes, []string{"127.0.0.0/8", "::1/128"}, nil)
+	if err != nil {
+		panic(err)
+	}
+	egress.SetPolicy(policy)
+	// failures of one test must not be retried or open the circuit of a repository shared with later tests
+	options := upstream.DefaultOptions()
+	options.MaxRetries = 0
+	options.FailureThreshold = 0
+	upstream.SetOptions(options)
+}
+
 // TestLoadRepoIndex 测试索引文件加载
 func TestLoadRepoIndex(t *testing.T) {
 	// 模拟测试服务器
@@ -40,7 +63,7 @@ entries:
 	defer ts.Close()
 
 	repoEntry := &repo.Entry{URL: ts.URL}
-	index, err := LoadRepoIndex(repoEntry)
+	index, err := LoadRepoIndex(context.Background(), repoEntry)
 	assert.NoError(t, err)
 	assert.Equal(t, "v1", index.APIVersion)
 	assert.Contains(t, index.Entries, "test-chart")
@@ -56,7 +79,7 @@ func TestLoadChartBytes(t *testing.T) {
 	defer ts.Close()
 
 	repoEntry := &repo.Entry{URL: ts.URL}
-	buf, err := LoadChartBytes("test-chart.tgz", repoEntry)
+	buf, err := LoadChartBytes(context.Background(), "test-chart.tgz", repoEntry)
 	assert.NoError(t, err)
 	assert.Equal(t, "test chart data", buf.String())
 }
@@ -69,7 +92,101 @@ func TestLoadData_StatusCode(t *testing.T) {
 	defer ts.Close()
 
 	repoEntry := &repo.Entry{URL: ts.URL}
-	_, err := LoadData(ts.URL, repoEntry)
+	_, err := LoadData(context.Background(), ts.URL, repoEntry)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "received non-200 status code: 404")
 }
+
+// TestLoadData_redactsSpanURL 测试span中不记录url中的凭据
+func TestLoadData_redactsSpanURL(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		_ = provider.Shutdown(context.Background())
+	})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	u := strings.Replace(ts.URL, "http://", "http://admin:s3cret@", 1)
+	_, err := LoadData(context.Background(), u, &repo.Entry{Name: "traced", URL: u})
+	assert.NoError(t, err)
+	spans := recorder.Ended()
+	assert.NotEmpty(t, spans)
+	for _, span := range spans {
+		for _, attr := range span.Attributes() {
+			assert.NotContains(t, attr.Value.Emit(), "s3cret")
+		}
+	}
+}
+
+// TestLoadRepoIndex_egressViolation 测试拦截指向元数据地址的仓库
+func TestLoadRepoIndex_egressViolation(t *testing.T) {
+	_, err := LoadRepoIndex(context.Background(), &repo.Entry{Name: "metadata", URL: "http://169.254.169.254/latest"})
+	assert.True(t, egress.IsViolation(err))
+
+	response, blocked := egressViolationResponse(err)
+	assert.True(t, blocked)
+	assert.Contains(t, response.Msg, "169.254.169.254")
+}
+
+// TestLoadRepoIndex_proxyAndHeaders 测试经代理访问仓库并附加自定义请求头
+func TestLoadRepoIndex_proxyAndHeaders(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		if r.Header.Get("X-Tenant-ID") != "tenant-a" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte("apiVersion: v1\nentries: {}\n"))
+	}))
+	defer proxy.Close()
+
+	repoEntry := &repo.Entry{Name: "proxied-repo", URL: "http://charts.example.invalid/stable"}
+	storeRepoConnection(repoEntry.Name, &repoConnection{
+		proxy:   helm.ProxyConfig{URL: proxy.URL},
+		headers: map[string]string{"X-Tenant-ID": "tenant-a"},
+	})
+	defer storeRepoConnection(repoEntry.Name, nil)
+
+	index, err := LoadRepoIndex(context.Background(), repoEntry)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", index.APIVersion)
+	assert.Equal(t, "http://charts.example.invalid/stable/index.yaml", proxied)
+}
+
+// TestLoadOCI_sharedClient 测试OCI拉取经仓库共享客户端，失败计入熔断器
+func TestLoadOCI_sharedClient(t *testing.T) {
+	options := upstream.DefaultOptions()
+	options.MaxRetries = 0
+	options.FailureThreshold = 1
+	upstream.SetOptions(options)
+	defer func() {
+		options.FailureThreshold = 0
+		upstream.SetOptions(options)
+	}()
+
+	var tunneled string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tunneled = r.Host
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer proxy.Close()
+
+	repoEntry := &repo.Entry{Name: "oci-repo", URL: "oci://charts.example.invalid/stable"}
+	storeRepoConnection(repoEntry.Name, &repoConnection{proxy: helm.ProxyConfig{URL: proxy.URL}})
+	defer storeRepoConnection(repoEntry.Name, nil)
+
+	_, err := LoadOCI(context.Background(), "oci://charts.example.invalid/stable/nginx:1.0.0", repoEntry)
+	assert.Error(t, err)
+	assert.Equal(t, "charts.example.invalid:443", tunneled)
+
+	health, ok := upstream.RepoHealth(repoEntry.Name)
+	assert.True(t, ok)
+	assert.Equal(t, upstream.StateOpen, health.State)
+}
@@ -0,0 +1,482 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package helm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"helm.sh/helm/v3/pkg/repo"
+	v1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+
+	"marketplace-service/pkg/constant"
+	"marketplace-service/pkg/models/helm"
+	"marketplace-service/pkg/utils/httputil"
+	"marketplace-service/pkg/utils/k8sutil"
+)
+
+const (
+	referenceKindSecret    = "secret"
+	referenceKindConfigMap = "config map"
+
+	// referenceLabel label an existing secret or config map opts in to be referenced by repositories with,
+	// a caller who may create repositories can not have any object of the namespace sent to a repository
+	referenceLabel = "marketplace.openfuyao.com/repository-credentials"
+	// managedLabel label of the secrets and config maps marketplace creates for the credentials of a repository
+	managedLabel = "marketplace.openfuyao.com/managed-credentials"
+)
+
+var (
+	// errReferenceNotLabeled an existing object is referenced without opting in with referenceLabel
+	errReferenceNotLabeled = errors.New("referenced object is not labeled " + referenceLabel + "=true")
+	// errReferenceNotManaged a reference to an object marketplace manages names another object than the one
+	// created for the repository
+	errReferenceNotManaged = errors.New("referenced object is not managed by marketplace for the repository")
+)
+
+// repoReference reference of a repository to a secret or config map
+type repoReference struct {
+	kind     string
+	name     string
+	external bool
+	// suffix of the name of the object marketplace creates for the repository
+	suffix string
+}
+
+// repoReferenceList basic auth, tls, ca, headers and auth references of the repository that name an object
+func repoReferenceList(spec helm.HelmChartRepositorySpec) []repoReference {
+	references := []repoReference{
+		{referenceKindSecret, spec.BasicAuth.Name, spec.BasicAuth.External, repoCRSecretBasicAuth},
+		{referenceKindSecret, spec.TLS.Name, spec.TLS.External, repoCRSecretTLS},
+		{referenceKindConfigMap, spec.CA.Name, spec.CA.External, repoCRConfigMapCA},
+	}
+	if spec.Headers != nil {
+		references = append(references,
+			repoReference{referenceKindSecret, spec.Headers.Name, spec.Headers.External, repoCRSecretHeaders})
+	}
+	if spec.Auth != nil {
+		references = append(references,
+			repoReference{referenceKindSecret, spec.Auth.Secret.Name, spec.Auth.Secret.External, repoCRSecretAuth})
+	}
+	return slices.DeleteFunc(references, func(reference repoReference) bool {
+		return reference.name == ""
+	})
+}
+
+// managedReferenceName name of the object marketplace creates for the repository with the suffix
+func managedReferenceName(displayName, suffix string) string {
+	return displayName + suffix
+}
+
+// managedObjectMeta object meta of a secret or config map marketplace creates for a repository
+func managedObjectMeta(name string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      name,
+		Namespace: constant.MarketplaceServiceDefaultNamespace,
+		Labels:    map[string]string{managedLabel: "true"},
+	}
+}
+
+func managedObject(labels map[string]string) bool {
+	return labels[managedLabel] == "true"
+}
+
+// LabelRepoReferences label the secrets and config maps marketplace created for repositories before it labeled
+// them with managedLabel, references to them are rejected otherwise
+func (c *helmClient) LabelRepoReferences() {
+	repoList, err := c.listCustomRepo()
+	if err != nil {
+		c.log().Errorf("list repositories to label their credentials failed, %v", err)
+		return
+	}
+	for i := range repoList {
+		for _, reference := range repoReferenceList(repoList[i].Spec) {
+			if reference.external || reference.name != managedReferenceName(repoList[i].Spec.DisplayName,
+				reference.suffix) {
+				continue
+			}
+			if err = c.labelManagedObject(reference.kind, reference.name); err != nil {
+				c.log().Errorf("label %s %s of repo %s failed, %v", reference.kind, reference.name,
+					repoList[i].Spec.DisplayName, err)
+			}
+		}
+	}
+}
+
+// labelManagedObject add managedLabel to the secret or config map, a missing object is left to the sync of the
+// repository to report
+func (c *helmClient) labelManagedObject(kind, name string) error {
+	namespace := constant.MarketplaceServiceDefaultNamespace
+	if kind == referenceKindConfigMap {
+		configMap, err := k8sutil.GetConfigMap(c.requestContext(), c.clientset, name, namespace)
+		if k8sErrors.IsNotFound(err) || (err == nil && (configMap == nil || managedObject(configMap.Labels))) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		configMap.Labels = labels.Merge(configMap.Labels, managedObjectMeta(name).Labels)
+		_, err = k8sutil.UpdateConfigMap(c.requestContext(), c.clientset, configMap)
+		return err
+	}
+	secret, err := k8sutil.GetSecret(c.requestContext(), c.clientset, name, namespace)
+	if k8sErrors.IsNotFound(err) || (err == nil && (secret == nil || managedObject(secret.Labels))) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	secret.Labels = labels.Merge(secret.Labels, managedObjectMeta(name).Labels)
+	_, err = k8sutil.UpdateSecret(c.requestContext(), c.clientset, secret)
+	return err
+}
+
+// secretReferenceData data of a referenced secret, decrypted if marketplace sealed it. The keys of an existing
+// secret are mapped to the keys marketplace reads, it must carry referenceLabel. Any other secret must be the
+// one marketplace created for the repository, named managedName
+func (c *helmClient) secretReferenceData(ref helm.SecretReference, managedName string) (map[string][]byte, error) {
+	if !ref.External && ref.Name != managedName {
+		return nil, errReferenceNotManaged
+	}
+	secret, err := k8sutil.GetSecret(c.requestContext(), c.clientset, ref.Name,
+		constant.MarketplaceServiceDefaultNamespace)
+	if err != nil || secret == nil {
+		return nil, err
+	}
+	if !ref.External {
+		if !managedObject(secret.Labels) {
+			return nil, errReferenceNotManaged
+		}
+		return c.openSecret(secret)
+	}
+	if !referenceOptedIn(secret.Labels) {
+		return nil, errReferenceNotLabeled
+	}
+	data, err := c.openSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+	return mapReferenceKeys(data, ref.Keys), nil
+}
+
+// configMapReferenceData data of a referenced config map. The keys of an existing config map are mapped to
+// the keys marketplace reads, it must carry referenceLabel. Any other config map must be the one marketplace
+// created for the repository, named managedName
+func (c *helmClient) configMapReferenceData(ref helm.ConfigMapReference,
+	managedName string) (map[string]string, error) {
+	if !ref.External && ref.Name != managedName {
+		return nil, errReferenceNotManaged
+	}
+	configMap, err := k8sutil.GetConfigMap(c.requestContext(), c.clientset, ref.Name,
+		constant.MarketplaceServiceDefaultNamespace)
+	if err != nil || configMap == nil {
+		return nil, err
+	}
+	if !ref.External {
+		if !managedObject(configMap.Labels) {
+			return nil, errReferenceNotManaged
+		}
+		return configMap.Data, nil
+	}
+	if !referenceOptedIn(configMap.Labels) {
+		return nil, errReferenceNotLabeled
+	}
+	return mapReferenceKeys(configMap.Data, ref.Keys), nil
+}
+
+func referenceOptedIn(labels map[string]string) bool {
+	return labels[referenceLabel] == "true"
+}
+
+func mapReferenceKeys[V any](data map[string]V, keys map[string]string) map[string]V {
+	if len(keys) == 0 {
+		return data
+	}
+	mapped := make(map[string]V, len(data)+len(keys))
+	for key, value := range data {
+		mapped[key] = value
+	}
+	for key, source := range keys {
+		if value, ok := data[source]; ok {
+			mapped[key] = value
+		}
+	}
+	return mapped
+}
+
+// missingReferenceKey first of keys without a value in data
+func missingReferenceKey[V ~string | ~[]byte](data map[string]V, keys ...string) (string, bool) {
+	for _, key := range keys {
+		if len(data[key]) == 0 {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// resolveRepoReferences read the existing secrets and config map referenced by a create or update request
+// into entry, a reference given along with inline credentials, or to a missing or incomplete object is rejected
+func (c *helmClient) resolveRepoReferences(repoEntry *helm.SafeRepoEntry,
+	entry *repo.Entry) (*httputil.ResponseJson, int, error) {
+	if ref := repoEntry.BasicAuthRef; ref != nil {
+		if len(repoEntry.Username) != 0 || len(repoEntry.Password) != 0 {
+			return referenceConflict("basicAuthRef", "username and password")
+		}
+		data, err := c.secretReferenceData(externalSecretReference(ref), "")
+		if response, status, err := checkReference(referenceKindSecret, ref.Name, data, err,
+			mapKeyUsername, mapKeyPassword); err != nil {
+			return response, status, err
+		}
+		entry.Username = string(data[mapKeyUsername])
+		entry.Password = string(data[mapKeyPassword])
+	}
+	if ref := repoEntry.TLSRef; ref != nil {
+		if repoEntry.CertFile != "" || repoEntry.KeyFile != "" {
+			return referenceConflict("tlsRef", "certFile and keyFile")
+		}
+		data, err := c.secretReferenceData(externalSecretReference(ref), "")
+		if response, status, err := checkReference(referenceKindSecret, ref.Name, data, err,
+			mapKeyTLSCrt, mapKeyTLSKey); err != nil {
+			return response, status, err
+		}
+		entry.CertFile = string(data[mapKeyTLSCrt])
+		entry.KeyFile = string(data[mapKeyTLSKey])
+	}
+	if ref := repoEntry.CARef; ref != nil {
+		if repoEntry.CAFile != "" {
+			return referenceConflict("caRef", "caFile")
+		}
+		data, err := c.configMapReferenceData(externalConfigMapReference(ref), "")
+		if response, status, err := checkReference(referenceKindConfigMap, ref.Name, data, err,
+			mapKeyCAKey); err != nil {
+			return response, status, err
+		}
+		entry.CAFile = data[mapKeyCAKey]
+	}
+	return nil, 0, nil
+}
+
+func referenceConflict(ref, inline string) (*httputil.ResponseJson, int, error) {
+	msg := fmt.Sprintf("%s can not be given along with %s", ref, inline)
+	return &httputil.ResponseJson{Code: constant.ClientError, Msg: msg}, http.StatusBadRequest, errors.New(msg)
+}
+
+// checkReference response of a referenced object that could not be read or lacks one of keys
+func checkReference[V ~string | ~[]byte](kind, name string, data map[string]V, err error,
+	keys ...string) (*httputil.ResponseJson, int, error) {
+	var msg string
+	switch {
+	case name == "":
+		msg = fmt.Sprintf("name of the referenced %s is missing", kind)
+	case k8sErrors.IsNotFound(err):
+		msg = fmt.Sprintf("%s %s not found in namespace %s", kind, name, constant.MarketplaceServiceDefaultNamespace)
+	case errors.Is(err, errReferenceNotLabeled):
+		msg = fmt.Sprintf("%s %s is not labeled %s=true", kind, name, referenceLabel)
+	case errors.Is(err, errReferenceNotManaged):
+		msg = fmt.Sprintf("%s %s is not the one marketplace manages for the repository, reference an existing %s"+
+			" as external", kind, name, kind)
+	case err != nil:
+		return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError, err
+	default:
+		key, missing := missingReferenceKey(data, keys...)
+		if !missing {
+			return nil, 0, nil
+		}
+		msg = fmt.Sprintf("%s %s has no value for key %s", kind, name, key)
+	}
+	return &httputil.ResponseJson{Code: constant.ClientError, Msg: msg}, http.StatusBadRequest, errors.New(msg)
+}
+
+// externalSecretReference reference of the repository cr to an existing secret
+func externalSecretReference(ref *helm.SecretReference) helm.SecretReference {
+	external := *ref.DeepCopy()
+	external.External = true
+	return external
+}
+
+// externalConfigMapReference reference of the repository cr to an existing config map
+func externalConfigMapReference(ref *helm.ConfigMapReference) helm.ConfigMapReference {
+	external := *ref.DeepCopy()
+	external.External = true
+	return external
+}
+
+// repoReferences basic auth, tls and ca references of the repository cr, to the existing objects referenced by
+// the request or the ones marketplace created from its inline credentials
+func repoReferences(repoEntry *helm.SafeRepoEntry, authRef, tlsRef *v1.Secret,
+	caRef *v1.ConfigMap) (helm.SecretReference, helm.SecretReference, helm.ConfigMapReference) {
+	var basicAuth, tls helm.SecretReference
+	var ca helm.ConfigMapReference
+	if repoEntry.BasicAuthRef != nil {
+		basicAuth = externalSecretReference(repoEntry.BasicAuthRef)
+	} else if authRef != nil {
+		basicAuth.Name = authRef.Name
+	}
+	if repoEntry.TLSRef != nil {
+		tls = externalSecretReference(repoEntry.TLSRef)
+	} else if tlsRef != nil {
+		tls.Name = tlsRef.Name
+	}
+	if repoEntry.CARef != nil {
+		ca = externalConfigMapReference(repoEntry.CARef)
+	} else if caRef != nil {
+		ca.Name = caRef.Name
+	}
+	return basicAuth, tls, ca
+}
+
+// managedReferences copy of the repository without its references to external objects, so that updating
+// the credentials marketplace manages neither writes nor deletes them
+func managedReferences(repository *helm.HelmChartRepository) *helm.HelmChartRepository {
+	managed := repository.DeepCopy()
+	if managed.Spec.BasicAuth.External {
+		managed.Spec.BasicAuth = helm.SecretReference{}
+	}
+	if managed.Spec.TLS.External {
+		managed.Spec.TLS = helm.SecretReference{}
+	}
+	if managed.Spec.CA.External {
+		managed.Spec.CA = helm.ConfigMapReference{}
+	}
+	if managed.Spec.Headers != nil && managed.Spec.Headers.External {
+		managed.Spec.Headers = nil
+	}
+	if managed.Spec.Auth != nil && managed.Spec.Auth.Secret.External {
+		managed.Spec.Auth.Secret = helm.SecretReference{}
+	}
+	return managed
+}
+
+// externalOnly the reference if it points to an existing secret, nil otherwise
+func externalOnly(ref *helm.SecretReference) *helm.SecretReference {
+	if ref == nil || !ref.External {
+		return nil
+	}
+	return ref
+}
+
+// referencesExternal whether the repository references the existing object of kind and name
+func referencesExternal(repository *helm.HelmChartRepository, kind, name string) bool {
+	return slices.ContainsFunc(repoReferenceList(repository.Spec), func(reference repoReference) bool {
+		return reference.external && reference.kind == kind && reference.name == name
+	})
+}
+
+// referenceWatcher resynchronizes the repositories referencing an existing secret or config map once it
+// changes, e.g. when external-secrets rotates the credentials
+type referenceWatcher struct {
+	client *helmClient
+	resync func(repository *helm.HelmChartRepository)
+}
+
+// WatchRepoReferences resynchronize repositories when the existing secrets and config maps they reference
+// change, until ctx is done
+func (c *helmClient) WatchRepoReferences(ctx context.Context) {
+	client := c.detached()
+	watcher := &referenceWatcher{client: client, resync: client.resyncRepository}
+	watcher.run(ctx)
+}
+
+// run watch the secrets and config maps opted in with referenceLabel, the others of the namespace, e.g. leader
+// election config maps, are neither cached nor looked up
+func (w *referenceWatcher) run(ctx context.Context) {
+	factory := informers.NewSharedInformerFactoryWithOptions(w.client.clientset, 0,
+		informers.WithNamespace(constant.MarketplaceServiceDefaultNamespace),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.LabelSelector = referenceLabel + "=true"
+		}))
+	handler := cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(obj interface{}, isInInitialList bool) {
+			// referenced objects created after the repository, the initial list is covered by the startup sync
+			if !isInInitialList {
+				w.changed(obj)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			if resourceVersion(oldObj) != resourceVersion(newObj) {
+				w.changed(newObj)
+			}
+		},
+	}
+	for _, informer := range []cache.SharedIndexInformer{
+		factory.Core().V1().Secrets().Informer(),
+		factory.Core().V1().ConfigMaps().Informer(),
+	} {
+		if _, err := informer.AddEventHandler(handler); err != nil {
+			w.client.log().Errorf("watch repository references failed, %v", err)
+			return
+		}
+	}
+	factory.Start(ctx.Done())
+	<-ctx.Done()
+	factory.Shutdown()
+}
+
+func resourceVersion(obj interface{}) string {
+	switch object := obj.(type) {
+	case *v1.Secret:
+		return object.ResourceVersion
+	case *v1.ConfigMap:
+		return object.ResourceVersion
+	default:
+		return ""
+	}
+}
+
+func (w *referenceWatcher) changed(obj interface{}) {
+	var kind, name string
+	var labels map[string]string
+	switch object := obj.(type) {
+	case *v1.Secret:
+		kind, name, labels = referenceKindSecret, object.Name, object.Labels
+	case *v1.ConfigMap:
+		kind, name, labels = referenceKindConfigMap, object.Name, object.Labels
+	default:
+		return
+	}
+	if !referenceOptedIn(labels) {
+		return
+	}
+	repoList, err := w.client.listCustomRepo()
+	if err != nil {
+		w.client.log().Errorf("list repositories referencing %s %s failed, %v", kind, name, err)
+		return
+	}
+	for i := range repoList {
+		// suspended repositories pick up the change when they are resumed
+		if repoList[i].Spec.Suspended || !referencesExternal(&repoList[i], kind, name) {
+			continue
+		}
+		w.client.log().Infof("%s %s referenced by repo %s changed, resynchronizing", kind, name,
+			repoList[i].Spec.DisplayName)
+		go w.resync(&repoList[i])
+	}
+}
+
+// resyncRepository synchronize the index of the repository, logging failures
+func (c *helmClient) resyncRepository(repository *helm.HelmChartRepository) {
+	errRepoList := make(chan errRepo, 1)
+	c.asyncUpdateRepository(repository, errRepoList)
+	close(errRepoList)
+	for errRepo := range errRepoList {
+		c.log().Errorf("repo %s sync failed: %v", errRepo.Name, errRepo.Err)
+	}
+}
@@ -0,0 +1,234 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package helm
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	dynamicFake "k8s.io/client-go/dynamic/fake"
+	clientSetFake "k8s.io/client-go/kubernetes/fake"
+
+	"marketplace-service/pkg/constant"
+	"marketplace-service/pkg/models/helm"
+	"marketplace-service/pkg/utils/k8sutil"
+)
+
+const mockExternalSecret = "team-chart-credentials"
+
+func mockExternalSecretObject() *v1.Secret {
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            mockExternalSecret,
+			Namespace:       constant.MarketplaceServiceDefaultNamespace,
+			ResourceVersion: "1",
+			Labels:          map[string]string{referenceLabel: "true"},
+		},
+		Data: map[string][]byte{"user": []byte("robot"), "token": []byte("s3cret")},
+	}
+}
+
+// Test_helmClient_resolveRepoReferences 测试引用已有secret与configmap
+func Test_helmClient_resolveRepoReferences(t *testing.T) {
+	ca := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-ca", Namespace: constant.MarketplaceServiceDefaultNamespace,
+			Labels: map[string]string{referenceLabel: "true"}},
+		Data: map[string]string{mapKeyCAKey: "-----BEGIN CERTIFICATE-----"},
+	}
+	unlabeled := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "service-secret", Namespace: constant.MarketplaceServiceDefaultNamespace},
+		Data:       map[string][]byte{"key": []byte("private")},
+	}
+	c := &helmClient{clientset: clientSetFake.NewSimpleClientset(mockExternalSecretObject(), ca, unlabeled)}
+	basicAuthRef := &helm.SecretReference{Name: mockExternalSecret,
+		Keys: map[string]string{mapKeyUsername: "user", mapKeyPassword: "token"}}
+
+	repoEntry := &helm.SafeRepoEntry{Name: mockCredentialRepo, BasicAuthRef: basicAuthRef,
+		CARef: &helm.ConfigMapReference{Name: "team-ca"}}
+	entry := safeRepoEntryToRepoEntry(repoEntry)
+	_, _, err := c.resolveRepoReferences(repoEntry, entry)
+	assert.NoError(t, err)
+	assert.Equal(t, "robot", entry.Username)
+	assert.Equal(t, "s3cret", entry.Password)
+	assert.Equal(t, "-----BEGIN CERTIFICATE-----", entry.CAFile)
+
+	tests := []struct {
+		name      string
+		repoEntry *helm.SafeRepoEntry
+	}{
+		{"inline credentials", &helm.SafeRepoEntry{Username: []byte("admin"), BasicAuthRef: basicAuthRef}},
+		{"missing secret", &helm.SafeRepoEntry{TLSRef: &helm.SecretReference{Name: "absent"}}},
+		{"missing key", &helm.SafeRepoEntry{BasicAuthRef: &helm.SecretReference{Name: mockExternalSecret}}},
+		{"missing name", &helm.SafeRepoEntry{CARef: &helm.ConfigMapReference{}}},
+		{"unlabeled secret", &helm.SafeRepoEntry{BasicAuthRef: &helm.SecretReference{Name: "service-secret",
+			Keys: map[string]string{mapKeyUsername: "key", mapKeyPassword: "key"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, status, err := c.resolveRepoReferences(tt.repoEntry, safeRepoEntryToRepoEntry(tt.repoEntry))
+			assert.Error(t, err)
+			assert.Equal(t, http.StatusBadRequest, status)
+		})
+	}
+}
+
+// Test_helmClient_externalReferences 测试外部secret被读取但不被删除或轮换
+func Test_helmClient_externalReferences(t *testing.T) {
+	clientset := clientSetFake.NewSimpleClientset(mockSymmetricKeySecret("current-key", ""),
+		mockExternalSecretObject())
+	c := &helmClient{clientset: clientset, dynamicClient: mockDynamicClient()}
+	repoCR := mockCredentialRepoCR("", "")
+	repoCR.Spec.BasicAuth, _, _ = repoReferences(&helm.SafeRepoEntry{BasicAuthRef: &helm.SecretReference{
+		Name: mockExternalSecret, Keys: map[string]string{mapKeyUsername: "user", mapKeyPassword: "token"}}},
+		nil, nil, nil)
+	assert.True(t, repoCR.Spec.BasicAuth.External)
+	object, err := k8sutil.StructToUnstructured(repoCR)
+	assert.NoError(t, err)
+	_, err = c.dynamicClient.Resource(repoCRDGVR).Create(context.Background(), object, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	repoEntry, err := c.repoCRtoRepoEntry(repoCR)
+	assert.NoError(t, err)
+	assert.Equal(t, "robot", repoEntry.Username)
+	assert.Equal(t, "s3cret", repoEntry.Password)
+
+	_, status := c.RotateRepoCredentials()
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []byte("s3cret"), getMockSecret(t, clientset, mockExternalSecret).Data["token"])
+
+	assert.NoError(t, c.deleteRepoCR(mockCredentialRepo))
+	getMockSecret(t, clientset, mockExternalSecret)
+}
+
+// Test_helmClient_externalHeadersAndAuth 测试外部请求头与认证secret被读取但不被改写、轮换或删除
+func Test_helmClient_externalHeadersAndAuth(t *testing.T) {
+	clientset := clientSetFake.NewSimpleClientset(mockSymmetricKeySecret("current-key", ""),
+		mockExternalSecretObject())
+	c := &helmClient{clientset: clientset, dynamicClient: mockDynamicClient()}
+	repoCR := mockCredentialRepoCR("", "")
+	repoCR.Spec.Headers = &helm.SecretReference{Name: mockExternalSecret, External: true}
+	repoCR.Spec.Auth = &helm.RepositoryAuth{Type: "token", Secret: helm.SecretReference{Name: mockExternalSecret,
+		Keys: map[string]string{mapKeyToken: "token"}, External: true}}
+	object, err := k8sutil.StructToUnstructured(repoCR)
+	assert.NoError(t, err)
+	_, err = c.dynamicClient.Resource(repoCRDGVR).Create(context.Background(), object, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	assert.True(t, referencesExternal(repoCR, referenceKindSecret, mockExternalSecret))
+	managed := managedReferences(repoCR)
+	assert.Nil(t, managed.Spec.Headers)
+	assert.Empty(t, managed.Spec.Auth.Secret.Name)
+
+	repoEntry, err := c.repoCRtoRepoEntry(repoCR)
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cret", loadRepoConnection(repoEntry.Name).auth.Token)
+	assert.Equal(t, "robot", loadRepoConnection(repoEntry.Name).headers["user"])
+	storeRepoConnection(repoEntry.Name, nil)
+
+	_, status := c.RotateRepoCredentials()
+	assert.Equal(t, http.StatusOK, status)
+	_, status = c.updateRepoSecretAndConfigmap(&helm.SafeRepoEntry{Name: mockCredentialRepo, URL: repoCR.Spec.URL},
+		repoCR)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, mockExternalSecret, repoCR.Spec.Headers.Name)
+	assert.True(t, repoCR.Spec.Auth.Secret.External)
+	assert.Equal(t, mockExternalSecretObject().Data, getMockSecret(t, clientset, mockExternalSecret).Data)
+
+	assert.NoError(t, c.deleteRepoCR(mockCredentialRepo))
+	getMockSecret(t, clientset, mockExternalSecret)
+}
+
+// Test_referenceWatcher 测试外部secret变化时重新同步引用它的仓库
+func Test_referenceWatcher(t *testing.T) {
+	secret := mockExternalSecretObject()
+	clientset := clientSetFake.NewSimpleClientset(secret)
+	c := &helmClient{clientset: clientset, dynamicClient: mockDynamicClient()}
+	referencing := mockCredentialRepoCR(mockExternalSecret, "")
+	referencing.Spec.BasicAuth.External = true
+	managed := mockCredentialRepoCR(mockExternalSecret, "")
+	managed.Name, managed.Spec.DisplayName = "managed", "managed"
+	for _, repository := range []*helm.HelmChartRepository{referencing, managed} {
+		object, err := k8sutil.StructToUnstructured(repository)
+		assert.NoError(t, err)
+		_, err = c.dynamicClient.Resource(repoCRDGVR).Create(context.Background(), object, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+
+	resynced := make(chan string, 10)
+	watcher := &referenceWatcher{client: c, resync: func(repository *helm.HelmChartRepository) {
+		resynced <- repository.Spec.DisplayName
+	}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go watcher.run(ctx)
+
+	// the fake clientset keeps resource versions as given, bump them like the api server
+	version := 1
+	assert.Eventually(t, func() bool {
+		version++
+		secret.ResourceVersion = strconv.Itoa(version)
+		secret.Data["token"] = []byte("rotated-" + secret.ResourceVersion)
+		_, err := clientset.CoreV1().Secrets(constant.MarketplaceServiceDefaultNamespace).Update(ctx, secret,
+			metav1.UpdateOptions{})
+		assert.NoError(t, err)
+		select {
+		case name := <-resynced:
+			return name == mockCredentialRepo
+		default:
+			return false
+		}
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+// Test_referenceWatcher_unlabeled 测试未加标签的secret与configmap变化时不查询仓库
+func Test_referenceWatcher_unlabeled(t *testing.T) {
+	dynamicClient := mockDynamicClient().(*dynamicFake.FakeDynamicClient)
+	c := &helmClient{clientset: clientSetFake.NewSimpleClientset(), dynamicClient: dynamicClient}
+	watcher := &referenceWatcher{client: c, resync: func(*helm.HelmChartRepository) {}}
+
+	watcher.changed(&v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "marketplace-service-leader"}})
+	watcher.changed(&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: mockExternalSecret}})
+	assert.Empty(t, dynamicClient.Actions())
+
+	watcher.changed(mockExternalSecretObject())
+	assert.Len(t, dynamicClient.Actions(), 1)
+}
+
+// Test_helmClient_managedReferences 测试非外部引用只能指向marketplace为该仓库创建的对象
+func Test_helmClient_managedReferences(t *testing.T) {
+	foreign := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: mockCredentialRepo + repoCRSecretHeaders,
+			Namespace: constant.MarketplaceServiceDefaultNamespace},
+		Data: map[string][]byte{"X-Api-Key": []byte("private")},
+	}
+	c := &helmClient{clientset: clientSetFake.NewSimpleClientset(foreign, mockExternalSecretObject())}
+	repoCR := mockCredentialRepoCR("", "")
+
+	for _, name := range []string{mockExternalSecret, foreign.Name} {
+		repoCR.Spec.Headers = &helm.SecretReference{Name: name}
+		_, err := c.repoCRtoRepoEntry(repoCR)
+		assert.ErrorIs(t, err, errReferenceNotManaged)
+	}
+
+	_, err := c.createRepoCRHeaders(mockCredentialRepo, map[string]string{"X-Api-Key": "team"})
+	assert.NoError(t, err)
+	repoEntry, err := c.repoCRtoRepoEntry(repoCR)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"X-Api-Key": "team"}, loadRepoConnection(repoEntry.Name).headers)
+	storeRepoConnection(repoEntry.Name, nil)
+}
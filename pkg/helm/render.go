@@ -0,0 +1,138 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package helm
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"sigs.k8s.io/yaml"
+
+	"marketplace-service/pkg/zlog"
+)
+
+const (
+	previewNamespace = "default"
+	notesFileSuffix  = "NOTES.txt"
+)
+
+// renderedManifest a single kubernetes object rendered from chart templates or crds directory
+type renderedManifest struct {
+	// Source template file or crd file of the object
+	Source     string
+	APIVersion string
+	Kind       string
+	Name       string
+	Content    string
+}
+
+// getClusterCapabilities capabilities of the cluster the service is running in, as helm install would see them
+func (c *helmClient) getClusterCapabilities() (*chartutil.Capabilities, error) {
+	serverVersion, err := c.clientset.Discovery().ServerVersion()
+	if err != nil {
+		return nil, fmt.Errorf("could not get server version from Kubernetes: %v", err)
+	}
+	versionSet, err := action.GetVersionSet(c.clientset.Discovery())
+	if err != nil {
+		return nil, err
+	}
+	return &chartutil.Capabilities{
+		KubeVersion: chartutil.KubeVersion{
+			Version: serverVersion.GitVersion,
+			Major:   serverVersion.Major,
+			Minor:   serverVersion.Minor,
+		},
+		APIVersions: versionSet,
+		HelmVersion: chartutil.DefaultCapabilities.HelmVersion,
+	}, nil
+}
+
+// renderChartManifests render chart with values the same way helm install does, without accessing the cluster
+func renderChartManifests(chrt *chart.Chart, values map[string]interface{},
+	caps *chartutil.Capabilities) ([]renderedManifest, error) {
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+	if err := chartutil.ProcessDependenciesWithMerge(chrt, values); err != nil {
+		return nil, err
+	}
+	options := chartutil.ReleaseOptions{
+		Name:      chrt.Metadata.Name,
+		Namespace: previewNamespace,
+		Revision:  1,
+		IsInstall: true,
+	}
+	renderValues, err := chartutil.ToRenderValues(chrt, values, options, caps)
+	if err != nil {
+		return nil, err
+	}
+	files, err := engine.Render(chrt, renderValues)
+	if err != nil {
+		return nil, err
+	}
+
+	manifests := make([]renderedManifest, 0)
+	for _, crdObject := range chrt.CRDObjects() {
+		manifests = append(manifests, parseManifests(crdObject.Filename, string(crdObject.File.Data))...)
+	}
+	templateNames := make([]string, 0, len(files))
+	for name := range files {
+		templateNames = append(templateNames, name)
+	}
+	sort.Strings(templateNames)
+	for _, name := range templateNames {
+		if strings.HasSuffix(name, notesFileSuffix) || strings.HasPrefix(path.Base(name), "_") {
+			continue
+		}
+		manifests = append(manifests, parseManifests(name, files[name])...)
+	}
+	return manifests, nil
+}
+
+func parseManifests(source, content string) []renderedManifest {
+	manifests := make([]renderedManifest, 0)
+	splitManifests := releaseutil.SplitManifests(content)
+	keys := make([]string, 0, len(splitManifests))
+	for key := range splitManifests {
+		keys = append(keys, key)
+	}
+	sort.Sort(releaseutil.BySplitManifestsOrder(keys))
+	for _, key := range keys {
+		var head releaseutil.SimpleHead
+		if err := yaml.Unmarshal([]byte(splitManifests[key]), &head); err != nil {
+			zlog.Warnf("skip unparsable manifest in %s, %v", source, err)
+			continue
+		}
+		if head.Version == "" || head.Kind == "" {
+			continue
+		}
+		manifest := renderedManifest{
+			Source:     source,
+			APIVersion: head.Version,
+			Kind:       head.Kind,
+			Content:    splitManifests[key],
+		}
+		if head.Metadata != nil {
+			manifest.Name = head.Metadata.Name
+		}
+		manifests = append(manifests, manifest)
+	}
+	return manifests
+}
@@ -22,7 +22,10 @@ import (
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"helm.sh/helm/v3/pkg/repo"
+	helmtime "helm.sh/helm/v3/pkg/time"
 	v1 "k8s.io/api/core/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -33,12 +36,15 @@ import (
 	"k8s.io/apimachinery/pkg/util/json"
 
 	"marketplace-service/pkg/constant"
+	"marketplace-service/pkg/egress"
+	"marketplace-service/pkg/metrics"
 	"marketplace-service/pkg/models/helm"
 	"marketplace-service/pkg/server/param"
+	"marketplace-service/pkg/tracing"
+	"marketplace-service/pkg/upstream"
 	"marketplace-service/pkg/utils/httputil"
 	"marketplace-service/pkg/utils/k8sutil"
 	"marketplace-service/pkg/utils/util"
-	"marketplace-service/pkg/zlog"
 )
 
 // map key
@@ -59,6 +65,8 @@ const (
 	repoCRSecretBasicAuth = "basic-auth"
 	repoCRSecretTLS       = "tls"
 	repoCRConfigMapCA     = "ca"
+	repoCRSecretHeaders   = "headers"
+	repoCRSecretAuth      = "auth"
 
 	mapKeyUsername = "username"
 	mapKeyPassword = "password"
@@ -66,13 +74,17 @@ const (
 	mapKeyTLSKey   = "tls.key"
 	mapKeyCAKey    = "ca"
 
+	mapKeyToken        = "token"
+	mapKeyClientID     = "clientID"
+	mapKeyClientSecret = "clientSecret"
+
 	requestLastTime = 10
 )
 
 func (c *helmClient) isRepoModificationAllowed(repoName string) (*httputil.ResponseJson, int, error) {
 	config, err := util.GetMarketplaceServiceConfig(c.clientset)
 	if err != nil || config == nil {
-		zlog.Errorf("error getting marketplace-service config: %v", err)
+		c.log().Errorf("error getting marketplace-service config: %v", err)
 		return &httputil.ResponseJson{
 			Code: http.StatusInternalServerError,
 			Msg:  "error getting marketplace-service config"}, http.StatusInternalServerError, err
@@ -98,34 +110,71 @@ func (c *helmClient) UpdateRepo(repoEntry *helm.SafeRepoEntry) (*httputil.Respon
 	repository, err := c.getCustomRepoByName(repoEntry.Name)
 	if err != nil {
 		if k8sErrors.IsNotFound(err) {
-			zlog.Errorf("update repository：%s not exist", repoEntry.Name)
+			c.log().Errorf("update repository：%s not exist", repoEntry.Name)
 			return &httputil.ResponseJson{
 					Code: constant.ClientError,
 					Msg:  fmt.Sprintf("creation failed, repository：%s doesn't exist.", repoEntry.Name)},
 				http.StatusBadRequest
 		}
-		zlog.Errorf("update repository %s failed in check existence ,%v", repoEntry.Name, err)
+		c.log().Errorf("update repository %s failed in check existence ,%v", repoEntry.Name, err)
 		return httputil.GetDefaultServerFailureResponseJson(),
 			http.StatusInternalServerError
 	}
-	index, err := LoadRepoIndex(safeRepoEntryToRepoEntry(repoEntry))
-	if err != nil {
-		zlog.Errorf("load repository index failed in update, %v", err)
-		return &httputil.ResponseJson{
-				Code: constant.ClientError,
-				Msg:  "unable to connect to the remote server, please try another url",
-			},
-			http.StatusBadRequest
+	if response, valid := validateRepoConnection(repoEntry); !valid {
+		return response, http.StatusBadRequest
+	}
+	entry := safeRepoEntryToRepoEntry(repoEntry)
+	if response, status, err := c.resolveRepoReferences(repoEntry, entry); err != nil {
+		c.log().Errorf("resolve references of repository %s failed, %v", repoEntry.Name, err)
+		return response, status
+	}
+	previous := loadRepoConnection(repoEntry.Name)
+	storeRepoConnection(repoEntry.Name, safeRepoEntryConnection(repoEntry))
+	// a suspended repository is not contacted, its chart cache stays as it was until it is resumed
+	var index *repo.IndexFile
+	if !repoSuspended(repoEntry, repository) {
+		index, err = LoadRepoIndex(c.requestContext(), entry)
+		if err != nil {
+			storeRepoConnection(repoEntry.Name, previous)
+			c.log().Errorf("load repository index failed in update, %v", err)
+			if response, blocked := egressViolationResponse(err); blocked {
+				return response, http.StatusBadRequest
+			}
+			return &httputil.ResponseJson{
+					Code: constant.ClientError,
+					Msg:  "unable to connect to the remote server, please try another url",
+				},
+				http.StatusBadRequest
+		}
 	}
 
 	// create corresponding secret and configmap
 	responseJson, status := c.updateRepoSecretAndConfigmap(repoEntry, repository)
-	if status == http.StatusOK {
+	if status == http.StatusOK && index != nil {
 		cachedData.SetChartCache(repoEntry.Name, index)
 	}
 	return responseJson, status
 }
 
+// repoSuspended whether the repository is suspended once the update request is applied
+func repoSuspended(repoEntry *helm.SafeRepoEntry, repository *helm.HelmChartRepository) bool {
+	if repoEntry.Suspended != nil {
+		return *repoEntry.Suspended
+	}
+	return repository.Spec.Suspended
+}
+
+// activeRepositories repositories that are not suspended
+func activeRepositories(repoList []helm.HelmChartRepository) []helm.HelmChartRepository {
+	active := make([]helm.HelmChartRepository, 0, len(repoList))
+	for i := range repoList {
+		if !repoList[i].Spec.Suspended {
+			active = append(active, repoList[i])
+		}
+	}
+	return active
+}
+
 /*
 CreateRepo create repo based on user input
 repoEntry contains user custom name, and corresponding CRD's name is created in lower case of that name
@@ -135,7 +184,7 @@ so keep in mind in lowercase conversion and use of strings.toLower()
 func (c *helmClient) CreateRepo(repoEntry *helm.SafeRepoEntry) (*httputil.ResponseJson, int) {
 	// check & validate
 	if valid, err := k8sutil.ResourceMetadataRegexValid(strings.ToLower(repoEntry.Name)); !valid || err != nil {
-		zlog.Errorf("create repository name check failed, name %s , %v", repoEntry.Name, err)
+		c.log().Errorf("create repository name check failed, name %s , %v", repoEntry.Name, err)
 		return &httputil.ResponseJson{
 				Code: constant.ClientError,
 				Msg: "must consist of lower case alphanumeric characters, '-' or '.'," +
@@ -144,13 +193,13 @@ func (c *helmClient) CreateRepo(repoEntry *helm.SafeRepoEntry) (*httputil.Respon
 	}
 	listCustomRepo, err := c.listCustomRepo()
 	if err != nil {
-		zlog.Errorf("create repo %s failed in list existed repo cr ,%v", repoEntry.Name, err)
+		c.log().Errorf("create repo %s failed in list existed repo cr ,%v", repoEntry.Name, err)
 		return httputil.GetDefaultServerFailureResponseJson(),
 			http.StatusInternalServerError
 	}
 	for _, customRepo := range listCustomRepo {
 		if customRepo.Spec.URL == repoEntry.URL || customRepo.Spec.DisplayName == repoEntry.Name {
-			zlog.Errorf("creation failed, repo：%s already exist.", repoEntry.Name)
+			c.log().Errorf("creation failed, repo：%s already exist.", repoEntry.Name)
 			return &httputil.ResponseJson{
 					Code: constant.ClientError,
 					Msg:  "creation failed, name or url already exist."},
@@ -158,9 +207,22 @@ func (c *helmClient) CreateRepo(repoEntry *helm.SafeRepoEntry) (*httputil.Respon
 		}
 	}
 
-	index, err := LoadRepoIndex(safeRepoEntryToRepoEntry(repoEntry))
+	if response, valid := validateRepoConnection(repoEntry); !valid {
+		return response, http.StatusBadRequest
+	}
+	entry := safeRepoEntryToRepoEntry(repoEntry)
+	if response, status, err := c.resolveRepoReferences(repoEntry, entry); err != nil {
+		c.log().Errorf("resolve references of repository %s failed, %v", repoEntry.Name, err)
+		return response, status
+	}
+	storeRepoConnection(repoEntry.Name, safeRepoEntryConnection(repoEntry))
+	index, err := LoadRepoIndex(c.requestContext(), entry)
 	if err != nil {
-		zlog.Errorf("load repo index failed, %v", err)
+		storeRepoConnection(repoEntry.Name, nil)
+		c.log().Errorf("load repo index failed, %v", err)
+		if response, blocked := egressViolationResponse(err); blocked {
+			return response, http.StatusBadRequest
+		}
 		return &httputil.ResponseJson{
 				Code: constant.ClientError,
 				Msg:  "unable to find index.yaml, please provide correct ChartMuseum project url",
@@ -178,24 +240,51 @@ func (c *helmClient) CreateRepo(repoEntry *helm.SafeRepoEntry) (*httputil.Respon
 
 func (c *helmClient) updateRepoSecretAndConfigmap(repoEntry *helm.SafeRepoEntry,
 	repository *helm.HelmChartRepository) (*httputil.ResponseJson, int) {
-	_, err := c.updateRepoCRBasicAuth(repository, repoEntry.Name, repoEntry.Username, repoEntry.Password)
+	// existing secrets and config maps referenced by the repository are left to their owners
+	managed := managedReferences(repository)
+	authRef, err := c.updateRepoCRBasicAuth(managed, repoEntry.Name, repoEntry.Username, repoEntry.Password)
+	if err != nil {
+		c.log().Errorf("update auth secret failed, %v", err)
+		return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
+	}
+	tlsRef, err := c.updateRepoCRTLS(managed, repoEntry.Name, repoEntry.KeyFile, repoEntry.CertFile)
+	if err != nil {
+		c.log().Errorf("update tls secret failed, %v", err)
+		return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
+	}
+	caRef, err := c.updateRepoCRCA(managed, repoEntry.Name, repoEntry.CAFile)
 	if err != nil {
-		zlog.Errorf("update auth secret failed, %v", err)
+		c.log().Errorf("update ca config map failed, %v", err)
 		return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
 	}
-	_, err = c.updateRepoCRTLS(repository, repoEntry.Name, repoEntry.KeyFile, repoEntry.CertFile)
+	repository.Spec.BasicAuth, repository.Spec.TLS, repository.Spec.CA = repoReferences(repoEntry, authRef, tlsRef,
+		caRef)
+	headersRef, err := c.updateRepoCRHeaders(managed, repoEntry.Name, repoEntry.Headers)
 	if err != nil {
-		zlog.Errorf("update tls secret failed, %v", err)
+		c.log().Errorf("update headers secret failed, %v", err)
 		return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
 	}
-	_, err = c.updateRepoCRCA(repository, repoEntry.Name, repoEntry.CAFile)
+	// an existing headers or auth secret stays referenced until the entry brings its own or moves the repository
+	// to another url
+	sameURL := repoEntry.URL == repository.Spec.URL
+	if headersRef != nil {
+		repository.Spec.Headers = &helm.SecretReference{Name: headersRef.Name}
+	} else if !sameURL {
+		repository.Spec.Headers = nil
+	} else {
+		repository.Spec.Headers = externalOnly(repository.Spec.Headers)
+	}
+	repoAuthRef, err := c.updateRepoCRAuth(managed, repoEntry.Name, repoEntry.Auth)
 	if err != nil {
-		zlog.Errorf("update ca config map failed, %v", err)
+		c.log().Errorf("update auth secret failed, %v", err)
 		return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
 	}
+	if repoAuthRef != nil || !sameURL || repository.Spec.Auth == nil || !repository.Spec.Auth.Secret.External {
+		repository.Spec.Auth = repositoryAuth(repoEntry.Auth, repoAuthRef)
+	}
 	updatedRepoCR, err := c.updateRepoCR(repository, repoEntry)
 	if err != nil {
-		zlog.Errorf("update repo cr failed %v", err)
+		c.log().Errorf("update repo cr failed %v", err)
 		return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
 	}
 	return &httputil.ResponseJson{
@@ -208,22 +297,32 @@ func (c *helmClient) updateRepoSecretAndConfigmap(repoEntry *helm.SafeRepoEntry,
 func (c *helmClient) createRepoSecretAndConfigmap(repoEntry *helm.SafeRepoEntry) (*httputil.ResponseJson, int) {
 	authRef, err := c.createRepoCRBasicAuth(repoEntry.Name, repoEntry.Username, repoEntry.Password)
 	if err != nil {
-		zlog.Errorf("create auth secret failed, %v", err)
+		c.log().Errorf("create auth secret failed, %v", err)
 		return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
 	}
 	tlsRef, err := c.createRepoCRTLS(repoEntry.Name, repoEntry.KeyFile, repoEntry.CertFile)
 	if err != nil {
-		zlog.Errorf("create tls secret failed, %v", err)
+		c.log().Errorf("create tls secret failed, %v", err)
 		return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
 	}
 	caRef, err := c.createRepoCRCA(repoEntry.Name, repoEntry.CAFile)
 	if err != nil {
-		zlog.Errorf("create ca config map failed, %v", err)
+		c.log().Errorf("create ca config map failed, %v", err)
+		return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
+	}
+	headersRef, err := c.createRepoCRHeaders(repoEntry.Name, repoEntry.Headers)
+	if err != nil {
+		c.log().Errorf("create headers secret failed, %v", err)
+		return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
+	}
+	repoAuthRef, err := c.createRepoCRAuth(repoEntry.Name, repoEntry.Auth)
+	if err != nil {
+		c.log().Errorf("create auth secret failed, %v", err)
 		return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
 	}
-	createdRepoCR, err := c.createRepoCR(repoEntry, authRef, tlsRef, caRef)
+	createdRepoCR, err := c.createRepoCR(repoEntry, authRef, tlsRef, caRef, headersRef, repoAuthRef)
 	if err != nil {
-		zlog.Errorf("create repo cr failed %v", err)
+		c.log().Errorf("create repo cr failed %v", err)
 		return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
 	}
 	return &httputil.ResponseJson{
@@ -233,6 +332,18 @@ func (c *helmClient) createRepoSecretAndConfigmap(repoEntry *helm.SafeRepoEntry)
 	}, http.StatusCreated
 }
 
+// egressViolationResponse response naming the url rejected by the egress policy, if err is caused by it
+func egressViolationResponse(err error) (*httputil.ResponseJson, bool) {
+	var violation *egress.ViolationError
+	if !errors.As(err, &violation) {
+		return nil, false
+	}
+	return &httputil.ResponseJson{
+		Code: constant.ClientError,
+		Msg:  fmt.Sprintf("repository url rejected by egress policy, %s", violation.Error()),
+	}, true
+}
+
 // safeRepoEntryToRepoEntry convert from custom SafeRepoEntry struct to helm repo.Entry struct
 func safeRepoEntryToRepoEntry(safeRepoEntry *helm.SafeRepoEntry) *repo.Entry {
 	return &repo.Entry{
@@ -253,13 +364,13 @@ func (c *helmClient) DeleteRepo(repoName string) (*httputil.ResponseJson, int) {
 		return responseJson, status
 	}
 	repoName = strings.ToLower(repoName)
-	exists, err := k8sutil.CrExists(c.dynamicClient, repoName, "", repoCRDGVR)
+	exists, err := k8sutil.CrExists(c.requestContext(), c.dynamicClient, repoName, "", repoCRDGVR)
 	if err != nil {
-		zlog.Warnf("delete repo %s failed in check existence", repoName)
+		c.log().Warnf("delete repo %s failed in check existence", repoName)
 		return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
 	}
 	if !exists {
-		zlog.Warnf("deletion failed, repo：%s doesn't exists.", repoName)
+		c.log().Warnf("deletion failed, repo：%s doesn't exists.", repoName)
 		return &httputil.ResponseJson{
 			Code: constant.ClientError,
 			Msg:  fmt.Sprintf("deletion failed, repo：%s doesn't exists.", repoName),
@@ -267,10 +378,14 @@ func (c *helmClient) DeleteRepo(repoName string) (*httputil.ResponseJson, int) {
 	}
 	err = c.deleteRepoCR(repoName)
 	if err != nil {
-		zlog.Errorf("delete repo cr failed %v", err)
+		c.log().Errorf("delete repo cr failed %v", err)
 		return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
 	}
 	cachedData.DeleteChartCache(repoName)
+	publishRepoEvent(helm.CatalogEventRepoDeleted, repoName)
+	metrics.DeleteRepo(repoName)
+	upstream.Forget(repoName)
+	repoConnectionMap.Delete(repoName)
 	return &httputil.ResponseJson{
 		Code: constant.Success,
 		Msg:  fmt.Sprintf("%s deleted", repoName),
@@ -294,7 +409,7 @@ func (c *helmClient) GetRepo(repoName string) (*httputil.ResponseJson, int) {
 				Msg:  fmt.Sprintf("repository %s not found", repoName),
 			}, http.StatusNotFound
 		}
-		zlog.Errorf("get custom helm repo %s failed, %v", repoName, err)
+		c.log().Errorf("get custom helm repo %s failed, %v", repoName, err)
 		return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
 	}
 	return &httputil.ResponseJson{
@@ -308,14 +423,16 @@ func (c *helmClient) ListRepo(query *param.Query, repo string) (*httputil.Respon
 	repoList := make([]*helm.RepoResponse, 0)
 	customRepoList, err := c.listCustomRepo()
 	if err != nil {
-		zlog.Errorf("list custom helm repo failed, %v", err)
+		c.log().Errorf("list custom helm repo failed, %v", err)
 		return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
 	}
 	for i := range customRepoList {
 		if repo == "" || strings.Contains(customRepoList[i].Spec.DisplayName, strings.ToLower(repo)) {
 			repoList = append(repoList, &helm.RepoResponse{
-				Name: customRepoList[i].Spec.DisplayName,
-				URL:  customRepoList[i].Spec.URL,
+				Name:      customRepoList[i].Spec.DisplayName,
+				URL:       customRepoList[i].Spec.URL,
+				Suspended: customRepoList[i].Spec.Suspended,
+				Health:    repoHealth(customRepoList[i].Spec.DisplayName),
 			})
 		}
 	}
@@ -358,7 +475,7 @@ func sortRepoByName(repoList []*helm.RepoResponse, ascending bool) {
 }
 
 func (c *helmClient) listCustomRepo() ([]helm.HelmChartRepository, error) {
-	crList, err := c.dynamicClient.Resource(repoCRDGVR).List(context.Background(), metav1.ListOptions{})
+	crList, err := c.dynamicClient.Resource(repoCRDGVR).List(c.requestContext(), metav1.ListOptions{})
 	if err != nil {
 		return nil, err
 	}
@@ -367,7 +484,7 @@ func (c *helmClient) listCustomRepo() ([]helm.HelmChartRepository, error) {
 		var customRepo helm.HelmChartRepository
 		err := runtime.DefaultUnstructuredConverter.FromUnstructured(unstructuredCR.Object, &customRepo)
 		if err != nil {
-			zlog.Debugf("error converting to HelmChartRepository: %s", unstructuredCR.GetName())
+			c.log().Debugf("error converting to HelmChartRepository: %s", unstructuredCR.GetName())
 			return nil, err
 		} else {
 			customRepoList = append(customRepoList, customRepo)
@@ -378,10 +495,10 @@ func (c *helmClient) listCustomRepo() ([]helm.HelmChartRepository, error) {
 
 func (c *helmClient) getCustomRepoByName(repoName string) (*helm.HelmChartRepository, error) {
 	metaName := strings.ToLower(repoName)
-	cr, err := c.dynamicClient.Resource(repoCRDGVR).Get(context.Background(), metaName, metav1.GetOptions{})
+	cr, err := c.dynamicClient.Resource(repoCRDGVR).Get(c.requestContext(), metaName, metav1.GetOptions{})
 	if err != nil {
 		if k8sErrors.IsNotFound(err) {
-			zlog.Warnf("can't find repo cr based on name %s", metaName)
+			c.log().Warnf("can't find repo cr based on name %s", metaName)
 			return nil, err
 		}
 		return nil, err
@@ -389,7 +506,7 @@ func (c *helmClient) getCustomRepoByName(repoName string) (*helm.HelmChartReposi
 	var customRepo *helm.HelmChartRepository
 	err = runtime.DefaultUnstructuredConverter.FromUnstructured(cr.Object, &customRepo)
 	if err != nil {
-		zlog.Debugf("error converting repo cr to HelmChartRepository: %s", cr.GetName())
+		c.log().Debugf("error converting repo cr to HelmChartRepository: %s", cr.GetName())
 		return nil, err
 	}
 	return customRepo, nil
@@ -408,30 +525,55 @@ func (c *helmClient) repoCRtoRepoEntry(repoCR *helm.HelmChartRepository) (*repo.
 		PassCredentialsAll:    repoCR.Spec.PassCredentialsAll,
 	}
 
+	displayName := repoCR.Spec.DisplayName
 	if repoCR.Spec.BasicAuth.Name != "" {
-		basicAuth, err := k8sutil.GetSecret(c.clientset, repoCR.Spec.BasicAuth.Name,
-			constant.MarketplaceServiceDefaultNamespace)
-		if err != nil || basicAuth == nil {
+		data, err := c.secretReferenceData(repoCR.Spec.BasicAuth,
+			managedReferenceName(displayName, repoCRSecretBasicAuth))
+		if err != nil || data == nil {
 			return nil, err
 		}
-		repoEntry.Username = string(basicAuth.Data[mapKeyUsername])
-		repoEntry.Password = string(basicAuth.Data[mapKeyPassword])
+		repoEntry.Username = string(data[mapKeyUsername])
+		repoEntry.Password = string(data[mapKeyPassword])
 	}
 	if repoCR.Spec.TLS.Name != "" {
-		tls, err := k8sutil.GetSecret(c.clientset, repoCR.Spec.TLS.Name, constant.MarketplaceServiceDefaultNamespace)
-		if err != nil || tls == nil {
+		data, err := c.secretReferenceData(repoCR.Spec.TLS, managedReferenceName(displayName, repoCRSecretTLS))
+		if err != nil || data == nil {
 			return nil, err
 		}
-		repoEntry.CertFile = string(tls.Data[mapKeyTLSCrt])
-		repoEntry.KeyFile = string(tls.Data[mapKeyTLSKey])
+		repoEntry.CertFile = string(data[mapKeyTLSCrt])
+		repoEntry.KeyFile = string(data[mapKeyTLSKey])
 	}
 	if repoCR.Spec.CA.Name != "" {
-		ca, err := k8sutil.GetConfigMap(c.clientset, repoCR.Spec.CA.Name, constant.MarketplaceServiceDefaultNamespace)
-		if err != nil && ca == nil {
+		data, err := c.configMapReferenceData(repoCR.Spec.CA, managedReferenceName(displayName, repoCRConfigMapCA))
+		if err != nil {
+			return nil, err
+		}
+		repoEntry.CAFile = data[mapKeyCAKey]
+	}
+	connection := &repoConnection{}
+	if repoCR.Spec.Proxy != nil {
+		connection.proxy = *repoCR.Spec.Proxy
+	}
+	if repoCR.Spec.Headers != nil && repoCR.Spec.Headers.Name != "" {
+		data, err := c.secretReferenceData(*repoCR.Spec.Headers, managedReferenceName(displayName,
+			repoCRSecretHeaders))
+		if err != nil || data == nil {
 			return nil, err
 		}
-		repoEntry.CAFile = ca.Data[mapKeyCAKey]
+		connection.headers = make(map[string]string, len(data))
+		for name, value := range data {
+			connection.headers[name] = string(value)
+		}
 	}
+	if repoCR.Spec.Auth != nil && repoCR.Spec.Auth.Secret.Name != "" {
+		data, err := c.secretReferenceData(repoCR.Spec.Auth.Secret, managedReferenceName(displayName,
+			repoCRSecretAuth))
+		if err != nil || data == nil {
+			return nil, err
+		}
+		connection.auth = repositoryAuthToAuth(repoCR.Spec.Auth, data)
+	}
+	storeRepoConnection(repoEntry.Name, connection)
 	return repoEntry, nil
 }
 
@@ -445,24 +587,27 @@ func (c *helmClient) SyncAllRepos() (*httputil.ResponseJson, int) {
 	errRepoList := make(chan errRepo, 10)
 	repoList, err := c.listCustomRepo()
 	if err != nil {
-		zlog.Errorf("syncAll repos failed, %v", err)
+		c.log().Errorf("syncAll repos failed, %v", err)
 		return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
 	}
+	client := c.detached()
 	var wg sync.WaitGroup
-	for _, repository := range repoList {
+	// suspended repositories keep their chart cache as it was
+	for _, repository := range activeRepositories(repoList) {
 		tmpRepository := repository
 		wg.Add(1)
 		go func(repoCopy *helm.HelmChartRepository) {
 			defer wg.Done()
-			c.asyncUpdateRepository(repoCopy, errRepoList)
+			client.asyncUpdateRepository(repoCopy, errRepoList)
 		}(&tmpRepository)
 	}
 	go func() {
 		wg.Wait()
 		close(errRepoList)
-		zlog.Infof("sync all repository complete")
+		markChartCacheWarm()
+		client.log().Infof("sync all repository complete")
 		for errRepo := range errRepoList {
-			zlog.Errorf("repo %s sync failed: %v", errRepo.Name, errRepo.Err)
+			client.log().Errorf("repo %s sync failed: %v", errRepo.Name, errRepo.Err)
 		}
 	}()
 	return &httputil.ResponseJson{
@@ -472,19 +617,28 @@ func (c *helmClient) SyncAllRepos() (*httputil.ResponseJson, int) {
 }
 
 func (c *helmClient) asyncUpdateRepository(repository *helm.HelmChartRepository, errRepoList chan<- errRepo) {
-	zlog.Infof("syncing %s", repository.Spec.DisplayName)
+	ctx, span := tracing.Start(c.requestContext(), "SyncRepository",
+		trace.WithAttributes(attribute.String(repoAttributeKey, repository.Spec.DisplayName)))
+	c = c.withContext(ctx)
+	c.log().Infof("syncing %s", repository.Spec.DisplayName)
+	start := time.Now()
+	var err error
+	defer func() {
+		metrics.ObserveRepoSync(repository.Spec.DisplayName, time.Since(start), err)
+		tracing.EndSpan(span, err)
+	}()
 	repoEntry, err := c.repoCRtoRepoEntry(repository)
 	if err != nil {
-		zlog.Errorf("error convert repo cr %s to repo entry", repository.Name)
+		c.log().Errorf("error convert repo cr %s to repo entry", repository.Name)
 		errRepoList <- errRepo{
 			Name: repository.Name,
 			Err:  err,
 		}
 		return
 	}
-	err = cachedData.UpdateChartCache(repoEntry)
+	err = cachedData.UpdateChartCache(c.requestContext(), repoEntry)
 	if err != nil {
-		zlog.Errorf("error update repo %s chart cache", repository.Name)
+		c.log().Errorf("error update repo %s chart cache", repository.Name)
 		errRepoList <- errRepo{
 			Name: repository.Name,
 			Err:  err,
@@ -493,7 +647,7 @@ func (c *helmClient) asyncUpdateRepository(repository *helm.HelmChartRepository,
 	}
 	err = c.patchRepoModificationTime(repository.Name)
 	if err != nil {
-		zlog.Errorf("error updating modification time for repository %s", repository.Spec.DisplayName)
+		c.log().Errorf("error updating modification time for repository %s", repository.Spec.DisplayName)
 		errRepoList <- errRepo{
 			Name: repository.Name,
 			Err:  err,
@@ -513,15 +667,21 @@ func (c *helmClient) SyncRepo(repoDisplayName string) (*httputil.ResponseJson, i
 	repository, err := c.getCustomRepoByName(repoDisplayName)
 	if err != nil {
 		if k8sErrors.IsNotFound(err) {
-			zlog.Warnf("repository %s not found", repoDisplayName)
+			c.log().Warnf("repository %s not found", repoDisplayName)
 			return &httputil.ResponseJson{
 				Code: constant.ClientError,
 				Msg:  fmt.Sprintf(" repository %s not found", repoDisplayName),
 			}, http.StatusBadRequest
 		}
-		zlog.Errorf("error get repository %s, %v", repoDisplayName, err)
+		c.log().Errorf("error get repository %s, %v", repoDisplayName, err)
 		return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
 	}
+	if repository.Spec.Suspended {
+		return &httputil.ResponseJson{
+			Code: constant.ClientError,
+			Msg:  fmt.Sprintf("repository %s is suspended, resume it to sync", repoDisplayName),
+		}, http.StatusBadRequest
+	}
 	status, _ := repoAsyncTaskMap.Load(repoDisplayName)
 	switch status {
 	case syncInProgressMsg:
@@ -534,14 +694,15 @@ func (c *helmClient) SyncRepo(repoDisplayName string) (*httputil.ResponseJson, i
 	default:
 		return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
 	}
+	client := c.detached()
 	go func() {
 		errRepoList := make(chan errRepo, 1)
-		c.asyncUpdateRepository(repository, errRepoList)
+		client.asyncUpdateRepository(repository, errRepoList)
 		close(errRepoList)
-		zlog.Infof("sync repo %s complete", repoDisplayName)
+		client.log().Infof("sync repo %s complete", repoDisplayName)
 		repoAsyncTaskMap.Store(repoDisplayName, syncCompleteMsg)
 		for errRepo := range errRepoList {
-			zlog.Errorf("repo %s sync failed: %v", errRepo.Name, errRepo.Err)
+			client.log().Errorf("repo %s sync failed: %v", errRepo.Name, errRepo.Err)
 			repoAsyncTaskMap.Store(repoDisplayName, syncFailedMsg)
 		}
 	}()
@@ -560,11 +721,35 @@ func (c *helmClient) GetRepoSyncStatus(repoName string) (*httputil.ResponseJson,
 			Msg:  fmt.Sprintf("%s update task not found", repoName),
 		}, http.StatusBadRequest
 	} else {
-		return &httputil.ResponseJson{
+		response := &httputil.ResponseJson{
 			Code: constant.Success,
 			Msg:  status.(string),
-		}, http.StatusOK
+		}
+		if health := repoHealth(repoName); health != nil {
+			response.Data = health
+		}
+		return response, http.StatusOK
+	}
+}
+
+// repoHealth circuit breaker state of the repository, nil if no request has been sent to it
+func repoHealth(repoName string) *helm.RepoHealth {
+	health, ok := upstream.RepoHealth(repoName)
+	if !ok {
+		return nil
+	}
+	result := &helm.RepoHealth{
+		State:               health.State,
+		ConsecutiveFailures: health.ConsecutiveFailures,
+		LastError:           health.LastError,
+	}
+	if !health.LastFailure.IsZero() {
+		result.LastFailure = &helmtime.Time{Time: health.LastFailure}
 	}
+	if !health.RetryAt.IsZero() {
+		result.RetryAt = &helmtime.Time{Time: health.RetryAt}
+	}
+	return result
 }
 
 func (c *helmClient) patchRepoModificationTime(name string) error {
@@ -580,7 +765,7 @@ func (c *helmClient) patchRepoModificationTime(name string) error {
 	if err != nil {
 		return err
 	}
-	_, err = c.dynamicClient.Resource(repoCRDGVR).Namespace("").Patch(context.TODO(), name,
+	_, err = c.dynamicClient.Resource(repoCRDGVR).Namespace("").Patch(c.requestContext(), name,
 		types.MergePatchType, patchData, metav1.PatchOptions{})
 	if err != nil {
 		return err
@@ -591,22 +776,26 @@ func (c *helmClient) patchRepoModificationTime(name string) error {
 func (c *helmClient) updateRepoCR(customHelmRepository *helm.HelmChartRepository,
 	repoEntry *helm.SafeRepoEntry) (*unstructured.Unstructured, error) {
 	customHelmRepository.Spec.URL = repoEntry.URL
+	customHelmRepository.Spec.Proxy = repoEntry.Proxy.DeepCopy()
+	if repoEntry.Suspended != nil {
+		customHelmRepository.Spec.Suspended = *repoEntry.Suspended
+	}
 	repoUnstructured, err := k8sutil.StructToUnstructured(customHelmRepository)
 	if err != nil {
-		zlog.Errorf("convert repo cr to unstructured error, %v", err)
+		c.log().Errorf("convert repo cr to unstructured error, %v", err)
 		return nil, err
 	}
-	repoUnstructured, err = c.dynamicClient.Resource(repoCRDGVR).Update(context.TODO(), repoUnstructured,
+	repoUnstructured, err = c.dynamicClient.Resource(repoCRDGVR).Update(c.requestContext(), repoUnstructured,
 		metav1.UpdateOptions{})
 	if err != nil {
 		return nil, err
 	}
-	zlog.Infof("repo CR: %s updated", customHelmRepository.Name)
+	c.log().Infof("repo CR: %s updated", customHelmRepository.Name)
 	return repoUnstructured, nil
 }
 
 func (c *helmClient) createRepoCR(repoEntry *helm.SafeRepoEntry, authRef *v1.Secret,
-	tlsRef *v1.Secret, caRef *v1.ConfigMap) (*unstructured.Unstructured, error) {
+	tlsRef *v1.Secret, caRef *v1.ConfigMap, headersRef, repoAuthRef *v1.Secret) (*unstructured.Unstructured, error) {
 	// construct struct and pass it to dynamic client for cr creation
 	customHelmRepository := &helm.HelmChartRepository{
 		TypeMeta:   getRepoCRTypeMeta(),
@@ -616,60 +805,75 @@ func (c *helmClient) createRepoCR(repoEntry *helm.SafeRepoEntry, authRef *v1.Sec
 			URL:                   repoEntry.URL,
 			InsecureSkipTLSVerify: repoEntry.InsecureSkipTLSVerify,
 			PassCredentialsAll:    repoEntry.PassCredentialsAll,
+			Suspended:             repoEntry.Suspended != nil && *repoEntry.Suspended,
 		},
 	}
-	if authRef != nil {
-		customHelmRepository.Spec.BasicAuth.Name = authRef.Name
-	}
-	if tlsRef != nil {
-		customHelmRepository.Spec.TLS.Name = tlsRef.Name
-	}
-	if caRef != nil {
-		customHelmRepository.Spec.CA.Name = caRef.Name
+	customHelmRepository.Spec.BasicAuth, customHelmRepository.Spec.TLS, customHelmRepository.Spec.CA =
+		repoReferences(repoEntry, authRef, tlsRef, caRef)
+	if headersRef != nil {
+		customHelmRepository.Spec.Headers = &helm.SecretReference{Name: headersRef.Name}
 	}
+	customHelmRepository.Spec.Proxy = repoEntry.Proxy.DeepCopy()
+	customHelmRepository.Spec.Auth = repositoryAuth(repoEntry.Auth, repoAuthRef)
 	repoUnstructured, err := k8sutil.StructToUnstructured(customHelmRepository)
 	if err != nil {
-		zlog.Errorf("convert repo cr to unstructured error, %v", err)
+		c.log().Errorf("convert repo cr to unstructured error, %v", err)
 		return nil, err
 	}
-	repoUnstructured, err = c.dynamicClient.Resource(repoCRDGVR).Create(context.TODO(),
+	repoUnstructured, err = c.dynamicClient.Resource(repoCRDGVR).Create(c.requestContext(),
 		repoUnstructured, metav1.CreateOptions{})
 
 	if err != nil {
 		return nil, err
 	}
-	zlog.Infof("repo CR: %s created", customHelmRepository.Name)
+	c.log().Infof("repo CR: %s created", customHelmRepository.Name)
 	return repoUnstructured, nil
 }
 
 func (c *helmClient) deleteRepoCR(repoCRName string) error {
 	// request last at most 10 seconds
-	ctx, cancel := context.WithTimeout(context.Background(), requestLastTime*time.Second)
+	ctx, cancel := context.WithTimeout(c.requestContext(), requestLastTime*time.Second)
 	defer cancel()
 
 	chartRepository, err := c.getCustomRepoByName(repoCRName)
 	if err != nil {
 		return err
 	}
-	if chartRepository.Spec.BasicAuth.Name != "" {
-		err = k8sutil.DeleteSecret(c.clientset, chartRepository.Spec.BasicAuth.Name,
+	if chartRepository.Spec.BasicAuth.Name != "" && !chartRepository.Spec.BasicAuth.External {
+		err = k8sutil.DeleteSecret(c.requestContext(), c.clientset, chartRepository.Spec.BasicAuth.Name,
 			constant.MarketplaceServiceDefaultNamespace)
 		if err != nil {
-			zlog.Errorf("delete repo cr basic auth failed %v", err)
+			c.log().Errorf("delete repo cr basic auth failed %v", err)
 		}
 	}
-	if chartRepository.Spec.TLS.Name != "" {
-		err = k8sutil.DeleteSecret(c.clientset, chartRepository.Spec.TLS.Name,
+	if chartRepository.Spec.TLS.Name != "" && !chartRepository.Spec.TLS.External {
+		err = k8sutil.DeleteSecret(c.requestContext(), c.clientset, chartRepository.Spec.TLS.Name,
 			constant.MarketplaceServiceDefaultNamespace)
 		if err != nil {
-			zlog.Errorf("delete repo cr tls failed %v", err)
+			c.log().Errorf("delete repo cr tls failed %v", err)
 		}
 	}
-	if chartRepository.Spec.CA.Name != "" {
-		err = k8sutil.DeleteConfigMap(c.clientset, chartRepository.Spec.CA.Name,
+	if chartRepository.Spec.CA.Name != "" && !chartRepository.Spec.CA.External {
+		err = k8sutil.DeleteConfigMap(c.requestContext(), c.clientset, chartRepository.Spec.CA.Name,
 			constant.MarketplaceServiceDefaultNamespace)
 		if err != nil {
-			zlog.Errorf("delete repo cr ca failed %v", err)
+			c.log().Errorf("delete repo cr ca failed %v", err)
+		}
+	}
+	if chartRepository.Spec.Headers != nil && chartRepository.Spec.Headers.Name != "" &&
+		!chartRepository.Spec.Headers.External {
+		err = k8sutil.DeleteSecret(c.requestContext(), c.clientset, chartRepository.Spec.Headers.Name,
+			constant.MarketplaceServiceDefaultNamespace)
+		if err != nil {
+			c.log().Errorf("delete repo cr headers failed %v", err)
+		}
+	}
+	if chartRepository.Spec.Auth != nil && chartRepository.Spec.Auth.Secret.Name != "" &&
+		!chartRepository.Spec.Auth.Secret.External {
+		err = k8sutil.DeleteSecret(c.requestContext(), c.clientset, chartRepository.Spec.Auth.Secret.Name,
+			constant.MarketplaceServiceDefaultNamespace)
+		if err != nil {
+			c.log().Errorf("delete repo cr auth failed %v", err)
 		}
 	}
 
@@ -677,67 +881,69 @@ func (c *helmClient) deleteRepoCR(repoCRName string) error {
 	if err != nil {
 		return err
 	}
-	zlog.Infof("repo cr: %s deleted", repoCRName)
+	c.log().Infof("repo cr: %s deleted", repoCRName)
 	return nil
 }
 
 func (c *helmClient) createRepoCRBasicAuth(repoName string, username, password []byte) (*v1.Secret, error) {
 	if len(username) == 0 || len(password) == 0 {
-		zlog.Infof("skip creating repo auth %s, username or pasword is empty", repoName)
+		c.log().Infof("skip creating repo auth %s, username or pasword is empty", repoName)
 		return nil, nil
 	}
 	secretName := repoName + repoCRSecretBasicAuth
 	secret := &v1.Secret{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      secretName,
-			Namespace: constant.MarketplaceServiceDefaultNamespace,
-		},
+		ObjectMeta: managedObjectMeta(secretName),
 		Data: map[string][]byte{
 			mapKeyUsername: username,
 			mapKeyPassword: password,
 		},
 	}
-	var err error
-	secret, err = k8sutil.CreateSecret(c.clientset, secret)
+	if err := c.sealSecret(secret); err != nil {
+		return nil, err
+	}
+	created, err := k8sutil.CreateSecret(c.requestContext(), c.clientset, secret)
 	if err != nil {
 		if k8sErrors.IsAlreadyExists(err) {
-			return k8sutil.UpdateSecret(c.clientset, secret)
+			return k8sutil.UpdateSecret(c.requestContext(), c.clientset, secret)
 		}
 		return nil, err
 	}
-	for _, value := range secret.Data {
+	for _, value := range created.Data {
 		util.ClearByte(value)
 	}
-	return secret, nil
+	return created, nil
 }
 
 func (c *helmClient) updateRepoCRBasicAuth(repo *helm.HelmChartRepository, repoName string,
 	username, password []byte) (*v1.Secret, error) {
 
 	if len(repo.Spec.BasicAuth.Name) == 0 && len(username) == 0 {
-		zlog.Infof("skip updating repo auth %s, username or pasword is empty", repoName)
+		c.log().Infof("skip updating repo auth %s, username or pasword is empty", repoName)
 		return nil, nil
 	} else if len(repo.Spec.BasicAuth.Name) == 0 && len(username) != 0 {
 		return c.createRepoCRBasicAuth(repoName, username, password)
 	} else if len(repo.Spec.BasicAuth.Name) != 0 && len(username) == 0 {
-		return nil, k8sutil.DeleteSecret(c.clientset, repo.Spec.BasicAuth.Name,
+		return nil, k8sutil.DeleteSecret(c.requestContext(), c.clientset, repo.Spec.BasicAuth.Name,
 			constant.MarketplaceServiceDefaultNamespace)
 	} else if len(repo.Spec.BasicAuth.Name) != 0 && len(username) != 0 {
-		secret := &v1.Secret{}
+		secret := &v1.Secret{ObjectMeta: managedObjectMeta(repo.Spec.BasicAuth.Name)}
 		secret.Data = map[string][]byte{
 			mapKeyUsername: username,
 			mapKeyPassword: password,
 		}
+		if err := c.sealSecret(secret); err != nil {
+			return nil, err
+		}
 		return c.updateAndClearSecretData(secret)
 	} else {
-		zlog.Errorf("something goes wrong in update repo cr basic auth, repo cr %v, username %s ", repo, username)
+		c.log().Errorf("something goes wrong in update repo cr basic auth, repo cr %v, username %s ", repo, username)
 		return nil, fmt.Errorf("something goes wrong in update repo cr basic auth, repo cr %v,"+
 			" username %s ", repo, username)
 	}
 }
 
 func (c *helmClient) updateAndClearSecretData(secret *v1.Secret) (*v1.Secret, error) {
-	_, err := k8sutil.UpdateSecret(c.clientset, secret)
+	_, err := k8sutil.UpdateSecret(c.requestContext(), c.clientset, secret)
 	if err != nil {
 		return nil, err
 	}
@@ -752,22 +958,25 @@ func (c *helmClient) updateRepoCRTLS(repo *helm.HelmChartRepository, repoName st
 	// make sure tls secret certFile and keyFile is always valid
 	// either two of them are valid, or no-exist
 	if len(repo.Spec.TLS.Name) == 0 && (len(certFile) == 0 && len(keyFile) == 0) {
-		zlog.Infof("skip updating repo tls %s, username or pasword is empty", repoName)
+		c.log().Infof("skip updating repo tls %s, username or pasword is empty", repoName)
 		return nil, nil
 	} else if len(repo.Spec.TLS.Name) == 0 && (len(certFile) != 0 || len(keyFile) != 0) {
 		return c.createRepoCRTLS(repoName, certFile, keyFile)
 	} else if len(repo.Spec.TLS.Name) != 0 && (len(certFile) == 0 && len(keyFile) == 0) {
-		return nil, k8sutil.DeleteSecret(c.clientset, repo.Spec.TLS.Name,
+		return nil, k8sutil.DeleteSecret(c.requestContext(), c.clientset, repo.Spec.TLS.Name,
 			constant.MarketplaceServiceDefaultNamespace)
 	} else if len(repo.Spec.TLS.Name) != 0 && (len(certFile) != 0 || len(keyFile) != 0) {
-		secret := &v1.Secret{}
+		secret := &v1.Secret{ObjectMeta: managedObjectMeta(repo.Spec.TLS.Name)}
 		secret.StringData = map[string]string{
 			mapKeyTLSCrt: certFile,
 			mapKeyTLSKey: keyFile,
 		}
-		return k8sutil.UpdateSecret(c.clientset, secret)
+		if err := c.sealSecret(secret); err != nil {
+			return nil, err
+		}
+		return k8sutil.UpdateSecret(c.requestContext(), c.clientset, secret)
 	} else {
-		zlog.Errorf("something goes wrong in update repo cr tls, repo cr %v,certFile %s ,keyFile %s",
+		c.log().Errorf("something goes wrong in update repo cr tls, repo cr %v,certFile %s ,keyFile %s",
 			repo, certFile, keyFile)
 		return nil, fmt.Errorf("something goes wrong in update repo cr tls, repo cr %v,certFile %s"+
 			" ,keyFile %s", repo, certFile, keyFile)
@@ -776,26 +985,24 @@ func (c *helmClient) updateRepoCRTLS(repo *helm.HelmChartRepository, repoName st
 
 func (c *helmClient) createRepoCRTLS(repoName, certFile, keyFile string) (*v1.Secret, error) {
 	if len(certFile) == 0 || len(keyFile) == 0 {
-		zlog.Infof("create repo TLS %s, certFile or keyFile is empty", repoName)
+		c.log().Infof("create repo TLS %s, certFile or keyFile is empty", repoName)
 		return nil, nil
 	}
 	secretName := repoName + repoCRSecretTLS
-	namespace := constant.MarketplaceServiceDefaultNamespace
 	secret := &v1.Secret{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      secretName,
-			Namespace: namespace,
-		},
+		ObjectMeta: managedObjectMeta(secretName),
 		StringData: map[string]string{
 			mapKeyTLSCrt: certFile,
 			mapKeyTLSKey: keyFile,
 		},
 	}
-
-	_, err := k8sutil.CreateSecret(c.clientset, secret)
+	if err := c.sealSecret(secret); err != nil {
+		return nil, err
+	}
+	_, err := k8sutil.CreateSecret(c.requestContext(), c.clientset, secret)
 	if err != nil {
 		if k8sErrors.IsAlreadyExists(err) {
-			return k8sutil.UpdateSecret(c.clientset, secret)
+			return k8sutil.UpdateSecret(c.requestContext(), c.clientset, secret)
 		}
 		return nil, err
 	}
@@ -804,21 +1011,21 @@ func (c *helmClient) createRepoCRTLS(repoName, certFile, keyFile string) (*v1.Se
 
 func (c *helmClient) updateRepoCRCA(repo *helm.HelmChartRepository, repoName, caFile string) (*v1.ConfigMap, error) {
 	if len(repo.Spec.CA.Name) == 0 && len(caFile) == 0 {
-		zlog.Infof("skip updating repo ca %s, username or pasword is empty", repoName)
+		c.log().Infof("skip updating repo ca %s, username or pasword is empty", repoName)
 		return nil, nil
 	} else if len(repo.Spec.CA.Name) == 0 && len(caFile) != 0 {
 		return c.createRepoCRCA(repoName, caFile)
 	} else if len(repo.Spec.CA.Name) != 0 && len(caFile) == 0 {
-		return nil, k8sutil.DeleteConfigMap(c.clientset, repo.Spec.CA.Name,
+		return nil, k8sutil.DeleteConfigMap(c.requestContext(), c.clientset, repo.Spec.CA.Name,
 			constant.MarketplaceServiceDefaultNamespace)
 	} else if len(repo.Spec.CA.Name) != 0 && len(caFile) != 0 {
-		configmap := &v1.ConfigMap{}
+		configmap := &v1.ConfigMap{ObjectMeta: managedObjectMeta(repo.Spec.CA.Name)}
 		configmap.Data = map[string]string{
 			mapKeyCAKey: caFile,
 		}
-		return k8sutil.UpdateConfigMap(c.clientset, configmap)
+		return k8sutil.UpdateConfigMap(c.requestContext(), c.clientset, configmap)
 	} else {
-		zlog.Errorf("something goes wrong in update repo cr ca, repo cr %v, caFile %s ", repo, caFile)
+		c.log().Errorf("something goes wrong in update repo cr ca, repo cr %v, caFile %s ", repo, caFile)
 		return nil, fmt.Errorf("something goes wrong in update repo cr ca, repo cr %v,"+
 			" caFile %s ", repo, caFile)
 	}
@@ -826,29 +1033,121 @@ func (c *helmClient) updateRepoCRCA(repo *helm.HelmChartRepository, repoName, ca
 
 func (c *helmClient) createRepoCRCA(repoName, caFile string) (*v1.ConfigMap, error) {
 	if len(caFile) == 0 {
-		zlog.Infof("create repo CA %s, caFile is empty", repoName)
+		c.log().Infof("create repo CA %s, caFile is empty", repoName)
 		return nil, nil
 	}
 
 	configMap := &v1.ConfigMap{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      repoName + repoCRConfigMapCA,
-			Namespace: constant.MarketplaceServiceDefaultNamespace,
-		},
+		ObjectMeta: managedObjectMeta(repoName + repoCRConfigMapCA),
 		Data: map[string]string{
 			mapKeyCAKey: caFile,
 		},
 	}
-	_, err := k8sutil.CreateConfigMap(c.clientset, configMap)
+	_, err := k8sutil.CreateConfigMap(c.requestContext(), c.clientset, configMap)
 	if err != nil {
 		if k8sErrors.IsAlreadyExists(err) {
-			return k8sutil.UpdateConfigMap(c.clientset, configMap)
+			return k8sutil.UpdateConfigMap(c.requestContext(), c.clientset, configMap)
 		}
 		return nil, err
 	}
 	return configMap, nil
 }
 
+func (c *helmClient) createRepoCRHeaders(repoName string, headers map[string]string) (*v1.Secret, error) {
+	if len(headers) == 0 {
+		c.log().Infof("skip creating repo headers %s, headers are empty", repoName)
+		return nil, nil
+	}
+	secret := &v1.Secret{
+		ObjectMeta: managedObjectMeta(repoName + repoCRSecretHeaders),
+		StringData: headers,
+	}
+	if err := c.sealSecret(secret); err != nil {
+		return nil, err
+	}
+	_, err := k8sutil.CreateSecret(c.requestContext(), c.clientset, secret)
+	if err != nil {
+		if k8sErrors.IsAlreadyExists(err) {
+			return k8sutil.UpdateSecret(c.requestContext(), c.clientset, secret)
+		}
+		return nil, err
+	}
+	return secret, nil
+}
+
+func (c *helmClient) updateRepoCRHeaders(repo *helm.HelmChartRepository, repoName string,
+	headers map[string]string) (*v1.Secret, error) {
+	if repo.Spec.Headers == nil || len(repo.Spec.Headers.Name) == 0 {
+		return c.createRepoCRHeaders(repoName, headers)
+	}
+	if len(headers) == 0 {
+		return nil, k8sutil.DeleteSecret(c.requestContext(), c.clientset, repo.Spec.Headers.Name,
+			constant.MarketplaceServiceDefaultNamespace)
+	}
+	secret := &v1.Secret{
+		ObjectMeta: managedObjectMeta(repo.Spec.Headers.Name),
+		StringData: headers,
+	}
+	if err := c.sealSecret(secret); err != nil {
+		return nil, err
+	}
+	return k8sutil.UpdateSecret(c.requestContext(), c.clientset, secret)
+}
+
+func (c *helmClient) createRepoCRAuth(repoName string, auth *helm.RepoAuthEntry) (*v1.Secret, error) {
+	if auth == nil || auth.Type == "" {
+		c.log().Infof("skip creating repo token auth %s, auth is empty", repoName)
+		return nil, nil
+	}
+	secret := &v1.Secret{
+		ObjectMeta: managedObjectMeta(repoName + repoCRSecretAuth),
+		Data:       repoAuthSecretData(auth),
+	}
+	if err := c.sealSecret(secret); err != nil {
+		return nil, err
+	}
+	_, err := k8sutil.CreateSecret(c.requestContext(), c.clientset, secret)
+	if err != nil {
+		if k8sErrors.IsAlreadyExists(err) {
+			return k8sutil.UpdateSecret(c.requestContext(), c.clientset, secret)
+		}
+		return nil, err
+	}
+	return secret, nil
+}
+
+func (c *helmClient) updateRepoCRAuth(repo *helm.HelmChartRepository, repoName string,
+	auth *helm.RepoAuthEntry) (*v1.Secret, error) {
+	if repo.Spec.Auth == nil || len(repo.Spec.Auth.Secret.Name) == 0 {
+		return c.createRepoCRAuth(repoName, auth)
+	}
+	if auth == nil || auth.Type == "" {
+		return nil, k8sutil.DeleteSecret(c.requestContext(), c.clientset, repo.Spec.Auth.Secret.Name,
+			constant.MarketplaceServiceDefaultNamespace)
+	}
+	secret := &v1.Secret{
+		ObjectMeta: managedObjectMeta(repo.Spec.Auth.Secret.Name),
+		Data:       repoAuthSecretData(auth),
+	}
+	if err := c.sealSecret(secret); err != nil {
+		return nil, err
+	}
+	return k8sutil.UpdateSecret(c.requestContext(), c.clientset, secret)
+}
+
+// repositoryAuth auth of the repository cr referencing the credentials secret, nil without authentication
+func repositoryAuth(auth *helm.RepoAuthEntry, secret *v1.Secret) *helm.RepositoryAuth {
+	if auth == nil || secret == nil {
+		return nil
+	}
+	return &helm.RepositoryAuth{
+		Type:     auth.Type,
+		Secret:   helm.SecretReference{Name: secret.Name},
+		TokenURL: auth.TokenURL,
+		Scopes:   auth.Scopes,
+	}
+}
+
 func getRepoCRObjectMeta(repoName string) metav1.ObjectMeta {
 	objectMeta := metav1.ObjectMeta{
 		Name: repoName,
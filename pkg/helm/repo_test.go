@@ -25,6 +25,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
 	"helm.sh/helm/v3/pkg/repo"
 	"k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -1020,10 +1021,12 @@ func Test_helmClient_createRepoCR(t *testing.T) {
 		clientset     kubernetes.Interface
 	}
 	type args struct {
-		repoEntry *helm.SafeRepoEntry
-		authRef   *v1.Secret
-		tlsRef    *v1.Secret
-		caRef     *v1.ConfigMap
+		repoEntry   *helm.SafeRepoEntry
+		authRef     *v1.Secret
+		tlsRef      *v1.Secret
+		caRef       *v1.ConfigMap
+		headersRef  *v1.Secret
+		repoAuthRef *v1.Secret
 	}
 	var tests []struct {
 		name    string
@@ -1039,7 +1042,8 @@ func Test_helmClient_createRepoCR(t *testing.T) {
 				dynamicClient: tt.fields.dynamicClient,
 				clientset:     tt.fields.clientset,
 			}
-			got, err := c.createRepoCR(tt.args.repoEntry, tt.args.authRef, tt.args.tlsRef, tt.args.caRef)
+			got, err := c.createRepoCR(tt.args.repoEntry, tt.args.authRef, tt.args.tlsRef, tt.args.caRef,
+				tt.args.headersRef, tt.args.repoAuthRef)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("createRepoCR() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -1629,3 +1633,61 @@ func Test_safeRepoEntryToRepoEntry(t *testing.T) {
 		})
 	}
 }
+
+func latestChartsOf(t *testing.T, c *helmClient, repoName string, repositories []string) int {
+	chartList, err := c.getLatestChartsByRepos(repositories)
+	assert.NoError(t, err)
+	count := 0
+	for _, chartVersion := range chartList {
+		if chartVersion.Repo == repoName {
+			count++
+		}
+	}
+	return count
+}
+
+// Test_helmClient_suspendRepo 测试暂停仓库后不再展示与同步, 恢复后重新加载
+func Test_helmClient_suspendRepo(t *testing.T) {
+	const repoName = "suspendable"
+	server := httptest.NewServer(newTestRepoHandler())
+	c := newRepoFileClient(t)
+	// credentials of a request are cleared once stored
+	newRepoEntry := func(url string, suspended *bool) *helm.SafeRepoEntry {
+		return &helm.SafeRepoEntry{Name: repoName, URL: url, Username: []byte("admin"), Password: []byte("s3cret"),
+			Suspended: suspended}
+	}
+	_, status := c.CreateRepo(newRepoEntry(server.URL, nil))
+	assert.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, 2, latestChartsOf(t, c, repoName, nil))
+
+	// a suspended repository is not contacted
+	server.Close()
+	suspended, resumed := true, false
+	_, status = c.UpdateRepo(newRepoEntry(server.URL, &suspended))
+	assert.Equal(t, http.StatusOK, status)
+	assert.Zero(t, latestChartsOf(t, c, repoName, nil))
+	assert.Zero(t, latestChartsOf(t, c, repoName, []string{repoName}))
+	_, cached := cachedData.GetChartCacheByRepo(repoName)
+	assert.True(t, cached)
+	_, status = c.SyncRepo(repoName)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	response, status := c.ListRepo(&param.Query{Pagination: &param.Pagination{}}, repoName)
+	assert.Equal(t, http.StatusOK, status)
+	items := response.Data.(*helm.ListResponse).Items
+	assert.Len(t, items, 1)
+	assert.True(t, items[0].(*helm.RepoResponse).Suspended)
+
+	// updates leaving the flag out keep the repository suspended
+	_, status = c.UpdateRepo(newRepoEntry(server.URL, nil))
+	assert.Equal(t, http.StatusOK, status)
+	repository, err := c.getCustomRepoByName(repoName)
+	assert.NoError(t, err)
+	assert.True(t, repository.Spec.Suspended)
+
+	server = httptest.NewServer(newTestRepoHandler())
+	defer server.Close()
+	_, status = c.UpdateRepo(newRepoEntry(server.URL, &resumed))
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 2, latestChartsOf(t, c, repoName, nil))
+}
@@ -0,0 +1,350 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package helm
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/repo"
+	"sigs.k8s.io/yaml"
+
+	"marketplace-service/pkg/constant"
+	"marketplace-service/pkg/egress"
+	"marketplace-service/pkg/models/helm"
+	"marketplace-service/pkg/utils/envelope"
+	"marketplace-service/pkg/utils/httputil"
+	"marketplace-service/pkg/utils/k8sutil"
+)
+
+/*
+ExportRepos all repositories in the repositories.yaml format of helm, the credentials are left out, exported in
+plaintext or sealed with the symmetric key of the service. As in create and update requests certFile, keyFile
+and caFile carry the PEM content instead of a path.
+The format has no place for the proxy, headers and token authentication of a repository, importing the file
+keeps those of an existing repository
+*/
+func (c *helmClient) ExportRepos(credentials string) (*httputil.ResponseJson, int) {
+	var keyring *envelope.Keyring
+	switch credentials {
+	case "", helm.RepoExportCredentialsNone, helm.RepoExportCredentialsPlain:
+	case helm.RepoExportCredentialsEncrypted:
+		var err error
+		if keyring, err = c.keyring(); err != nil {
+			c.log().Errorf("read symmetric key for export failed, %v", err)
+			return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
+		}
+		if !keyring.Enabled() {
+			return &httputil.ResponseJson{
+				Code: constant.ClientError,
+				Msg:  "no symmetric key configured to encrypt the exported credentials",
+			}, http.StatusBadRequest
+		}
+	default:
+		return &httputil.ResponseJson{
+			Code: constant.ClientError,
+			Msg:  "credentials must be one of none, plain or encrypted",
+		}, http.StatusBadRequest
+	}
+
+	repositories, err := c.listCustomRepo()
+	if err != nil {
+		c.log().Errorf("list repositories for export failed, %v", err)
+		return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
+	}
+	sort.Slice(repositories, func(i, j int) bool {
+		return repositories[i].Spec.DisplayName < repositories[j].Spec.DisplayName
+	})
+	file := repo.NewFile()
+	for i := range repositories {
+		entry, err := c.repoCRtoRepoEntry(&repositories[i])
+		if err != nil || entry == nil {
+			c.log().Errorf("read repository %s for export failed, %v", repositories[i].Spec.DisplayName, err)
+			return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
+		}
+		if err = exportCredentials(keyring, credentials, entry); err != nil {
+			c.log().Errorf("encrypt credentials of repository %s failed, %v", entry.Name, err)
+			return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
+		}
+		file.Add(entry)
+	}
+	return &httputil.ResponseJson{
+		Code: constant.Success,
+		Msg:  "success",
+		Data: file,
+	}, http.StatusOK
+}
+
+// exportCredentials leave out or seal the credentials of an exported entry according to the credentials mode
+func exportCredentials(keyring *envelope.Keyring, credentials string, entry *repo.Entry) error {
+	switch credentials {
+	case helm.RepoExportCredentialsPlain:
+		return nil
+	case helm.RepoExportCredentialsEncrypted:
+		for field, value := range map[string]*string{
+			mapKeyUsername: &entry.Username,
+			mapKeyPassword: &entry.Password,
+			mapKeyTLSKey:   &entry.KeyFile,
+		} {
+			if *value == "" {
+				continue
+			}
+			sealed, err := keyring.SealValue(sealedValueField(entry.Name, field), *value)
+			if err != nil {
+				return err
+			}
+			*value = sealed
+		}
+		return nil
+	default:
+		entry.Username, entry.Password, entry.CertFile, entry.KeyFile = "", "", "", ""
+		return nil
+	}
+}
+
+// sealedValueField binds a sealed credential to the repository and field it was exported from
+func sealedValueField(repoName, field string) string {
+	return strings.ToLower(repoName) + "/" + field
+}
+
+/*
+ImportRepos create the repositories of a repositories.yaml that do not exist and update those that do, an entry
+failing does not keep the others from being imported. Credentials sealed by an export are decrypted with the
+symmetric key. Existing repositories keep the credentials an entry leaves out along with their proxy, headers
+and token authentication. A dry run validates the entries without connecting to the repositories
+*/
+func (c *helmClient) ImportRepos(data []byte, dryRun bool) (*httputil.ResponseJson, int) {
+	file := &repo.File{}
+	if err := yaml.Unmarshal(data, file); err != nil || len(file.Repositories) == 0 {
+		c.log().Warnf("invalid repositories file, %v", err)
+		return &httputil.ResponseJson{
+			Code: constant.ClientError,
+			Msg:  "please provide a repositories.yaml with at least one repository",
+		}, http.StatusBadRequest
+	}
+	repositories, err := c.listCustomRepo()
+	if err != nil {
+		c.log().Errorf("list repositories for import failed, %v", err)
+		return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
+	}
+	importer := &repoImporter{
+		client:   c,
+		existing: make(map[string]*helm.HelmChartRepository, len(repositories)),
+		seen:     make(map[string]bool, len(file.Repositories)),
+		dryRun:   dryRun,
+	}
+	for i := range repositories {
+		importer.existing[strings.ToLower(repositories[i].Spec.DisplayName)] = &repositories[i]
+	}
+
+	response := &helm.RepoImportResponse{DryRun: dryRun, Results: make([]helm.RepoImportResult, 0,
+		len(file.Repositories))}
+	for _, entry := range file.Repositories {
+		if entry == nil {
+			continue
+		}
+		result := importer.importEntry(entry)
+		if result.Status == helm.RepoImportFailed {
+			response.Failed++
+		}
+		response.Results = append(response.Results, result)
+	}
+	if response.Failed > 0 {
+		return &httputil.ResponseJson{
+			Code: constant.ClientError,
+			Msg:  fmt.Sprintf("%d of %d repositories failed to import", response.Failed, len(response.Results)),
+			Data: response,
+		}, http.StatusBadRequest
+	}
+	return &httputil.ResponseJson{
+		Code: constant.Success,
+		Msg:  "success",
+		Data: response,
+	}, http.StatusOK
+}
+
+// repoImporter imports the entries of a repositories.yaml one by one
+type repoImporter struct {
+	client *helmClient
+	// existing repositories by lower case name, like the names of their crs
+	existing map[string]*helm.HelmChartRepository
+	seen     map[string]bool
+	dryRun   bool
+	// keyring read on the first sealed credential
+	keyring *envelope.Keyring
+}
+
+func (i *repoImporter) importEntry(entry *repo.Entry) helm.RepoImportResult {
+	key := strings.ToLower(entry.Name)
+	repository, exist := i.existing[key]
+	result := helm.RepoImportResult{Name: entry.Name, Action: helm.RepoImportCreate}
+	if exist {
+		result.Action = helm.RepoImportUpdate
+	}
+	if i.seen[key] {
+		return importFailed(result, "repository listed more than once")
+	}
+	i.seen[key] = true
+	if response, status, err := i.client.isRepoModificationAllowed(entry.Name); err != nil {
+		if status != http.StatusBadRequest {
+			return importFailed(result, response.Msg)
+		}
+		result.Status, result.Message = helm.RepoImportSkipped, response.Msg
+		return result
+	}
+
+	repoEntry, err := i.safeRepoEntry(entry)
+	if err != nil {
+		i.client.log().Warnf("decrypt credentials of repository %s failed, %v", entry.Name, err)
+		return importFailed(result, fmt.Sprintf("decrypt credentials failed, %v", err))
+	}
+	if exist {
+		if err = i.client.keepRepoSettings(repoEntry, repository); err != nil {
+			i.client.log().Errorf("read settings of repository %s failed, %v", entry.Name, err)
+			return importFailed(result, "read settings of the existing repository failed")
+		}
+	}
+	if i.dryRun {
+		return i.validate(result, repoEntry)
+	}
+
+	var response *httputil.ResponseJson
+	var status int
+	if exist {
+		response, status = i.client.UpdateRepo(repoEntry)
+	} else {
+		response, status = i.client.CreateRepo(repoEntry)
+	}
+	if status >= http.StatusMultipleChoices {
+		return importFailed(result, response.Msg)
+	}
+	result.Status, result.Message = helm.RepoImportSucceeded, response.Msg
+	return result
+}
+
+// validate the checks of a create or update short of loading the index of the repository
+func (i *repoImporter) validate(result helm.RepoImportResult, repoEntry *helm.SafeRepoEntry) helm.RepoImportResult {
+	if result.Action == helm.RepoImportCreate {
+		if valid, err := k8sutil.ResourceMetadataRegexValid(strings.ToLower(repoEntry.Name)); !valid || err != nil {
+			return importFailed(result, "must consist of lower case alphanumeric characters, '-' or '.',"+
+				" and must start and end with an alphanumeric character")
+		}
+		for _, repository := range i.existing {
+			if repository.Spec.URL == repoEntry.URL {
+				return importFailed(result, fmt.Sprintf("url already used by repository %s",
+					repository.Spec.DisplayName))
+			}
+		}
+	}
+	if response, valid := validateRepoConnection(repoEntry); !valid {
+		return importFailed(result, response.Msg)
+	}
+	if response, _, err := i.client.resolveRepoReferences(repoEntry, safeRepoEntryToRepoEntry(repoEntry)); err != nil {
+		return importFailed(result, response.Msg)
+	}
+	if err := egress.CheckURL(i.client.requestContext(), repoEntry.URL); err != nil {
+		if response, blocked := egressViolationResponse(err); blocked {
+			return importFailed(result, response.Msg)
+		}
+		return importFailed(result, fmt.Sprintf("invalid repository url, %v", err))
+	}
+	result.Status = helm.RepoImportValid
+	return result
+}
+
+// safeRepoEntry request form of an imported entry with its sealed credentials decrypted
+func (i *repoImporter) safeRepoEntry(entry *repo.Entry) (*helm.SafeRepoEntry, error) {
+	values := map[string]string{
+		mapKeyUsername: entry.Username,
+		mapKeyPassword: entry.Password,
+		mapKeyTLSKey:   entry.KeyFile,
+	}
+	for field, value := range values {
+		if !envelope.IsSealedValue(value) {
+			continue
+		}
+		if i.keyring == nil {
+			keyring, err := i.client.keyring()
+			if err != nil {
+				return nil, err
+			}
+			i.keyring = keyring
+		}
+		opened, err := i.keyring.OpenValue(sealedValueField(entry.Name, field), value)
+		if err != nil {
+			return nil, err
+		}
+		values[field] = opened
+	}
+	return &helm.SafeRepoEntry{
+		Name:                  entry.Name,
+		URL:                   entry.URL,
+		Username:              []byte(values[mapKeyUsername]),
+		Password:              []byte(values[mapKeyPassword]),
+		CertFile:              entry.CertFile,
+		KeyFile:               values[mapKeyTLSKey],
+		CAFile:                entry.CAFile,
+		InsecureSkipTLSVerify: entry.InsecureSkipTLSverify,
+		PassCredentialsAll:    entry.PassCredentialsAll,
+	}, nil
+}
+
+// keepRepoSettings settings of an existing repository the imported entry does not carry. Credentials left out
+// or matching those of an existing secret or config map the repository references keep the reference. An entry
+// moving the repository to another url keeps none, the credentials are never sent to another host
+func (c *helmClient) keepRepoSettings(repoEntry *helm.SafeRepoEntry, repository *helm.HelmChartRepository) error {
+	if repoEntry.URL != repository.Spec.URL {
+		return nil
+	}
+	current, err := c.repoCRtoRepoEntry(repository)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		return fmt.Errorf("credentials of repository %s not found", repository.Spec.DisplayName)
+	}
+	spec := repository.Spec
+	if (len(repoEntry.Username) == 0 && len(repoEntry.Password) == 0) || (spec.BasicAuth.External &&
+		string(repoEntry.Username) == current.Username && string(repoEntry.Password) == current.Password) {
+		repoEntry.Username, repoEntry.Password = []byte(current.Username), []byte(current.Password)
+		if spec.BasicAuth.External {
+			repoEntry.Username, repoEntry.Password, repoEntry.BasicAuthRef = nil, nil, spec.BasicAuth.DeepCopy()
+		}
+	}
+	if (repoEntry.CertFile == "" && repoEntry.KeyFile == "") || (spec.TLS.External &&
+		repoEntry.CertFile == current.CertFile && repoEntry.KeyFile == current.KeyFile) {
+		repoEntry.CertFile, repoEntry.KeyFile = current.CertFile, current.KeyFile
+		if spec.TLS.External {
+			repoEntry.CertFile, repoEntry.KeyFile, repoEntry.TLSRef = "", "", spec.TLS.DeepCopy()
+		}
+	}
+	if repoEntry.CAFile == "" || (spec.CA.External && repoEntry.CAFile == current.CAFile) {
+		repoEntry.CAFile = current.CAFile
+		if spec.CA.External {
+			repoEntry.CAFile, repoEntry.CARef = "", spec.CA.DeepCopy()
+		}
+	}
+	repoEntry.Proxy = spec.Proxy.DeepCopy()
+	if connection := loadRepoConnection(current.Name); connection != nil {
+		repoEntry.Headers = connection.headers
+		repoEntry.Auth = authToRepoAuthEntry(connection.auth)
+	}
+	return nil
+}
+
+func importFailed(result helm.RepoImportResult, msg string) helm.RepoImportResult {
+	result.Status, result.Message = helm.RepoImportFailed, msg
+	return result
+}
@@ -0,0 +1,193 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package helm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/repo"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"marketplace-service/pkg/constant"
+	"marketplace-service/pkg/models/helm"
+	"marketplace-service/pkg/utils/envelope"
+)
+
+const mockImportedRepo = "imported"
+
+func newRepoFileClient(t *testing.T, secrets ...*v1.Secret) *helmClient {
+	clientset := mockClientset()
+	for _, secret := range secrets {
+		_, err := clientset.CoreV1().Secrets(constant.MarketplaceServiceDefaultNamespace).Create(context.Background(),
+			secret, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+	return &helmClient{clientset: clientset, dynamicClient: mockDynamicClient()}
+}
+
+func exportedRepoFile(t *testing.T, c *helmClient, credentials string) *repo.File {
+	response, status := c.ExportRepos(credentials)
+	assert.Equal(t, http.StatusOK, status)
+	file, ok := response.Data.(*repo.File)
+	assert.True(t, ok)
+	assert.Len(t, file.Repositories, 1)
+	return file
+}
+
+// Test_helmClient_ExportRepos 测试以repositories.yaml格式导出仓库
+func Test_helmClient_ExportRepos(t *testing.T) {
+	server := httptest.NewServer(newTestRepoHandler())
+	defer server.Close()
+	c := newRepoFileClient(t, mockSymmetricKeySecret("current-key", ""))
+	_, status := c.CreateRepo(&helm.SafeRepoEntry{Name: mockImportedRepo, URL: server.URL,
+		Username: []byte("admin"), Password: []byte("s3cret"), CAFile: "-----BEGIN CERTIFICATE-----"})
+	assert.Equal(t, http.StatusCreated, status)
+
+	entry := exportedRepoFile(t, c, "").Repositories[0]
+	assert.Equal(t, mockImportedRepo, entry.Name)
+	assert.Equal(t, server.URL, entry.URL)
+	assert.Empty(t, entry.Username)
+	assert.Empty(t, entry.Password)
+	assert.Equal(t, "-----BEGIN CERTIFICATE-----", entry.CAFile)
+
+	entry = exportedRepoFile(t, c, helm.RepoExportCredentialsPlain).Repositories[0]
+	assert.Equal(t, "admin", entry.Username)
+	assert.Equal(t, "s3cret", entry.Password)
+
+	entry = exportedRepoFile(t, c, helm.RepoExportCredentialsEncrypted).Repositories[0]
+	assert.True(t, envelope.IsSealedValue(entry.Username))
+	assert.True(t, envelope.IsSealedValue(entry.Password))
+	password, err := envelope.NewKeyring([]byte("current-key")).OpenValue(
+		sealedValueField(mockImportedRepo, mapKeyPassword), entry.Password)
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cret", password)
+
+	_, status = c.ExportRepos("base64")
+	assert.Equal(t, http.StatusBadRequest, status)
+	_, status = newRepoFileClient(t).ExportRepos(helm.RepoExportCredentialsEncrypted)
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func importResults(t *testing.T, c *helmClient, data string, dryRun bool, wantStatus int) map[string]string {
+	response, status := c.ImportRepos([]byte(data), dryRun)
+	assert.Equal(t, wantStatus, status)
+	importResponse, ok := response.Data.(*helm.RepoImportResponse)
+	if !ok {
+		return nil
+	}
+	assert.Equal(t, dryRun, importResponse.DryRun)
+	results := make(map[string]string, len(importResponse.Results))
+	for _, result := range importResponse.Results {
+		results[result.Name+"/"+result.Action] = result.Status
+	}
+	return results
+}
+
+// Test_helmClient_ImportRepos 测试从repositories.yaml批量创建与更新仓库
+func Test_helmClient_ImportRepos(t *testing.T) {
+	server := httptest.NewServer(newTestRepoHandler())
+	defer server.Close()
+	c := newRepoFileClient(t)
+	file := fmt.Sprintf(`apiVersion: v1
+repositories:
+  - name: %s
+    url: %s
+    username: admin
+    password: s3cret
+  - name: openFuyao
+    url: https://cr.openfuyao.cn/chartrepo/openfuyao-catalog
+`, mockImportedRepo, server.URL)
+
+	results := importResults(t, c, file, true, http.StatusOK)
+	assert.Equal(t, map[string]string{mockImportedRepo + "/" + helm.RepoImportCreate: helm.RepoImportValid,
+		"openFuyao/" + helm.RepoImportCreate: helm.RepoImportSkipped}, results)
+	repositories, err := c.listCustomRepo()
+	assert.NoError(t, err)
+	assert.Empty(t, repositories)
+
+	results = importResults(t, c, file, false, http.StatusOK)
+	assert.Equal(t, helm.RepoImportSucceeded, results[mockImportedRepo+"/"+helm.RepoImportCreate])
+
+	// credentials left out of the file are kept by the existing repository
+	results = importResults(t, c, fmt.Sprintf("repositories:\n  - name: %s\n    url: %s\n", mockImportedRepo,
+		server.URL), false, http.StatusOK)
+	assert.Equal(t, helm.RepoImportSucceeded, results[mockImportedRepo+"/"+helm.RepoImportUpdate])
+	repository, err := c.getCustomRepoByName(mockImportedRepo)
+	assert.NoError(t, err)
+	entry, err := c.repoCRtoRepoEntry(repository)
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cret", entry.Password)
+
+	results = importResults(t, c, fmt.Sprintf(`repositories:
+  - name: Invalid_Name
+    url: %s/other
+  - name: %s
+    url: %s
+    password: wrong
+  - name: %s
+    url: %s
+`, server.URL, mockImportedRepo, server.URL, mockImportedRepo, server.URL), false, http.StatusBadRequest)
+	assert.Equal(t, map[string]string{"Invalid_Name/" + helm.RepoImportCreate: helm.RepoImportFailed,
+		mockImportedRepo + "/" + helm.RepoImportUpdate: helm.RepoImportFailed}, results)
+
+	// moving the repository to another host keeps none of its credentials
+	var leaked bool
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _, leaked = r.BasicAuth()
+		_, _ = w.Write([]byte(mockTestIndex))
+	}))
+	defer other.Close()
+	results = importResults(t, c, fmt.Sprintf("repositories:\n  - name: %s\n    url: %s\n", mockImportedRepo,
+		other.URL), false, http.StatusOK)
+	assert.Equal(t, helm.RepoImportSucceeded, results[mockImportedRepo+"/"+helm.RepoImportUpdate])
+	assert.False(t, leaked)
+	repository, err = c.getCustomRepoByName(mockImportedRepo)
+	assert.NoError(t, err)
+	assert.Empty(t, repository.Spec.BasicAuth.Name)
+
+	assert.Nil(t, importResults(t, c, "repositories: []", false, http.StatusBadRequest))
+	assert.Nil(t, importResults(t, c, "{", false, http.StatusBadRequest))
+}
+
+// Test_helmClient_ImportExportedRepos 测试加密导出的仓库可在另一环境导入
+func Test_helmClient_ImportExportedRepos(t *testing.T) {
+	server := httptest.NewServer(newTestRepoHandler())
+	defer server.Close()
+	source := newRepoFileClient(t, mockSymmetricKeySecret("current-key", ""))
+	_, status := source.CreateRepo(&helm.SafeRepoEntry{Name: mockImportedRepo, URL: server.URL,
+		Username: []byte("admin"), Password: []byte("s3cret")})
+	assert.Equal(t, http.StatusCreated, status)
+	data, err := yaml.Marshal(exportedRepoFile(t, source, helm.RepoExportCredentialsEncrypted))
+	assert.NoError(t, err)
+
+	results := importResults(t, newRepoFileClient(t, mockSymmetricKeySecret("other-key", "")), string(data), true,
+		http.StatusBadRequest)
+	assert.Equal(t, helm.RepoImportFailed, results[mockImportedRepo+"/"+helm.RepoImportCreate])
+
+	target := newRepoFileClient(t, mockSymmetricKeySecret("current-key", ""))
+	results = importResults(t, target, string(data), false, http.StatusOK)
+	assert.Equal(t, helm.RepoImportSucceeded, results[mockImportedRepo+"/"+helm.RepoImportCreate])
+	repository, err := target.getCustomRepoByName(mockImportedRepo)
+	assert.NoError(t, err)
+	entry, err := target.repoCRtoRepoEntry(repository)
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", entry.Username)
+	assert.Equal(t, "s3cret", entry.Password)
+}
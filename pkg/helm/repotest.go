@@ -0,0 +1,331 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package helm
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"helm.sh/helm/v3/pkg/repo"
+	helmtime "helm.sh/helm/v3/pkg/time"
+
+	"marketplace-service/pkg/constant"
+	"marketplace-service/pkg/egress"
+	"marketplace-service/pkg/models/helm"
+	"marketplace-service/pkg/upstream"
+	"marketplace-service/pkg/utils/httputil"
+)
+
+const (
+	repoTestTimeout     = 60 * time.Second
+	repoTestDialTimeout = 10 * time.Second
+)
+
+// TestRepo diagnose connecting to the repository of a create request step by step. Referenced secrets are
+// read, but nothing is created or cached
+func (c *helmClient) TestRepo(repoEntry *helm.SafeRepoEntry) (*httputil.ResponseJson, int) {
+	if response, valid := validateRepoConnection(repoEntry); !valid {
+		return response, http.StatusBadRequest
+	}
+	entry := safeRepoEntryToRepoEntry(repoEntry)
+	if response, status, err := c.resolveRepoReferences(repoEntry, entry); err != nil {
+		c.log().Errorf("resolve references of repository %s failed, %v", repoEntry.Name, err)
+		return response, status
+	}
+	indexURL, err := url.Parse(repoIndexURL(entry.URL))
+	if err != nil || (indexURL.Scheme != "http" && indexURL.Scheme != "https") || indexURL.Hostname() == "" {
+		return &httputil.ResponseJson{
+			Code: constant.ClientError,
+			Msg:  "repository url must be an http or https url",
+		}, http.StatusBadRequest
+	}
+	ctx, cancel := context.WithTimeout(c.requestContext(), repoTestTimeout)
+	defer cancel()
+	if response, blocked := egressViolationResponse(egress.CheckURL(ctx, indexURL.String())); blocked {
+		return response, http.StatusBadRequest
+	}
+
+	test := &repoTest{
+		ctx:        ctx,
+		entry:      entry,
+		connection: safeRepoEntryConnection(repoEntry),
+		indexURL:   indexURL,
+		report:     &helm.RepoTestReport{Steps: make([]helm.RepoTestStep, 0)},
+	}
+	test.run()
+	test.report.Success = test.failed == ""
+	msg := "repository is reachable"
+	if !test.report.Success {
+		msg = fmt.Sprintf("repository test failed at step %s", test.failed)
+	}
+	c.log().Infof("repository %s tested, %s", repoEntry.Name, msg)
+	return &httputil.ResponseJson{Code: constant.Success, Msg: msg, Data: test.report}, http.StatusOK
+}
+
+// repoTest state carried from one step of the connectivity test to the next
+type repoTest struct {
+	ctx        context.Context
+	entry      *repo.Entry
+	connection *repoConnection
+	indexURL   *url.URL
+	report     *helm.RepoTestReport
+	// failed name of the first failed step, the steps after it are skipped
+	failed string
+
+	host, port string
+	viaProxy   bool
+	addresses  []net.IP
+	conn       net.Conn
+	resp       *http.Response
+}
+
+func (t *repoTest) run() {
+	defer func() {
+		if t.conn != nil {
+			_ = t.conn.Close()
+		}
+		if t.resp != nil {
+			_ = t.resp.Body.Close()
+		}
+	}()
+	t.step(helm.RepoTestStepDNS, t.resolve)
+	t.step(helm.RepoTestStepTCP, t.dial)
+	t.step(helm.RepoTestStepTLS, t.handshake)
+	t.step(helm.RepoTestStepAuth, t.authenticate)
+	t.step(helm.RepoTestStepIndex, t.fetchIndex)
+}
+
+// step run fn unless an earlier step failed, fn sets the outcome of the step if it did not pass
+func (t *repoTest) step(name string, fn func(step *helm.RepoTestStep)) {
+	step := helm.RepoTestStep{Name: name, Status: helm.RepoTestPassed}
+	if t.failed != "" {
+		step.Status = helm.RepoTestSkipped
+		step.Message = fmt.Sprintf("step %s failed", t.failed)
+	} else {
+		start := time.Now()
+		fn(&step)
+		step.DurationMillis = time.Since(start).Milliseconds()
+		if step.Status == helm.RepoTestFailed {
+			t.failed = name
+		}
+	}
+	t.report.Steps = append(t.report.Steps, step)
+}
+
+func failStep(step *helm.RepoTestStep, format string, args ...interface{}) {
+	step.Status = helm.RepoTestFailed
+	step.Message = fmt.Sprintf(format, args...)
+}
+
+// resolve the addresses of the repository, or of its proxy if the index is requested through one
+func (t *repoTest) resolve(step *helm.RepoTestStep) {
+	target := t.indexURL
+	proxy, err := upstream.Proxy(t.connection.proxy.URL, t.connection.proxy.NoProxy)
+	if err == nil && proxy != nil {
+		var proxyURL *url.URL
+		if proxyURL, err = proxy(&http.Request{URL: t.indexURL}); proxyURL != nil {
+			target, t.viaProxy = proxyURL, true
+			step.Message = fmt.Sprintf("requests are sent through proxy %s", proxyURL.Host)
+		}
+	}
+	if err != nil {
+		failStep(step, "invalid proxy, %v", err)
+		return
+	}
+	t.host, t.port = target.Hostname(), target.Port()
+	if t.port == "" {
+		t.port = map[string]string{"http": "80", "https": "443"}[target.Scheme]
+	}
+
+	ips := []net.IP{net.ParseIP(t.host)}
+	if ips[0] == nil {
+		addresses, err := net.DefaultResolver.LookupIPAddr(t.ctx, t.host)
+		if err != nil {
+			failStep(step, "resolve %s failed, %v", t.host, err)
+			return
+		}
+		ips = ips[:0]
+		for _, address := range addresses {
+			ips = append(ips, address.IP)
+		}
+	}
+	var violation error
+	for _, ip := range ips {
+		step.Addresses = append(step.Addresses, ip.String())
+		if err = egress.CurrentPolicy().CheckIP(ip); err != nil {
+			violation = err
+			continue
+		}
+		t.addresses = append(t.addresses, ip)
+	}
+	if len(t.addresses) == 0 {
+		failStep(step, "no address of %s is allowed by the egress policy, %v", t.host, violation)
+	}
+}
+
+// dial the resolved addresses until one accepts the connection
+func (t *repoTest) dial(step *helm.RepoTestStep) {
+	dialer := &net.Dialer{Timeout: repoTestDialTimeout}
+	var err error
+	for _, ip := range t.addresses {
+		address := net.JoinHostPort(ip.String(), t.port)
+		if t.conn, err = dialer.DialContext(t.ctx, "tcp", address); err == nil {
+			step.Addresses = []string{address}
+			return
+		}
+	}
+	failStep(step, "connect to %s failed, %v", net.JoinHostPort(t.host, t.port), err)
+}
+
+// handshake with the tls settings of the repository, the chain is verified separately so that it is reported
+// even if the repository client does not verify it
+func (t *repoTest) handshake(step *helm.RepoTestStep) {
+	switch {
+	case t.viaProxy:
+		step.Status = helm.RepoTestSkipped
+		step.Message = "tls is negotiated through the proxy, see step auth"
+		return
+	case t.indexURL.Scheme != "https":
+		step.Status = helm.RepoTestSkipped
+		step.Message = "repository is served over plain http"
+		return
+	default:
+	}
+	config, err := httputil.GetHarborHTTPConfig(t.entry)
+	if err != nil {
+		failStep(step, "invalid tls settings, %v", err)
+		return
+	}
+	if config == nil {
+		config = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	probe := config.Clone()
+	probe.ServerName = t.indexURL.Hostname()
+	probe.InsecureSkipVerify = true
+	conn := tls.Client(t.conn, probe)
+	if err = conn.HandshakeContext(t.ctx); err != nil {
+		failStep(step, "tls handshake failed, %v", err)
+		return
+	}
+	state := conn.ConnectionState()
+	for _, certificate := range state.PeerCertificates {
+		step.Certificates = append(step.Certificates, helm.RepoTestCertificate{
+			Subject:   certificate.Subject.String(),
+			Issuer:    certificate.Issuer.String(),
+			DNSNames:  certificate.DNSNames,
+			NotBefore: helmtime.Time{Time: certificate.NotBefore},
+			NotAfter:  helmtime.Time{Time: certificate.NotAfter},
+		})
+	}
+	err = verifyChain(state.PeerCertificates, config.RootCAs, probe.ServerName)
+	switch {
+	case err == nil:
+		step.Message = fmt.Sprintf("%s, certificate chain verified", tls.VersionName(state.Version))
+	case config.InsecureSkipVerify:
+		step.Status = helm.RepoTestWarning
+		step.Message = fmt.Sprintf("certificate chain is not trusted, %v, the repository client skips verification", err)
+	default:
+		failStep(step, "certificate chain is not trusted, %v", err)
+	}
+}
+
+func verifyChain(certificates []*x509.Certificate, roots *x509.CertPool, serverName string) error {
+	if len(certificates) == 0 {
+		return errors.New("no certificate presented")
+	}
+	intermediates := x509.NewCertPool()
+	for _, certificate := range certificates[1:] {
+		intermediates.AddCert(certificate)
+	}
+	_, err := certificates[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		DNSName:       serverName,
+	})
+	return err
+}
+
+// authenticate request the index with the credentials of the repository, the way synchronization does
+func (t *repoTest) authenticate(step *helm.RepoTestStep) {
+	client, err := upstream.NewClient(t.entry.Name, repoSettings(t.entry, t.connection))
+	if err != nil {
+		failStep(step, "invalid connection settings, %v", err)
+		return
+	}
+	req, err := http.NewRequestWithContext(t.ctx, http.MethodGet, t.indexURL.String(), nil)
+	if err != nil {
+		failStep(step, "invalid index url, %v", err)
+		return
+	}
+	if t.entry.Username != "" && t.entry.Password != "" {
+		req.SetBasicAuth(t.entry.Username, t.entry.Password)
+	}
+	if t.resp, err = client.Do(req); err != nil {
+		failStep(step, "request %s failed, %v", t.indexURL.Redacted(), err)
+		return
+	}
+	credentials := t.credentials()
+	switch {
+	case t.resp.StatusCode != http.StatusUnauthorized && t.resp.StatusCode != http.StatusForbidden:
+		step.Message = fmt.Sprintf("%s accepted", credentials)
+	case credentials == anonymousAccess:
+		failStep(step, "repository requires credentials, it answered %s", t.resp.Status)
+	default:
+		failStep(step, "repository rejected the %s, it answered %s", credentials, t.resp.Status)
+	}
+}
+
+const anonymousAccess = "anonymous access"
+
+// credentials description of the credentials the repository is requested with
+func (t *repoTest) credentials() string {
+	if t.connection.auth != nil {
+		return t.connection.auth.Type + " credentials"
+	}
+	if t.entry.Username != "" && t.entry.Password != "" {
+		return "basic credentials"
+	}
+	return anonymousAccess
+}
+
+// fetchIndex read and parse the index answered to the auth step
+func (t *repoTest) fetchIndex(step *helm.RepoTestStep) {
+	if t.resp.StatusCode != http.StatusOK {
+		failStep(step, "index.yaml answered %s", t.resp.Status)
+		return
+	}
+	buffer := newLimitedBuffer(int64(constant.ResponseBodyLimit << constant.ToMegabytes))
+	if _, err := io.Copy(buffer, t.resp.Body); err != nil {
+		failStep(step, "read index.yaml failed, %v", err)
+		return
+	}
+	index, err := loadIndex(buffer.buffer.Bytes())
+	if err != nil {
+		failStep(step, "index.yaml is not a helm repository index, %v", err)
+		return
+	}
+	t.report.IndexAPIVersion = index.APIVersion
+	t.report.ChartCount = len(index.Entries)
+	for _, versions := range index.Entries {
+		t.report.VersionCount += len(versions)
+	}
+	step.Message = fmt.Sprintf("%d charts with %d versions", t.report.ChartCount, t.report.VersionCount)
+}
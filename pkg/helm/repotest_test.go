@@ -0,0 +1,126 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package helm
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"marketplace-service/pkg/models/helm"
+)
+
+const mockTestIndex = `apiVersion: v1
+entries:
+  nginx:
+    - name: nginx
+      version: 1.1.0
+      urls: [charts/nginx-1.1.0.tgz]
+    - name: nginx
+      version: 1.0.0
+      urls: [charts/nginx-1.0.0.tgz]
+  redis:
+    - name: redis
+      version: 7.0.0
+      urls: [charts/redis-7.0.0.tgz]
+`
+
+func newTestRepoHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "admin" || password != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(mockTestIndex))
+	})
+}
+
+func repoTestStatuses(report *helm.RepoTestReport) map[string]string {
+	statuses := make(map[string]string, len(report.Steps))
+	for _, step := range report.Steps {
+		statuses[step.Name] = step.Status
+	}
+	return statuses
+}
+
+// Test_helmClient_TestRepo 测试仓库连通性逐步诊断
+func Test_helmClient_TestRepo(t *testing.T) {
+	server := httptest.NewServer(newTestRepoHandler())
+	defer server.Close()
+	tlsServer := httptest.NewTLSServer(newTestRepoHandler())
+	defer tlsServer.Close()
+
+	tests := []struct {
+		name      string
+		repoEntry *helm.SafeRepoEntry
+		want      map[string]string
+		charts    int
+	}{
+		{
+			name: "http",
+			repoEntry: &helm.SafeRepoEntry{Name: "test-http", URL: server.URL,
+				Username: []byte("admin"), Password: []byte("s3cret")},
+			want: map[string]string{helm.RepoTestStepDNS: helm.RepoTestPassed, helm.RepoTestStepTCP: helm.RepoTestPassed,
+				helm.RepoTestStepTLS: helm.RepoTestSkipped, helm.RepoTestStepAuth: helm.RepoTestPassed,
+				helm.RepoTestStepIndex: helm.RepoTestPassed},
+			charts: 2,
+		},
+		{
+			name: "untrusted certificate",
+			repoEntry: &helm.SafeRepoEntry{Name: "test-tls", URL: tlsServer.URL + "/",
+				Username: []byte("admin"), Password: []byte("s3cret")},
+			want: map[string]string{helm.RepoTestStepDNS: helm.RepoTestPassed, helm.RepoTestStepTCP: helm.RepoTestPassed,
+				helm.RepoTestStepTLS: helm.RepoTestWarning, helm.RepoTestStepAuth: helm.RepoTestPassed,
+				helm.RepoTestStepIndex: helm.RepoTestPassed},
+			charts: 2,
+		},
+		{
+			name: "rejected credentials",
+			repoEntry: &helm.SafeRepoEntry{Name: "test-auth", URL: server.URL,
+				Username: []byte("admin"), Password: []byte("wrong")},
+			want: map[string]string{helm.RepoTestStepDNS: helm.RepoTestPassed, helm.RepoTestStepTCP: helm.RepoTestPassed,
+				helm.RepoTestStepTLS: helm.RepoTestSkipped, helm.RepoTestStepAuth: helm.RepoTestFailed,
+				helm.RepoTestStepIndex: helm.RepoTestSkipped},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &helmClient{}
+			got, status := c.TestRepo(tt.repoEntry)
+			assert.Equal(t, http.StatusOK, status)
+			report := got.Data.(*helm.RepoTestReport)
+			assert.Equal(t, tt.want, repoTestStatuses(report))
+			assert.Equal(t, tt.charts > 0, report.Success)
+			assert.Equal(t, tt.charts, report.ChartCount)
+			if tt.charts > 0 {
+				assert.Equal(t, 3, report.VersionCount)
+				assert.Equal(t, "v1", report.IndexAPIVersion)
+			}
+			_, cached := cachedData.GetChartCacheByRepo(tt.repoEntry.Name)
+			assert.False(t, cached)
+			assert.Nil(t, loadRepoConnection(tt.repoEntry.Name))
+		})
+	}
+}
+
+// Test_helmClient_TestRepo_invalid 测试非法仓库地址
+func Test_helmClient_TestRepo_invalid(t *testing.T) {
+	c := &helmClient{}
+	_, status := c.TestRepo(&helm.SafeRepoEntry{Name: "test-invalid", URL: "ftp://charts.example.com"})
+	assert.Equal(t, http.StatusBadRequest, status)
+	_, status = c.TestRepo(&helm.SafeRepoEntry{Name: "test-invalid", URL: "http://charts.example.com",
+		Proxy: &helm.ProxyConfig{URL: "socks5://proxy.example.com"}})
+	assert.Equal(t, http.StatusBadRequest, status)
+}
@@ -0,0 +1,597 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package helm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	goErrors "errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/yaml"
+
+	"marketplace-service/pkg/constant"
+	"marketplace-service/pkg/models/helm"
+	"marketplace-service/pkg/utils/httputil"
+	"marketplace-service/pkg/zlog"
+)
+
+const (
+	snapshotLabelRelease      = "openfuyao.com/release-name"
+	snapshotLabelRevision     = "openfuyao.com/release-revision"
+	snapshotAnnotationPVC     = "openfuyao.com/pvc-name"
+	snapshotAnnotationPVCSpec = "openfuyao.com/pvc-spec"
+
+	snapshotAPIGroup = "snapshot.storage.k8s.io"
+	snapshotKind     = "VolumeSnapshot"
+
+	kindPersistentVolumeClaim = "PersistentVolumeClaim"
+	kindStatefulSet           = "StatefulSet"
+
+	defaultSnapshotTimeoutSeconds = 300
+	maxSnapshotTimeoutSeconds     = 1800
+	snapshotPollIntervalSeconds   = 2
+	maxResourceNameLength         = 253
+	snapshotNameHashLength        = 8
+)
+
+// manifestObject fields of a rendered manifest needed to find release volumes
+type manifestObject struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec struct {
+		VolumeClaimTemplates []struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+		} `json:"volumeClaimTemplates"`
+	} `json:"spec"`
+}
+
+// pvcSpecRecord the part of a persistent volume claim kept with the snapshot for restoring
+type pvcSpecRecord struct {
+	Labels           map[string]string               `json:"labels,omitempty"`
+	AccessModes      []v1.PersistentVolumeAccessMode `json:"accessModes,omitempty"`
+	StorageClassName *string                         `json:"storageClassName,omitempty"`
+	VolumeMode       *v1.PersistentVolumeMode        `json:"volumeMode,omitempty"`
+	Resources        v1.VolumeResourceRequirements   `json:"resources,omitempty"`
+}
+
+// CreateReleaseSnapshots create a VolumeSnapshot for every persistent volume claim owned by the release,
+// snapshots are recorded against the currently deployed revision
+func (c *helmClient) CreateReleaseSnapshots(namespace, releaseName string,
+	snapshotRequest *helm.SnapshotRequest) (*httputil.ResponseJson, int) {
+	rel, responseJson, status := c.getRelease(namespace, releaseName, 0)
+	if responseJson != nil {
+		return responseJson, status
+	}
+	pvcList, err := c.getReleasePVCs(rel)
+	if err != nil {
+		c.log().Errorf("error finding persistent volume claims of release %s/%s, %v", namespace, releaseName, err)
+		return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
+	}
+
+	result := &helm.ReleaseSnapshotResponse{
+		Release:   rel.Name,
+		Namespace: rel.Namespace,
+		Revision:  rel.Version,
+		Snapshots: make([]helm.ReleaseSnapshot, 0, len(pvcList)),
+	}
+	snapshots := make([]*snapshotv1.VolumeSnapshot, 0, len(pvcList))
+	for i := range pvcList {
+		snapshot, err := c.createPVCSnapshot(rel, &pvcList[i], snapshotRequest.VolumeSnapshotClassName)
+		if err != nil {
+			c.log().Errorf("error creating snapshot for pvc %s/%s, %v", namespace, pvcList[i].Name, err)
+			return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	if snapshotRequest.Wait && len(snapshots) > 0 {
+		snapshots, err = c.waitSnapshotsReady(namespace, snapshots, snapshotRequest.TimeoutSeconds)
+		if err != nil {
+			c.log().Errorf("error waiting snapshots of release %s/%s, %v", namespace, releaseName, err)
+			for _, snapshot := range snapshots {
+				result.Snapshots = append(result.Snapshots, convertToReleaseSnapshot(snapshot))
+			}
+			return &httputil.ResponseJson{
+				Code: constant.ServerError,
+				Msg:  fmt.Sprintf("snapshots of release %s are not ready: %v", releaseName, err),
+				Data: result,
+			}, http.StatusInternalServerError
+		}
+	}
+	for _, snapshot := range snapshots {
+		result.Snapshots = append(result.Snapshots, convertToReleaseSnapshot(snapshot))
+	}
+	return &httputil.ResponseJson{
+		Code: constant.FileCreated,
+		Msg:  fmt.Sprintf("%d snapshots created for release %s revision %d", len(snapshots), rel.Name, rel.Version),
+		Data: result,
+	}, http.StatusCreated
+}
+
+// ListReleaseSnapshots list snapshots taken for the release, revision 0 means all revisions
+func (c *helmClient) ListReleaseSnapshots(namespace, releaseName string, revision int) (*httputil.ResponseJson, int) {
+	snapshots, err := c.listReleaseSnapshots(namespace, releaseName, revision)
+	if err != nil {
+		c.log().Errorf("error listing snapshots of release %s/%s, %v", namespace, releaseName, err)
+		return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
+	}
+	result := &helm.ReleaseSnapshotResponse{
+		Release:   releaseName,
+		Namespace: namespace,
+		Revision:  revision,
+		Snapshots: make([]helm.ReleaseSnapshot, 0, len(snapshots)),
+	}
+	for i := range snapshots {
+		result.Snapshots = append(result.Snapshots, convertToReleaseSnapshot(&snapshots[i]))
+	}
+	return &httputil.ResponseJson{
+		Code: constant.Success,
+		Msg:  "success",
+		Data: result,
+	}, http.StatusOK
+}
+
+// RestoreReleaseSnapshots recreate persistent volume claims of the release from snapshots of the given revision,
+// workloads using the claims should be scaled down before restoring
+func (c *helmClient) RestoreReleaseSnapshots(namespace, releaseName string, revision int,
+	restoreRequest *helm.SnapshotRestoreRequest) (*httputil.ResponseJson, int) {
+	if revision <= 0 {
+		return &httputil.ResponseJson{
+			Code: constant.ClientError,
+			Msg:  "please provide release revision for restore",
+		}, http.StatusBadRequest
+	}
+	snapshots, err := c.listReleaseSnapshots(namespace, releaseName, revision)
+	if err != nil {
+		c.log().Errorf("error listing snapshots of release %s/%s, %v", namespace, releaseName, err)
+		return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
+	}
+	if len(snapshots) == 0 {
+		return &httputil.ResponseJson{
+			Code: constant.ResourceNotFound,
+			Msg:  fmt.Sprintf("no snapshot found for release %s revision %d", releaseName, revision),
+		}, http.StatusNotFound
+	}
+	responseJson, status := c.checkSnapshotsRestorable(namespace, snapshots, restoreRequest.Force)
+	if responseJson != nil {
+		return responseJson, status
+	}
+
+	// every claim is prepared before the first one is deleted, so an invalid snapshot leaves the release untouched
+	pvcs := make([]*v1.PersistentVolumeClaim, 0, len(snapshots))
+	for i := range snapshots {
+		pvc, err := restoredPVC(&snapshots[i])
+		if err != nil {
+			c.log().Errorf("error preparing restore of release %s/%s, %v", namespace, releaseName, err)
+			return &httputil.ResponseJson{Code: constant.ClientError, Msg: err.Error()}, http.StatusBadRequest
+		}
+		if err = c.dryRunCreatePVC(pvc, restoreRequest.Force); err != nil {
+			c.log().Errorf("error checking restore of pvc %s/%s, %v", namespace, pvc.Name, err)
+			return &httputil.ResponseJson{
+				Code: constant.ClientError,
+				Msg:  fmt.Sprintf("persistent volume claim %s can not be restored, %v", pvc.Name, err),
+			}, http.StatusBadRequest
+		}
+		pvcs = append(pvcs, pvc)
+	}
+	restorable := pvcs
+	var failures []string
+	if restoreRequest.Force {
+		restorable, failures = c.deletePVCsForRestore(namespace, pvcs)
+	}
+
+	// a claim failing to restore does not stop the others, the response lists those left missing
+	result := &helm.SnapshotRestoreResponse{Restored: make([]string, 0, len(restorable)), Missing: make([]string, 0)}
+	for _, pvc := range restorable {
+		_, err = c.clientset.CoreV1().PersistentVolumeClaims(namespace).Create(c.requestContext(), pvc,
+			metav1.CreateOptions{})
+		if err != nil {
+			c.log().Errorf("error restoring pvc from snapshot %s/%s, %v", namespace, pvc.Spec.DataSource.Name, err)
+			failures = append(failures, fmt.Sprintf("failed to restore from snapshot %s", pvc.Spec.DataSource.Name))
+			result.Missing = append(result.Missing, pvc.Name)
+			continue
+		}
+		c.log().Infof("pvc %s/%s restored from snapshot %s", namespace, pvc.Name, pvc.Spec.DataSource.Name)
+		result.Restored = append(result.Restored, pvc.Name)
+	}
+	if len(failures) > 0 {
+		return &httputil.ResponseJson{
+			Code: constant.ServerError,
+			Msg:  strings.Join(failures, ", "),
+			Data: result,
+		}, http.StatusInternalServerError
+	}
+	c.log().Infof("release %s/%s volumes restored from revision %d", namespace, releaseName, revision)
+	return &httputil.ResponseJson{
+		Code: constant.Success,
+		Msg:  fmt.Sprintf("%d persistent volume claims restored", len(result.Restored)),
+		Data: result,
+	}, http.StatusOK
+}
+
+// deletePVCsForRestore delete the claims a forced restore replaces, up to the first one failing to delete. The
+// claims deleted by then are returned to be restored all the same, so that none of them is left missing
+func (c *helmClient) deletePVCsForRestore(namespace string,
+	pvcs []*v1.PersistentVolumeClaim) ([]*v1.PersistentVolumeClaim, []string) {
+	for i, pvc := range pvcs {
+		err := c.deletePVCAndWait(namespace, pvc.Name)
+		if err == nil {
+			continue
+		}
+		c.log().Errorf("error deleting pvc %s/%s before restore, %v", namespace, pvc.Name, err)
+		deleted := pvcs[:i]
+		// a claim whose deletion timed out may be gone by now
+		_, getErr := c.clientset.CoreV1().PersistentVolumeClaims(namespace).Get(c.requestContext(), pvc.Name,
+			metav1.GetOptions{})
+		if errors.IsNotFound(getErr) {
+			deleted = pvcs[:i+1]
+		}
+		return deleted, []string{fmt.Sprintf("failed to delete persistent volume claim %s before restore", pvc.Name)}
+	}
+	return pvcs, nil
+}
+
+func (c *helmClient) getRelease(namespace, releaseName string, revision int) (*release.Release,
+	*httputil.ResponseJson, int) {
+	if namespace == "" || releaseName == "" {
+		return nil, &httputil.ResponseJson{
+			Code: constant.ClientError,
+			Msg:  "please provide namespace and release name",
+		}, http.StatusBadRequest
+	}
+	store := storage.Init(driver.NewSecrets(c.clientset.CoreV1().Secrets(namespace)))
+	var (
+		rel *release.Release
+		err error
+	)
+	if revision > 0 {
+		rel, err = store.Get(releaseName, revision)
+	} else {
+		rel, err = store.Deployed(releaseName)
+		if err != nil {
+			rel, err = store.Last(releaseName)
+		}
+	}
+	if err != nil {
+		if goErrors.Is(err, driver.ErrReleaseNotFound) || strings.Contains(err.Error(), "not found") {
+			return nil, &httputil.ResponseJson{
+				Code: constant.ResourceNotFound,
+				Msg:  fmt.Sprintf("release %s not found in namespace %s", releaseName, namespace),
+			}, http.StatusNotFound
+		}
+		c.log().Errorf("error getting release %s/%s, %v", namespace, releaseName, err)
+		return nil, httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
+	}
+	return rel, nil, http.StatusOK
+}
+
+// getReleasePVCs return persistent volume claims declared in the release manifest,
+// including claims created from StatefulSet volumeClaimTemplates
+func (c *helmClient) getReleasePVCs(rel *release.Release) ([]v1.PersistentVolumeClaim, error) {
+	claimNames := make(map[string]bool)
+	claimPrefixes := make([]string, 0)
+	for _, manifest := range releaseutil.SplitManifests(rel.Manifest) {
+		var object manifestObject
+		if err := yaml.Unmarshal([]byte(manifest), &object); err != nil {
+			c.log().Warnf("skip unparsable manifest in release %s, %v", rel.Name, err)
+			continue
+		}
+		switch object.Kind {
+		case kindPersistentVolumeClaim:
+			claimNames[object.Metadata.Name] = true
+		case kindStatefulSet:
+			for _, template := range object.Spec.VolumeClaimTemplates {
+				claimPrefixes = append(claimPrefixes,
+					fmt.Sprintf("%s-%s-", template.Metadata.Name, object.Metadata.Name))
+			}
+		default:
+		}
+	}
+	if len(claimNames) == 0 && len(claimPrefixes) == 0 {
+		return nil, nil
+	}
+
+	pvcList, err := c.clientset.CoreV1().PersistentVolumeClaims(rel.Namespace).List(c.requestContext(),
+		metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]v1.PersistentVolumeClaim, 0)
+	for _, pvc := range pvcList.Items {
+		if claimNames[pvc.Name] || isStatefulSetClaim(pvc.Name, claimPrefixes) {
+			result = append(result, pvc)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// isStatefulSetClaim StatefulSet claims are named <template>-<statefulset>-<ordinal>
+func isStatefulSetClaim(pvcName string, claimPrefixes []string) bool {
+	for _, prefix := range claimPrefixes {
+		if !strings.HasPrefix(pvcName, prefix) {
+			continue
+		}
+		if _, err := strconv.Atoi(strings.TrimPrefix(pvcName, prefix)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *helmClient) createPVCSnapshot(rel *release.Release, pvc *v1.PersistentVolumeClaim,
+	snapshotClassName string) (*snapshotv1.VolumeSnapshot, error) {
+	specRecord, err := json.Marshal(pvcSpecRecord{
+		Labels:           pvc.Labels,
+		AccessModes:      pvc.Spec.AccessModes,
+		StorageClassName: pvc.Spec.StorageClassName,
+		VolumeMode:       pvc.Spec.VolumeMode,
+		Resources:        pvc.Spec.Resources,
+	})
+	if err != nil {
+		return nil, err
+	}
+	pvcName := pvc.Name
+	snapshot := &snapshotv1.VolumeSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getSnapshotName(rel.Name, rel.Version, pvc.Name),
+			Namespace: rel.Namespace,
+			Labels: map[string]string{
+				snapshotLabelRelease:  rel.Name,
+				snapshotLabelRevision: strconv.Itoa(rel.Version),
+			},
+			Annotations: map[string]string{
+				snapshotAnnotationPVC:     pvc.Name,
+				snapshotAnnotationPVCSpec: string(specRecord),
+			},
+		},
+		Spec: snapshotv1.VolumeSnapshotSpec{
+			Source: snapshotv1.VolumeSnapshotSource{PersistentVolumeClaimName: &pvcName},
+		},
+	}
+	if snapshotClassName != "" {
+		snapshot.Spec.VolumeSnapshotClassName = &snapshotClassName
+	}
+
+	created, err := c.snapshotClient.SnapshotV1().VolumeSnapshots(rel.Namespace).Create(c.requestContext(),
+		snapshot, metav1.CreateOptions{})
+	if err != nil {
+		if errors.IsAlreadyExists(err) {
+			c.log().Infof("snapshot %s/%s already exists, reuse it", rel.Namespace, snapshot.Name)
+			return c.snapshotClient.SnapshotV1().VolumeSnapshots(rel.Namespace).Get(c.requestContext(),
+				snapshot.Name, metav1.GetOptions{})
+		}
+		return nil, err
+	}
+	c.log().Infof("snapshot %s/%s created for pvc %s", rel.Namespace, created.Name, pvc.Name)
+	return created, nil
+}
+
+// getSnapshotName names too long for a resource are truncated and suffixed with a hash of the full name, so
+// that claims sharing a long prefix do not share a snapshot
+func getSnapshotName(releaseName string, revision int, pvcName string) string {
+	name := fmt.Sprintf("%s-r%d-%s", releaseName, revision, pvcName)
+	if len(name) <= maxResourceNameLength {
+		return name
+	}
+	sum := sha256.Sum256([]byte(name))
+	suffix := hex.EncodeToString(sum[:])[:snapshotNameHashLength]
+	return strings.TrimRight(name[:maxResourceNameLength-len(suffix)-1], "-.") + "-" + suffix
+}
+
+func (c *helmClient) waitSnapshotsReady(namespace string, snapshots []*snapshotv1.VolumeSnapshot,
+	timeoutSeconds int) ([]*snapshotv1.VolumeSnapshot, error) {
+	if timeoutSeconds <= 0 {
+		timeoutSeconds = defaultSnapshotTimeoutSeconds
+	}
+	if timeoutSeconds > maxSnapshotTimeoutSeconds {
+		timeoutSeconds = maxSnapshotTimeoutSeconds
+	}
+	current := snapshots
+	err := wait.PollUntilContextTimeout(c.requestContext(), snapshotPollIntervalSeconds*time.Second,
+		time.Duration(timeoutSeconds)*time.Second, true, func(ctx context.Context) (bool, error) {
+			latest := make([]*snapshotv1.VolumeSnapshot, 0, len(snapshots))
+			allReady := true
+			for _, snapshot := range snapshots {
+				got, err := c.snapshotClient.SnapshotV1().VolumeSnapshots(namespace).Get(ctx, snapshot.Name,
+					metav1.GetOptions{})
+				if err != nil {
+					return false, err
+				}
+				if got.Status != nil && got.Status.Error != nil && got.Status.Error.Message != nil {
+					return false, fmt.Errorf("snapshot %s failed: %s", got.Name, *got.Status.Error.Message)
+				}
+				allReady = allReady && isSnapshotReady(got)
+				latest = append(latest, got)
+			}
+			current = latest
+			return allReady, nil
+		})
+	return current, err
+}
+
+func isSnapshotReady(snapshot *snapshotv1.VolumeSnapshot) bool {
+	return snapshot.Status != nil && snapshot.Status.ReadyToUse != nil && *snapshot.Status.ReadyToUse
+}
+
+func (c *helmClient) listReleaseSnapshots(namespace, releaseName string,
+	revision int) ([]snapshotv1.VolumeSnapshot, error) {
+	selector := labels.Set{snapshotLabelRelease: releaseName}
+	if revision > 0 {
+		selector[snapshotLabelRevision] = strconv.Itoa(revision)
+	}
+	snapshotList, err := c.snapshotClient.SnapshotV1().VolumeSnapshots(namespace).List(c.requestContext(),
+		metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	snapshots := snapshotList.Items
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Name < snapshots[j].Name
+	})
+	return snapshots, nil
+}
+
+func (c *helmClient) checkSnapshotsRestorable(namespace string, snapshots []snapshotv1.VolumeSnapshot,
+	force bool) (*httputil.ResponseJson, int) {
+	existing := make([]string, 0)
+	for i := range snapshots {
+		if !isSnapshotReady(&snapshots[i]) {
+			return &httputil.ResponseJson{
+				Code: constant.ClientError,
+				Msg:  fmt.Sprintf("snapshot %s is not ready to use", snapshots[i].Name),
+			}, http.StatusBadRequest
+		}
+		pvcName := snapshots[i].Annotations[snapshotAnnotationPVC]
+		if pvcName == "" {
+			return &httputil.ResponseJson{
+				Code: constant.ClientError,
+				Msg:  fmt.Sprintf("snapshot %s has no source pvc recorded", snapshots[i].Name),
+			}, http.StatusBadRequest
+		}
+		_, err := c.clientset.CoreV1().PersistentVolumeClaims(namespace).Get(c.requestContext(), pvcName,
+			metav1.GetOptions{})
+		if err == nil {
+			existing = append(existing, pvcName)
+		} else if !errors.IsNotFound(err) {
+			c.log().Errorf("error getting pvc %s/%s, %v", namespace, pvcName, err)
+			return httputil.GetDefaultServerFailureResponseJson(), http.StatusInternalServerError
+		}
+	}
+	if len(existing) > 0 && !force {
+		return &httputil.ResponseJson{
+			Code: constant.ClientError,
+			Msg: fmt.Sprintf("persistent volume claims %s still exist, scale down the workloads and "+
+				"restore with force", strings.Join(existing, ",")),
+		}, http.StatusBadRequest
+	}
+	return nil, http.StatusOK
+}
+
+// restoredPVC persistent volume claim recreating the claim recorded in snapshot from its data
+func restoredPVC(snapshot *snapshotv1.VolumeSnapshot) (*v1.PersistentVolumeClaim, error) {
+	var specRecord pvcSpecRecord
+	if err := json.Unmarshal([]byte(snapshot.Annotations[snapshotAnnotationPVCSpec]), &specRecord); err != nil {
+		return nil, fmt.Errorf("invalid pvc spec recorded in snapshot %s: %v", snapshot.Name, err)
+	}
+
+	apiGroup := snapshotAPIGroup
+	pvc := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      snapshot.Annotations[snapshotAnnotationPVC],
+			Namespace: snapshot.Namespace,
+			Labels:    specRecord.Labels,
+		},
+		Spec: v1.PersistentVolumeClaimSpec{
+			AccessModes:      specRecord.AccessModes,
+			StorageClassName: specRecord.StorageClassName,
+			VolumeMode:       specRecord.VolumeMode,
+			Resources:        specRecord.Resources,
+			DataSource: &v1.TypedLocalObjectReference{
+				APIGroup: &apiGroup,
+				Kind:     snapshotKind,
+				Name:     snapshot.Name,
+			},
+		},
+	}
+	if snapshot.Status != nil && snapshot.Status.RestoreSize != nil {
+		if pvc.Spec.Resources.Requests == nil {
+			pvc.Spec.Resources.Requests = v1.ResourceList{}
+		}
+		requested, ok := pvc.Spec.Resources.Requests[v1.ResourceStorage]
+		if !ok || requested.Cmp(*snapshot.Status.RestoreSize) < 0 {
+			pvc.Spec.Resources.Requests[v1.ResourceStorage] = *snapshot.Status.RestoreSize
+		}
+	}
+	return pvc, nil
+}
+
+// dryRunCreatePVC let the api server admit the restored claim, e.g. against quotas, before any claim is deleted.
+// A claim still existing on a forced restore frees its own quota when deleted, so it is not checked
+func (c *helmClient) dryRunCreatePVC(pvc *v1.PersistentVolumeClaim, force bool) error {
+	_, err := c.clientset.CoreV1().PersistentVolumeClaims(pvc.Namespace).Get(c.requestContext(), pvc.Name,
+		metav1.GetOptions{})
+	if err == nil && force {
+		return nil
+	}
+	_, err = c.clientset.CoreV1().PersistentVolumeClaims(pvc.Namespace).Create(c.requestContext(), pvc,
+		metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}})
+	return err
+}
+
+func (c *helmClient) deletePVCAndWait(namespace, pvcName string) error {
+	err := c.clientset.CoreV1().PersistentVolumeClaims(namespace).Delete(c.requestContext(), pvcName,
+		metav1.DeleteOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	return wait.PollUntilContextTimeout(c.requestContext(), snapshotPollIntervalSeconds*time.Second,
+		defaultSnapshotTimeoutSeconds*time.Second, true, func(ctx context.Context) (bool, error) {
+			_, err := c.clientset.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, pvcName, metav1.GetOptions{})
+			if errors.IsNotFound(err) {
+				return true, nil
+			}
+			return false, err
+		})
+}
+
+func convertToReleaseSnapshot(snapshot *snapshotv1.VolumeSnapshot) helm.ReleaseSnapshot {
+	revision, err := strconv.Atoi(snapshot.Labels[snapshotLabelRevision])
+	if err != nil {
+		zlog.Warnf("snapshot %s has invalid revision label", snapshot.Name)
+	}
+	result := helm.ReleaseSnapshot{
+		Name:       snapshot.Name,
+		PVCName:    snapshot.Annotations[snapshotAnnotationPVC],
+		Revision:   revision,
+		ReadyToUse: isSnapshotReady(snapshot),
+	}
+	if snapshot.Status == nil {
+		return result
+	}
+	result.CreationTime = snapshot.Status.CreationTime
+	if snapshot.Status.RestoreSize != nil {
+		result.RestoreSize = snapshot.Status.RestoreSize.String()
+	}
+	if snapshot.Status.Error != nil && snapshot.Status.Error.Message != nil {
+		result.Error = *snapshot.Status.Error.Message
+	}
+	return result
+}
@@ -0,0 +1,360 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package helm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	snapshotFake "github.com/kubernetes-csi/external-snapshotter/client/v4/clientset/versioned/fake"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	clientSetFake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"marketplace-service/pkg/constant"
+	"marketplace-service/pkg/models/helm"
+)
+
+const (
+	mockSnapshotNamespace = "default"
+	mockSnapshotRelease   = "mysql"
+	mockReleaseManifest   = `---
+# Source: mysql/templates/pvc.yaml
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: mysql-data
+spec:
+  accessModes: ["ReadWriteOnce"]
+---
+# Source: mysql/templates/statefulset.yaml
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: mysql
+spec:
+  volumeClaimTemplates:
+  - metadata:
+      name: log
+`
+)
+
+func mockPVC(name string) *v1.PersistentVolumeClaim {
+	return &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: mockSnapshotNamespace},
+		Spec: v1.PersistentVolumeClaimSpec{
+			AccessModes: []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+			Resources: v1.VolumeResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse("1Gi")},
+			},
+		},
+	}
+}
+
+func mockSnapshotClientset(t *testing.T) kubernetes.Interface {
+	clientset := clientSetFake.NewSimpleClientset(mockPVC("mysql-data"), mockPVC("log-mysql-0"),
+		mockPVC("log-mysql-1"), mockPVC("log-mysql-backup"), mockPVC("unrelated"))
+	store := storage.Init(driver.NewSecrets(clientset.CoreV1().Secrets(mockSnapshotNamespace)))
+	err := store.Create(&release.Release{
+		Name:      mockSnapshotRelease,
+		Namespace: mockSnapshotNamespace,
+		Version:   2,
+		Info:      &release.Info{Status: release.StatusDeployed},
+		Manifest:  mockReleaseManifest,
+	})
+	if err != nil {
+		t.Fatalf("create release failed, %v", err)
+	}
+	return clientset
+}
+
+func Test_helmClient_CreateReleaseSnapshots(t *testing.T) {
+	tests := []struct {
+		name      string
+		release   string
+		want      []string
+		wantCode  int32
+		wantState int
+	}{
+		{
+			name:      "Test_helmClient_CreateReleaseSnapshots_success",
+			release:   mockSnapshotRelease,
+			want:      []string{"mysql-r2-log-mysql-0", "mysql-r2-log-mysql-1", "mysql-r2-mysql-data"},
+			wantCode:  constant.FileCreated,
+			wantState: http.StatusCreated,
+		},
+		{
+			name:      "Test_helmClient_CreateReleaseSnapshots_release_not_found",
+			release:   "not-exist",
+			wantCode:  constant.ResourceNotFound,
+			wantState: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &helmClient{
+				clientset:      mockSnapshotClientset(t),
+				snapshotClient: snapshotFake.NewSimpleClientset(),
+			}
+			got, got1 := c.CreateReleaseSnapshots(mockSnapshotNamespace, tt.release,
+				&helm.SnapshotRequest{VolumeSnapshotClassName: "csi-snapclass"})
+			if got.Code != tt.wantCode || got1 != tt.wantState {
+				t.Fatalf("CreateReleaseSnapshots() got = %v, %v, want %v, %v", got.Code, got1, tt.wantCode,
+					tt.wantState)
+			}
+			if tt.want == nil {
+				return
+			}
+			snapshots := got.Data.(*helm.ReleaseSnapshotResponse).Snapshots
+			if len(snapshots) != len(tt.want) {
+				t.Fatalf("CreateReleaseSnapshots() got %d snapshots, want %d", len(snapshots), len(tt.want))
+			}
+			for i, snapshot := range snapshots {
+				if snapshot.Name != tt.want[i] || snapshot.Revision != 2 {
+					t.Errorf("CreateReleaseSnapshots() got snapshot %v, want %s", snapshot, tt.want[i])
+				}
+			}
+			// 再次创建时复用已有快照
+			_, got1 = c.CreateReleaseSnapshots(mockSnapshotNamespace, tt.release, &helm.SnapshotRequest{})
+			if got1 != http.StatusCreated {
+				t.Errorf("CreateReleaseSnapshots() repeated got %v, want %v", got1, http.StatusCreated)
+			}
+		})
+	}
+}
+
+func Test_helmClient_RestoreReleaseSnapshots(t *testing.T) {
+	tests := []struct {
+		name      string
+		ready     bool
+		force     bool
+		wantState int
+	}{
+		{
+			name:      "Test_helmClient_RestoreReleaseSnapshots_not_ready",
+			ready:     false,
+			force:     true,
+			wantState: http.StatusBadRequest,
+		},
+		{
+			name:      "Test_helmClient_RestoreReleaseSnapshots_pvc_exists",
+			ready:     true,
+			force:     false,
+			wantState: http.StatusBadRequest,
+		},
+		{
+			name:      "Test_helmClient_RestoreReleaseSnapshots_force",
+			ready:     true,
+			force:     true,
+			wantState: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &helmClient{
+				clientset:      mockSnapshotClientset(t),
+				snapshotClient: snapshotFake.NewSimpleClientset(),
+			}
+			_, status := c.CreateReleaseSnapshots(mockSnapshotNamespace, mockSnapshotRelease, &helm.SnapshotRequest{})
+			if status != http.StatusCreated {
+				t.Fatalf("CreateReleaseSnapshots() got %v", status)
+			}
+			markSnapshotsReady(t, c, tt.ready)
+
+			got, got1 := c.RestoreReleaseSnapshots(mockSnapshotNamespace, mockSnapshotRelease, 2,
+				&helm.SnapshotRestoreRequest{Force: tt.force})
+			if got1 != tt.wantState {
+				t.Fatalf("RestoreReleaseSnapshots() got = %v, %v, want %v", got.Msg, got1, tt.wantState)
+			}
+			if got1 != http.StatusOK {
+				return
+			}
+			pvc, err := c.clientset.CoreV1().PersistentVolumeClaims(mockSnapshotNamespace).Get(context.Background(),
+				"mysql-data", metav1.GetOptions{})
+			if err != nil || pvc.Spec.DataSource == nil || pvc.Spec.DataSource.Name != "mysql-r2-mysql-data" {
+				t.Errorf("RestoreReleaseSnapshots() pvc not restored from snapshot, %v", err)
+			}
+		})
+	}
+}
+
+// Test_helmClient_RestoreReleaseSnapshots_keepsPVCs 校验失败时不删除任何pvc
+func Test_helmClient_RestoreReleaseSnapshots_keepsPVCs(t *testing.T) {
+	c := &helmClient{
+		clientset:      mockSnapshotClientset(t),
+		snapshotClient: snapshotFake.NewSimpleClientset(),
+	}
+	_, status := c.CreateReleaseSnapshots(mockSnapshotNamespace, mockSnapshotRelease, &helm.SnapshotRequest{})
+	if status != http.StatusCreated {
+		t.Fatalf("CreateReleaseSnapshots() got %v", status)
+	}
+	markSnapshotsReady(t, c, true)
+	snapshot, err := c.snapshotClient.SnapshotV1().VolumeSnapshots(mockSnapshotNamespace).Get(context.Background(),
+		"mysql-r2-mysql-data", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get snapshot failed, %v", err)
+	}
+	snapshot.Annotations[snapshotAnnotationPVCSpec] = "{"
+	_, err = c.snapshotClient.SnapshotV1().VolumeSnapshots(mockSnapshotNamespace).Update(context.Background(),
+		snapshot, metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatalf("update snapshot failed, %v", err)
+	}
+
+	_, status = c.RestoreReleaseSnapshots(mockSnapshotNamespace, mockSnapshotRelease, 2,
+		&helm.SnapshotRestoreRequest{Force: true})
+	if status != http.StatusBadRequest {
+		t.Fatalf("RestoreReleaseSnapshots() got %v, want %v", status, http.StatusBadRequest)
+	}
+	pvcs, err := c.clientset.CoreV1().PersistentVolumeClaims(mockSnapshotNamespace).List(context.Background(),
+		metav1.ListOptions{})
+	if err != nil || len(pvcs.Items) != 5 {
+		t.Fatalf("RestoreReleaseSnapshots() deleted pvcs, %v", err)
+	}
+	for _, pvc := range pvcs.Items {
+		if pvc.Spec.DataSource != nil {
+			t.Errorf("RestoreReleaseSnapshots() restored pvc %s", pvc.Name)
+		}
+	}
+}
+
+// Test_helmClient_RestoreReleaseSnapshots_partial 强制恢复部分失败时已删除的pvc仍被恢复，并列出缺失的pvc
+func Test_helmClient_RestoreReleaseSnapshots_partial(t *testing.T) {
+	tests := []struct {
+		name         string
+		verb         string
+		failing      string
+		wantRestored []string
+		wantMissing  []string
+	}{
+		{
+			name:         "Test_helmClient_RestoreReleaseSnapshots_delete_fails",
+			verb:         "delete",
+			failing:      "log-mysql-1",
+			wantRestored: []string{"log-mysql-0"},
+			wantMissing:  []string{},
+		},
+		{
+			name:         "Test_helmClient_RestoreReleaseSnapshots_create_fails",
+			verb:         "create",
+			failing:      "log-mysql-1",
+			wantRestored: []string{"log-mysql-0", "mysql-data"},
+			wantMissing:  []string{"log-mysql-1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientset := mockSnapshotClientset(t).(*clientSetFake.Clientset)
+			c := &helmClient{clientset: clientset, snapshotClient: snapshotFake.NewSimpleClientset()}
+			_, status := c.CreateReleaseSnapshots(mockSnapshotNamespace, mockSnapshotRelease, &helm.SnapshotRequest{})
+			if status != http.StatusCreated {
+				t.Fatalf("CreateReleaseSnapshots() got %v", status)
+			}
+			markSnapshotsReady(t, c, true)
+			clientset.PrependReactor(tt.verb, "persistentvolumeclaims",
+				func(action k8stesting.Action) (bool, runtime.Object, error) {
+					var name string
+					switch action := action.(type) {
+					case k8stesting.DeleteAction:
+						name = action.GetName()
+					case k8stesting.CreateAction:
+						name = action.GetObject().(*v1.PersistentVolumeClaim).Name
+					}
+					return name == tt.failing, nil, fmt.Errorf("%s %s failed", tt.verb, name)
+				})
+
+			got, status := c.RestoreReleaseSnapshots(mockSnapshotNamespace, mockSnapshotRelease, 2,
+				&helm.SnapshotRestoreRequest{Force: true})
+			if status != http.StatusInternalServerError {
+				t.Fatalf("RestoreReleaseSnapshots() got %v, want %v", status, http.StatusInternalServerError)
+			}
+			result := got.Data.(*helm.SnapshotRestoreResponse)
+			if !reflect.DeepEqual(result.Restored, tt.wantRestored) || !reflect.DeepEqual(result.Missing,
+				tt.wantMissing) {
+				t.Errorf("RestoreReleaseSnapshots() got %v, want restored %v, missing %v", result,
+					tt.wantRestored, tt.wantMissing)
+			}
+			for _, name := range []string{"mysql-data", "log-mysql-0", "log-mysql-1"} {
+				_, err := clientset.CoreV1().PersistentVolumeClaims(mockSnapshotNamespace).Get(context.Background(),
+					name, metav1.GetOptions{})
+				if slices.Contains(tt.wantMissing, name) != errors.IsNotFound(err) {
+					t.Errorf("RestoreReleaseSnapshots() pvc %s, %v", name, err)
+				}
+			}
+		})
+	}
+}
+
+func Test_getSnapshotName(t *testing.T) {
+	long := strings.Repeat("data", 70)
+	first := getSnapshotName(mockSnapshotRelease, 2, long+"-0")
+	second := getSnapshotName(mockSnapshotRelease, 2, long+"-1")
+	if len(first) > maxResourceNameLength || len(second) > maxResourceNameLength || first == second {
+		t.Errorf("getSnapshotName() = %s, %s", first, second)
+	}
+	if got := getSnapshotName(mockSnapshotRelease, 2, "mysql-data"); got != "mysql-r2-mysql-data" {
+		t.Errorf("getSnapshotName() = %s", got)
+	}
+}
+
+func markSnapshotsReady(t *testing.T, c *helmClient, ready bool) {
+	snapshotList, err := c.snapshotClient.SnapshotV1().VolumeSnapshots(mockSnapshotNamespace).List(
+		context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("list snapshots failed, %v", err)
+	}
+	restoreSize := resource.MustParse("2Gi")
+	for i := range snapshotList.Items {
+		snapshot := &snapshotList.Items[i]
+		snapshot.Status = &snapshotv1.VolumeSnapshotStatus{ReadyToUse: &ready, RestoreSize: &restoreSize}
+		_, err = c.snapshotClient.SnapshotV1().VolumeSnapshots(mockSnapshotNamespace).Update(context.Background(),
+			snapshot, metav1.UpdateOptions{})
+		if err != nil {
+			t.Fatalf("update snapshot failed, %v", err)
+		}
+	}
+}
+
+func Test_isStatefulSetClaim(t *testing.T) {
+	prefixes := []string{"log-mysql-"}
+	tests := []struct {
+		pvcName string
+		want    bool
+	}{
+		{pvcName: "log-mysql-0", want: true},
+		{pvcName: "log-mysql-12", want: true},
+		{pvcName: "log-mysql-backup", want: false},
+		{pvcName: "data-mysql-0", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pvcName, func(t *testing.T) {
+			if got := isStatefulSetClaim(tt.pvcName, prefixes); got != tt.want {
+				t.Errorf("isStatefulSetClaim() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package helm
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"sync"
+
+	"helm.sh/helm/v3/pkg/repo"
+
+	"marketplace-service/pkg/models/helm"
+	"marketplace-service/pkg/zlog"
+)
+
+const (
+	// catalogHistorySize number of recent events kept for watchers resuming from a resource version
+	catalogHistorySize = 1024
+
+	// epochShift resource versions carry the epoch of the process in their high bits, so versions of a previous
+	// run or of another replica are never mistaken for versions of this one
+	epochShift = 32
+)
+
+// ErrResourceVersionExpired the resource version is older than the kept history or from a previous run
+var ErrResourceVersionExpired = errors.New("resource version is too old or unknown, please list the catalog again")
+
+var catalogWatcher = newCatalogBroadcaster(catalogHistorySize)
+
+// CatalogSubscription receives catalog events of the watched repositories
+type CatalogSubscription struct {
+	// resourceVersion latest resource version when subscribed
+	resourceVersion uint64
+	events          chan helm.CatalogEvent
+	repos           map[string]struct{}
+	broadcaster     *catalogBroadcaster
+}
+
+// ResourceVersion latest resource version when subscribed, events are newer than it unless replayed
+func (s *CatalogSubscription) ResourceVersion() uint64 {
+	return s.resourceVersion
+}
+
+// Events channel of catalog events, closed when the subscription is stopped or falls behind
+func (s *CatalogSubscription) Events() <-chan helm.CatalogEvent {
+	return s.events
+}
+
+// Stop unsubscribe from the catalog events
+func (s *CatalogSubscription) Stop() {
+	s.broadcaster.unsubscribe(s)
+}
+
+func (s *CatalogSubscription) matches(event *helm.CatalogEvent) bool {
+	if len(s.repos) == 0 {
+		return true
+	}
+	_, ok := s.repos[event.Repo]
+	return ok
+}
+
+// catalogBroadcaster numbers catalog events and fans them out to subscriptions
+type catalogBroadcaster struct {
+	sync.Mutex
+	// epoch random per broadcaster, the high bits of its resource versions
+	epoch           uint64
+	resourceVersion uint64
+	historySize     int
+	history         []helm.CatalogEvent
+	subscriptions   map[*CatalogSubscription]struct{}
+}
+
+func newCatalogBroadcaster(historySize int) *catalogBroadcaster {
+	epoch := uint64(rand.Uint32N(math.MaxUint32)) + 1
+	return &catalogBroadcaster{
+		epoch:           epoch,
+		resourceVersion: epoch << epochShift,
+		historySize:     historySize,
+		history:         make([]helm.CatalogEvent, 0, historySize),
+		subscriptions:   make(map[*CatalogSubscription]struct{}),
+	}
+}
+
+// WatchCatalog subscribe to catalog events of the repositories, all repositories if empty.
+// Events after resourceVersion are replayed, 0 only receives new events
+func WatchCatalog(resourceVersion uint64, repos []string) (*CatalogSubscription, error) {
+	return catalogWatcher.subscribe(resourceVersion, repos)
+}
+
+func (b *catalogBroadcaster) subscribe(resourceVersion uint64, repos []string) (*CatalogSubscription, error) {
+	b.Lock()
+	defer b.Unlock()
+	if resourceVersion > 0 && resourceVersion>>epochShift != b.epoch {
+		return nil, ErrResourceVersionExpired
+	}
+	if resourceVersion > b.resourceVersion {
+		return nil, ErrResourceVersionExpired
+	}
+	if resourceVersion > 0 && len(b.history) > 0 && resourceVersion+1 < b.history[0].ResourceVersion {
+		return nil, ErrResourceVersionExpired
+	}
+	subscription := &CatalogSubscription{
+		resourceVersion: b.resourceVersion,
+		events:          make(chan helm.CatalogEvent, b.historySize),
+		repos:           make(map[string]struct{}, len(repos)),
+		broadcaster:     b,
+	}
+	for _, repoName := range repos {
+		subscription.repos[repoName] = struct{}{}
+	}
+	if resourceVersion > 0 {
+		for i := range b.history {
+			if b.history[i].ResourceVersion > resourceVersion && subscription.matches(&b.history[i]) {
+				subscription.events <- b.history[i]
+			}
+		}
+	}
+	b.subscriptions[subscription] = struct{}{}
+	return subscription, nil
+}
+
+func (b *catalogBroadcaster) unsubscribe(subscription *CatalogSubscription) {
+	b.Lock()
+	defer b.Unlock()
+	if _, ok := b.subscriptions[subscription]; ok {
+		delete(b.subscriptions, subscription)
+		close(subscription.events)
+	}
+}
+
+// publish number the events and deliver them, subscriptions that can not keep up are closed
+func (b *catalogBroadcaster) publish(events ...helm.CatalogEvent) {
+	if len(events) == 0 {
+		return
+	}
+	b.Lock()
+	defer b.Unlock()
+	for _, event := range events {
+		b.resourceVersion++
+		event.ResourceVersion = b.resourceVersion
+		if len(b.history) == b.historySize {
+			b.history = append(b.history[:0], b.history[1:]...)
+		}
+		b.history = append(b.history, event)
+		for subscription := range b.subscriptions {
+			if !subscription.matches(&event) {
+				continue
+			}
+			select {
+			case subscription.events <- event:
+			default:
+				zlog.Warnf("catalog watcher falls behind at resource version %d, closed", event.ResourceVersion)
+				delete(b.subscriptions, subscription)
+				close(subscription.events)
+			}
+		}
+	}
+}
+
+func publishRepoEvent(eventType, repoName string) {
+	catalogWatcher.publish(helm.CatalogEvent{Type: eventType, Repo: repoName})
+}
+
+// chartCacheEvents events of replacing the cached chart entries of a repository. The first load of a repository is
+// a single RepoCreated event, watchers list its charts instead of receiving an event per chart version
+func chartCacheEvents(repoName string, oldEntries map[string]repo.ChartVersions, loaded bool,
+	newEntries map[string]repo.ChartVersions) []helm.CatalogEvent {
+	if !loaded {
+		return []helm.CatalogEvent{{Type: helm.CatalogEventRepoCreated, Repo: repoName}}
+	}
+	return diffChartEntries(repoName, oldEntries, newEntries)
+}
+
+// diffChartEntries events turning the old chart entries of a repository into the new ones
+func diffChartEntries(repoName string, oldEntries, newEntries map[string]repo.ChartVersions) []helm.CatalogEvent {
+	oldVersions := indexChartVersions(oldEntries)
+	newVersions := indexChartVersions(newEntries)
+	events := make([]helm.CatalogEvent, 0)
+	for key, newVersion := range newVersions {
+		oldVersion, exist := oldVersions[key]
+		switch {
+		case !exist:
+			events = append(events, newChartEvent(helm.CatalogEventChartAdded, repoName, key, newVersion))
+		case chartVersionChanged(oldVersion, newVersion):
+			events = append(events, newChartEvent(helm.CatalogEventChartChanged, repoName, key, newVersion))
+		default:
+		}
+	}
+	for key := range oldVersions {
+		if _, exist := newVersions[key]; !exist {
+			events = append(events, newChartEvent(helm.CatalogEventChartRemoved, repoName, key, nil))
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Chart != events[j].Chart {
+			return events[i].Chart < events[j].Chart
+		}
+		return events[i].Version < events[j].Version
+	})
+	return events
+}
+
+type chartVersionKey struct {
+	chart   string
+	version string
+}
+
+func indexChartVersions(entries map[string]repo.ChartVersions) map[chartVersionKey]*repo.ChartVersion {
+	versions := make(map[chartVersionKey]*repo.ChartVersion)
+	for chartName, chartVersions := range entries {
+		for _, chartVersion := range chartVersions {
+			if chartVersion == nil || chartVersion.Metadata == nil {
+				continue
+			}
+			versions[chartVersionKey{chart: chartName, version: chartVersion.Version}] = chartVersion
+		}
+	}
+	return versions
+}
+
+func chartVersionChanged(oldVersion, newVersion *repo.ChartVersion) bool {
+	if oldVersion.Digest != "" || newVersion.Digest != "" {
+		return oldVersion.Digest != newVersion.Digest
+	}
+	return !oldVersion.Created.Equal(newVersion.Created)
+}
+
+func newChartEvent(eventType, repoName string, key chartVersionKey,
+	chartVersion *repo.ChartVersion) helm.CatalogEvent {
+	return helm.CatalogEvent{
+		Type:         eventType,
+		Repo:         repoName,
+		Chart:        key.chart,
+		Version:      key.version,
+		ChartVersion: chartVersion,
+	}
+}
@@ -0,0 +1,130 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package helm
+
+import (
+	"errors"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+
+	"marketplace-service/pkg/models/helm"
+)
+
+func mockChartVersions(digests map[string]string) repo.ChartVersions {
+	chartVersions := make(repo.ChartVersions, 0, len(digests))
+	for version, digest := range digests {
+		chartVersions = append(chartVersions, &repo.ChartVersion{
+			Metadata: &chart.Metadata{Name: "nginx", Version: version},
+			Digest:   digest,
+		})
+	}
+	return chartVersions
+}
+
+func Test_diffChartEntries(t *testing.T) {
+	oldEntries := map[string]repo.ChartVersions{
+		"nginx": mockChartVersions(map[string]string{"1.0.0": "a", "1.1.0": "b"}),
+	}
+	newEntries := map[string]repo.ChartVersions{
+		"nginx": mockChartVersions(map[string]string{"1.1.0": "c", "1.2.0": "d"}),
+	}
+	got := diffChartEntries("local", oldEntries, newEntries)
+	want := []struct {
+		eventType string
+		version   string
+	}{
+		{helm.CatalogEventChartRemoved, "1.0.0"},
+		{helm.CatalogEventChartChanged, "1.1.0"},
+		{helm.CatalogEventChartAdded, "1.2.0"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("diffChartEntries() got %d events, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Type != want[i].eventType || got[i].Version != want[i].version || got[i].Repo != "local" {
+			t.Errorf("diffChartEntries() event %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if len(diffChartEntries("local", newEntries, newEntries)) != 0 {
+		t.Errorf("diffChartEntries() of same entries should be empty")
+	}
+}
+
+// version resource version of the nth event of the broadcaster
+func (b *catalogBroadcaster) version(n uint64) uint64 {
+	return b.epoch<<epochShift | n
+}
+
+func Test_chartCacheEvents(t *testing.T) {
+	entries := map[string]repo.ChartVersions{
+		"nginx": mockChartVersions(map[string]string{"1.0.0": "a", "1.1.0": "b"}),
+	}
+	got := chartCacheEvents("local", nil, false, entries)
+	if len(got) != 1 || got[0].Type != helm.CatalogEventRepoCreated || got[0].Repo != "local" {
+		t.Errorf("chartCacheEvents() of first load = %+v, want one RepoCreated event", got)
+	}
+	if got = chartCacheEvents("local", nil, true, entries); len(got) != 2 {
+		t.Errorf("chartCacheEvents() of loaded repository got %d events, want 2", len(got))
+	}
+}
+
+func Test_catalogBroadcaster(t *testing.T) {
+	broadcaster := newCatalogBroadcaster(2)
+	broadcaster.publish(helm.CatalogEvent{Type: helm.CatalogEventRepoCreated, Repo: "b"},
+		helm.CatalogEvent{Type: helm.CatalogEventRepoCreated, Repo: "a"},
+		helm.CatalogEvent{Type: helm.CatalogEventRepoCreated, Repo: "b"},
+		helm.CatalogEvent{Type: helm.CatalogEventRepoCreated, Repo: "a"})
+
+	if _, err := broadcaster.subscribe(broadcaster.version(1), nil); !errors.Is(err, ErrResourceVersionExpired) {
+		t.Errorf("subscribe() from evicted history error = %v", err)
+	}
+	if _, err := broadcaster.subscribe(broadcaster.version(5), nil); !errors.Is(err, ErrResourceVersionExpired) {
+		t.Errorf("subscribe() from future resource version error = %v", err)
+	}
+	restarted := newCatalogBroadcaster(2)
+	restarted.epoch = broadcaster.epoch + 1
+	if _, err := restarted.subscribe(broadcaster.version(3), nil); !errors.Is(err, ErrResourceVersionExpired) {
+		t.Errorf("subscribe() from resource version of another epoch error = %v", err)
+	}
+
+	replay, err := broadcaster.subscribe(broadcaster.version(3), []string{"a"})
+	if err != nil {
+		t.Fatalf("subscribe() error = %v", err)
+	}
+	if event := <-replay.Events(); event.ResourceVersion != broadcaster.version(4) || event.Repo != "a" {
+		t.Errorf("subscribe() replayed event = %+v", event)
+	}
+	broadcaster.publish(helm.CatalogEvent{Type: helm.CatalogEventRepoDeleted, Repo: "b"},
+		helm.CatalogEvent{Type: helm.CatalogEventRepoDeleted, Repo: "a"})
+	if event := <-replay.Events(); event.ResourceVersion != broadcaster.version(6) ||
+		event.Type != helm.CatalogEventRepoDeleted {
+		t.Errorf("filtered event = %+v", event)
+	}
+
+	slow, err := broadcaster.subscribe(0, nil)
+	if err != nil {
+		t.Fatalf("subscribe() error = %v", err)
+	}
+	broadcaster.publish(helm.CatalogEvent{Repo: "a"}, helm.CatalogEvent{Repo: "a"}, helm.CatalogEvent{Repo: "a"})
+	received := 0
+	for range slow.Events() {
+		received++
+	}
+	if received != 2 {
+		t.Errorf("slow subscription received %d events before closed, want 2", received)
+	}
+	replay.Stop()
+	replay.Stop()
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor record handled unary rpcs
+func UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	observeRPC(info.FullMethod, start, err)
+	return resp, err
+}
+
+// StreamServerInterceptor record server streams, their messages and how long they stay open
+func StreamServerInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+	start := time.Now()
+	grpcStreamsActive.WithLabelValues(info.FullMethod).Inc()
+	defer grpcStreamsActive.WithLabelValues(info.FullMethod).Dec()
+	err := handler(srv, &monitoredStream{ServerStream: stream, method: info.FullMethod})
+	observeRPC(info.FullMethod, start, err)
+	return err
+}
+
+func observeRPC(method string, start time.Time, err error) {
+	grpcHandledTotal.WithLabelValues(method, status.Code(err).String()).Inc()
+	grpcHandlingDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+type monitoredStream struct {
+	grpc.ServerStream
+	method string
+}
+
+func (s *monitoredStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		grpcStreamMsgSent.WithLabelValues(s.method).Inc()
+	}
+	return err
+}
+
+func (s *monitoredStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		grpcStreamMsgReceived.WithLabelValues(s.method).Inc()
+	}
+	return err
+}
@@ -0,0 +1,236 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+/*
+Package metrics 定义了marketplace-service暴露给prometheus的指标
+*/
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace = "marketplace"
+
+	// ResultSuccess and ResultFailure values of the result label
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+
+	// CircuitClosed, CircuitHalfOpen and CircuitOpen values of the repository circuit state
+	CircuitClosed   = 0
+	CircuitHalfOpen = 1
+	CircuitOpen     = 2
+
+	// Path http path the metrics are served on
+	Path = "/metrics"
+)
+
+// Registry registry of all marketplace-service metrics, besides go runtime and process metrics
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Total number of HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "code"})
+
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by method, route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "code"})
+
+	grpcHandledTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "handled_total",
+		Help:      "Total number of RPCs completed by method and status code.",
+	}, []string{"method", "code"})
+
+	grpcHandlingDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "handling_duration_seconds",
+		Help:      "RPC latency by method, streams are measured until they end.",
+		Buckets:   []float64{0.005, 0.05, 0.25, 1, 5, 30, 120, 600, 3600},
+	}, []string{"method"})
+
+	grpcStreamsActive = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "streams_active",
+		Help:      "Number of server streams currently open by method.",
+	}, []string{"method"})
+
+	grpcStreamMsgSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "stream_msg_sent_total",
+		Help:      "Total number of stream messages sent by method.",
+	}, []string{"method"})
+
+	grpcStreamMsgReceived = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "stream_msg_received_total",
+		Help:      "Total number of stream messages received by method.",
+	}, []string{"method"})
+
+	repoSyncDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "repo",
+		Name:      "sync_duration_seconds",
+		Help:      "Duration of repository index synchronization.",
+		Buckets:   []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+	}, []string{"repo"})
+
+	repoSyncTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "repo",
+		Name:      "sync_total",
+		Help:      "Total number of repository synchronizations by result.",
+	}, []string{"repo", "result"})
+
+	repoLastSyncSuccess = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "repo",
+		Name:      "last_sync_success_timestamp_seconds",
+		Help:      "Unix time of the last successful repository synchronization.",
+	}, []string{"repo"})
+
+	cacheCharts = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "charts",
+		Help:      "Number of charts cached by repository.",
+	}, []string{"repo"})
+
+	cacheChartVersions = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "chart_versions",
+		Help:      "Number of chart versions cached by repository.",
+	}, []string{"repo"})
+
+	remoteFetchBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "remote",
+		Name:      "fetch_bytes_total",
+		Help:      "Total number of bytes fetched from remote repositories.",
+	}, []string{"repo"})
+
+	repoCircuitState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "repo",
+		Name:      "circuit_state",
+		Help:      "Circuit breaker state of the repository, 0 closed, 1 half-open, 2 open.",
+	}, []string{"repo"})
+
+	repoTokenRefreshTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "repo",
+		Name:      "token_refresh_total",
+		Help:      "Total number of repository bearer and oauth2 token refreshes by result.",
+	}, []string{"repo", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequestsTotal,
+		httpRequestDuration,
+		grpcHandledTotal,
+		grpcHandlingDuration,
+		grpcStreamsActive,
+		grpcStreamMsgSent,
+		grpcStreamMsgReceived,
+		repoSyncDuration,
+		repoSyncTotal,
+		repoLastSyncSuccess,
+		cacheCharts,
+		cacheChartVersions,
+		remoteFetchBytes,
+		repoCircuitState,
+		repoTokenRefreshTotal,
+	)
+}
+
+// Handler serves the registry in prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// ObserveHTTPRequest record a served http request, route is the path template of the selected route
+func ObserveHTTPRequest(method, route, code string, duration time.Duration) {
+	httpRequestsTotal.WithLabelValues(method, route, code).Inc()
+	httpRequestDuration.WithLabelValues(method, route, code).Observe(duration.Seconds())
+}
+
+// ObserveRepoSync record a repository synchronization
+func ObserveRepoSync(repo string, duration time.Duration, err error) {
+	repoSyncDuration.WithLabelValues(repo).Observe(duration.Seconds())
+	if err != nil {
+		repoSyncTotal.WithLabelValues(repo, ResultFailure).Inc()
+		return
+	}
+	repoSyncTotal.WithLabelValues(repo, ResultSuccess).Inc()
+	repoLastSyncSuccess.WithLabelValues(repo).SetToCurrentTime()
+}
+
+// SetCacheSize record the cached charts and chart versions of the repository
+func SetCacheSize(repo string, charts, versions int) {
+	cacheCharts.WithLabelValues(repo).Set(float64(charts))
+	cacheChartVersions.WithLabelValues(repo).Set(float64(versions))
+}
+
+// AddRemoteFetchBytes record bytes fetched from the repository
+func AddRemoteFetchBytes(repo string, size int64) {
+	remoteFetchBytes.WithLabelValues(repo).Add(float64(size))
+}
+
+// SetRepoCircuitState record the circuit breaker state of the repository
+func SetRepoCircuitState(repo string, state int) {
+	repoCircuitState.WithLabelValues(repo).Set(float64(state))
+}
+
+// ObserveTokenRefresh record a bearer or oauth2 token refresh of the repository
+func ObserveTokenRefresh(repo string, err error) {
+	if err != nil {
+		repoTokenRefreshTotal.WithLabelValues(repo, ResultFailure).Inc()
+		return
+	}
+	repoTokenRefreshTotal.WithLabelValues(repo, ResultSuccess).Inc()
+}
+
+// DeleteRepo drop all series of a deleted repository
+func DeleteRepo(repo string) {
+	labels := prometheus.Labels{"repo": repo}
+	repoSyncDuration.DeletePartialMatch(labels)
+	repoSyncTotal.DeletePartialMatch(labels)
+	repoLastSyncSuccess.DeletePartialMatch(labels)
+	cacheCharts.DeletePartialMatch(labels)
+	cacheChartVersions.DeletePartialMatch(labels)
+	remoteFetchBytes.DeletePartialMatch(labels)
+	repoCircuitState.DeletePartialMatch(labels)
+	repoTokenRefreshTotal.DeletePartialMatch(labels)
+}
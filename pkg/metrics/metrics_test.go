@@ -0,0 +1,117 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestObserveRepoSync(t *testing.T) {
+	ObserveRepoSync("sync-test", time.Second, nil)
+	ObserveRepoSync("sync-test", time.Second, errors.New("unreachable"))
+	ObserveRepoSync("sync-test", time.Second, errors.New("unreachable"))
+
+	if got := testutil.ToFloat64(repoSyncTotal.WithLabelValues("sync-test", ResultSuccess)); got != 1 {
+		t.Errorf("sync success count = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(repoSyncTotal.WithLabelValues("sync-test", ResultFailure)); got != 2 {
+		t.Errorf("sync failure count = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(repoLastSyncSuccess.WithLabelValues("sync-test")); got <= 0 {
+		t.Errorf("last sync success = %v, want current time", got)
+	}
+
+	SetCacheSize("sync-test", 2, 5)
+	AddRemoteFetchBytes("sync-test", 1024)
+	DeleteRepo("sync-test")
+	if got := testutil.CollectAndCount(cacheChartVersions); got != 0 {
+		t.Errorf("cache series after repo deleted = %d, want 0", got)
+	}
+	if got := testutil.CollectAndCount(repoSyncTotal); got != 0 {
+		t.Errorf("sync series after repo deleted = %d, want 0", got)
+	}
+}
+
+type fakeServerStream struct {
+	grpc.ServerStream
+}
+
+func (s *fakeServerStream) SendMsg(m interface{}) error {
+	return nil
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	const method = "/test.Service/Stream"
+	info := &grpc.StreamServerInfo{FullMethod: method, IsServerStream: true}
+	err := StreamServerInterceptor(nil, &fakeServerStream{}, info, func(srv interface{}, stream grpc.ServerStream) error {
+		if got := testutil.ToFloat64(grpcStreamsActive.WithLabelValues(method)); got != 1 {
+			t.Errorf("active streams = %v, want 1", got)
+		}
+		for i := 0; i < 3; i++ {
+			if err := stream.SendMsg(i); err != nil {
+				return err
+			}
+		}
+		return status.Error(codes.NotFound, "chart not found")
+	})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("StreamServerInterceptor() error = %v, want the handler error", err)
+	}
+	if got := testutil.ToFloat64(grpcStreamsActive.WithLabelValues(method)); got != 0 {
+		t.Errorf("active streams = %v, want 0", got)
+	}
+	if got := testutil.ToFloat64(grpcStreamMsgSent.WithLabelValues(method)); got != 3 {
+		t.Errorf("sent messages = %v, want 3", got)
+	}
+	if got := testutil.ToFloat64(grpcHandledTotal.WithLabelValues(method, codes.NotFound.String())); got != 1 {
+		t.Errorf("handled = %v, want 1", got)
+	}
+
+	_, err = UnaryServerInterceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, nil
+		})
+	if err != nil || testutil.ToFloat64(grpcHandledTotal.WithLabelValues(method, codes.OK.String())) != 1 {
+		t.Errorf("UnaryServerInterceptor() not recorded as handled, err = %v", err)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	ObserveHTTPRequest("GET", "/helm-repos/{repo}", "200", time.Millisecond)
+	ObserveTokenRefresh("openfuyao", nil)
+
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest("GET", Path, nil))
+	body, _ := io.ReadAll(recorder.Body)
+	for _, want := range []string{
+		`marketplace_http_requests_total{code="200",method="GET",route="/helm-repos/{repo}"} 1`,
+		`marketplace_http_request_duration_seconds_bucket{code="200",method="GET",route="/helm-repos/{repo}"`,
+		`marketplace_repo_token_refresh_total{repo="openfuyao",result="success"} 1`,
+		"go_goroutines",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("Handler() output misses %s", want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package helm
+
+// check results, ordered by severity
+const (
+	CheckResultPass = "pass"
+	CheckResultWarn = "warn"
+	CheckResultFail = "fail"
+)
+
+// check types of compatibility report
+const (
+	CheckTypeKubeVersion = "kubeVersion"
+	CheckTypeRender      = "render"
+	CheckTypeAPIVersion  = "apiVersion"
+)
+
+// CompatibilityReport restful response for checking a chart version against the cluster
+type CompatibilityReport struct {
+	Chart   string `json:"chart"`
+	Version string `json:"version"`
+	// KubeVersion version of the cluster
+	KubeVersion string `json:"kubeVersion"`
+	// Result worst result of all checks
+	Result string               `json:"result"`
+	Checks []CompatibilityCheck `json:"checks"`
+}
+
+// CompatibilityCheck a single check of the compatibility report
+type CompatibilityCheck struct {
+	Type       string `json:"type"`
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+	// Sources template files using the apiVersion and kind
+	Sources []string `json:"sources,omitempty"`
+	Result  string   `json:"result"`
+	Reason  string   `json:"reason"`
+}
+
+// deprecation status of an api version at target kubernetes version
+const (
+	DeprecationStatusDeprecated = "deprecated"
+	DeprecationStatusRemoved    = "removed"
+)
+
+// DeprecationReport restful response for deprecated api versions used by a chart version
+type DeprecationReport struct {
+	Chart   string `json:"chart"`
+	Version string `json:"version"`
+	// TargetVersion kubernetes version checked against, all known deprecations if empty
+	TargetVersion string               `json:"targetVersion,omitempty"`
+	Findings      []DeprecationFinding `json:"findings"`
+}
+
+// DeprecationFinding a manifest using a deprecated or removed api version
+type DeprecationFinding struct {
+	Source       string `json:"source"`
+	Name         string `json:"name,omitempty"`
+	APIVersion   string `json:"apiVersion"`
+	Kind         string `json:"kind"`
+	Status       string `json:"status"`
+	DeprecatedIn string `json:"deprecatedIn"`
+	RemovedIn    string `json:"removedIn,omitempty"`
+	Replacement  string `json:"replacement,omitempty"`
+}
+
+// DeprecationBadge summary of deprecated api versions used by a chart version, the earliest kubernetes
+// versions the chart is affected at, empty if the chart uses no deprecated api versions
+type DeprecationBadge struct {
+	DeprecatedIn string `json:"deprecatedIn,omitempty"`
+	RemovedIn    string `json:"removedIn,omitempty"`
+}
@@ -0,0 +1,55 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package helm
+
+// CRDPreflightResponse restful response for checking chart CRDs against the cluster
+type CRDPreflightResponse struct {
+	Chart   string `json:"chart"`
+	Version string `json:"version"`
+	// Conflict true if any CRD of the chart can not be installed safely
+	Conflict bool                 `json:"conflict"`
+	CRDs     []CRDPreflightResult `json:"crds"`
+}
+
+// CRDPreflightResult check result of a single CRD shipped in the chart
+type CRDPreflightResult struct {
+	Name  string `json:"name"`
+	Group string `json:"group"`
+	Kind  string `json:"kind"`
+	Scope string `json:"scope"`
+	File  string `json:"file"`
+	// Versions versions served by the CRD in the chart
+	Versions       []string `json:"versions"`
+	StorageVersion string   `json:"storageVersion"`
+	// Exists true if a definition with the same name is installed in the cluster
+	Exists bool `json:"exists"`
+	// Owner manager of the installed definition
+	Owner *CRDOwner `json:"owner,omitempty"`
+	// InstalledVersions versions served by the installed definition
+	InstalledVersions []string `json:"installedVersions,omitempty"`
+	// StoredVersions versions objects of the installed definition are persisted in
+	StoredVersions []string `json:"storedVersions,omitempty"`
+	// Compatible false if installing the chart CRD would break the installed definition
+	Compatible bool     `json:"compatible"`
+	Downgrade  bool     `json:"downgrade"`
+	Warnings   []string `json:"warnings,omitempty"`
+}
+
+// CRDOwner manager of an installed CRD, a helm release or another controller
+type CRDOwner struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	// Chart helm chart label of the installed definition
+	Chart string `json:"chart,omitempty"`
+}
@@ -0,0 +1,66 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package helm
+
+// ResourceEstimateRequest request body for estimating resources of a chart version
+type ResourceEstimateRequest struct {
+	// Values yaml values overriding chart default values
+	Values string `json:"values"`
+}
+
+// ResourceEstimate restful response of resources a chart version takes once installed
+type ResourceEstimate struct {
+	Chart   string `json:"chart"`
+	Version string `json:"version"`
+	// Total cpu and memory of all replicas, DaemonSets excluded
+	Total ResourceSummary `json:"total"`
+	// PerNode cpu and memory DaemonSets take on every node they are scheduled on
+	PerNode   ResourceSummary    `json:"perNode"`
+	Workloads []WorkloadEstimate `json:"workloads"`
+	Storage   []StorageEstimate  `json:"storage"`
+	// TotalStorage sum of persistent volume claim requests
+	TotalStorage string `json:"totalStorage"`
+	// ContainersWithoutRequests containers in kind/name/container form missing cpu or memory requests
+	ContainersWithoutRequests []string `json:"containersWithoutRequests"`
+}
+
+// ResourceSummary cpu and memory requests and limits
+type ResourceSummary struct {
+	Requests map[string]string `json:"requests"`
+	Limits   map[string]string `json:"limits"`
+}
+
+// WorkloadEstimate resources of a single workload
+type WorkloadEstimate struct {
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+	Source   string `json:"source"`
+	Replicas int32  `json:"replicas"`
+	// PerNode true for DaemonSets, one replica runs on every matching node
+	PerNode bool `json:"perNode,omitempty"`
+	// Pod effective resources of a single pod
+	Pod ResourceSummary `json:"pod"`
+	// Total resources of all replicas
+	Total ResourceSummary `json:"total"`
+}
+
+// StorageEstimate storage requested by a persistent volume claim or a StatefulSet volumeClaimTemplate
+type StorageEstimate struct {
+	Kind         string `json:"kind"`
+	Name         string `json:"name"`
+	Source       string `json:"source"`
+	StorageClass string `json:"storageClass,omitempty"`
+	Size         string `json:"size"`
+	Replicas     int32  `json:"replicas"`
+	Total        string `json:"total"`
+}
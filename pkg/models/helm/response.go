@@ -40,6 +40,8 @@ type ChartVersionResponse struct {
 	Repo string `json:"repo"`
 	// RepoUrl repo url
 	RepoUrl string `json:"repoUrl"`
+	// Deprecation deprecated api versions badge, present once the chart version has been checked
+	Deprecation *DeprecationBadge `json:"deprecation,omitempty"`
 }
 
 // ChartTemplateResponse restful response template file in helm chart
@@ -77,4 +79,121 @@ type ListResponse struct {
 type RepoResponse struct {
 	Name string `json:"name"`
 	URL  string `json:"url"`
+	// Suspended the repository is excluded from the catalog and syncs
+	Suspended bool `json:"suspended"`
+	// Health connection health of the repository, omitted until the first request to it
+	Health *RepoHealth `json:"health,omitempty"`
+}
+
+// RepoHealth circuit breaker state of the repository connection
+type RepoHealth struct {
+	// State closed, open or half-open, requests to an open repository fail fast until RetryAt
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	LastError           string     `json:"lastError,omitempty"`
+	LastFailure         *time.Time `json:"lastFailure,omitempty"`
+	RetryAt             *time.Time `json:"retryAt,omitempty"`
+}
+
+// Repository connectivity test steps, in the order they run
+const (
+	RepoTestStepDNS   = "dns"
+	RepoTestStepTCP   = "tcp"
+	RepoTestStepTLS   = "tls"
+	RepoTestStepAuth  = "auth"
+	RepoTestStepIndex = "index"
+)
+
+// Outcomes of a repository connectivity test step
+const (
+	RepoTestPassed  = "passed"
+	RepoTestWarning = "warning"
+	RepoTestFailed  = "failed"
+	RepoTestSkipped = "skipped"
+)
+
+// RepoTestReport diagnostics of connecting to a repository without creating it
+type RepoTestReport struct {
+	// Success whether no step failed, warnings do not keep the repository from being created
+	Success bool           `json:"success"`
+	Steps   []RepoTestStep `json:"steps"`
+	// ChartCount and VersionCount charts and chart versions in the index
+	ChartCount      int    `json:"chartCount"`
+	VersionCount    int    `json:"versionCount"`
+	IndexAPIVersion string `json:"indexAPIVersion,omitempty"`
+}
+
+// RepoTestStep outcome of a step of the repository connectivity test
+type RepoTestStep struct {
+	Name           string `json:"name"`
+	Status         string `json:"status"`
+	DurationMillis int64  `json:"durationMillis"`
+	Message        string `json:"message,omitempty"`
+	// Addresses resolved for the dns step, the one connected to for the tcp step
+	Addresses []string `json:"addresses,omitempty"`
+	// Certificates chain presented by the repository in the tls step, leaf first
+	Certificates []RepoTestCertificate `json:"certificates,omitempty"`
+}
+
+// RepoTestCertificate certificate presented by the repository
+type RepoTestCertificate struct {
+	Subject   string    `json:"subject"`
+	Issuer    string    `json:"issuer"`
+	DNSNames  []string  `json:"dnsNames,omitempty"`
+	NotBefore time.Time `json:"notBefore"`
+	NotAfter  time.Time `json:"notAfter"`
+}
+
+// CredentialRotationResponse outcome of re-encrypting the repository credential secrets
+type CredentialRotationResponse struct {
+	// Encrypted plaintext secrets encrypted for the first time
+	Encrypted int `json:"encrypted"`
+	// Rewrapped secrets whose data key is wrapped by the current symmetric key now
+	Rewrapped int `json:"rewrapped"`
+	// Unchanged secrets wrapped by the current symmetric key already
+	Unchanged int `json:"unchanged"`
+	// Failed repositories whose credentials could not be re-encrypted
+	Failed []string `json:"failed"`
+}
+
+// Credentials modes of a repository export
+const (
+	// RepoExportCredentialsNone credentials are left out, ca certificates are kept
+	RepoExportCredentialsNone = "none"
+	// RepoExportCredentialsPlain credentials are exported in plaintext
+	RepoExportCredentialsPlain = "plain"
+	// RepoExportCredentialsEncrypted credentials are sealed with the symmetric key of the service
+	RepoExportCredentialsEncrypted = "encrypted"
+)
+
+// Actions of importing a repository
+const (
+	RepoImportCreate = "create"
+	RepoImportUpdate = "update"
+)
+
+// Outcomes of importing a repository
+const (
+	RepoImportSucceeded = "succeeded"
+	RepoImportFailed    = "failed"
+	// RepoImportValid the entry passed validation in a dry run
+	RepoImportValid = "valid"
+	// RepoImportSkipped the entry names a repository the service manages itself
+	RepoImportSkipped = "skipped"
+)
+
+// RepoImportResult outcome of importing an entry of a repositories.yaml
+type RepoImportResult struct {
+	Name    string `json:"name"`
+	Action  string `json:"action"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// RepoImportResponse outcome of importing a repositories.yaml
+type RepoImportResponse struct {
+	DryRun  bool               `json:"dryRun"`
+	Results []RepoImportResult `json:"results"`
+	// Failed entries that failed to import, the other entries are imported regardless
+	Failed int `json:"failed"`
 }
@@ -0,0 +1,59 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package helm
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SnapshotRequest request body for protecting release volumes before upgrade or uninstall
+type SnapshotRequest struct {
+	// VolumeSnapshotClassName snapshot class used for every snapshot, cluster default if empty
+	VolumeSnapshotClassName string `json:"volumeSnapshotClassName"`
+	// Wait blocks the request until every snapshot is ready to use
+	Wait bool `json:"wait"`
+	// TimeoutSeconds maximum waiting time when Wait is true
+	TimeoutSeconds int `json:"timeoutSeconds"`
+}
+
+// SnapshotRestoreRequest request body for restoring release volumes from snapshots
+type SnapshotRestoreRequest struct {
+	// Force deletes existing persistent volume claims before recreating them
+	Force bool `json:"force"`
+}
+
+// SnapshotRestoreResponse restful response for restoring release volumes, a failed restore lists the
+// persistent volume claims it left missing
+type SnapshotRestoreResponse struct {
+	Restored []string `json:"restored"`
+	Missing  []string `json:"missing"`
+}
+
+// ReleaseSnapshotResponse restful response for snapshots taken for a release revision
+type ReleaseSnapshotResponse struct {
+	Release   string            `json:"release"`
+	Namespace string            `json:"namespace"`
+	Revision  int               `json:"revision,omitempty"`
+	Snapshots []ReleaseSnapshot `json:"snapshots"`
+}
+
+// ReleaseSnapshot restful response volume snapshot fields
+type ReleaseSnapshot struct {
+	Name         string       `json:"name"`
+	PVCName      string       `json:"pvcName"`
+	Revision     int          `json:"revision"`
+	ReadyToUse   bool         `json:"readyToUse"`
+	RestoreSize  string       `json:"restoreSize,omitempty"`
+	CreationTime *metav1.Time `json:"creationTime,omitempty"`
+	Error        string       `json:"error,omitempty"`
+}
@@ -62,18 +62,68 @@ type HelmChartRepositorySpec struct {
 
 	// Pass credentials to all domains
 	PassCredentialsAll bool `json:"passCredentialsAll"`
+
+	// Suspended excludes the repository from the catalog, official tag lookups and syncs
+	// Its chart cache and secrets are kept, so it is back as it was on resume
+	Suspended bool `json:"suspended,omitempty"`
+
+	// Proxy is an optional http(s) proxy requests to the repository are sent through
+	Proxy *ProxyConfig `json:"proxy,omitempty"`
+
+	// Headers is an optional reference to a secret of additional request headers
+	// Each key of the secret is a header name, its value the header value
+	Headers *SecretReference `json:"headers,omitempty"`
+
+	// Auth is an optional token, oauth2 client credentials or docker config authentication
+	// It is used besides BasicAuth, basic credentials answer the token challenges of the repository as well
+	Auth *RepositoryAuth `json:"auth,omitempty"`
+}
+
+// RepositoryAuth CRD authentication of the repository
+type RepositoryAuth struct {
+	// type of the authentication, one of token, oauth2 or dockerconfig
+	Type string `json:"type"`
+	// secret is a reference to a secret of the credentials
+	// The key "token" is used to store the bearer token of type token
+	// The keys "clientID" and "clientSecret" are used to store the client credentials of type oauth2
+	// The key ".dockerconfigjson" is used to store the docker config of type dockerconfig
+	Secret SecretReference `json:"secret"`
+	// tokenURL is the token endpoint of the oauth2 client credentials flow
+	TokenURL string `json:"tokenURL,omitempty"`
+	// scopes requested by the oauth2 client credentials flow
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+// ProxyConfig CRD proxy of the repository
+type ProxyConfig struct {
+	// url of the http or https proxy, e.g. http://proxy.example.com:3128
+	URL string `json:"url,omitempty"`
+	// noProxy hosts, domains and cidrs reached directly, in the format of the NO_PROXY environment variable
+	NoProxy []string `json:"noProxy,omitempty"`
 }
 
 // SecretReference CRD secret reference
 type SecretReference struct {
 	// name is the metadata.name of the referenced secret
 	Name string `json:"name"`
+	// keys maps the keys read by marketplace, e.g. "username", to the keys of the referenced secret
+	Keys map[string]string `json:"keys,omitempty"`
+	// external marks an existing secret managed outside of marketplace, e.g. by external-secrets
+	// It is watched for rotations, but never written or deleted by marketplace, and must opt in with the label
+	// marketplace.openfuyao.com/repository-credentials=true
+	External bool `json:"external,omitempty"`
 }
 
 // ConfigMapReference CRD configmap reference
 type ConfigMapReference struct {
 	// name is the metadata.name of the referenced config map
 	Name string `json:"name"`
+	// keys maps the keys read by marketplace, e.g. "ca", to the keys of the referenced config map
+	Keys map[string]string `json:"keys,omitempty"`
+	// external marks an existing config map managed outside of marketplace
+	// It is watched for rotations, but never written or deleted by marketplace, and must opt in with the label
+	// marketplace.openfuyao.com/repository-credentials=true
+	External bool `json:"external,omitempty"`
 }
 
 // DeepCopyInto copies all properties of this object into another object of the same type.
@@ -105,9 +155,12 @@ func (in *HelmChartRepository) DeepCopyObject() runtime.Object {
 // DeepCopyInto copies all properties of this object into another object of the same type.
 func (in *HelmChartRepositorySpec) DeepCopyInto(out *HelmChartRepositorySpec) {
 	*out = *in
-	out.BasicAuth = in.BasicAuth
-	out.TLS = in.TLS
-	out.CA = in.CA
+	in.BasicAuth.DeepCopyInto(&out.BasicAuth)
+	in.TLS.DeepCopyInto(&out.TLS)
+	in.CA.DeepCopyInto(&out.CA)
+	out.Proxy = in.Proxy.DeepCopy()
+	out.Headers = in.Headers.DeepCopy()
+	out.Auth = in.Auth.DeepCopy()
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmChartRepositorySpec.
@@ -120,9 +173,48 @@ func (in *HelmChartRepositorySpec) DeepCopy() *HelmChartRepositorySpec {
 	return out
 }
 
+// DeepCopyInto copies all properties of this object into another object of the same type.
+func (in *ProxyConfig) DeepCopyInto(out *ProxyConfig) {
+	*out = *in
+	if in.NoProxy != nil {
+		out.NoProxy = make([]string, len(in.NoProxy))
+		copy(out.NoProxy, in.NoProxy)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxyConfig.
+func (in *ProxyConfig) DeepCopy() *ProxyConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ProxyConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies all properties of this object into another object of the same type.
+func (in *RepositoryAuth) DeepCopyInto(out *RepositoryAuth) {
+	*out = *in
+	if in.Scopes != nil {
+		out.Scopes = make([]string, len(in.Scopes))
+		copy(out.Scopes, in.Scopes)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RepositoryAuth.
+func (in *RepositoryAuth) DeepCopy() *RepositoryAuth {
+	if in == nil {
+		return nil
+	}
+	out := new(RepositoryAuth)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto copies all properties of this object into another object of the same type.
 func (in *SecretReference) DeepCopyInto(out *SecretReference) {
 	*out = *in
+	out.Keys = copyKeys(in.Keys)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretReference.
@@ -138,6 +230,7 @@ func (in *SecretReference) DeepCopy() *SecretReference {
 // DeepCopyInto copies all properties of this object into another object of the same type.
 func (in *ConfigMapReference) DeepCopyInto(out *ConfigMapReference) {
 	*out = *in
+	out.Keys = copyKeys(in.Keys)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigMapReference.
@@ -150,6 +243,17 @@ func (in *ConfigMapReference) DeepCopy() *ConfigMapReference {
 	return out
 }
 
+func copyKeys(in map[string]string) map[string]string {
+	if in == nil {
+		return nil
+	}
+	out := make(map[string]string, len(in))
+	for key, value := range in {
+		out[key] = value
+	}
+	return out
+}
+
 // AddHelmChartRepositoryToScheme add helm chart repository to scheme
 func AddHelmChartRepositoryToScheme(scheme *runtime.Scheme) error {
 	schemeBuilder := runtime.NewSchemeBuilder(addKnownTypes)
@@ -190,6 +294,33 @@ type SafeRepoEntry struct {
 	CAFile                string `json:"caFile"`
 	InsecureSkipTLSVerify bool   `json:"insecure_skip_tls_verify"`
 	PassCredentialsAll    bool   `json:"pass_credentials_all"`
+	// Proxy http(s) proxy of the repository, nil to connect directly
+	Proxy *ProxyConfig `json:"proxy,omitempty"`
+	// Headers additional request headers, stored in a secret referenced by the repository
+	Headers map[string]string `json:"headers,omitempty"`
+	// Auth token, oauth2 or docker config authentication, nil for basic auth only
+	Auth *RepoAuthEntry `json:"auth,omitempty"`
+	// BasicAuthRef, TLSRef and CARef reference an existing secret or config map in the marketplace namespace
+	// labeled marketplace.openfuyao.com/repository-credentials=true, instead of username and password, certFile
+	// and keyFile, or caFile
+	BasicAuthRef *SecretReference    `json:"basicAuthRef,omitempty"`
+	TLSRef       *SecretReference    `json:"tlsRef,omitempty"`
+	CARef        *ConfigMapReference `json:"caRef,omitempty"`
+	// Suspended suspends or resumes the repository, nil keeps the current state on update
+	Suspended *bool `json:"suspended,omitempty"`
+}
+
+// RepoAuthEntry authentication of a repository, the credentials are stored in a secret referenced by it
+type RepoAuthEntry struct {
+	// Type token, oauth2 or dockerconfig
+	Type         string   `json:"type"`
+	Token        []byte   `json:"token,omitempty"`
+	ClientID     string   `json:"clientID,omitempty"`
+	ClientSecret []byte   `json:"clientSecret,omitempty"`
+	TokenURL     string   `json:"tokenURL,omitempty"`
+	Scopes       []string `json:"scopes,omitempty"`
+	// DockerConfig content of a .dockerconfigjson
+	DockerConfig []byte `json:"dockerConfig,omitempty"`
 }
 
 // MarketplaceServiceConfig marketplace-service config read from marketplace configmap.data
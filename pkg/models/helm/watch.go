@@ -0,0 +1,36 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package helm
+
+import "helm.sh/helm/v3/pkg/repo"
+
+// types of catalog events. RepoCreated is published when a repository is created and when it is loaded first
+// after a restart, watchers list its charts then
+const (
+	CatalogEventChartAdded   = "ChartAdded"
+	CatalogEventChartRemoved = "ChartRemoved"
+	CatalogEventChartChanged = "ChartChanged"
+	CatalogEventRepoCreated  = "RepoCreated"
+	CatalogEventRepoDeleted  = "RepoDeleted"
+)
+
+// CatalogEvent change of the chart catalog, ordered by ResourceVersion
+type CatalogEvent struct {
+	Type            string
+	ResourceVersion uint64
+	Repo            string
+	Chart           string
+	Version         string
+	// ChartVersion index entry of the chart version, nil for removed charts and repository events
+	ChartVersion *repo.ChartVersion
+}
@@ -0,0 +1,261 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"helm.sh/helm/v3/pkg/chart"
+
+	"marketplace-service/pkg/models/helm"
+	pb "marketplace-service/pkg/rpc/helmchart"
+	"marketplace-service/pkg/server/param"
+	"marketplace-service/pkg/utils/httputil"
+	"marketplace-service/pkg/utils/util"
+)
+
+// ListRepositories rpc server side function, list helm repositories like the rest api
+func (s *Server) ListRepositories(ctx context.Context,
+	req *pb.ListRepositoriesRequest) (*pb.ListRepositoriesResponse, error) {
+	repoName := util.EscapeSpecialChars(req.GetRepoName())
+	if !util.IsValidSearchParam(repoName) {
+		return nil, status.Error(codes.InvalidArgument, "invalid repository name")
+	}
+	result, httpStatus := s.operation(ctx).ListRepo(toQuery(req.GetPage()), repoName)
+	if httpStatus != http.StatusOK {
+		return nil, toStatusError(result, httpStatus)
+	}
+	listResponse, ok := result.Data.(*helm.ListResponse)
+	if !ok {
+		return nil, status.Error(codes.Internal, "unexpected repository list")
+	}
+	response := &pb.ListRepositoriesResponse{
+		Repositories: make([]*pb.Repository, 0, len(listResponse.Items)),
+		Page:         toPageResponse(listResponse),
+	}
+	for _, item := range listResponse.Items {
+		if repository, ok := item.(*helm.RepoResponse); ok {
+			response.Repositories = append(response.Repositories, &pb.Repository{
+				Name: repository.Name,
+				Url:  repository.URL,
+			})
+		}
+	}
+	return response, nil
+}
+
+// ListCharts rpc server side function, list latest charts with the same filters as the rest api
+func (s *Server) ListCharts(ctx context.Context, req *pb.ListChartsRequest) (*pb.ListChartsResponse, error) {
+	searchParam := &helm.ChartSearchParam{
+		Repositories: util.SanitizeArray(req.GetRepositories()),
+		Chart:        util.EscapeSpecialChars(req.GetChart()),
+		Types:        util.SanitizeArray(req.GetTypes()),
+		Query:        toQuery(req.GetPage()),
+		Scene:        util.SanitizeArray(req.GetScenes()),
+	}
+	if !util.IsValidSearchParam(searchParam.Chart) {
+		return nil, status.Error(codes.InvalidArgument, "invalid chart name")
+	}
+	result, httpStatus := s.operation(ctx).GetLatestCharts(searchParam)
+	if httpStatus != http.StatusOK {
+		return nil, toStatusError(result, httpStatus)
+	}
+	listResponse, ok := result.Data.(*helm.ListResponse)
+	if !ok {
+		return nil, status.Error(codes.Internal, "unexpected chart list")
+	}
+	response := &pb.ListChartsResponse{
+		Charts: make([]*pb.ChartVersion, 0, len(listResponse.Items)),
+		Page:   toPageResponse(listResponse),
+	}
+	for _, item := range listResponse.Items {
+		if chartVersion, ok := item.(*helm.ChartVersionResponse); ok {
+			response.Charts = append(response.Charts, toChartVersion(chartVersion))
+		}
+	}
+	return response, nil
+}
+
+// GetChartVersions rpc server side function, return all versions of a chart in repository
+func (s *Server) GetChartVersions(ctx context.Context,
+	req *pb.ChartVersionsRequest) (*pb.ChartVersionsResponse, error) {
+	chartVersions, err := s.getChartVersions(ctx, req.GetRepoName(), req.GetChartName(), "")
+	if err != nil {
+		return nil, err
+	}
+	response := &pb.ChartVersionsResponse{Versions: make([]*pb.ChartVersion, 0, len(chartVersions))}
+	for i := range chartVersions {
+		response.Versions = append(response.Versions, toChartVersion(&chartVersions[i]))
+	}
+	return response, nil
+}
+
+// GetChartMetadata rpc server side function, return metadata of a chart version in repository
+func (s *Server) GetChartMetadata(ctx context.Context,
+	req *pb.ChartMetadataRequest) (*pb.ChartMetadataResponse, error) {
+	if req.GetChartVersion() == "" {
+		return nil, status.Error(codes.InvalidArgument, "chart version is required")
+	}
+	chartVersions, err := s.getChartVersions(ctx, req.GetRepoName(), req.GetChartName(), req.GetChartVersion())
+	if err != nil {
+		return nil, err
+	}
+	if len(chartVersions) == 0 {
+		return nil, status.Errorf(codes.NotFound, "chart %s with version %s not found in repo %s",
+			req.GetChartName(), req.GetChartVersion(), req.GetRepoName())
+	}
+	return &pb.ChartMetadataResponse{Metadata: toChartVersion(&chartVersions[0])}, nil
+}
+
+// GetChartFiles rpc server side function, return files of a chart version in repository
+func (s *Server) GetChartFiles(ctx context.Context, req *pb.ChartFilesRequest) (*pb.ChartFilesResponse, error) {
+	result, httpStatus := s.operation(ctx).GetChartFiles(util.EscapeSpecialChars(req.GetRepoName()),
+		util.EscapeSpecialChars(req.GetChartName()), util.EscapeSpecialChars(req.GetChartVersion()),
+		util.EscapeSpecialChars(req.GetFileType()))
+	if httpStatus != http.StatusOK {
+		return nil, toStatusError(result, httpStatus)
+	}
+	response := &pb.ChartFilesResponse{}
+	switch data := result.Data.(type) {
+	case *helm.ChartDetailResponse:
+		response.Readme = data.Readme
+		response.Values = data.Values
+		response.Chart = data.Chart
+	case []helm.ChartTemplateResponse:
+		for _, template := range data {
+			response.Files = append(response.Files, &pb.ChartFile{Name: template.Name, Data: template.Data})
+		}
+	case map[string]string:
+		names := make([]string, 0, len(data))
+		for name := range data {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			response.Files = append(response.Files, &pb.ChartFile{Name: name, Data: data[name]})
+		}
+	default:
+		return nil, status.Error(codes.Internal, "unexpected chart files")
+	}
+	return response, nil
+}
+
+func (s *Server) getChartVersions(ctx context.Context, repoName, chartName,
+	version string) ([]helm.ChartVersionResponse, error) {
+	result, httpStatus := s.operation(ctx).GetChartVersion(util.EscapeSpecialChars(repoName),
+		util.EscapeSpecialChars(chartName), util.EscapeSpecialChars(version))
+	if httpStatus != http.StatusOK {
+		return nil, toStatusError(result, httpStatus)
+	}
+	chartVersions, ok := result.Data.([]helm.ChartVersionResponse)
+	if !ok {
+		return nil, status.Error(codes.Internal, "unexpected chart versions")
+	}
+	return chartVersions, nil
+}
+
+// toStatusError map http status of helm operations to grpc status codes
+func toStatusError(result *httputil.ResponseJson, httpStatus int) error {
+	message := http.StatusText(httpStatus)
+	if result != nil && result.Msg != "" {
+		message = result.Msg
+	}
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return status.Error(codes.InvalidArgument, message)
+	case http.StatusUnauthorized:
+		return status.Error(codes.Unauthenticated, message)
+	case http.StatusForbidden:
+		return status.Error(codes.PermissionDenied, message)
+	case http.StatusNotFound:
+		return status.Error(codes.NotFound, message)
+	case http.StatusConflict:
+		return status.Error(codes.AlreadyExists, message)
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusBadGateway,
+		http.StatusGatewayTimeout:
+		return status.Error(codes.Unavailable, message)
+	default:
+		return status.Error(codes.Internal, message)
+	}
+}
+
+func toQuery(page *pb.PageRequest) *param.Query {
+	limit, currentPage := -1, 1
+	if page.GetLimit() > 0 {
+		limit = int(page.GetLimit())
+	}
+	if page.GetPage() > 0 {
+		currentPage = int(page.GetPage())
+	}
+	return param.NewQuery(limit, currentPage, page.GetSortBy(), page.GetOrder())
+}
+
+func toPageResponse(listResponse *helm.ListResponse) *pb.PageResponse {
+	return &pb.PageResponse{
+		TotalItems:  int32(listResponse.TotalItems),
+		CurrentPage: int32(listResponse.CurrentPage),
+		TotalPages:  int32(listResponse.TotalPages),
+	}
+}
+
+func toChartVersion(chartVersion *helm.ChartVersionResponse) *pb.ChartVersion {
+	result := &pb.ChartVersion{
+		Repo:    chartVersion.Repo,
+		RepoUrl: chartVersion.RepoUrl,
+	}
+	entry := chartVersion.Metadata
+	if entry == nil {
+		return result
+	}
+	result.Digest = entry.Digest
+	result.Urls = entry.URLs
+	if !entry.Created.IsZero() {
+		result.Created = entry.Created.Format(time.RFC3339)
+	}
+	fillChartMetadata(result, entry.Metadata)
+	return result
+}
+
+func fillChartMetadata(result *pb.ChartVersion, metadata *chart.Metadata) {
+	if metadata == nil {
+		return
+	}
+	result.Name = metadata.Name
+	result.Version = metadata.Version
+	result.AppVersion = metadata.AppVersion
+	result.Description = metadata.Description
+	result.ApiVersion = metadata.APIVersion
+	result.Type = metadata.Type
+	result.KubeVersion = metadata.KubeVersion
+	result.Icon = metadata.Icon
+	result.Home = metadata.Home
+	result.Keywords = metadata.Keywords
+	result.Sources = metadata.Sources
+	result.Annotations = metadata.Annotations
+	result.Deprecated = metadata.Deprecated
+	for _, maintainer := range metadata.Maintainers {
+		if maintainer == nil {
+			continue
+		}
+		result.Maintainers = append(result.Maintainers, &pb.Maintainer{
+			Name:  maintainer.Name,
+			Email: maintainer.Email,
+			Url:   maintainer.URL,
+		})
+	}
+}
@@ -0,0 +1,146 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+
+	helmv1 "marketplace-service/pkg/api/marketplace/v1beta1"
+	"marketplace-service/pkg/constant"
+	"marketplace-service/pkg/helm"
+	helmModel "marketplace-service/pkg/models/helm"
+	pb "marketplace-service/pkg/rpc/helmchart"
+	"marketplace-service/pkg/server/param"
+	"marketplace-service/pkg/utils/httputil"
+)
+
+// fakeOperation overrides the helm operations used by rpc methods
+type fakeOperation struct {
+	helm.Operation
+	versions []helmModel.ChartVersionResponse
+}
+
+func (f *fakeOperation) WithContext(ctx context.Context) helm.Operation {
+	return f
+}
+
+func (f *fakeOperation) ListRepo(query *param.Query, repoName string) (*httputil.ResponseJson, int) {
+	items := []interface{}{&helmModel.RepoResponse{Name: "local", URL: "http://local"}}
+	return &httputil.ResponseJson{
+		Code: constant.Success,
+		Data: &helmModel.ListResponse{Items: items, TotalItems: 1, CurrentPage: query.Pagination.CurrentPage,
+			TotalPages: 1},
+	}, http.StatusOK
+}
+
+func (f *fakeOperation) GetChartVersion(repoName, chartName, version string) (*httputil.ResponseJson, int) {
+	if repoName != "local" {
+		return &httputil.ResponseJson{Code: constant.ResourceNotFound, Msg: "repository not found"},
+			http.StatusNotFound
+	}
+	result := make([]helmModel.ChartVersionResponse, 0)
+	for _, chartVersion := range f.versions {
+		if version == "" || chartVersion.Metadata.Version == version {
+			result = append(result, chartVersion)
+		}
+	}
+	return &httputil.ResponseJson{Code: constant.Success, Data: result}, http.StatusOK
+}
+
+func newFakeServer() *Server {
+	operation := &fakeOperation{}
+	for _, version := range []string{"1.0.0", "1.1.0"} {
+		operation.versions = append(operation.versions, helmModel.ChartVersionResponse{
+			Metadata: &repo.ChartVersion{
+				Metadata: &chart.Metadata{Name: "nginx", Version: version,
+					Maintainers: []*chart.Maintainer{{Name: "team"}}},
+				URLs: []string{"charts/nginx-" + version + ".tgz"},
+			},
+			Repo: "local",
+		})
+	}
+	return &Server{Handler: &helmv1.Handler{HelmHandler: operation}}
+}
+
+func TestServer_ListRepositories(t *testing.T) {
+	got, err := newFakeServer().ListRepositories(context.Background(), &pb.ListRepositoriesRequest{
+		Page: &pb.PageRequest{Page: 1, Limit: 10},
+	})
+	if err != nil {
+		t.Fatalf("ListRepositories() error = %v", err)
+	}
+	if len(got.Repositories) != 1 || got.Repositories[0].Url != "http://local" || got.Page.TotalItems != 1 {
+		t.Errorf("ListRepositories() got = %v", got)
+	}
+}
+
+func TestServer_GetChartMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      *pb.ChartMetadataRequest
+		wantCode codes.Code
+	}{
+		{
+			name:     "TestServer_GetChartMetadata_success",
+			req:      &pb.ChartMetadataRequest{RepoName: "local", ChartName: "nginx", ChartVersion: "1.1.0"},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "TestServer_GetChartMetadata_version_not_found",
+			req:      &pb.ChartMetadataRequest{RepoName: "local", ChartName: "nginx", ChartVersion: "2.0.0"},
+			wantCode: codes.NotFound,
+		},
+		{
+			name:     "TestServer_GetChartMetadata_repo_not_found",
+			req:      &pb.ChartMetadataRequest{RepoName: "other", ChartName: "nginx", ChartVersion: "1.0.0"},
+			wantCode: codes.NotFound,
+		},
+		{
+			name:     "TestServer_GetChartMetadata_no_version",
+			req:      &pb.ChartMetadataRequest{RepoName: "local", ChartName: "nginx"},
+			wantCode: codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newFakeServer().GetChartMetadata(context.Background(), tt.req)
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("GetChartMetadata() error = %v, want %v", err, tt.wantCode)
+			}
+			if err != nil {
+				return
+			}
+			if got.Metadata.Version != "1.1.0" || len(got.Metadata.Maintainers) != 1 {
+				t.Errorf("GetChartMetadata() got = %v", got.Metadata)
+			}
+		})
+	}
+}
+
+func TestServer_GetChartVersions(t *testing.T) {
+	got, err := newFakeServer().GetChartVersions(context.Background(),
+		&pb.ChartVersionsRequest{RepoName: "local", ChartName: "nginx"})
+	if err != nil {
+		t.Fatalf("GetChartVersions() error = %v", err)
+	}
+	if len(got.Versions) != 2 || got.Versions[0].Urls[0] != "charts/nginx-1.0.0.tgz" {
+		t.Errorf("GetChartVersions() got = %v", got.Versions)
+	}
+}
@@ -19,7 +19,13 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	ChartManager_GetHelmChart_FullMethodName = "/rpc.ChartManager/GetHelmChart"
+	ChartManager_GetHelmChart_FullMethodName     = "/rpc.ChartManager/GetHelmChart"
+	ChartManager_ListRepositories_FullMethodName = "/rpc.ChartManager/ListRepositories"
+	ChartManager_ListCharts_FullMethodName       = "/rpc.ChartManager/ListCharts"
+	ChartManager_GetChartVersions_FullMethodName = "/rpc.ChartManager/GetChartVersions"
+	ChartManager_GetChartFiles_FullMethodName    = "/rpc.ChartManager/GetChartFiles"
+	ChartManager_GetChartMetadata_FullMethodName = "/rpc.ChartManager/GetChartMetadata"
+	ChartManager_WatchCatalog_FullMethodName     = "/rpc.ChartManager/WatchCatalog"
 )
 
 // ChartManagerClient is the client API for ChartManager service.
@@ -27,6 +33,12 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type ChartManagerClient interface {
 	GetHelmChart(ctx context.Context, in *ChartRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ChartResponse], error)
+	ListRepositories(ctx context.Context, in *ListRepositoriesRequest, opts ...grpc.CallOption) (*ListRepositoriesResponse, error)
+	ListCharts(ctx context.Context, in *ListChartsRequest, opts ...grpc.CallOption) (*ListChartsResponse, error)
+	GetChartVersions(ctx context.Context, in *ChartVersionsRequest, opts ...grpc.CallOption) (*ChartVersionsResponse, error)
+	GetChartFiles(ctx context.Context, in *ChartFilesRequest, opts ...grpc.CallOption) (*ChartFilesResponse, error)
+	GetChartMetadata(ctx context.Context, in *ChartMetadataRequest, opts ...grpc.CallOption) (*ChartMetadataResponse, error)
+	WatchCatalog(ctx context.Context, in *WatchCatalogRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CatalogEvent], error)
 }
 
 type chartManagerClient struct {
@@ -56,11 +68,86 @@ func (c *chartManagerClient) GetHelmChart(ctx context.Context, in *ChartRequest,
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type ChartManager_GetHelmChartClient = grpc.ServerStreamingClient[ChartResponse]
 
+func (c *chartManagerClient) ListRepositories(ctx context.Context, in *ListRepositoriesRequest, opts ...grpc.CallOption) (*ListRepositoriesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListRepositoriesResponse)
+	err := c.cc.Invoke(ctx, ChartManager_ListRepositories_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chartManagerClient) ListCharts(ctx context.Context, in *ListChartsRequest, opts ...grpc.CallOption) (*ListChartsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListChartsResponse)
+	err := c.cc.Invoke(ctx, ChartManager_ListCharts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chartManagerClient) GetChartVersions(ctx context.Context, in *ChartVersionsRequest, opts ...grpc.CallOption) (*ChartVersionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ChartVersionsResponse)
+	err := c.cc.Invoke(ctx, ChartManager_GetChartVersions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chartManagerClient) GetChartFiles(ctx context.Context, in *ChartFilesRequest, opts ...grpc.CallOption) (*ChartFilesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ChartFilesResponse)
+	err := c.cc.Invoke(ctx, ChartManager_GetChartFiles_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chartManagerClient) GetChartMetadata(ctx context.Context, in *ChartMetadataRequest, opts ...grpc.CallOption) (*ChartMetadataResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ChartMetadataResponse)
+	err := c.cc.Invoke(ctx, ChartManager_GetChartMetadata_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chartManagerClient) WatchCatalog(ctx context.Context, in *WatchCatalogRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CatalogEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ChartManager_ServiceDesc.Streams[1], ChartManager_WatchCatalog_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchCatalogRequest, CatalogEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ChartManager_WatchCatalogClient = grpc.ServerStreamingClient[CatalogEvent]
+
 // ChartManagerServer is the server API for ChartManager service.
 // All implementations must embed UnimplementedChartManagerServer
 // for forward compatibility.
 type ChartManagerServer interface {
 	GetHelmChart(*ChartRequest, grpc.ServerStreamingServer[ChartResponse]) error
+	ListRepositories(context.Context, *ListRepositoriesRequest) (*ListRepositoriesResponse, error)
+	ListCharts(context.Context, *ListChartsRequest) (*ListChartsResponse, error)
+	GetChartVersions(context.Context, *ChartVersionsRequest) (*ChartVersionsResponse, error)
+	GetChartFiles(context.Context, *ChartFilesRequest) (*ChartFilesResponse, error)
+	GetChartMetadata(context.Context, *ChartMetadataRequest) (*ChartMetadataResponse, error)
+	WatchCatalog(*WatchCatalogRequest, grpc.ServerStreamingServer[CatalogEvent]) error
 	mustEmbedUnimplementedChartManagerServer()
 }
 
@@ -74,6 +161,24 @@ type UnimplementedChartManagerServer struct{}
 func (UnimplementedChartManagerServer) GetHelmChart(*ChartRequest, grpc.ServerStreamingServer[ChartResponse]) error {
 	return status.Errorf(codes.Unimplemented, "method GetHelmChart not implemented")
 }
+func (UnimplementedChartManagerServer) ListRepositories(context.Context, *ListRepositoriesRequest) (*ListRepositoriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRepositories not implemented")
+}
+func (UnimplementedChartManagerServer) ListCharts(context.Context, *ListChartsRequest) (*ListChartsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCharts not implemented")
+}
+func (UnimplementedChartManagerServer) GetChartVersions(context.Context, *ChartVersionsRequest) (*ChartVersionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetChartVersions not implemented")
+}
+func (UnimplementedChartManagerServer) GetChartFiles(context.Context, *ChartFilesRequest) (*ChartFilesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetChartFiles not implemented")
+}
+func (UnimplementedChartManagerServer) GetChartMetadata(context.Context, *ChartMetadataRequest) (*ChartMetadataResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetChartMetadata not implemented")
+}
+func (UnimplementedChartManagerServer) WatchCatalog(*WatchCatalogRequest, grpc.ServerStreamingServer[CatalogEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchCatalog not implemented")
+}
 func (UnimplementedChartManagerServer) mustEmbedUnimplementedChartManagerServer() {}
 func (UnimplementedChartManagerServer) testEmbeddedByValue()                      {}
 
@@ -106,19 +211,146 @@ func _ChartManager_GetHelmChart_Handler(srv interface{}, stream grpc.ServerStrea
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type ChartManager_GetHelmChartServer = grpc.ServerStreamingServer[ChartResponse]
 
+func _ChartManager_ListRepositories_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRepositoriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChartManagerServer).ListRepositories(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ChartManager_ListRepositories_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChartManagerServer).ListRepositories(ctx, req.(*ListRepositoriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChartManager_ListCharts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListChartsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChartManagerServer).ListCharts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ChartManager_ListCharts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChartManagerServer).ListCharts(ctx, req.(*ListChartsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChartManager_GetChartVersions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChartVersionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChartManagerServer).GetChartVersions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ChartManager_GetChartVersions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChartManagerServer).GetChartVersions(ctx, req.(*ChartVersionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChartManager_GetChartFiles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChartFilesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChartManagerServer).GetChartFiles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ChartManager_GetChartFiles_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChartManagerServer).GetChartFiles(ctx, req.(*ChartFilesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChartManager_GetChartMetadata_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChartMetadataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChartManagerServer).GetChartMetadata(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ChartManager_GetChartMetadata_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChartManagerServer).GetChartMetadata(ctx, req.(*ChartMetadataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChartManager_WatchCatalog_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchCatalogRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ChartManagerServer).WatchCatalog(m, &grpc.GenericServerStream[WatchCatalogRequest, CatalogEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ChartManager_WatchCatalogServer = grpc.ServerStreamingServer[CatalogEvent]
+
 // ChartManager_ServiceDesc is the grpc.ServiceDesc for ChartManager service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
 var ChartManager_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "rpc.ChartManager",
 	HandlerType: (*ChartManagerServer)(nil),
-	Methods:     []grpc.MethodDesc{},
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListRepositories",
+			Handler:    _ChartManager_ListRepositories_Handler,
+		},
+		{
+			MethodName: "ListCharts",
+			Handler:    _ChartManager_ListCharts_Handler,
+		},
+		{
+			MethodName: "GetChartVersions",
+			Handler:    _ChartManager_GetChartVersions_Handler,
+		},
+		{
+			MethodName: "GetChartFiles",
+			Handler:    _ChartManager_GetChartFiles_Handler,
+		},
+		{
+			MethodName: "GetChartMetadata",
+			Handler:    _ChartManager_GetChartMetadata_Handler,
+		},
+	},
 	Streams: []grpc.StreamDesc{
 		{
 			StreamName:    "GetHelmChart",
 			Handler:       _ChartManager_GetHelmChart_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "WatchCatalog",
+			Handler:       _ChartManager_WatchCatalog_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "helmchart.proto",
 }
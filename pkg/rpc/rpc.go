@@ -13,39 +13,167 @@
 package rpc
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 
 	helmv1 "marketplace-service/pkg/api/marketplace/v1beta1"
+	"marketplace-service/pkg/auth"
+	"marketplace-service/pkg/egress"
+	marketplaceErrors "marketplace-service/pkg/errors"
+	"marketplace-service/pkg/helm"
 	pb "marketplace-service/pkg/rpc/helmchart"
 	"marketplace-service/pkg/zlog"
 )
 
+// target systems of GetHelmChart
+const (
+	// TargetSystemUnspecified stream the archive as stored in the repository
+	TargetSystemUnspecified int32 = 0
+	// TargetSystemHelm the archive must load as a helm chart before streaming
+	TargetSystemHelm int32 = 1
+)
+
+const (
+	chunkSize = 16384
+
+	contentTypeGzip = "application/gzip"
+)
+
+// MethodAttributes authorization attributes of the chart manager methods, those of the rest routes serving
+// the same data
+var MethodAttributes = map[string]auth.Attributes{
+	pb.ChartManager_GetHelmChart_FullMethodName:     auth.NewAttributes(auth.ResourceHelmCharts, auth.VerbGet),
+	pb.ChartManager_ListRepositories_FullMethodName: auth.NewAttributes(auth.ResourceHelmChartRepositories, auth.VerbList),
+	pb.ChartManager_ListCharts_FullMethodName:       auth.NewAttributes(auth.ResourceHelmCharts, auth.VerbList),
+	pb.ChartManager_GetChartVersions_FullMethodName: auth.NewAttributes(auth.ResourceHelmCharts, auth.VerbGet),
+	pb.ChartManager_GetChartFiles_FullMethodName:    auth.NewAttributes(auth.ResourceHelmCharts, auth.VerbGet),
+	pb.ChartManager_GetChartMetadata_FullMethodName: auth.NewAttributes(auth.ResourceHelmCharts, auth.VerbGet),
+	// the catalog events carry the repositories as well as the charts
+	pb.ChartManager_WatchCatalog_FullMethodName: auth.NewAttributes(auth.ResourceHelmCharts, auth.VerbList).
+		WithRequired(auth.NewAttributes(auth.ResourceHelmChartRepositories, auth.VerbList)),
+}
+
 // Server rpc server struct
 type Server struct {
 	pb.UnimplementedChartManagerServer
 	Handler *helmv1.Handler
+	// Shutdown closed when the grpc server stops, ends long-running watches
+	Shutdown <-chan struct{}
 }
 
-// GetHelmChart rpc server side function, return helm chart from registry
+// operation helm operations bound to the context of the rpc
+func (s *Server) operation(ctx context.Context) helm.Operation {
+	return s.Handler.HelmHandler.WithContext(ctx)
+}
+
+// GetHelmChart rpc server side function, return helm chart from registry.
+// The first message carries the archive header, the following ones the chunks from resumeOffset
 func (s *Server) GetHelmChart(req *pb.ChartRequest, stream pb.ChartManager_GetHelmChartServer) error {
-	chartBytes, err := s.Handler.HelmHandler.GetChartBytesByVersion(req.GetRepoName(), req.GetChartName(),
-		req.GetChartVersion())
+	if req.GetTargetSystem() != TargetSystemUnspecified && req.GetTargetSystem() != TargetSystemHelm {
+		return status.Errorf(codes.InvalidArgument, "unsupported target system %d", req.GetTargetSystem())
+	}
+	if req.GetResumeOffset() < 0 {
+		return status.Error(codes.InvalidArgument, "resume offset must not be negative")
+	}
+	chartBytes, err := s.operation(stream.Context()).GetChartBytesByVersion(req.GetRepoName(),
+		req.GetChartName(), req.GetChartVersion())
+	if err != nil {
+		zlog.FromContext(stream.Context()).Errorf("Failed to get chart bytes: %v", err)
+		return toChartStatusError(err)
+	}
+	data := chartBytes.Bytes()
+	size := int64(len(data))
+	if req.GetResumeOffset() > size {
+		return status.Errorf(codes.OutOfRange, "resume offset %d exceeds chart size %d", req.GetResumeOffset(), size)
+	}
+
+	header, err := getChartHeader(data, req.GetTargetSystem())
 	if err != nil {
-		zlog.Errorf("Failed to get chart bytes: %v", err)
-		return status.Error(codes.Internal, "internal server error")
-	}
-	chunkSize := 16384
-	for chartBytes.Len() > 0 {
-		chunk := make([]byte, chunkSize)
-		n, _ := chartBytes.Read(chunk)
-		if err := stream.Send(&pb.ChartResponse{
+		return err
+	}
+	header.ResumeOffset = req.GetResumeOffset()
+	if err = stream.Send(&pb.ChartResponse{
+		Success: true,
+		Header:  header,
+		Offset:  req.GetResumeOffset(),
+	}); err != nil {
+		return err
+	}
+
+	for offset := req.GetResumeOffset(); offset < size; offset += chunkSize {
+		end := min(offset+chunkSize, size)
+		if err = stream.Send(&pb.ChartResponse{
 			Success:    true,
-			ChartBytes: chunk[:n],
+			ChartBytes: data[offset:end],
+			Offset:     offset,
 		}); err != nil {
 			return err
 		}
 	}
-
 	return nil
 }
+
+func getChartHeader(data []byte, targetSystem int32) (*pb.ChartHeader, error) {
+	digest := sha256.Sum256(data)
+	header := &pb.ChartHeader{
+		Size:         int64(len(data)),
+		Sha256:       hex.EncodeToString(digest[:]),
+		ContentType:  detectContentType(data),
+		TargetSystem: targetSystem,
+	}
+	loadedChart, err := loader.LoadArchive(bytes.NewReader(data))
+	if err != nil {
+		if targetSystem == TargetSystemHelm {
+			return nil, status.Errorf(codes.FailedPrecondition, "archive is not a valid helm chart: %v", err)
+		}
+		zlog.Warnf("chart archive can not be loaded, header without metadata, %v", err)
+		return header, nil
+	}
+	header.Metadata = &pb.ChartVersion{Digest: header.Sha256}
+	fillChartMetadata(header.Metadata, loadedChart.Metadata)
+	return header, nil
+}
+
+func detectContentType(data []byte) string {
+	if len(data) > 1 && data[0] == 0x1f && data[1] == 0x8b {
+		return contentTypeGzip
+	}
+	return http.DetectContentType(data)
+}
+
+// toChartStatusError map errors of loading charts to grpc status codes
+func toChartStatusError(err error) error {
+	var notFoundError *marketplaceErrors.ResourceNotFoundError
+	var responseError *marketplaceErrors.HttpResponseNotOKError
+	var connectionError *marketplaceErrors.ConnectionError
+	switch {
+	case errors.As(err, &notFoundError) || k8sErrors.IsNotFound(err):
+		return status.Error(codes.NotFound, err.Error())
+	case egress.IsViolation(err):
+		return status.Error(codes.PermissionDenied, "chart url is rejected by the egress policy")
+	case errors.As(err, &connectionError):
+		return status.Error(codes.Unavailable, "chart repository is unreachable")
+	case errors.As(err, &responseError):
+		switch {
+		case responseError.StatusCode == http.StatusUnauthorized || responseError.StatusCode == http.StatusForbidden:
+			return status.Error(codes.PermissionDenied, "access to chart repository is denied")
+		case responseError.StatusCode == http.StatusNotFound:
+			return status.Error(codes.NotFound, "chart archive not found in repository")
+		case responseError.StatusCode == http.StatusTooManyRequests ||
+			responseError.StatusCode >= http.StatusInternalServerError:
+			return status.Error(codes.Unavailable, "chart repository is unavailable")
+		default:
+		}
+	default:
+	}
+	return status.Error(codes.Internal, "internal server error")
+}
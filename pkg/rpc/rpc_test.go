@@ -0,0 +1,187 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+
+	helmv1 "marketplace-service/pkg/api/marketplace/v1beta1"
+	"marketplace-service/pkg/egress"
+	marketplaceErrors "marketplace-service/pkg/errors"
+	"marketplace-service/pkg/helm"
+	pb "marketplace-service/pkg/rpc/helmchart"
+)
+
+// fakeChartOperation serves chart archives by chart name
+type fakeChartOperation struct {
+	fakeOperation
+	archives map[string][]byte
+	err      error
+}
+
+func (f *fakeChartOperation) WithContext(ctx context.Context) helm.Operation {
+	return f
+}
+
+func (f *fakeChartOperation) GetChartBytesByVersion(repoName, chartName, version string) (*bytes.Buffer, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	archive, ok := f.archives[chartName]
+	if !ok {
+		return nil, &marketplaceErrors.ResourceNotFoundError{Message: "no chart information found"}
+	}
+	return bytes.NewBuffer(archive), nil
+}
+
+// fakeChartStream collects messages sent by GetHelmChart
+type fakeChartStream struct {
+	grpc.ServerStream
+	responses []*pb.ChartResponse
+}
+
+func (f *fakeChartStream) Send(response *pb.ChartResponse) error {
+	f.responses = append(f.responses, response)
+	return nil
+}
+
+func (f *fakeChartStream) Context() context.Context {
+	return context.Background()
+}
+
+func mockChartArchive(t *testing.T) []byte {
+	chrt := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "nginx", Version: "1.0.0", APIVersion: chart.APIVersionV2},
+		Values:   map[string]interface{}{"padding": string(bytes.Repeat([]byte("x"), 2*chunkSize))},
+	}
+	filename, err := chartutil.Save(chrt, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return archive
+}
+
+func TestServer_GetHelmChart(t *testing.T) {
+	archive := mockChartArchive(t)
+	digest := sha256.Sum256(archive)
+	operation := &fakeChartOperation{archives: map[string][]byte{"nginx": archive, "raw": []byte("plain text")}}
+	server := &Server{Handler: &helmv1.Handler{HelmHandler: operation}}
+	tests := []struct {
+		name     string
+		req      *pb.ChartRequest
+		wantCode codes.Code
+	}{
+		{
+			name:     "TestServer_GetHelmChart_full",
+			req:      &pb.ChartRequest{RepoName: "local", ChartName: "nginx", TargetSystem: TargetSystemHelm},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "TestServer_GetHelmChart_resume",
+			req:      &pb.ChartRequest{RepoName: "local", ChartName: "nginx", ResumeOffset: 100},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "TestServer_GetHelmChart_resume_out_of_range",
+			req:      &pb.ChartRequest{RepoName: "local", ChartName: "nginx", ResumeOffset: int64(len(archive) + 1)},
+			wantCode: codes.OutOfRange,
+		},
+		{
+			name:     "TestServer_GetHelmChart_invalid_target",
+			req:      &pb.ChartRequest{RepoName: "local", ChartName: "nginx", TargetSystem: 7},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "TestServer_GetHelmChart_not_helm_chart",
+			req:      &pb.ChartRequest{RepoName: "local", ChartName: "raw", TargetSystem: TargetSystemHelm},
+			wantCode: codes.FailedPrecondition,
+		},
+		{
+			name:     "TestServer_GetHelmChart_not_found",
+			req:      &pb.ChartRequest{RepoName: "local", ChartName: "redis"},
+			wantCode: codes.NotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := &fakeChartStream{}
+			err := server.GetHelmChart(tt.req, stream)
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("GetHelmChart() error = %v, want %v", err, tt.wantCode)
+			}
+			if err != nil {
+				return
+			}
+			header := stream.responses[0].GetHeader()
+			if header.GetSha256() != hex.EncodeToString(digest[:]) || header.GetSize() != int64(len(archive)) ||
+				header.GetContentType() != contentTypeGzip || header.GetMetadata().GetName() != "nginx" {
+				t.Fatalf("GetHelmChart() header = %v", header)
+			}
+			received := make([]byte, 0, len(archive))
+			for _, response := range stream.responses[1:] {
+				if response.GetOffset() != tt.req.GetResumeOffset()+int64(len(received)) {
+					t.Fatalf("GetHelmChart() chunk offset = %d, received %d", response.GetOffset(), len(received))
+				}
+				received = append(received, response.GetChartBytes()...)
+			}
+			if !bytes.Equal(received, archive[tt.req.GetResumeOffset():]) {
+				t.Errorf("GetHelmChart() received %d bytes, want %d", len(received),
+					len(archive)-int(tt.req.GetResumeOffset()))
+			}
+		})
+	}
+}
+
+func Test_toChartStatusError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want codes.Code
+	}{
+		{"Test_toChartStatusError_not_found", &marketplaceErrors.ResourceNotFoundError{}, codes.NotFound},
+		{"Test_toChartStatusError_connection", &marketplaceErrors.ConnectionError{Err: errors.New("refused")},
+			codes.Unavailable},
+		{"Test_toChartStatusError_unauthorized",
+			&marketplaceErrors.HttpResponseNotOKError{StatusCode: http.StatusUnauthorized}, codes.PermissionDenied},
+		{"Test_toChartStatusError_bad_gateway",
+			&marketplaceErrors.HttpResponseNotOKError{StatusCode: http.StatusBadGateway}, codes.Unavailable},
+		{"Test_toChartStatusError_egress",
+			&marketplaceErrors.ConnectionError{Err: &egress.ViolationError{Target: "http://10.0.0.1"}},
+			codes.PermissionDenied},
+		{"Test_toChartStatusError_unknown", errors.New("boom"), codes.Internal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := status.Code(toChartStatusError(tt.err)); got != tt.want {
+				t.Errorf("toChartStatusError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package rpc
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"marketplace-service/pkg/helm"
+	helmModel "marketplace-service/pkg/models/helm"
+	pb "marketplace-service/pkg/rpc/helmchart"
+	"marketplace-service/pkg/utils/util"
+)
+
+var catalogEventTypes = map[string]pb.CatalogEventType{
+	helmModel.CatalogEventChartAdded:   pb.CatalogEventType_CHART_ADDED,
+	helmModel.CatalogEventChartRemoved: pb.CatalogEventType_CHART_REMOVED,
+	helmModel.CatalogEventChartChanged: pb.CatalogEventType_CHART_CHANGED,
+	helmModel.CatalogEventRepoCreated:  pb.CatalogEventType_REPO_CREATED,
+	helmModel.CatalogEventRepoDeleted:  pb.CatalogEventType_REPO_DELETED,
+}
+
+// WatchCatalog rpc server side function, stream chart and repository changes of the catalog.
+// Clients resume with the resource version of the last received event, OutOfRange means list again
+func (s *Server) WatchCatalog(req *pb.WatchCatalogRequest, stream pb.ChartManager_WatchCatalogServer) error {
+	var resourceVersion uint64
+	if req.GetResourceVersion() != "" {
+		var err error
+		resourceVersion, err = strconv.ParseUint(req.GetResourceVersion(), 10, 64)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid resource version %s", req.GetResourceVersion())
+		}
+	}
+	subscription, err := helm.WatchCatalog(resourceVersion, util.SanitizeArray(req.GetRepositories()))
+	if errors.Is(err, helm.ErrResourceVersionExpired) {
+		return status.Error(codes.OutOfRange, err.Error())
+	}
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	defer subscription.Stop()
+
+	if resourceVersion == 0 {
+		if err = stream.Send(&pb.CatalogEvent{
+			Type:            pb.CatalogEventType_BOOKMARK,
+			ResourceVersion: strconv.FormatUint(subscription.ResourceVersion(), 10),
+		}); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		case <-s.Shutdown:
+			return status.Error(codes.Unavailable, "server is shutting down, please watch again")
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return status.Error(codes.Aborted, "watch falls behind, please resume from the last resource version")
+			}
+			if err = stream.Send(toCatalogEvent(&event)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func toCatalogEvent(event *helmModel.CatalogEvent) *pb.CatalogEvent {
+	result := &pb.CatalogEvent{
+		Type:            catalogEventTypes[event.Type],
+		ResourceVersion: strconv.FormatUint(event.ResourceVersion, 10),
+		Repo:            event.Repo,
+		Chart:           event.Chart,
+		Version:         event.Version,
+	}
+	if event.ChartVersion != nil {
+		result.ChartVersion = &pb.ChartVersion{
+			Digest: event.ChartVersion.Digest,
+			Urls:   event.ChartVersion.URLs,
+			Repo:   event.Repo,
+		}
+		if !event.ChartVersion.Created.IsZero() {
+			result.ChartVersion.Created = event.ChartVersion.Created.Format(time.RFC3339)
+		}
+		fillChartMetadata(result.ChartVersion, event.ChartVersion.Metadata)
+	}
+	return result
+}
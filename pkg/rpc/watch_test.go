@@ -0,0 +1,93 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+
+	"marketplace-service/pkg/helm"
+	pb "marketplace-service/pkg/rpc/helmchart"
+)
+
+// fakeWatchStream forwards sent events and cancels the watch after want events
+type fakeWatchStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	cancel context.CancelFunc
+	events []*pb.CatalogEvent
+	want   int
+}
+
+func (f *fakeWatchStream) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeWatchStream) Send(event *pb.CatalogEvent) error {
+	f.events = append(f.events, event)
+	if len(f.events) == f.want {
+		f.cancel()
+	}
+	return nil
+}
+
+func newFakeWatchStream(want int) *fakeWatchStream {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &fakeWatchStream{ctx: ctx, cancel: cancel, want: want}
+}
+
+func TestServer_WatchCatalog(t *testing.T) {
+	server := newFakeServer()
+	cache := helm.NewChartCache()
+	cache.SetChartCache("watch-other", &repo.IndexFile{})
+	cache.SetChartCache("watch-local", &repo.IndexFile{Entries: map[string]repo.ChartVersions{
+		"nginx": {{Metadata: &chart.Metadata{Name: "nginx", Version: "1.0.0"}, Digest: "a"}},
+	}})
+
+	bookmark := newFakeWatchStream(1)
+	if err := server.WatchCatalog(&pb.WatchCatalogRequest{}, bookmark); status.Code(err) != codes.Canceled {
+		t.Fatalf("WatchCatalog() error = %v", err)
+	}
+	if bookmark.events[0].Type != pb.CatalogEventType_BOOKMARK {
+		t.Fatalf("WatchCatalog() first event = %v", bookmark.events[0])
+	}
+
+	cache.SetChartCache("watch-other", &repo.IndexFile{Entries: map[string]repo.ChartVersions{
+		"redis": {{Metadata: &chart.Metadata{Name: "redis", Version: "7.0.0"}}},
+	}})
+	cache.DeleteChartCache("watch-local")
+
+	resumed := newFakeWatchStream(1)
+	err := server.WatchCatalog(&pb.WatchCatalogRequest{Repositories: []string{"watch-local"},
+		ResourceVersion: bookmark.events[0].ResourceVersion}, resumed)
+	if status.Code(err) != codes.Canceled {
+		t.Fatalf("WatchCatalog() resume error = %v", err)
+	}
+	if event := resumed.events[0]; event.Type != pb.CatalogEventType_CHART_REMOVED || event.Repo != "watch-local" ||
+		event.Chart != "nginx" || event.Version != "1.0.0" {
+		t.Errorf("WatchCatalog() resumed event = %v", event)
+	}
+
+	for _, resourceVersion := range []string{"abc", "99999999"} {
+		err = server.WatchCatalog(&pb.WatchCatalogRequest{ResourceVersion: resourceVersion}, newFakeWatchStream(1))
+		if code := status.Code(err); code != codes.InvalidArgument && code != codes.OutOfRange {
+			t.Errorf("WatchCatalog() with resource version %s error = %v", resourceVersion, err)
+		}
+	}
+}
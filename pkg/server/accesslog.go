@@ -14,13 +14,18 @@ package server
 
 import (
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/emicklei/go-restful/v3"
 
+	"marketplace-service/pkg/metrics"
 	"marketplace-service/pkg/zlog"
 )
 
+// unmatchedRoute route label of requests matching no route, keeps the label cardinality bounded
+const unmatchedRoute = "unmatched"
+
 type logFunction func(format string, args ...interface{})
 
 func logResponse(req *restful.Request, resp *restful.Response, start time.Time, logFunc logFunction) {
@@ -35,13 +40,19 @@ func logResponse(req *restful.Request, resp *restful.Response, start time.Time,
 	)
 }
 
-// RecordAccessLogs log recording function
+// RecordAccessLogs log recording function, requests are counted in metrics by route template as well
 func RecordAccessLogs(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
 	start := time.Now()
 	chain.ProcessFilter(req, resp)
+	route := req.SelectedRoutePath()
+	if route == "" {
+		route = unmatchedRoute
+	}
+	metrics.ObserveHTTPRequest(req.Request.Method, route, strconv.Itoa(resp.StatusCode()), time.Since(start))
+	log := zlog.FromContext(req.Request.Context())
 	if resp.StatusCode() > http.StatusBadRequest {
-		logResponse(req, resp, start, zlog.Warnf)
+		logResponse(req, resp, start, log.Warnf)
 	} else {
-		logResponse(req, resp, start, zlog.Infof)
+		logResponse(req, resp, start, log.Infof)
 	}
 }
@@ -37,7 +37,7 @@ func TestRecordAccessLogs_BadRequest(t *testing.T) {
 	defer patches.Reset()
 
 	infofCalled := false
-	patches.ApplyFunc(zlog.Infof, func(_ string, _ ...interface{}) {
+	patches.ApplyMethod(&zlog.Logger{}, "Infof", func(_ *zlog.Logger, _ string, _ ...interface{}) {
 		infofCalled = true
 	})
 
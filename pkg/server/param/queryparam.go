@@ -24,24 +24,31 @@ import (
 
 // parameters for URI and documentation
 const (
-	Repository = "repo"
-	Tag        = "tag"
-	SortType   = "sortType"
-	Chart      = "chart"
-	Release    = "release"
-	Namespace  = "namespace"
-	Version    = "version"
-	FileType   = "fileType"
-	AppType    = "appType"
-	Page       = "page"
-	Time       = "time"
-	Name       = "name"
-	Limit      = "limit"
-	Extension  = "extension"
-	SortBy     = "sortBy"
-	Order      = "order"
-	Scene      = "scene"
-	Status     = "status"
+	Repository    = "repo"
+	Tag           = "tag"
+	SortType      = "sortType"
+	Chart         = "chart"
+	Release       = "release"
+	Namespace     = "namespace"
+	Version       = "version"
+	FileType      = "fileType"
+	AppType       = "appType"
+	Page          = "page"
+	Time          = "time"
+	Name          = "name"
+	Limit         = "limit"
+	Extension     = "extension"
+	SortBy        = "sortBy"
+	Order         = "order"
+	Scene         = "scene"
+	Status        = "status"
+	Revision      = "revision"
+	Package       = "package"
+	TargetVersion = "targetVersion"
+	User          = "user"
+	Action        = "action"
+	Credentials   = "credentials"
+	DryRun        = "dryRun"
 
 	FuyaoPlugin = "fuyaoPlugin"
 	FuyaoTurbo  = "fuyaoTurbo"
@@ -129,8 +136,6 @@ func (p *Pagination) GetPaginationResult(total int) (startIndex, endIndex, total
 
 // ParseQueryParameter parse query parameter from request parameter
 func ParseQueryParameter(request *restful.Request) *Query {
-	query := &Query{}
-
 	limit, err := strconv.Atoi(request.QueryParameter(Limit))
 	if err != nil {
 		limit = -1
@@ -139,15 +144,14 @@ func ParseQueryParameter(request *restful.Request) *Query {
 	if err != nil {
 		page = 1
 	}
-	query.Pagination = newPagination(limit, page)
-
-	query.SortBy = request.QueryParameter(SortType)
-	order := request.QueryParameter(Order)
-	if order == descending {
-		query.Ascending = false
-	} else {
-		query.Ascending = true
-	}
+	return NewQuery(limit, page, request.QueryParameter(SortType), request.QueryParameter(Order))
+}
 
-	return query
+// NewQuery build query from pagination and sorting terms, for callers other than restful requests
+func NewQuery(limit, page int, sortBy, order string) *Query {
+	return &Query{
+		Pagination: newPagination(limit, page),
+		SortBy:     sortBy,
+		Ascending:  order != descending,
+	}
 }
@@ -0,0 +1,102 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package server
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"marketplace-service/pkg/zlog"
+)
+
+const (
+	// RequestIDHeader header propagating the request id from callers and returning it to them
+	RequestIDHeader = "X-Request-ID"
+
+	requestIDMetadata  = "x-request-id"
+	requestIDAttribute = "marketplace.request_id"
+)
+
+// validRequestID ids propagated from callers, others are replaced so they cannot forge log lines
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+func requestIDOf(propagated string) string {
+	if validRequestID.MatchString(propagated) {
+		return propagated
+	}
+	return uuid.NewString()
+}
+
+// withRequestID tag the logs and the span of ctx with the request id
+func withRequestID(ctx context.Context, requestID string, keysAndValues ...interface{}) context.Context {
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String(requestIDAttribute, requestID))
+	return zlog.NewContext(ctx, append([]interface{}{zlog.RequestIDKey, requestID}, keysAndValues...)...)
+}
+
+// RequestID propagate the X-Request-ID of the caller or generate one, the id and the route tag every
+// log line of the request and are returned in the response header
+func RequestID(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+	requestID := requestIDOf(req.Request.Header.Get(RequestIDHeader))
+	resp.Header().Set(RequestIDHeader, requestID)
+	route := req.SelectedRoutePath()
+	if route == "" {
+		route = unmatchedRoute
+	}
+	req.Request = req.Request.WithContext(withRequestID(req.Request.Context(), requestID, zlog.RouteKey, route))
+	chain.ProcessFilter(req, resp)
+}
+
+// RequestIDUnaryInterceptor the grpc counterpart of RequestID, the id is read from and returned in the
+// x-request-id metadata
+func RequestIDUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(grpcRequestContext(ctx, info.FullMethod), req)
+}
+
+// RequestIDStreamInterceptor the grpc stream counterpart of RequestID
+func RequestIDStreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+	return handler(srv, &requestIDStream{ServerStream: stream, ctx: grpcRequestContext(stream.Context(),
+		info.FullMethod)})
+}
+
+func grpcRequestContext(ctx context.Context, method string) context.Context {
+	var propagated string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestIDMetadata); len(values) > 0 {
+			propagated = values[0]
+		}
+	}
+	requestID := requestIDOf(propagated)
+	if err := grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadata, requestID)); err != nil {
+		zlog.Debugf("set request id header of %s failed, %v", method, err)
+	}
+	return withRequestID(ctx, requestID, zlog.RouteKey, method)
+}
+
+// requestIDStream server stream whose context carries the request id
+type requestIDStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *requestIDStream) Context() context.Context {
+	return s.ctx
+}
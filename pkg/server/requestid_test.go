@@ -0,0 +1,68 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRequestID 测试请求id的透传与生成
+func TestRequestID(t *testing.T) {
+	var handled context.Context
+	webService := new(restful.WebService).Produces(restful.MIME_JSON)
+	webService.Route(webService.GET("/helm-repos").To(func(req *restful.Request, resp *restful.Response) {
+		handled = req.Request.Context()
+		resp.WriteHeader(http.StatusOK)
+	}))
+	container := restful.NewContainer()
+	container.Add(webService)
+	container.Filter(RequestID)
+
+	tests := []struct {
+		name       string
+		propagated string
+		generated  bool
+	}{
+		{"propagated", "abc-123.def:1", false},
+		{"missing", "", true},
+		{"forged", "id\ninjected", true},
+		{"too long", strings.Repeat("a", 129), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/helm-repos", nil)
+			if tt.propagated != "" {
+				req.Header.Set(RequestIDHeader, tt.propagated)
+			}
+			recorder := httptest.NewRecorder()
+			container.ServeHTTP(recorder, req)
+
+			requestID := recorder.Header().Get(RequestIDHeader)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			if tt.generated {
+				assert.NotEqual(t, tt.propagated, requestID)
+				assert.Regexp(t, validRequestID, requestID)
+			} else {
+				assert.Equal(t, tt.propagated, requestID)
+			}
+			assert.NotNil(t, handled)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package runtime
+
+import (
+	"os"
+)
+
+const (
+	defaultAuditLogPath    = "/var/log/marketplace-service/audit.log"
+	defaultAuditRecentSize = 1000
+	defaultAuditMaxSize    = 20
+	defaultAuditMaxBackups = 10
+	defaultAuditMaxAge     = 90
+)
+
+// AuditConfig configuration of the audit trail
+type AuditConfig struct {
+	// LogPath dedicated audit log file, records are json lines
+	LogPath string
+
+	// rotation of the audit log, size in megabytes and age in days
+	MaxSize    int
+	MaxBackups int
+	MaxAge     int
+
+	// RecentSize records kept in memory for the query endpoint
+	RecentSize int
+
+	// EnableEvents report records as kubernetes events as well
+	EnableEvents bool
+}
+
+// NewAuditConfig create new audit config from environment
+func NewAuditConfig() *AuditConfig {
+	logPath := os.Getenv("AUDIT_LOG_PATH")
+	if logPath == "" {
+		logPath = defaultAuditLogPath
+	}
+	return &AuditConfig{
+		LogPath:      logPath,
+		MaxSize:      getEnvInt("AUDIT_LOG_MAX_SIZE", defaultAuditMaxSize),
+		MaxBackups:   getEnvInt("AUDIT_LOG_MAX_BACKUPS", defaultAuditMaxBackups),
+		MaxAge:       getEnvInt("AUDIT_LOG_MAX_AGE", defaultAuditMaxAge),
+		RecentSize:   getEnvInt("AUDIT_RECENT_SIZE", defaultAuditRecentSize),
+		EnableEvents: os.Getenv("AUDIT_EVENTS_ENABLED") == "true",
+	}
+}
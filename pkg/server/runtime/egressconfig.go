@@ -0,0 +1,70 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package runtime
+
+import (
+	"os"
+	"strings"
+
+	"marketplace-service/pkg/egress"
+)
+
+// EgressConfig destinations repository and chart urls may point to
+type EgressConfig struct {
+	// AllowedSchemes schemes of repository and chart urls
+	AllowedSchemes []string
+
+	// AllowCIDRs when set only these ranges are reachable, including otherwise blocked loopback and
+	// link-local addresses
+	AllowCIDRs []string
+
+	// DenyCIDRs ranges never reachable, e.g. the pod and service networks of the cluster
+	DenyCIDRs []string
+}
+
+// NewEgressConfig create new egress config from environment, loopback and link-local addresses are blocked
+// by default
+func NewEgressConfig() *EgressConfig {
+	c := &EgressConfig{
+		AllowedSchemes: splitList(os.Getenv("EGRESS_ALLOWED_SCHEMES")),
+		AllowCIDRs:     splitList(os.Getenv("EGRESS_ALLOW_CIDRS")),
+		DenyCIDRs:      splitList(os.Getenv("EGRESS_DENY_CIDRS")),
+	}
+	if len(c.AllowedSchemes) == 0 {
+		c.AllowedSchemes = egress.DefaultSchemes
+	}
+	return c
+}
+
+// Policy egress policy of the config
+func (c *EgressConfig) Policy() (*egress.Policy, error) {
+	return egress.NewPolicy(c.AllowedSchemes, c.AllowCIDRs, c.DenyCIDRs)
+}
+
+// Validate egress config 校验
+func (c *EgressConfig) Validate() []error {
+	if _, err := c.Policy(); err != nil {
+		return []error{err}
+	}
+	return nil
+}
+
+func splitList(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
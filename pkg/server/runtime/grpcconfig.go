@@ -0,0 +1,128 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package runtime
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"marketplace-service/pkg/constant"
+	"marketplace-service/pkg/zlog"
+)
+
+const (
+	defaultGrpcPort = 9038
+	// defaultGrpcMaxMsgSize chart metadata and files responses, archives are streamed in chunks
+	defaultGrpcMaxMsgSize = 16 * 1024 * 1024
+
+	defaultKeepaliveTime     = 2 * time.Minute
+	defaultKeepaliveTimeout  = 20 * time.Second
+	defaultKeepaliveMinTime  = 30 * time.Second
+	defaultGracefulStopLimit = 30 * time.Second
+)
+
+// GrpcConfig configuration of the grpc server
+type GrpcConfig struct {
+	// grpc port number
+	Port int
+
+	// tls private key file, tls is disabled if empty
+	PrivateKey string
+
+	// tls cert file
+	CertFile string
+
+	// tls CA file
+	CAFile string
+
+	// max size of received and sent messages in bytes
+	MaxRecvMsgSize int
+	MaxSendMsgSize int
+
+	// ping clients after KeepaliveTime of inactivity, close the connection after KeepaliveTimeout without ack
+	KeepaliveTime    time.Duration
+	KeepaliveTimeout time.Duration
+
+	// KeepaliveMinTime minimum interval clients are allowed to ping
+	KeepaliveMinTime time.Duration
+
+	// GracefulStopTimeout time for in-flight rpcs to finish before the server stops forcibly
+	GracefulStopTimeout time.Duration
+
+	// EnableReflection register the grpc reflection service
+	EnableReflection bool
+}
+
+// NewGrpcConfig create new grpc config from environment, tls files are shared with the http server
+func NewGrpcConfig() *GrpcConfig {
+	c := GrpcConfig{
+		Port:                getEnvInt("GRPC_PORT", defaultGrpcPort),
+		MaxRecvMsgSize:      getEnvInt("GRPC_MAX_RECV_MSG_SIZE", defaultGrpcMaxMsgSize),
+		MaxSendMsgSize:      getEnvInt("GRPC_MAX_SEND_MSG_SIZE", defaultGrpcMaxMsgSize),
+		KeepaliveTime:       defaultKeepaliveTime,
+		KeepaliveTimeout:    defaultKeepaliveTimeout,
+		KeepaliveMinTime:    defaultKeepaliveMinTime,
+		GracefulStopTimeout: defaultGracefulStopLimit,
+		EnableReflection:    os.Getenv("GRPC_ENABLE_REFLECTION") == "true",
+	}
+	if os.Getenv("ENABLE_TLS") != "true" {
+		return &c
+	}
+	if _, err := os.Stat(constant.TLSCertPath); err != nil {
+		zlog.Info("TLS cert file not exist, disable grpc tls")
+		return &c
+	}
+	c.CertFile = constant.TLSCertPath
+	c.PrivateKey = constant.TLSKeyPath
+	c.CAFile = constant.CAPath
+	return &c
+}
+
+// TLSEnabled whether the grpc server serves tls
+func (c *GrpcConfig) TLSEnabled() bool {
+	return c.CertFile != ""
+}
+
+// Validate grpc config 校验
+func (c *GrpcConfig) Validate() []error {
+	var errs []error
+	if c.Port <= 0 || c.Port > maxSecurePort {
+		errs = append(errs, fmt.Errorf("invalid grpc port %d", c.Port))
+	}
+	if c.MaxRecvMsgSize <= 0 || c.MaxSendMsgSize <= 0 {
+		errs = append(errs, fmt.Errorf("grpc message size limits must be positive"))
+	}
+	if c.TLSEnabled() {
+		for _, file := range []string{c.CertFile, c.PrivateKey, c.CAFile} {
+			if _, err := os.Stat(file); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errs
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value, exist := os.LookupEnv(key)
+	if !exist {
+		return defaultValue
+	}
+	result, err := strconv.Atoi(value)
+	if err != nil {
+		zlog.Warnf("invalid %s %s, use default value: %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return result
+}
@@ -0,0 +1,63 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package runtime
+
+import (
+	"testing"
+)
+
+func TestNewGrpcConfig(t *testing.T) {
+	t.Setenv("GRPC_PORT", "9100")
+	t.Setenv("GRPC_MAX_RECV_MSG_SIZE", "abc")
+	t.Setenv("GRPC_ENABLE_REFLECTION", "true")
+	t.Setenv("ENABLE_TLS", "false")
+	got := NewGrpcConfig()
+	if got.Port != 9100 || got.MaxRecvMsgSize != defaultGrpcMaxMsgSize || !got.EnableReflection || got.TLSEnabled() {
+		t.Errorf("NewGrpcConfig() = %+v", got)
+	}
+	if errs := got.Validate(); len(errs) != 0 {
+		t.Errorf("Validate() = %v", errs)
+	}
+}
+
+func TestGrpcConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  GrpcConfig
+		wantErr int
+	}{
+		{
+			name:    "TestGrpcConfigValidate_invalid_port",
+			config:  GrpcConfig{Port: 70000, MaxRecvMsgSize: 1, MaxSendMsgSize: 1},
+			wantErr: 1,
+		},
+		{
+			name:    "TestGrpcConfigValidate_invalid_size",
+			config:  GrpcConfig{Port: 9038, MaxRecvMsgSize: 0, MaxSendMsgSize: 1},
+			wantErr: 1,
+		},
+		{
+			name: "TestGrpcConfigValidate_missing_tls_files",
+			config: GrpcConfig{Port: 9038, MaxRecvMsgSize: 1, MaxSendMsgSize: 1,
+				CertFile: "/not/exist/tls.crt", PrivateKey: "/not/exist/tls.key", CAFile: "/not/exist/ca.crt"},
+			wantErr: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.Validate(); len(got) != tt.wantErr {
+				t.Errorf("Validate() = %v, want %d errors", got, tt.wantErr)
+			}
+		})
+	}
+}
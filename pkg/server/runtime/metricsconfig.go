@@ -0,0 +1,37 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package runtime
+
+import "fmt"
+
+const defaultMetricsPort = 9039
+
+// MetricsConfig configuration of the prometheus metrics listener. Metrics are served on a port of their own,
+// outside of the authenticated rest api, so scrapes can be limited by network policies instead
+type MetricsConfig struct {
+	// Port plain http port of /metrics, 0 disables the metrics endpoint
+	Port int
+}
+
+// NewMetricsConfig create new metrics config from environment
+func NewMetricsConfig() *MetricsConfig {
+	return &MetricsConfig{Port: getEnvInt("METRICS_PORT", defaultMetricsPort)}
+}
+
+// Validate metrics config 校验
+func (c *MetricsConfig) Validate() []error {
+	if c.Port < 0 || c.Port > maxSecurePort {
+		return []error{fmt.Errorf("invalid metrics port %d", c.Port)}
+	}
+	return nil
+}
@@ -45,6 +45,9 @@ type ServerConfig struct {
 
 	// tls CA file
 	CAFile string
+
+	// EnableAuth authenticate and authorize rest api and grpc requests
+	EnableAuth bool
 }
 
 // NewServerConfig create new server config
@@ -60,6 +63,7 @@ func NewServerConfig() *ServerConfig {
 		SecurePort:   0,
 		CertFile:     "",
 		PrivateKey:   "",
+		EnableAuth:   os.Getenv("ENABLE_AUTH") != "false",
 	}
 	if os.Getenv("ENABLE_TLS") != "true" {
 		s.InsecurePort = port
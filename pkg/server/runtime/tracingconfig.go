@@ -0,0 +1,80 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package runtime
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"marketplace-service/pkg/zlog"
+)
+
+// trace exporters, following the values of OTEL_TRACES_EXPORTER
+const (
+	TraceExporterNone   = "none"
+	TraceExporterOTLP   = "otlp"
+	TraceExporterStdout = "stdout"
+
+	defaultTraceSampleRatio = 1.0
+)
+
+// TracingConfig configuration of opentelemetry tracing, the otlp endpoint and headers are read by the
+// exporter from the standard OTEL_EXPORTER_OTLP_* environment
+type TracingConfig struct {
+	// Exporter none, otlp or stdout
+	Exporter string
+
+	// SampleRatio ratio of root spans sampled, child spans follow their parent
+	SampleRatio float64
+}
+
+// NewTracingConfig create new tracing config from environment, tracing is disabled by default
+func NewTracingConfig() *TracingConfig {
+	c := &TracingConfig{
+		Exporter:    strings.ToLower(os.Getenv("OTEL_TRACES_EXPORTER")),
+		SampleRatio: defaultTraceSampleRatio,
+	}
+	if c.Exporter == "" {
+		c.Exporter = TraceExporterNone
+	}
+	if ratio, exist := os.LookupEnv("OTEL_TRACES_SAMPLER_ARG"); exist {
+		value, err := strconv.ParseFloat(ratio, 64)
+		if err != nil {
+			zlog.Warnf("invalid OTEL_TRACES_SAMPLER_ARG %s, use default value: %v", ratio, defaultTraceSampleRatio)
+		} else {
+			c.SampleRatio = value
+		}
+	}
+	return c
+}
+
+// Enabled whether spans are exported
+func (c *TracingConfig) Enabled() bool {
+	return c.Exporter != TraceExporterNone
+}
+
+// Validate tracing config 校验
+func (c *TracingConfig) Validate() []error {
+	var errs []error
+	switch c.Exporter {
+	case TraceExporterNone, TraceExporterOTLP, TraceExporterStdout:
+	default:
+		errs = append(errs, fmt.Errorf("unsupported trace exporter %s", c.Exporter))
+	}
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("trace sample ratio %v out of range [0, 1]", c.SampleRatio))
+	}
+	return errs
+}
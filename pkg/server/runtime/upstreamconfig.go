@@ -0,0 +1,76 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package runtime
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	defaultUpstreamRequestTimeout   = 120 * time.Second
+	defaultUpstreamIdleConnTimeout  = 90 * time.Second
+	defaultUpstreamMaxRetries       = 3
+	defaultUpstreamFailureThreshold = 5
+	defaultUpstreamOpenDuration     = time.Minute
+)
+
+// UpstreamConfig timeouts, retries and circuit breaker of repository requests
+type UpstreamConfig struct {
+	// RequestTimeout overall time of a repository request including retries
+	RequestTimeout time.Duration
+
+	// IdleConnTimeout time an idle pooled connection is kept open
+	IdleConnTimeout time.Duration
+
+	// MaxRetries retries on network errors and 5xx responses, 0 disables retries
+	MaxRetries int
+
+	// FailureThreshold consecutive failures marking a repository unhealthy, 0 disables the circuit breaker
+	FailureThreshold int
+
+	// OpenDuration time requests to an unhealthy repository fail fast before it is probed again
+	OpenDuration time.Duration
+
+	// TokenRealmHosts hosts trusted to issue tokens for repositories on other hosts
+	TokenRealmHosts []string
+}
+
+// NewUpstreamConfig create new upstream config from environment
+func NewUpstreamConfig() *UpstreamConfig {
+	return &UpstreamConfig{
+		RequestTimeout:   getEnvSeconds("UPSTREAM_REQUEST_TIMEOUT_SECONDS", defaultUpstreamRequestTimeout),
+		IdleConnTimeout:  getEnvSeconds("UPSTREAM_IDLE_CONN_TIMEOUT_SECONDS", defaultUpstreamIdleConnTimeout),
+		MaxRetries:       getEnvInt("UPSTREAM_MAX_RETRIES", defaultUpstreamMaxRetries),
+		FailureThreshold: getEnvInt("UPSTREAM_FAILURE_THRESHOLD", defaultUpstreamFailureThreshold),
+		OpenDuration:     getEnvSeconds("UPSTREAM_OPEN_SECONDS", defaultUpstreamOpenDuration),
+		TokenRealmHosts:  splitList(os.Getenv("UPSTREAM_TOKEN_REALM_HOSTS")),
+	}
+}
+
+// Validate upstream config 校验
+func (c *UpstreamConfig) Validate() []error {
+	var errs []error
+	if c.RequestTimeout <= 0 || c.IdleConnTimeout <= 0 || c.OpenDuration <= 0 {
+		errs = append(errs, fmt.Errorf("upstream timeouts must be positive"))
+	}
+	if c.MaxRetries < 0 || c.FailureThreshold < 0 {
+		errs = append(errs, fmt.Errorf("upstream retries and failure threshold must not be negative"))
+	}
+	return errs
+}
+
+func getEnvSeconds(key string, defaultValue time.Duration) time.Duration {
+	return time.Duration(getEnvInt(key, int(defaultValue/time.Second))) * time.Second
+}
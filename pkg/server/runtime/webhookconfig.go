@@ -0,0 +1,74 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package runtime
+
+import (
+	"fmt"
+	"os"
+
+	"marketplace-service/pkg/constant"
+)
+
+const defaultWebhookPort = 9443
+
+// defaultWebhookExemptGroups cluster admins installing and upgrading the chart of the official and local
+// repositories
+var defaultWebhookExemptGroups = []string{"system:masters"}
+
+// WebhookConfig configuration of the admission webhook server of repository crs
+type WebhookConfig struct {
+	// Enabled serve the validating and defaulting webhooks
+	Enabled bool
+
+	// Port https port of the webhook server
+	Port int
+
+	// tls cert and private key files, the api server only calls webhooks over https
+	CertFile   string
+	PrivateKey string
+
+	// ExemptGroups groups of users allowed to modify the official and local repositories
+	ExemptGroups []string
+}
+
+// NewWebhookConfig create new webhook config from environment
+func NewWebhookConfig() *WebhookConfig {
+	c := &WebhookConfig{
+		Enabled:      os.Getenv("WEBHOOK_ENABLED") == "true",
+		Port:         getEnvInt("WEBHOOK_PORT", defaultWebhookPort),
+		CertFile:     constant.WebhookTLSCertPath,
+		PrivateKey:   constant.WebhookTLSKeyPath,
+		ExemptGroups: splitList(os.Getenv("WEBHOOK_EXEMPT_GROUPS")),
+	}
+	if _, exist := os.LookupEnv("WEBHOOK_EXEMPT_GROUPS"); !exist {
+		c.ExemptGroups = defaultWebhookExemptGroups
+	}
+	return c
+}
+
+// Validate webhook config 校验
+func (c *WebhookConfig) Validate() []error {
+	if !c.Enabled {
+		return nil
+	}
+	var errs []error
+	if c.Port <= 0 || c.Port > maxSecurePort {
+		errs = append(errs, fmt.Errorf("invalid webhook port %d", c.Port))
+	}
+	for _, file := range []string{c.CertFile, c.PrivateKey} {
+		if _, err := os.Stat(file); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,38 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package runtime
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewWebhookConfig(t *testing.T) {
+	t.Setenv("WEBHOOK_ENABLED", "true")
+	t.Setenv("WEBHOOK_PORT", "8443")
+	t.Setenv("WEBHOOK_EXEMPT_GROUPS", "system:masters, platform-admins")
+	got := NewWebhookConfig()
+	if !got.Enabled || got.Port != 8443 ||
+		!reflect.DeepEqual(got.ExemptGroups, []string{"system:masters", "platform-admins"}) {
+		t.Errorf("NewWebhookConfig() = %+v", got)
+	}
+
+	if errs := (&WebhookConfig{Port: 0}).Validate(); len(errs) != 0 {
+		t.Errorf("Validate() of disabled webhook = %v", errs)
+	}
+	config := WebhookConfig{Enabled: true, Port: 70000, CertFile: "/not/exist/tls.crt",
+		PrivateKey: "/not/exist/tls.key"}
+	if errs := config.Validate(); len(errs) != 3 {
+		t.Errorf("Validate() = %v, want 3 errors", errs)
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"marketplace-service/pkg/constant"
+	"marketplace-service/pkg/utils/httputil"
 )
 
 const (
@@ -59,6 +60,8 @@ func GetMarketplaceWebService() *restful.WebService {
 }
 
 func initRestfulRegister() {
+	// error responses of routes producing yaml only, e.g. the repositories export
+	restful.RegisterEntityAccessor(httputil.MimeYAML, httputil.NewYAMLAccessor())
 	restful.RegisterEntityAccessor("application/merge-patch+json", restful.NewEntityAccessorJSON(restful.MIME_JSON))
 	restful.RegisterEntityAccessor("application/json-patch+json", restful.NewEntityAccessorJSON(restful.MIME_JSON))
 }
@@ -16,41 +16,102 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
+	"sync"
+	"time"
 
 	"github.com/emicklei/go-restful/v3"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/reflection"
+	"gopkg.in/natefinch/lumberjack.v2"
 
 	"marketplace-service/cmd/config"
 	helmv1 "marketplace-service/pkg/api/marketplace/v1beta1"
+	"marketplace-service/pkg/audit"
+	"marketplace-service/pkg/auth"
 	"marketplace-service/pkg/client/k8s"
-	"marketplace-service/pkg/constant"
+	"marketplace-service/pkg/egress"
+	"marketplace-service/pkg/helm"
+	"marketplace-service/pkg/metrics"
 	"marketplace-service/pkg/rpc"
 	pb "marketplace-service/pkg/rpc/helmchart"
 	"marketplace-service/pkg/server/runtime"
+	"marketplace-service/pkg/tracing"
+	"marketplace-service/pkg/upstream"
 	"marketplace-service/pkg/utils/httputil"
+	"marketplace-service/pkg/utils/util"
+	"marketplace-service/pkg/webhook"
 	"marketplace-service/pkg/zlog"
 )
 
+// sideReadHeaderTimeout the api server and prometheus send their requests right after connecting
+const sideReadHeaderTimeout = 10 * time.Second
+
 // CServer including http server config, go-restful container and kubernetes client for connection
 type CServer struct {
 	// server
 	Server *http.Server
 
+	// GrpcServer serves the chart manager service, stopped together with the http server
+	GrpcServer *grpc.Server
+	grpcConfig *runtime.GrpcConfig
+	grpcHealth *health.Server
+	// grpcShutdown closed when grpc server stops, ends long-running watches
+	grpcShutdown     chan struct{}
+	grpcShutdownOnce sync.Once
+
 	// Container a Web Server（服务器），con WebServices 组成，此外还包含了若干个 Filters（过滤器）、
 	container *restful.Container
 
 	// helm用到的k8s client
 	KubernetesClient k8s.Client
+
+	// Auditor records repository and chart changes
+	Auditor *audit.Auditor
+
+	// WebhookServer serves the admission webhooks of repository crs over https, nil if disabled
+	WebhookServer *http.Server
+	webhookConfig *runtime.WebhookConfig
+
+	// MetricsServer serves /metrics on a port of its own, nil if disabled
+	MetricsServer *http.Server
+}
+
+func upstreamOptions(cfg *runtime.UpstreamConfig) upstream.Options {
+	options := upstream.DefaultOptions()
+	options.RequestTimeout = cfg.RequestTimeout
+	options.IdleConnTimeout = cfg.IdleConnTimeout
+	options.MaxRetries = cfg.MaxRetries
+	options.FailureThreshold = cfg.FailureThreshold
+	options.OpenDuration = cfg.OpenDuration
+	options.TokenRealmHosts = cfg.TokenRealmHosts
+	return options
 }
 
 // NewServer creates an cServer instance using given options
 func NewServer(cfg *config.RunConfig, ctx context.Context) (*CServer, error) {
-	server := &CServer{}
+	server := &CServer{
+		grpcConfig:    cfg.Grpc,
+		grpcHealth:    health.NewServer(),
+		grpcShutdown:  make(chan struct{}),
+		webhookConfig: cfg.Webhook,
+	}
+
+	egressPolicy, err := cfg.Egress.Policy()
+	if err != nil {
+		return nil, err
+	}
+	egress.SetPolicy(egressPolicy)
+	upstream.SetOptions(upstreamOptions(cfg.Upstream))
 
 	httpServer, err := initServer(cfg)
 	if err != nil {
@@ -58,8 +119,17 @@ func NewServer(cfg *config.RunConfig, ctx context.Context) (*CServer, error) {
 	}
 	server.Server = httpServer
 
+	webhookServer, err := initWebhookServer(cfg.Webhook)
+	if err != nil {
+		return nil, err
+	}
+	server.WebhookServer = webhookServer
+	server.MetricsServer = initMetricsServer(cfg.Metrics)
+
 	server.container = restful.NewContainer()
 	server.container.Router(restful.CurlyRouter{})
+	server.container.Filter(tracing.Filter)
+	server.container.Filter(RequestID)
 	server.container.Filter(RecordAccessLogs)
 
 	kubernetesClient, err := k8s.NewKubernetesClient(cfg.KubernetesCfg)
@@ -67,10 +137,52 @@ func NewServer(cfg *config.RunConfig, ctx context.Context) (*CServer, error) {
 		return nil, err
 	}
 	server.KubernetesClient = kubernetesClient
+	server.Auditor = newAuditor(cfg.Audit, kubernetesClient)
+	server.container.Filter(server.Auditor.Filter)
+	var authFilter *auth.Filter
+	if cfg.Server.EnableAuth {
+		authFilter = newAuthFilter(kubernetesClient)
+		server.container.Filter(authFilter.Filter)
+	}
+
+	grpcServer, err := initGrpcServer(cfg.Grpc, authFilter)
+	if err != nil {
+		return nil, err
+	}
+	server.GrpcServer = grpcServer
 
 	return server, nil
 }
 
+// newAuditor records go to the dedicated audit log, and kubernetes events if enabled
+func newAuditor(cfg *runtime.AuditConfig, kubernetesClient k8s.Client) *audit.Auditor {
+	sinks := []audit.Sink{audit.NewWriterSink(&lumberjack.Logger{
+		Filename:   cfg.LogPath,
+		MaxSize:    cfg.MaxSize,
+		MaxBackups: cfg.MaxBackups,
+		MaxAge:     cfg.MaxAge,
+		Compress:   true,
+	})}
+	if cfg.EnableEvents {
+		sinks = append(sinks, audit.NewEventSink(kubernetesClient.Kubernetes()))
+	}
+	return audit.NewAuditor(cfg.RecentSize, sinks...)
+}
+
+// newAuthFilter oauth tokens are accepted when the oauth server is configured in marketplace configmap
+func newAuthFilter(kubernetesClient k8s.Client) *auth.Filter {
+	var oauthServerHost string
+	var insecureSkipVerify bool
+	serviceConfig, err := util.GetMarketplaceServiceConfig(kubernetesClient.Kubernetes())
+	if err != nil {
+		zlog.Warnf("marketplace config not found, oauth tokens are not accepted, %v", err)
+	} else {
+		oauthServerHost = serviceConfig.OAuthServerHost
+		insecureSkipVerify = serviceConfig.InsecureSkipVerify == "true"
+	}
+	return auth.NewFilter(kubernetesClient.Kubernetes(), oauthServerHost, insecureSkipVerify)
+}
+
 func initServer(cfg *config.RunConfig) (*http.Server, error) {
 	httpServer := &http.Server{
 		Addr: fmt.Sprintf(":%d", cfg.Server.InsecurePort),
@@ -104,18 +216,73 @@ func initServer(cfg *config.RunConfig) (*http.Server, error) {
 	return httpServer, nil
 }
 
+// initWebhookServer the api server calls admission webhooks over https only
+func initWebhookServer(cfg *runtime.WebhookConfig) (*http.Server, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	certificate, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.PrivateKey)
+	if err != nil {
+		zlog.Errorf("error loading %s and %s , %v", cfg.CertFile, cfg.PrivateKey, err)
+		return nil, err
+	}
+	return &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.Port),
+		ReadHeaderTimeout: sideReadHeaderTimeout,
+		TLSConfig: &tls.Config{
+			Certificates: []tls.Certificate{certificate},
+			MinVersion:   tls.VersionTLS12,
+		},
+	}, nil
+}
+
+// initMetricsServer metrics carry repository names, they are kept off the rest api port which may be exposed
+// through the service
+func initMetricsServer(cfg *runtime.MetricsConfig) *http.Server {
+	if cfg.Port == 0 {
+		return nil
+	}
+	mux := http.NewServeMux()
+	mux.Handle(metrics.Path, metrics.Handler())
+	return &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.Port),
+		Handler:           mux,
+		ReadHeaderTimeout: sideReadHeaderTimeout,
+	}
+}
+
 // Run init marketplace-service server, bind route, set tls config, etc.
+// The http and grpc servers stop gracefully when ctx is cancelled
 func (s *CServer) Run(ctx context.Context) error {
-	var err error = nil
-	s.registerAPI()
+	s.registerAPI(ctx)
 	s.Server.Handler = s.container
 
-	shutdownCtx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.grpcConfig.Port))
+	if err != nil {
+		zlog.Errorf("grpc failed to listen on port %d, %v", s.grpcConfig.Port, err)
+		return err
+	}
+	grpcErr := make(chan error, 1)
+	go func() {
+		zlog.Infof("grpc listening at %v", listener.Addr())
+		grpcErr <- s.GrpcServer.Serve(listener)
+	}()
+	go s.serveHealthWhenCacheWarm(ctx)
+	serveSideServer("admission webhook", s.WebhookServer)
+	serveSideServer("metrics", s.MetricsServer)
 
+	stopped := make(chan error, 1)
 	go func() {
-		<-ctx.Done()
-		err = s.Server.Shutdown(shutdownCtx)
+		var serveErr error
+		select {
+		case <-ctx.Done():
+		case serveErr = <-grpcErr:
+			zlog.Errorf("grpc server stopped unexpectedly, %v", serveErr)
+		}
+		s.stopGrpcServer()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), s.grpcConfig.GracefulStopTimeout)
+		defer cancel()
+		stopped <- errors.Join(serveErr, s.Server.Shutdown(shutdownCtx), s.shutdownSideServers(shutdownCtx))
 	}()
 
 	if s.Server.TLSConfig != nil {
@@ -123,51 +290,134 @@ func (s *CServer) Run(ctx context.Context) error {
 	} else {
 		err = s.Server.ListenAndServe()
 	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return <-stopped
+	}
+	s.stopGrpcServer()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.grpcConfig.GracefulStopTimeout)
+	defer cancel()
+	if sideErr := s.shutdownSideServers(shutdownCtx); sideErr != nil {
+		zlog.Warnf("webhook or metrics server shutdown failed, %v", sideErr)
+	}
 	return err
 }
 
-func initRPCServer(handler *helmv1.Handler) {
-	lis, err := net.Listen("tcp", ":9038")
-	if err != nil {
-		zlog.Fatalf("failed to listen: %v", err)
+// serveSideServer serve the webhook or metrics server, https if it has a tls config. A failing side server is
+// logged but does not stop the service
+func serveSideServer(name string, server *http.Server) {
+	if server == nil {
+		return
 	}
-	s := getGrpcServer()
-
-	pb.RegisterChartManagerServer(s, &rpc.Server{
-		Handler: handler,
-	})
-	zlog.Infof("grpc listening at %v", lis.Addr())
 	go func() {
-		if err := s.Serve(lis); err != nil {
-			zlog.Fatalf("failed to serve: %v", err)
+		zlog.Infof("%s listening at %s", name, server.Addr)
+		var err error
+		if server.TLSConfig != nil {
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			zlog.Errorf("%s server stopped unexpectedly, %v", name, err)
 		}
 	}()
 }
 
-func getGrpcServer() *grpc.Server {
-	var enableTLS bool
-	enableTLS, err := httputil.IsHttpsEnabled(constant.TLSCertPath, constant.TLSKeyPath, constant.CAPath)
-	if err != nil {
-		enableTLS = false
+func (s *CServer) shutdownSideServers(ctx context.Context) error {
+	var errs []error
+	for _, server := range []*http.Server{s.WebhookServer, s.MetricsServer} {
+		if server != nil {
+			errs = append(errs, server.Shutdown(ctx))
+		}
 	}
-	tlsCfg, err := httputil.GetHttpConfig(constant.TLSCertPath, constant.TLSKeyPath, constant.CAPath, enableTLS)
-	if err != nil {
-		enableTLS = false
+	return errors.Join(errs...)
+}
+
+// initGrpcServer grpc callers are authenticated and authorized like rest api ones unless authFilter is nil
+func initGrpcServer(cfg *runtime.GrpcConfig, authFilter *auth.Filter) (*grpc.Server, error) {
+	unaryInterceptors := []grpc.UnaryServerInterceptor{RequestIDUnaryInterceptor, metrics.UnaryServerInterceptor}
+	streamInterceptors := []grpc.StreamServerInterceptor{RequestIDStreamInterceptor, metrics.StreamServerInterceptor}
+	if authFilter != nil {
+		unaryInterceptors = append(unaryInterceptors, authFilter.UnaryServerInterceptor(rpc.MethodAttributes))
+		streamInterceptors = append(streamInterceptors, authFilter.StreamServerInterceptor(rpc.MethodAttributes))
+	}
+	options := []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(cfg.MaxRecvMsgSize),
+		grpc.MaxSendMsgSize(cfg.MaxSendMsgSize),
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:    cfg.KeepaliveTime,
+			Timeout: cfg.KeepaliveTimeout,
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             cfg.KeepaliveMinTime,
+			PermitWithoutStream: true,
+		}),
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(unaryInterceptors...),
+		grpc.ChainStreamInterceptor(streamInterceptors...),
+	}
+	if cfg.TLSEnabled() {
+		tlsCfg, err := httputil.GetHttpConfig(cfg.CertFile, cfg.PrivateKey, cfg.CAFile, true)
+		if err != nil {
+			zlog.Errorf("error loading grpc tls config, %v", err)
+			return nil, err
+		}
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsCfg)))
+		zlog.Info("use grpc tls successfully")
+	}
+	return grpc.NewServer(options...), nil
+}
+
+func (s *CServer) registerGrpcServices(handler *helmv1.Handler) {
+	pb.RegisterChartManagerServer(s.GrpcServer, &rpc.Server{
+		Handler:  handler,
+		Shutdown: s.grpcShutdown,
+	})
+	healthpb.RegisterHealthServer(s.GrpcServer, s.grpcHealth)
+	s.grpcHealth.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	s.grpcHealth.SetServingStatus(pb.ChartManager_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_NOT_SERVING)
+	if s.grpcConfig.EnableReflection {
+		reflection.Register(s.GrpcServer)
 	}
+}
 
-	if !enableTLS {
-		s := grpc.NewServer()
-		return s
+// serveHealthWhenCacheWarm report serving once the chart cache is synchronized
+func (s *CServer) serveHealthWhenCacheWarm(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+	case <-helm.ChartCacheWarm():
+		zlog.Info("chart cache is warm, grpc serving")
+		s.grpcHealth.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+		s.grpcHealth.SetServingStatus(pb.ChartManager_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
 	}
-	zlog.Info("use grpc tls successfully")
-	creds := credentials.NewTLS(tlsCfg)
-	s := grpc.NewServer(grpc.Creds(creds))
-	return s
 }
 
-func (s *CServer) registerAPI() {
+// stopGrpcServer wait in-flight rpcs up to the graceful stop timeout, then close all connections
+func (s *CServer) stopGrpcServer() {
+	s.grpcShutdownOnce.Do(func() {
+		s.grpcHealth.Shutdown()
+		close(s.grpcShutdown)
+		done := make(chan struct{})
+		go func() {
+			s.GrpcServer.GracefulStop()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(s.grpcConfig.GracefulStopTimeout):
+			zlog.Warn("grpc graceful stop timeout, stop forcibly")
+			s.GrpcServer.Stop()
+		}
+		zlog.Info("grpc server stopped")
+	})
+}
+
+func (s *CServer) registerAPI(ctx context.Context) {
 	marketplaceServiceWebService := runtime.GetMarketplaceWebService()
-	handler := helmv1.BindMarketPlaceRoute(marketplaceServiceWebService, s.KubernetesClient.Config())
-	initRPCServer(handler)
+	handler := helmv1.BindMarketPlaceRoute(marketplaceServiceWebService, s.KubernetesClient.Config(), s.Auditor)
+	go handler.HelmHandler.WatchRepoReferences(ctx)
+	if s.WebhookServer != nil {
+		s.WebhookServer.Handler = webhook.NewServer(handler.HelmHandler, s.webhookConfig.ExemptGroups).Handler()
+	}
+	s.registerGrpcServices(handler)
 	s.container.Add(marketplaceServiceWebService)
 }
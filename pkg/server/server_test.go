@@ -0,0 +1,62 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	helmv1 "marketplace-service/pkg/api/marketplace/v1beta1"
+	"marketplace-service/pkg/server/runtime"
+)
+
+func newTestGrpcServer(t *testing.T) *CServer {
+	cfg := &runtime.GrpcConfig{Port: 9038, MaxRecvMsgSize: 1024, MaxSendMsgSize: 1024,
+		KeepaliveTime: time.Minute, KeepaliveTimeout: time.Second, KeepaliveMinTime: time.Second,
+		GracefulStopTimeout: time.Second, EnableReflection: true}
+	grpcServer, err := initGrpcServer(cfg, nil)
+	if err != nil {
+		t.Fatalf("initGrpcServer() error = %v", err)
+	}
+	return &CServer{GrpcServer: grpcServer, grpcConfig: cfg, grpcHealth: health.NewServer(),
+		grpcShutdown: make(chan struct{})}
+}
+
+func TestCServer_registerGrpcServices(t *testing.T) {
+	server := newTestGrpcServer(t)
+	server.registerGrpcServices(&helmv1.Handler{})
+
+	services := server.GrpcServer.GetServiceInfo()
+	for _, name := range []string{"rpc.ChartManager", "grpc.health.v1.Health", "grpc.reflection.v1.ServerReflection"} {
+		if _, ok := services[name]; !ok {
+			t.Errorf("registerGrpcServices() missing service %s", name)
+		}
+	}
+	response, err := server.grpcHealth.Check(context.Background(),
+		&healthpb.HealthCheckRequest{Service: "rpc.ChartManager"})
+	if err != nil || response.Status != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("health before cache warm = %v, %v", response, err)
+	}
+
+	server.stopGrpcServer()
+	server.stopGrpcServer()
+	select {
+	case <-server.grpcShutdown:
+	default:
+		t.Errorf("stopGrpcServer() should close grpc shutdown channel")
+	}
+}
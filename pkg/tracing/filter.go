@@ -0,0 +1,54 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package tracing
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Filter go-restful filter serving each routed request in a server span. Spans are named after the
+// route template so their names stay bounded, the trace context of the caller is continued
+func Filter(request *restful.Request, response *restful.Response, chain *restful.FilterChain) {
+	route := request.SelectedRoutePath()
+	if route == "" {
+		chain.ProcessFilter(request, response)
+		return
+	}
+	method := request.Request.Method
+	ctx := otel.GetTextMapPropagator().Extract(request.Request.Context(),
+		propagation.HeaderCarrier(request.Request.Header))
+	ctx, span := Tracer().Start(ctx, method+" "+route,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(method),
+			semconv.HTTPRoute(route),
+			semconv.URLPath(request.Request.URL.Path),
+		))
+	defer span.End()
+	request.Request = request.Request.WithContext(ctx)
+
+	chain.ProcessFilter(request, response)
+
+	status := response.StatusCode()
+	span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+}
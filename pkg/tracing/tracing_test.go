@@ -0,0 +1,103 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const callerTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func installRecorder(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		_ = provider.Shutdown(context.Background())
+	})
+	return recorder
+}
+
+func TestFilter(t *testing.T) {
+	recorder := installRecorder(t)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("traceparent") == "" {
+			t.Error("trace context not propagated to upstream")
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer upstream.Close()
+
+	webService := new(restful.WebService).Produces(restful.MIME_JSON)
+	webService.Route(webService.GET("/helm-repos/{repo}").To(
+		func(request *restful.Request, response *restful.Response) {
+			upstreamRequest, _ := http.NewRequestWithContext(request.Request.Context(), http.MethodGet,
+				upstream.URL, nil)
+			client := &http.Client{Transport: NewTransport(http.DefaultTransport)}
+			upstreamResponse, err := client.Do(upstreamRequest)
+			if err != nil {
+				t.Errorf("upstream request failed, %v", err)
+				return
+			}
+			_ = upstreamResponse.Body.Close()
+			response.WriteHeader(upstreamResponse.StatusCode)
+		}))
+	container := restful.NewContainer()
+	container.Filter(Filter)
+	container.Add(webService)
+
+	request := httptest.NewRequest(http.MethodGet, "/helm-repos/bitnami", nil)
+	request.Header.Set("traceparent", callerTraceParent)
+	container.ServeHTTP(httptest.NewRecorder(), request)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans, want server and client span", len(spans))
+	}
+	client, server := spans[0], spans[1]
+	if server.Name() != "GET /helm-repos/{repo}" || server.SpanKind() != trace.SpanKindServer {
+		t.Errorf("server span %s of kind %v", server.Name(), server.SpanKind())
+	}
+	if server.SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("server span did not continue the trace of the caller")
+	}
+	if server.Status().Code != codes.Error {
+		t.Errorf("server span status %v, want error for 503", server.Status().Code)
+	}
+	if client.Parent().SpanID() != server.SpanContext().SpanID() || client.SpanKind() != trace.SpanKindClient {
+		t.Errorf("client span is not a child of the server span")
+	}
+}
+
+func TestFilter_Unmatched(t *testing.T) {
+	recorder := installRecorder(t)
+	container := restful.NewContainer()
+	container.Filter(Filter)
+	container.Add(new(restful.WebService).Path("/helm-repos"))
+	container.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/unknown", nil))
+	if len(recorder.Ended()) != 0 {
+		t.Errorf("unmatched request traced")
+	}
+}
@@ -0,0 +1,471 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package upstream
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+
+	"marketplace-service/pkg/metrics"
+)
+
+// authentication types of a repository besides basic auth
+const (
+	AuthToken        = "token"
+	AuthOAuth2       = "oauth2"
+	AuthDockerConfig = "dockerconfig"
+)
+
+const (
+	authorizationHeader   = "Authorization"
+	authenticateHeader    = "Www-Authenticate"
+	bearerScheme          = "Bearer"
+	defaultTokenExpiresIn = 60 * time.Second
+	// tokenResponseLimit bytes of a token response read
+	tokenResponseLimit = 1 << 20
+)
+
+// Auth credentials of a repository. Basic credentials, from the repository or the docker config, are exchanged
+// for a bearer token when the repository answers with a token challenge, anonymous challenges are answered
+// as well
+type Auth struct {
+	// Type token, oauth2, dockerconfig or empty for basic auth only
+	Type string
+
+	// Username and Password basic credentials of the repository
+	Username string
+	Password string
+
+	// Token static bearer token of the token type
+	Token string
+
+	// ClientID, ClientSecret, TokenURL and Scopes of the oauth2 client credentials flow
+	ClientID     string
+	ClientSecret string
+	TokenURL     string
+	Scopes       []string
+
+	// DockerConfig content of a .dockerconfigjson, credentials are selected by the host of the request
+	DockerConfig []byte
+}
+
+// Validate whether the credentials of the auth type are complete
+func (a *Auth) Validate() error {
+	switch a.Type {
+	case "":
+		return nil
+	case AuthToken:
+		if a.Token == "" {
+			return errors.New("token is missing")
+		}
+	case AuthOAuth2:
+		if a.ClientID == "" || a.ClientSecret == "" {
+			return errors.New("oauth2 client id or secret is missing")
+		}
+		u, err := url.Parse(a.TokenURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("oauth2 token url must be an http or https url")
+		}
+	case AuthDockerConfig:
+		_, err := ParseDockerConfig(a.DockerConfig)
+		return err
+	default:
+		return fmt.Errorf("auth type %q is not supported, use %s, %s or %s", a.Type, AuthToken, AuthOAuth2,
+			AuthDockerConfig)
+	}
+	return nil
+}
+
+func (a *Auth) fingerprint() string {
+	if a == nil {
+		return ""
+	}
+	return strings.Join([]string{a.Type, a.Username, a.Password, a.Token, a.ClientID, a.ClientSecret, a.TokenURL,
+		strings.Join(a.Scopes, " "), string(a.DockerConfig)}, "\x00")
+}
+
+// DockerConfig credentials of a docker config by registry host
+type DockerConfig map[string]basicCredentials
+
+type basicCredentials struct {
+	username string
+	password string
+}
+
+// ParseDockerConfig credentials of the auths of a .dockerconfigjson
+func ParseDockerConfig(data []byte) (DockerConfig, error) {
+	var config struct {
+		Auths map[string]struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+			Auth     string `json:"auth"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, errors.New("docker config is not valid json")
+	}
+	if len(config.Auths) == 0 {
+		return nil, errors.New("docker config has no auths")
+	}
+	result := make(DockerConfig, len(config.Auths))
+	for registry, entry := range config.Auths {
+		credentials := basicCredentials{username: entry.Username, password: entry.Password}
+		if entry.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("auth of registry %s is not base64", registry)
+			}
+			username, password, found := strings.Cut(string(decoded), ":")
+			if !found {
+				return nil, fmt.Errorf("auth of registry %s is not username:password", registry)
+			}
+			credentials = basicCredentials{username: username, password: password}
+		}
+		result[registryHost(registry)] = credentials
+	}
+	return result, nil
+}
+
+// registryHost host of a docker config key, which may be a bare host or an url
+func registryHost(registry string) string {
+	if u, err := url.Parse(registry); err == nil && u.Host != "" {
+		return u.Host
+	}
+	host, _, _ := strings.Cut(registry, "/")
+	return host
+}
+
+// lookup credentials of host, with or without the port
+func (c DockerConfig) lookup(host string) (basicCredentials, bool) {
+	if credentials, ok := c[host]; ok {
+		return credentials, true
+	}
+	if hostname, _, found := strings.Cut(host, ":"); found {
+		credentials, ok := c[hostname]
+		return credentials, ok
+	}
+	return basicCredentials{}, false
+}
+
+// AuthTransport authenticate the requests sent by base with auth, tokens are only requested from realms on the
+// host of the repository
+func AuthTransport(repo string, base http.RoundTripper, auth *Auth) (http.RoundTripper, error) {
+	return newAuthTransport(repo, base, auth, nil)
+}
+
+// newAuthTransport authTransport also requesting tokens from the realms on realmHosts
+func newAuthTransport(repo string, base http.RoundTripper, auth *Auth, realmHosts []string) (*authTransport, error) {
+	if auth == nil {
+		auth = &Auth{}
+	}
+	t := &authTransport{repo: repo, base: base, auth: auth, realmHosts: realmHosts,
+		tokens: make(map[string]bearerToken), scopes: make(map[string]string)}
+	switch auth.Type {
+	case AuthDockerConfig:
+		config, err := ParseDockerConfig(auth.DockerConfig)
+		if err != nil {
+			return nil, err
+		}
+		t.dockerConfig = config
+	case AuthOAuth2:
+		config := &clientcredentials.Config{
+			ClientID:     auth.ClientID,
+			ClientSecret: auth.ClientSecret,
+			TokenURL:     auth.TokenURL,
+			Scopes:       auth.Scopes,
+		}
+		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: base})
+		t.tokenSource = oauth2.ReuseTokenSource(nil, &observedTokenSource{repo: repo, ctx: ctx, config: config})
+	default:
+	}
+	return t, nil
+}
+
+// bearerToken token issued on a challenge, reused for later requests to the host with the same scope
+type bearerToken struct {
+	value   string
+	expires time.Time
+}
+
+// authTransport sets the credentials of the repository on requests without authorization and answers bearer
+// token challenges of the repository, the way docker registries and harbor issue tokens
+type authTransport struct {
+	repo         string
+	base         http.RoundTripper
+	auth         *Auth
+	dockerConfig DockerConfig
+	tokenSource  oauth2.TokenSource
+	// realmHosts hosts trusted to issue tokens for a repository on another host
+	realmHosts []string
+
+	mu sync.Mutex
+	// tokens by host and scope
+	tokens map[string]bearerToken
+	// scopes scope of the last challenge by token resource
+	scopes map[string]string
+	now    func() time.Time
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	authorized := req
+	if current := req.Header.Get(authorizationHeader); current == "" || isBasic(current) {
+		value, err := t.authorization(req.URL, current != "")
+		if err != nil {
+			return nil, err
+		}
+		if value != "" {
+			authorized = req.Clone(req.Context())
+			authorized.Header.Set(authorizationHeader, value)
+		}
+	}
+	resp, err := t.base.RoundTrip(authorized)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || !t.challengeable() || !replayable(req) {
+		return resp, err
+	}
+	challenge, ok := parseChallenge(resp.Header.Get(authenticateHeader))
+	if !ok {
+		return resp, nil
+	}
+	token, tokenErr := t.fetchToken(req, challenge)
+	metrics.ObserveTokenRefresh(t.repo, tokenErr)
+	if tokenErr != nil {
+		return resp, nil
+	}
+	discard(resp)
+	retried := req.Clone(req.Context())
+	retried.Header.Set(authorizationHeader, bearerScheme+" "+token)
+	return t.base.RoundTrip(retried)
+}
+
+// authorization header value of a request to u, a request with basic credentials of the caller only
+// changes to a cached bearer token
+func (t *authTransport) authorization(u *url.URL, basic bool) (string, error) {
+	if token, ok := t.cachedToken(u); ok {
+		return bearerScheme + " " + token, nil
+	}
+	if basic {
+		return "", nil
+	}
+	switch t.auth.Type {
+	case AuthToken:
+		return bearerScheme + " " + t.auth.Token, nil
+	case AuthOAuth2:
+		token, err := t.tokenSource.Token()
+		if err != nil {
+			return "", fmt.Errorf("oauth2 token of repository %s: %w", t.repo, err)
+		}
+		return bearerScheme + " " + token.AccessToken, nil
+	default:
+	}
+	if credentials, ok := t.dockerConfig.lookup(u.Host); ok {
+		return basicAuthorization(credentials), nil
+	}
+	return "", nil
+}
+
+// challengeable static and oauth2 tokens are not exchanged on a challenge
+func (t *authTransport) challengeable() bool {
+	return t.auth.Type != AuthToken && t.auth.Type != AuthOAuth2
+}
+
+// cachedToken token issued for the scope of the last challenge of the resource of u
+func (t *authTransport) cachedToken(u *url.URL) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	scope, ok := t.scopes[tokenResource(u)]
+	if !ok {
+		return "", false
+	}
+	token, ok := t.tokens[tokenKey(u.Host, scope)]
+	if !ok || !t.clock().Before(token.expires) {
+		return "", false
+	}
+	return token.value, true
+}
+
+func (t *authTransport) clock() time.Time {
+	if t.now != nil {
+		return t.now()
+	}
+	return time.Now()
+}
+
+// fetchToken request a token from the realm of the challenge, with the basic credentials of the repository
+// if it has any. The realm must not downgrade https and must be on the host of the repository or a trusted
+// realm host, the credentials would be sent to whoever the challenge names otherwise
+func (t *authTransport) fetchToken(req *http.Request, challenge map[string]string) (string, error) {
+	realm, err := url.Parse(challenge["realm"])
+	if err != nil || (realm.Scheme != "http" && realm.Scheme != "https") || realm.Host == "" {
+		return "", fmt.Errorf("invalid token realm %q", challenge["realm"])
+	}
+	if req.URL.Scheme == "https" && realm.Scheme != "https" {
+		return "", fmt.Errorf("token realm %q of repository %s downgrades https", realm.Redacted(), t.repo)
+	}
+	if !strings.EqualFold(realm.Hostname(), req.URL.Hostname()) && !t.trustedRealm(realm.Hostname()) {
+		return "", fmt.Errorf("token realm host %s of repository %s is not the repository host nor trusted",
+			realm.Hostname(), t.repo)
+	}
+	query := realm.Query()
+	for _, param := range []string{"service", "scope"} {
+		if challenge[param] != "" {
+			query.Set(param, challenge[param])
+		}
+	}
+	realm.RawQuery = query.Encode()
+	tokenReq, err := http.NewRequestWithContext(req.Context(), http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if credentials, ok := t.credentials(req); ok {
+		tokenReq.SetBasicAuth(credentials.username, credentials.password)
+	}
+	resp, err := t.base.RoundTrip(tokenReq)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, tokenResponseLimit))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token request of repository %s answered %s", t.repo, resp.Status)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err = json.Unmarshal(body, &token); err != nil {
+		return "", fmt.Errorf("token response of repository %s is not valid json", t.repo)
+	}
+	value := token.Token
+	if value == "" {
+		value = token.AccessToken
+	}
+	if value == "" {
+		return "", fmt.Errorf("token response of repository %s has no token", t.repo)
+	}
+	expiresIn := defaultTokenExpiresIn
+	if token.ExpiresIn > 0 {
+		expiresIn = time.Duration(token.ExpiresIn) * time.Second
+	}
+	t.mu.Lock()
+	t.tokens[tokenKey(req.URL.Host, challenge["scope"])] = bearerToken{value: value, expires: t.clock().Add(expiresIn)}
+	t.scopes[tokenResource(req.URL)] = challenge["scope"]
+	t.mu.Unlock()
+	return value, nil
+}
+
+func (t *authTransport) trustedRealm(host string) bool {
+	for _, trusted := range t.realmHosts {
+		if strings.EqualFold(trusted, host) {
+			return true
+		}
+	}
+	return false
+}
+
+func tokenKey(host, scope string) string {
+	return host + " " + scope
+}
+
+// tokenResource requests sharing the scope of their token, the repository of a registry api request, e.g.
+// /v2/library/nginx/manifests/1.0, or the host for any other request
+func tokenResource(u *url.URL) string {
+	if strings.HasPrefix(u.Path, "/v2/") {
+		for _, kind := range []string{"/manifests/", "/blobs/", "/tags/"} {
+			if i := strings.LastIndex(u.Path, kind); i > 0 {
+				return u.Host + u.Path[:i]
+			}
+		}
+	}
+	return u.Host
+}
+
+// credentials basic credentials exchanged for a token, from the docker config or the repository
+func (t *authTransport) credentials(req *http.Request) (basicCredentials, bool) {
+	if credentials, ok := t.dockerConfig.lookup(req.URL.Host); ok {
+		return credentials, true
+	}
+	if username, password, ok := req.BasicAuth(); ok {
+		return basicCredentials{username: username, password: password}, true
+	}
+	if t.auth.Username != "" && t.auth.Password != "" {
+		return basicCredentials{username: t.auth.Username, password: t.auth.Password}, true
+	}
+	return basicCredentials{}, false
+}
+
+// observedTokenSource records the oauth2 token refreshes of a repository, tokens are reused until they expire
+type observedTokenSource struct {
+	repo   string
+	ctx    context.Context
+	config *clientcredentials.Config
+}
+
+// Token implements oauth2.TokenSource
+func (s *observedTokenSource) Token() (*oauth2.Token, error) {
+	token, err := s.config.Token(s.ctx)
+	metrics.ObserveTokenRefresh(s.repo, err)
+	return token, err
+}
+
+func isBasic(authorization string) bool {
+	return len(authorization) > len("Basic ") && strings.EqualFold(authorization[:len("Basic ")], "Basic ")
+}
+
+func basicAuthorization(credentials basicCredentials) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials.username+":"+credentials.password))
+}
+
+// parseChallenge parameters of a bearer challenge, e.g. Bearer realm="https://h/service/token",service="harbor"
+func parseChallenge(header string) (map[string]string, bool) {
+	scheme, params, found := strings.Cut(strings.TrimSpace(header), " ")
+	if !found || !strings.EqualFold(scheme, bearerScheme) {
+		return nil, false
+	}
+	result := make(map[string]string)
+	for len(params) > 0 {
+		var key, value string
+		key, params, _ = strings.Cut(params, "=")
+		key = strings.ToLower(strings.TrimSpace(strings.TrimLeft(key, ", ")))
+		params = strings.TrimSpace(params)
+		if strings.HasPrefix(params, `"`) {
+			end := strings.Index(params[1:], `"`)
+			if end < 0 {
+				return nil, false
+			}
+			value, params = params[1:end+1], params[end+2:]
+		} else {
+			value, params, _ = strings.Cut(params, ",")
+		}
+		if key != "" {
+			result[key] = strings.TrimSpace(value)
+		}
+	}
+	return result, result["realm"] != ""
+}
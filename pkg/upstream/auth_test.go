@@ -0,0 +1,275 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package upstream
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseDockerConfig 测试解析docker config凭据
+func TestParseDockerConfig(t *testing.T) {
+	auth := base64.StdEncoding.EncodeToString([]byte("robot:secret"))
+	config, err := ParseDockerConfig([]byte(`{"auths":{"https://harbor.example.com":{"auth":"` + auth + `"},` +
+		`"registry.example.com:5000":{"username":"admin","password":"pass"}}}`))
+	assert.NoError(t, err)
+	credentials, ok := config.lookup("harbor.example.com")
+	assert.True(t, ok)
+	assert.Equal(t, basicCredentials{username: "robot", password: "secret"}, credentials)
+	credentials, ok = config.lookup("registry.example.com:5000")
+	assert.True(t, ok)
+	assert.Equal(t, "admin", credentials.username)
+	_, ok = config.lookup("other.example.com")
+	assert.False(t, ok)
+
+	_, err = ParseDockerConfig([]byte(`{"auths":{}}`))
+	assert.Error(t, err)
+	_, err = ParseDockerConfig([]byte(`not json`))
+	assert.Error(t, err)
+}
+
+// TestAuth_Validate 测试认证配置校验
+func TestAuth_Validate(t *testing.T) {
+	assert.NoError(t, (&Auth{}).Validate())
+	assert.NoError(t, (&Auth{Type: AuthToken, Token: "token"}).Validate())
+	assert.Error(t, (&Auth{Type: AuthToken}).Validate())
+	assert.NoError(t, (&Auth{Type: AuthOAuth2, ClientID: "id", ClientSecret: "secret",
+		TokenURL: "https://sso.example.com/token"}).Validate())
+	assert.Error(t, (&Auth{Type: AuthOAuth2, ClientID: "id", ClientSecret: "secret", TokenURL: "ftp://x"}).Validate())
+	assert.Error(t, (&Auth{Type: AuthDockerConfig, DockerConfig: []byte(`{}`)}).Validate())
+	assert.Error(t, (&Auth{Type: "kerberos"}).Validate())
+}
+
+// TestParseChallenge 测试解析bearer质询
+func TestParseChallenge(t *testing.T) {
+	challenge, ok := parseChallenge(`Bearer realm="https://harbor.example.com/service/token",` +
+		`service="harbor-registry",scope="repository:library/nginx:pull"`)
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{
+		"realm":   "https://harbor.example.com/service/token",
+		"service": "harbor-registry",
+		"scope":   "repository:library/nginx:pull",
+	}, challenge)
+	_, ok = parseChallenge(`Basic realm="harbor"`)
+	assert.False(t, ok)
+	_, ok = parseChallenge(`Bearer service="harbor-registry"`)
+	assert.False(t, ok)
+}
+
+// newChallengeServer repository answering requests without the issued token with a bearer challenge
+func newChallengeServer(t *testing.T, tokenRequests *atomic.Int32) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/service/token" {
+			tokenRequests.Add(1)
+			username, password, ok := r.BasicAuth()
+			if !ok || username != "robot" || password != "secret" || r.URL.Query().Get("service") != "harbor" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"token":"issued","expires_in":300}`))
+			return
+		}
+		if r.Header.Get(authorizationHeader) != "Bearer issued" {
+			w.Header().Set(authenticateHeader,
+				fmt.Sprintf(`Bearer realm="%s/service/token",service="harbor"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestAuthTransport_challenge 测试使用仓库凭据应答token质询并缓存token
+func TestAuthTransport_challenge(t *testing.T) {
+	var tokenRequests atomic.Int32
+	server := newChallengeServer(t, &tokenRequests)
+	transport, err := AuthTransport("challenge", http.DefaultTransport,
+		&Auth{Username: "robot", Password: "secret"})
+	assert.NoError(t, err)
+	client := &http.Client{Transport: transport}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL + "/index.yaml")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		_ = resp.Body.Close()
+	}
+	assert.Equal(t, int32(1), tokenRequests.Load())
+}
+
+// TestAuthTransport_dockerConfig 测试使用docker config凭据应答token质询
+func TestAuthTransport_dockerConfig(t *testing.T) {
+	var tokenRequests atomic.Int32
+	server := newChallengeServer(t, &tokenRequests)
+	host := strings.TrimPrefix(server.URL, "http://")
+	transport, err := AuthTransport("docker", http.DefaultTransport, &Auth{Type: AuthDockerConfig,
+		DockerConfig: []byte(`{"auths":{"` + host + `":{"username":"robot","password":"secret"}}}`)})
+	assert.NoError(t, err)
+
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL + "/index.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	_ = resp.Body.Close()
+	assert.Equal(t, int32(1), tokenRequests.Load())
+}
+
+// TestAuthTransport_token 测试静态token
+func TestAuthTransport_token(t *testing.T) {
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get(authorizationHeader)
+	}))
+	defer server.Close()
+	transport, err := AuthTransport("token", http.DefaultTransport, &Auth{Type: AuthToken, Token: "static"})
+	assert.NoError(t, err)
+
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, "Bearer static", authorization)
+}
+
+// TestAuthTransport_oauth2 测试oauth2客户端凭据模式，token过期前复用
+func TestAuthTransport_oauth2(t *testing.T) {
+	var tokenRequests atomic.Int32
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			tokenRequests.Add(1)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"access_token":"oauth","token_type":"bearer","expires_in":300}`))
+			return
+		}
+		authorization = r.Header.Get(authorizationHeader)
+	}))
+	defer server.Close()
+	transport, err := AuthTransport("oauth2", http.DefaultTransport, &Auth{Type: AuthOAuth2, ClientID: "id",
+		ClientSecret: "secret", TokenURL: server.URL + "/token"})
+	assert.NoError(t, err)
+	client := &http.Client{Transport: transport}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL + "/index.yaml")
+		assert.NoError(t, err)
+		_ = resp.Body.Close()
+	}
+	assert.Equal(t, "Bearer oauth", authorization)
+	assert.Equal(t, int32(1), tokenRequests.Load())
+}
+
+// newRealmServer token realm issuing tokens to the robot credentials
+func newRealmServer(t *testing.T, tokenRequests *atomic.Int32) *httptest.Server {
+	realm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests.Add(1)
+		if username, password, ok := r.BasicAuth(); !ok || username != "robot" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"token":"issued"}`))
+	}))
+	t.Cleanup(realm.Close)
+	return realm
+}
+
+// TestAuthTransport_realmHost 测试仅向仓库主机或受信主机的realm发送凭据
+func TestAuthTransport_realmHost(t *testing.T) {
+	var tokenRequests atomic.Int32
+	realm := newRealmServer(t, &tokenRequests)
+	// the repository is reached on 127.0.0.1, the realm on localhost
+	realmURL := strings.Replace(realm.URL, "127.0.0.1", "localhost", 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(authorizationHeader) != "Bearer issued" {
+			w.Header().Set(authenticateHeader, fmt.Sprintf(`Bearer realm="%s/token"`, realmURL))
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+	auth := &Auth{Username: "robot", Password: "secret"}
+
+	untrusted, err := newAuthTransport("realm", http.DefaultTransport, auth, nil)
+	assert.NoError(t, err)
+	resp, err := (&http.Client{Transport: untrusted}).Get(server.URL + "/index.yaml")
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, int32(0), tokenRequests.Load())
+
+	trusted, err := newAuthTransport("realm", http.DefaultTransport, auth, []string{"localhost"})
+	assert.NoError(t, err)
+	resp, err = (&http.Client{Transport: trusted}).Get(server.URL + "/index.yaml")
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(1), tokenRequests.Load())
+}
+
+// TestAuthTransport_realmDowngrade 测试https仓库不向http realm发送凭据
+func TestAuthTransport_realmDowngrade(t *testing.T) {
+	var tokenRequests atomic.Int32
+	realm := newRealmServer(t, &tokenRequests)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(authenticateHeader, fmt.Sprintf(`Bearer realm="%s/token"`, realm.URL))
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+	transport, err := AuthTransport("downgrade", server.Client().Transport,
+		&Auth{Username: "robot", Password: "secret"})
+	assert.NoError(t, err)
+
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL + "/index.yaml")
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, int32(0), tokenRequests.Load())
+}
+
+// TestAuthTransport_scopes 测试不同scope的token分别缓存
+func TestAuthTransport_scopes(t *testing.T) {
+	var tokenRequests atomic.Int32
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			tokenRequests.Add(1)
+			_, _ = w.Write([]byte(`{"token":"` + r.URL.Query().Get("scope") + `"}`))
+			return
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v2/"), "/manifests/1.0.0")
+		scope := "repository:" + name + ":pull"
+		if r.Header.Get(authorizationHeader) != "Bearer "+scope {
+			w.Header().Set(authenticateHeader, fmt.Sprintf(`Bearer realm="%s/token",scope="%s"`, server.URL, scope))
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+	transport, err := AuthTransport("scopes", http.DefaultTransport, &Auth{Username: "robot", Password: "secret"})
+	assert.NoError(t, err)
+	client := &http.Client{Transport: transport}
+
+	for _, name := range []string{"charts/nginx", "charts/redis", "charts/nginx", "charts/redis"} {
+		resp, err := client.Get(server.URL + "/v2/" + name + "/manifests/1.0.0")
+		assert.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	assert.Equal(t, int32(2), tokenRequests.Load())
+}
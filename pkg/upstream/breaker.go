@@ -0,0 +1,175 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package upstream
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// circuit breaker states of a repository
+const (
+	// StateClosed requests pass through
+	StateClosed = "closed"
+	// StateOpen the repository is unhealthy, requests fail fast until the open duration elapses
+	StateOpen = "open"
+	// StateHalfOpen a single probe request decides whether the circuit closes or opens again
+	StateHalfOpen = "half-open"
+)
+
+// OpenError a request rejected because the circuit of the repository is open
+type OpenError struct {
+	Repo     string
+	Failures int
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("repository %s is unhealthy after %d consecutive failures, request suspended",
+		e.Repo, e.Failures)
+}
+
+// IsCircuitOpen whether err is caused by an open circuit
+func IsCircuitOpen(err error) bool {
+	var open *OpenError
+	return errors.As(err, &open)
+}
+
+// Health circuit breaker state of a repository
+type Health struct {
+	State               string
+	ConsecutiveFailures int
+	LastError           string
+	LastFailure         time.Time
+	// RetryAt time the open circuit lets the next probe through
+	RetryAt time.Time
+}
+
+// breaker counts consecutive failed requests of a repository, threshold <= 0 disables it
+type breaker struct {
+	mu           sync.Mutex
+	repo         string
+	threshold    int
+	openDuration time.Duration
+	now          func() time.Time
+	onChange     func(repo, state string)
+
+	state       string
+	failures    int
+	lastError   string
+	lastFailure time.Time
+	openedAt    time.Time
+	// probing a half-open probe is in flight
+	probing bool
+}
+
+func newBreaker(repo string, threshold int, openDuration time.Duration) *breaker {
+	return &breaker{
+		repo:         repo,
+		threshold:    threshold,
+		openDuration: openDuration,
+		now:          time.Now,
+		state:        StateClosed,
+	}
+}
+
+// allow whether a request may be sent, the first request after the open duration becomes the probe
+func (b *breaker) allow() error {
+	if b.threshold <= 0 {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case StateOpen:
+		if b.now().Before(b.openedAt.Add(b.openDuration)) {
+			return &OpenError{Repo: b.repo, Failures: b.failures}
+		}
+		b.setState(StateHalfOpen)
+		b.probing = true
+		return nil
+	case StateHalfOpen:
+		if b.probing {
+			return &OpenError{Repo: b.repo, Failures: b.failures}
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// success close the circuit
+func (b *breaker) success() {
+	if b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.probing = false
+	b.setState(StateClosed)
+}
+
+// failure count a failed request, a failed probe opens the circuit again
+func (b *breaker) failure(err error) {
+	if b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.lastError = err.Error()
+	b.lastFailure = b.now()
+	b.probing = false
+	if b.state == StateHalfOpen || b.failures >= b.threshold {
+		b.openedAt = b.lastFailure
+		b.setState(StateOpen)
+	}
+}
+
+// release end a request which tells nothing about the repository health, e.g. cancelled by the caller
+func (b *breaker) release() {
+	if b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+func (b *breaker) setState(state string) {
+	if b.state == state {
+		return
+	}
+	b.state = state
+	if b.onChange != nil {
+		b.onChange(b.repo, state)
+	}
+}
+
+func (b *breaker) health() Health {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	h := Health{
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		LastError:           b.lastError,
+		LastFailure:         b.lastFailure,
+	}
+	if b.state == StateOpen {
+		h.RetryAt = b.openedAt.Add(b.openDuration)
+	}
+	return h
+}
@@ -0,0 +1,69 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package upstream
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBreaker 测试熔断器的打开、半开探测与恢复
+func TestBreaker(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newBreaker("repo", 2, time.Minute)
+	b.now = func() time.Time { return now }
+	var states []string
+	b.onChange = func(_, state string) { states = append(states, state) }
+
+	assert.NoError(t, b.allow())
+	b.failure(errors.New("connection refused"))
+	assert.NoError(t, b.allow())
+	b.failure(errors.New("connection refused"))
+
+	err := b.allow()
+	assert.True(t, IsCircuitOpen(err))
+	health := b.health()
+	assert.Equal(t, StateOpen, health.State)
+	assert.Equal(t, 2, health.ConsecutiveFailures)
+	assert.Equal(t, "connection refused", health.LastError)
+	assert.Equal(t, now.Add(time.Minute), health.RetryAt)
+
+	now = now.Add(time.Minute)
+	assert.NoError(t, b.allow(), "probe after the open duration")
+	assert.True(t, IsCircuitOpen(b.allow()), "single probe in half-open state")
+	b.failure(errors.New("status 503"))
+	assert.True(t, IsCircuitOpen(b.allow()), "failed probe opens the circuit again")
+
+	now = now.Add(time.Minute)
+	assert.NoError(t, b.allow())
+	b.release()
+	assert.NoError(t, b.allow(), "released probe lets the next request probe")
+	b.success()
+	assert.NoError(t, b.allow())
+	assert.Equal(t, Health{State: StateClosed, LastError: "status 503", LastFailure: now.Add(-time.Minute)},
+		b.health())
+	assert.Equal(t, []string{StateOpen, StateHalfOpen, StateOpen, StateHalfOpen, StateClosed}, states)
+}
+
+// TestBreaker_disabled 测试阈值为0时不熔断
+func TestBreaker_disabled(t *testing.T) {
+	b := newBreaker("repo", 0, time.Minute)
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, b.allow())
+		b.failure(errors.New("connection refused"))
+	}
+	assert.Equal(t, StateClosed, b.health().State)
+}
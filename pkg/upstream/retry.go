@@ -0,0 +1,135 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package upstream
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"marketplace-service/pkg/egress"
+	"marketplace-service/pkg/zlog"
+)
+
+// drainLimit bytes of a discarded response read so the connection can be reused
+const drainLimit = 4096
+
+// retryTransport retries idempotent requests on network errors and 5xx responses with exponential backoff,
+// the outcome of each request is recorded by the circuit breaker of the repository
+type retryTransport struct {
+	base       http.RoundTripper
+	breaker    *breaker
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.breaker.allow(); err != nil {
+		return nil, err
+	}
+	ctx := req.Context()
+	var resp *http.Response
+	var err error
+	for attempt := 0; ; attempt++ {
+		resp, err = t.base.RoundTrip(req)
+		if attempt >= t.maxRetries || !replayable(req) || !retryable(ctx, resp, err) {
+			break
+		}
+		zlog.FromContext(ctx).Debugf("retry %s %s of repository %s after attempt %d: %v",
+			req.Method, req.URL.Redacted(), t.breaker.repo, attempt+1, describe(resp, err))
+		discard(resp)
+		if waitErr := sleep(ctx, t.backoff(attempt)); waitErr != nil {
+			resp, err = nil, waitErr
+			break
+		}
+	}
+	t.record(ctx, resp, err)
+	return resp, err
+}
+
+func (t *retryTransport) record(ctx context.Context, resp *http.Response, err error) {
+	switch {
+	case err != nil && (ctx.Err() != nil || egress.IsViolation(err)):
+		t.breaker.release()
+	case err != nil:
+		t.breaker.failure(err)
+	case resp.StatusCode >= http.StatusInternalServerError:
+		t.breaker.failure(fmt.Errorf("received status code %d", resp.StatusCode))
+	default:
+		t.breaker.success()
+	}
+}
+
+// backoff delay before the next attempt, doubled per attempt with jitter and capped at maxDelay
+func (t *retryTransport) backoff(attempt int) time.Duration {
+	delay := t.baseDelay << attempt
+	if delay <= 0 || delay > t.maxDelay {
+		delay = t.maxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// replayable whether the request can be sent again, only idempotent requests without body are retried
+func replayable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return req.Body == nil || req.Body == http.NoBody
+	default:
+		return false
+	}
+}
+
+func retryable(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return !egress.IsViolation(err)
+	}
+	return resp.StatusCode >= http.StatusInternalServerError
+}
+
+func describe(resp *http.Response, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return resp.Status
+}
+
+func discard(resp *http.Response) {
+	if resp == nil || resp.Body == nil {
+		return
+	}
+	_, _ = io.CopyN(io.Discard, resp.Body, drainLimit)
+	_ = resp.Body.Close()
+}
+
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
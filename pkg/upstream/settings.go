@@ -0,0 +1,163 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package upstream
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/http/httpguts"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// reservedHeaders headers managed by the http client, they cannot be set per repository
+var reservedHeaders = map[string]bool{
+	"Host":              true,
+	"Connection":        true,
+	"Content-Length":    true,
+	"Transfer-Encoding": true,
+	"Upgrade":           true,
+	"Te":                true,
+	"Trailer":           true,
+}
+
+// Settings connection settings of a repository
+type Settings struct {
+	// TLSKey identifies the tls settings returned by TLSConfig
+	TLSKey    string
+	TLSConfig func() (*tls.Config, error)
+
+	// ProxyURL http or https proxy, empty to connect directly
+	ProxyURL string
+	// NoProxy hosts, domains and cidrs reached directly, in the format of NO_PROXY
+	NoProxy []string
+
+	// Headers added to requests which do not set them already
+	Headers map[string]string
+
+	// Auth credentials of the repository, nil for none
+	Auth *Auth
+}
+
+// fingerprint identifies the settings, the client of a repository is rebuilt when it changes
+func (s Settings) fingerprint() string {
+	hash := sha256.New()
+	write := func(part string) {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	write(s.TLSKey)
+	write(s.ProxyURL)
+	write(strings.Join(s.NoProxy, ","))
+	names := make([]string, 0, len(s.Headers))
+	for name := range s.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		write(name)
+		write(s.Headers[name])
+	}
+	write(s.Auth.fingerprint())
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// ValidateProxy whether proxyURL is an http or https proxy url without credentials
+func ValidateProxy(proxyURL string) error {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return fmt.Errorf("proxy url is malformed")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("proxy scheme %q is not supported, use http or https", u.Scheme)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("proxy host is missing")
+	}
+	if u.User != nil {
+		return fmt.Errorf("proxy credentials in the url are not supported")
+	}
+	return nil
+}
+
+// ValidateHeaders whether headers are valid request headers which may be set per repository
+func ValidateHeaders(headers map[string]string) error {
+	for name, value := range headers {
+		if !httpguts.ValidHeaderFieldName(name) {
+			return fmt.Errorf("invalid header name %q", name)
+		}
+		if reservedHeaders[http.CanonicalHeaderKey(name)] {
+			return fmt.Errorf("header %s cannot be set", name)
+		}
+		if !httpguts.ValidHeaderFieldValue(value) {
+			return fmt.Errorf("invalid value of header %s", name)
+		}
+	}
+	return nil
+}
+
+// Proxy proxy function of a transport, nil when proxyURL is empty
+func Proxy(proxyURL string, noProxy []string) (func(*http.Request) (*url.URL, error), error) {
+	if proxyURL == "" {
+		return nil, nil
+	}
+	if err := ValidateProxy(proxyURL); err != nil {
+		return nil, err
+	}
+	proxyFunc := (&httpproxy.Config{
+		HTTPProxy:  proxyURL,
+		HTTPSProxy: proxyURL,
+		NoProxy:    strings.Join(noProxy, ","),
+	}).ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}, nil
+}
+
+// HeaderTransport add headers to the requests sent by base which do not set them already
+func HeaderTransport(base http.RoundTripper, headers map[string]string) http.RoundTripper {
+	if len(headers) == 0 {
+		return base
+	}
+	return &headerTransport{base: base, headers: headers}
+}
+
+type headerTransport struct {
+	base    http.RoundTripper
+	headers map[string]string
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var clone *http.Request
+	for name, value := range t.headers {
+		if req.Header.Get(name) != "" {
+			continue
+		}
+		if clone == nil {
+			// a RoundTripper must not modify the request
+			clone = req.Clone(req.Context())
+		}
+		clone.Header.Set(name, value)
+	}
+	if clone == nil {
+		return t.base.RoundTrip(req)
+	}
+	return t.base.RoundTrip(clone)
+}
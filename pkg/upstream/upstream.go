@@ -0,0 +1,262 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+// Package upstream shares http clients of chart repositories. Each repository gets a pooled transport with
+// request and idle timeouts, retries with exponential backoff and a circuit breaker which marks the
+// repository unhealthy after repeated failures
+package upstream
+
+import (
+	"crypto/tls"
+	"net/http"
+	"sync"
+	"time"
+
+	"marketplace-service/pkg/egress"
+	"marketplace-service/pkg/metrics"
+	"marketplace-service/pkg/tracing"
+)
+
+// default options of repository clients
+const (
+	DefaultRequestTimeout        = 120 * time.Second
+	DefaultResponseHeaderTimeout = 30 * time.Second
+	DefaultTLSHandshakeTimeout   = 30 * time.Second
+	DefaultIdleConnTimeout       = 90 * time.Second
+	DefaultMaxIdleConnsPerHost   = 4
+	DefaultMaxRetries            = 3
+	DefaultRetryBaseDelay        = 500 * time.Millisecond
+	DefaultRetryMaxDelay         = 10 * time.Second
+	DefaultFailureThreshold      = 5
+	DefaultOpenDuration          = time.Minute
+)
+
+// Options of repository clients
+type Options struct {
+	// RequestTimeout overall time of a request including retries and reading the body
+	RequestTimeout time.Duration
+	// ResponseHeaderTimeout time to wait for the response headers of a single attempt
+	ResponseHeaderTimeout time.Duration
+	TLSHandshakeTimeout   time.Duration
+	// IdleConnTimeout time an idle pooled connection is kept open
+	IdleConnTimeout     time.Duration
+	MaxIdleConnsPerHost int
+
+	// MaxRetries retries of idempotent requests on network errors and 5xx responses, 0 disables retries
+	MaxRetries     int
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+
+	// FailureThreshold consecutive failed requests opening the circuit of a repository, 0 disables the breaker
+	FailureThreshold int
+	// OpenDuration time requests to an unhealthy repository fail fast before a probe is let through
+	OpenDuration time.Duration
+
+	// TokenRealmHosts hosts trusted to issue tokens for repositories on other hosts, e.g. auth.docker.io,
+	// credentials are only sent to token realms on the host of the repository otherwise
+	TokenRealmHosts []string
+}
+
+// DefaultOptions default options of repository clients
+func DefaultOptions() Options {
+	return Options{
+		RequestTimeout:        DefaultRequestTimeout,
+		ResponseHeaderTimeout: DefaultResponseHeaderTimeout,
+		TLSHandshakeTimeout:   DefaultTLSHandshakeTimeout,
+		IdleConnTimeout:       DefaultIdleConnTimeout,
+		MaxIdleConnsPerHost:   DefaultMaxIdleConnsPerHost,
+		MaxRetries:            DefaultMaxRetries,
+		RetryBaseDelay:        DefaultRetryBaseDelay,
+		RetryMaxDelay:         DefaultRetryMaxDelay,
+		FailureThreshold:      DefaultFailureThreshold,
+		OpenDuration:          DefaultOpenDuration,
+	}
+}
+
+// repoClient client of a repository, rebuilt when the connection settings of the repository change
+type repoClient struct {
+	key       string
+	client    *http.Client
+	transport *http.Transport
+}
+
+// pool clients and breakers by repository name, breakers outlive clients so a changed certificate does not
+// reset the health of the repository
+type pool struct {
+	mu       sync.Mutex
+	options  Options
+	clients  map[string]*repoClient
+	breakers map[string]*breaker
+}
+
+var shared = newPool(DefaultOptions())
+
+func newPool(options Options) *pool {
+	return &pool{
+		options:  options,
+		clients:  make(map[string]*repoClient),
+		breakers: make(map[string]*breaker),
+	}
+}
+
+// SetOptions replace the options of repository clients, existing clients and breakers are dropped
+func SetOptions(options Options) {
+	shared.setOptions(options)
+}
+
+// Client shared client of the repository, rebuilt when the settings of the repository change
+func Client(repo string, settings Settings) (*http.Client, error) {
+	return shared.client(repo, settings)
+}
+
+// NewClient client of the repository that is not shared, without retries and circuit breaker, for one off
+// requests such as connectivity tests
+func NewClient(repo string, settings Settings) (*http.Client, error) {
+	options := shared.currentOptions()
+	options.MaxRetries = 0
+	options.FailureThreshold = 0
+	c, err := newPool(options).newClient(repo, settings.fingerprint(), settings)
+	if err != nil {
+		return nil, err
+	}
+	return c.client, nil
+}
+
+// Forget drop the client and breaker of a deleted repository, its metrics are dropped by metrics.DeleteRepo
+func Forget(repo string) {
+	shared.forget(repo)
+}
+
+// RepoHealth circuit breaker state of the repository, false if no request has been sent to it yet or the
+// breaker is disabled
+func RepoHealth(repo string) (Health, bool) {
+	return shared.health(repo)
+}
+
+func (p *pool) setOptions(options Options) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, c := range p.clients {
+		c.transport.CloseIdleConnections()
+	}
+	p.options = options
+	p.clients = make(map[string]*repoClient)
+	p.breakers = make(map[string]*breaker)
+}
+
+func (p *pool) currentOptions() Options {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.options
+}
+
+func (p *pool) client(repo string, settings Settings) (*http.Client, error) {
+	key := settings.fingerprint()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if c, ok := p.clients[repo]; ok && c.key == key {
+		return c.client, nil
+	}
+	c, err := p.newClient(repo, key, settings)
+	if err != nil {
+		return nil, err
+	}
+	if old, ok := p.clients[repo]; ok {
+		old.transport.CloseIdleConnections()
+	}
+	p.clients[repo] = c
+	return c.client, nil
+}
+
+func (p *pool) newClient(repo, key string, settings Settings) (*repoClient, error) {
+	var config *tls.Config
+	if settings.TLSConfig != nil {
+		var err error
+		if config, err = settings.TLSConfig(); err != nil {
+			return nil, err
+		}
+	}
+	proxy, err := Proxy(settings.ProxyURL, settings.NoProxy)
+	if err != nil {
+		return nil, err
+	}
+	transport := egress.Transport(&http.Transport{
+		Proxy:                 proxy,
+		TLSClientConfig:       config,
+		TLSHandshakeTimeout:   p.options.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: p.options.ResponseHeaderTimeout,
+		IdleConnTimeout:       p.options.IdleConnTimeout,
+		MaxIdleConnsPerHost:   p.options.MaxIdleConnsPerHost,
+	})
+	authenticated, err := newAuthTransport(repo, transport, settings.Auth, p.options.TokenRealmHosts)
+	if err != nil {
+		return nil, err
+	}
+	return &repoClient{
+		key:       key,
+		transport: transport,
+		client: &http.Client{
+			Timeout: p.options.RequestTimeout,
+			Transport: tracing.NewTransport(&retryTransport{
+				base:       HeaderTransport(authenticated, settings.Headers),
+				breaker:    p.breaker(repo),
+				maxRetries: p.options.MaxRetries,
+				baseDelay:  p.options.RetryBaseDelay,
+				maxDelay:   p.options.RetryMaxDelay,
+			}),
+		},
+	}, nil
+}
+
+func (p *pool) breaker(repo string) *breaker {
+	if b, ok := p.breakers[repo]; ok {
+		return b
+	}
+	b := newBreaker(repo, p.options.FailureThreshold, p.options.OpenDuration)
+	if b.threshold > 0 {
+		b.onChange = observeState
+		observeState(repo, StateClosed)
+	}
+	p.breakers[repo] = b
+	return b
+}
+
+func (p *pool) forget(repo string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if c, ok := p.clients[repo]; ok {
+		c.transport.CloseIdleConnections()
+	}
+	delete(p.clients, repo)
+	delete(p.breakers, repo)
+}
+
+func (p *pool) health(repo string) (Health, bool) {
+	p.mu.Lock()
+	b, ok := p.breakers[repo]
+	p.mu.Unlock()
+	if !ok || b.threshold <= 0 {
+		return Health{}, false
+	}
+	return b.health(), true
+}
+
+func observeState(repo, state string) {
+	switch state {
+	case StateOpen:
+		metrics.SetRepoCircuitState(repo, metrics.CircuitOpen)
+	case StateHalfOpen:
+		metrics.SetRepoCircuitState(repo, metrics.CircuitHalfOpen)
+	default:
+		metrics.SetRepoCircuitState(repo, metrics.CircuitClosed)
+	}
+}
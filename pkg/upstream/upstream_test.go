@@ -0,0 +1,142 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package upstream
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"marketplace-service/pkg/egress"
+)
+
+func init() {
+	// the test repositories are httptest servers on loopback, blocked by the default egress policy
+	policy, err := egress.NewPolicy(egress.DefaultSchemes, []string{"127.0.0.0/8", "::1/128"}, nil)
+	if err != nil {
+		panic(err)
+	}
+	egress.SetPolicy(policy)
+}
+
+func testPool() *pool {
+	options := DefaultOptions()
+	options.RetryBaseDelay = time.Millisecond
+	options.RetryMaxDelay = time.Millisecond
+	options.FailureThreshold = 2
+	return newPool(options)
+}
+
+// TestClient_retry 测试5xx响应重试后成功
+func TestClient_retry(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	p := testPool()
+	client, err := p.client("retry", Settings{})
+	assert.NoError(t, err)
+	resp, err := client.Get(ts.URL)
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(3), calls.Load())
+	health, ok := p.health("retry")
+	assert.True(t, ok)
+	assert.Equal(t, StateClosed, health.State)
+}
+
+// TestClient_circuitOpen 测试连续失败后熔断, 请求不再发送
+func TestClient_circuitOpen(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	p := testPool()
+	client, err := p.client("broken", Settings{})
+	assert.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(ts.URL)
+		assert.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	}
+	assert.Equal(t, int32(2*(DefaultMaxRetries+1)), calls.Load())
+
+	_, err = client.Get(ts.URL)
+	assert.True(t, IsCircuitOpen(err))
+	assert.Equal(t, int32(2*(DefaultMaxRetries+1)), calls.Load())
+	health, _ := p.health("broken")
+	assert.Equal(t, StateOpen, health.State)
+	assert.Equal(t, "received status code 503", health.LastError)
+}
+
+// TestClient_noRetry 测试非幂等请求与4xx响应不重试
+func TestClient_noRetry(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.Method == http.MethodGet {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	p := testPool()
+	client, err := p.client("no-retry", Settings{})
+	assert.NoError(t, err)
+	resp, err := client.Post(ts.URL, "text/plain", strings.NewReader("chart"))
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, int32(1), calls.Load())
+	resp, err = client.Get(ts.URL)
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, int32(2), calls.Load())
+	health, _ := p.health("no-retry")
+	assert.Equal(t, 0, health.ConsecutiveFailures, "4xx resets the failure count")
+}
+
+// TestClient_shared 测试客户端按仓库复用, 连接配置变化时重建
+func TestClient_shared(t *testing.T) {
+	p := testPool()
+	first, err := p.client("repo", Settings{TLSKey: "key"})
+	assert.NoError(t, err)
+	second, err := p.client("repo", Settings{TLSKey: "key"})
+	assert.NoError(t, err)
+	assert.Same(t, first, second)
+	rebuilt, err := p.client("repo", Settings{TLSKey: "changed"})
+	assert.NoError(t, err)
+	assert.NotSame(t, first, rebuilt)
+	assert.Equal(t, DefaultRequestTimeout, rebuilt.Timeout)
+
+	p.forget("repo")
+	_, ok := p.health("repo")
+	assert.False(t, ok)
+}
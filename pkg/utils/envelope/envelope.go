@@ -0,0 +1,257 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+// Package envelope encrypts secret data with a per secret data key wrapped by the service symmetric key,
+// so rotating the symmetric key only rewraps the data keys
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// annotations of encrypted secrets
+const (
+	// AnnotationEncryption encryption scheme of the secret data, absent for plaintext secrets
+	AnnotationEncryption = "marketplace.openfuyao.com/encryption"
+	// AnnotationDataKey data key wrapped by the symmetric key, base64 encoded
+	AnnotationDataKey = "marketplace.openfuyao.com/encrypted-data-key"
+	// AnnotationKeyID id of the symmetric key wrapping the data key
+	AnnotationKeyID = "marketplace.openfuyao.com/key-id"
+
+	// SchemeAESGCM data and data key sealed with AES-256-GCM, the nonce prefixes the ciphertext
+	SchemeAESGCM = "aes-256-gcm"
+
+	keySize   = 32
+	keyIDSize = 8
+
+	// sealedValuePrefix prefix of single values sealed by SealValue
+	sealedValuePrefix = "enc:" + SchemeAESGCM + ":"
+	sealedValueParts  = 3
+)
+
+// ErrUnknownKey the data key is wrapped by a symmetric key the keyring does not hold
+var ErrUnknownKey = errors.New("data key wrapped by unknown symmetric key")
+
+// Keyring current symmetric key sealing new secrets and previous ones still opening existing secrets
+type Keyring struct {
+	current string
+	keys    map[string][]byte
+}
+
+// NewKeyring keyring of the current symmetric key and the previous ones during a rotation, keys of any
+// length are stretched to 256 bits. An empty current key disables encryption
+func NewKeyring(current []byte, previous ...[]byte) *Keyring {
+	keyring := &Keyring{keys: make(map[string][]byte)}
+	for _, key := range previous {
+		if len(key) > 0 {
+			keyring.add(key)
+		}
+	}
+	if len(current) > 0 {
+		keyring.current = keyring.add(current)
+	}
+	return keyring
+}
+
+func (k *Keyring) add(key []byte) string {
+	derived := sha256.Sum256(key)
+	id := sha256.Sum256(derived[:])
+	keyID := hex.EncodeToString(id[:keyIDSize])
+	k.keys[keyID] = derived[:]
+	return keyID
+}
+
+// Enabled whether a current symmetric key is configured
+func (k *Keyring) Enabled() bool {
+	return k.current != ""
+}
+
+// IsEncrypted whether a secret with the annotations holds sealed data
+func IsEncrypted(annotations map[string]string) bool {
+	return annotations[AnnotationEncryption] != ""
+}
+
+// Seal encrypt data with a new data key, the returned annotations carry the wrapped data key and are
+// required to open it. Without a current key data is returned as is
+func (k *Keyring) Seal(data map[string][]byte) (map[string][]byte, map[string]string, error) {
+	if !k.Enabled() {
+		return data, nil, nil
+	}
+	dataKey := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, nil, err
+	}
+	sealed := make(map[string][]byte, len(data))
+	for field, value := range data {
+		ciphertext, err := seal(dataKey, value, []byte(field))
+		if err != nil {
+			return nil, nil, err
+		}
+		sealed[field] = ciphertext
+	}
+	annotations, err := k.wrap(dataKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sealed, annotations, nil
+}
+
+// Open decrypt data sealed by Seal, data of plaintext secrets is returned as is so secrets written before
+// encryption was enabled stay readable
+func (k *Keyring) Open(data map[string][]byte, annotations map[string]string) (map[string][]byte, error) {
+	if !IsEncrypted(annotations) {
+		return data, nil
+	}
+	dataKey, err := k.unwrap(annotations)
+	if err != nil {
+		return nil, err
+	}
+	opened := make(map[string][]byte, len(data))
+	for field, value := range data {
+		plaintext, err := open(dataKey, value, []byte(field))
+		if err != nil {
+			return nil, fmt.Errorf("decrypt field %s failed, %w", field, err)
+		}
+		opened[field] = plaintext
+	}
+	return opened, nil
+}
+
+// Rewrap wrap the data key of a sealed secret with the current symmetric key, false when it is wrapped
+// by the current key already
+func (k *Keyring) Rewrap(annotations map[string]string) (map[string]string, bool, error) {
+	if !k.Enabled() || annotations[AnnotationKeyID] == k.current {
+		return annotations, false, nil
+	}
+	dataKey, err := k.unwrap(annotations)
+	if err != nil {
+		return nil, false, err
+	}
+	wrapped, err := k.wrap(dataKey)
+	if err != nil {
+		return nil, false, err
+	}
+	return wrapped, true, nil
+}
+
+// SealValue encrypt a single value into a self contained string carrying the key id and the wrapped data
+// key, for values kept outside secrets. The field binds the value to where it belongs
+func (k *Keyring) SealValue(field, value string) (string, error) {
+	if !k.Enabled() {
+		return "", errors.New("no symmetric key configured")
+	}
+	sealed, annotations, err := k.Seal(map[string][]byte{field: []byte(value)})
+	if err != nil {
+		return "", err
+	}
+	return sealedValuePrefix + annotations[AnnotationKeyID] + ":" + annotations[AnnotationDataKey] + ":" +
+		base64.StdEncoding.EncodeToString(sealed[field]), nil
+}
+
+// OpenValue decrypt a value sealed by SealValue for the same field, plain values are returned as is
+func (k *Keyring) OpenValue(field, value string) (string, error) {
+	if !IsSealedValue(value) {
+		return value, nil
+	}
+	parts := strings.Split(strings.TrimPrefix(value, sealedValuePrefix), ":")
+	if len(parts) != sealedValueParts {
+		return "", errors.New("malformed sealed value")
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", err
+	}
+	opened, err := k.Open(map[string][]byte{field: ciphertext}, map[string]string{
+		AnnotationEncryption: SchemeAESGCM,
+		AnnotationKeyID:      parts[0],
+		AnnotationDataKey:    parts[1],
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(opened[field]), nil
+}
+
+// IsSealedValue whether the value was sealed by SealValue
+func IsSealedValue(value string) bool {
+	return strings.HasPrefix(value, sealedValuePrefix)
+}
+
+func (k *Keyring) wrap(dataKey []byte) (map[string]string, error) {
+	wrapped, err := seal(k.keys[k.current], dataKey, []byte(k.current))
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		AnnotationEncryption: SchemeAESGCM,
+		AnnotationDataKey:    base64.StdEncoding.EncodeToString(wrapped),
+		AnnotationKeyID:      k.current,
+	}, nil
+}
+
+func (k *Keyring) unwrap(annotations map[string]string) ([]byte, error) {
+	if scheme := annotations[AnnotationEncryption]; scheme != SchemeAESGCM {
+		return nil, fmt.Errorf("unsupported encryption scheme %s", scheme)
+	}
+	keyID := annotations[AnnotationKeyID]
+	key, exist := k.keys[keyID]
+	if !exist {
+		return nil, fmt.Errorf("%w %s", ErrUnknownKey, keyID)
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(annotations[AnnotationDataKey])
+	if err != nil {
+		return nil, err
+	}
+	return open(key, wrapped, []byte(keyID))
+}
+
+// seal AES-GCM encrypt plaintext, additional data binds the ciphertext to its field or key id
+func seal(key, plaintext, additionalData []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(key, ciphertext, additionalData []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, additionalData)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
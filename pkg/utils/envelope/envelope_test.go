@@ -0,0 +1,101 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package envelope
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSealOpen 测试凭据加解密
+func TestSealOpen(t *testing.T) {
+	keyring := NewKeyring([]byte("current-key"))
+	data := map[string][]byte{"username": []byte("admin"), "password": []byte("s3cret")}
+
+	sealed, annotations, err := keyring.Seal(data)
+	assert.NoError(t, err)
+	assert.True(t, IsEncrypted(annotations))
+	assert.False(t, bytes.Contains(sealed["password"], []byte("s3cret")))
+
+	opened, err := keyring.Open(sealed, annotations)
+	assert.NoError(t, err)
+	assert.Equal(t, data, opened)
+
+	// a field moved to another key fails authentication
+	sealed["username"], sealed["password"] = sealed["password"], sealed["username"]
+	_, err = keyring.Open(sealed, annotations)
+	assert.Error(t, err)
+}
+
+// TestOpenPlaintext 测试未加密的凭据可直接读取
+func TestOpenPlaintext(t *testing.T) {
+	data := map[string][]byte{"password": []byte("s3cret")}
+	opened, err := NewKeyring([]byte("current-key")).Open(data, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, data, opened)
+
+	sealed, annotations, err := NewKeyring(nil).Seal(data)
+	assert.NoError(t, err)
+	assert.Nil(t, annotations)
+	assert.Equal(t, data, sealed)
+}
+
+// TestRewrap 测试密钥轮换
+func TestRewrap(t *testing.T) {
+	data := map[string][]byte{"tls.key": []byte("private")}
+	sealed, annotations, err := NewKeyring([]byte("old-key")).Seal(data)
+	assert.NoError(t, err)
+
+	_, err = NewKeyring([]byte("new-key")).Open(sealed, annotations)
+	assert.True(t, errors.Is(err, ErrUnknownKey))
+
+	rotating := NewKeyring([]byte("new-key"), []byte("old-key"))
+	rewrapped, changed, err := rotating.Rewrap(annotations)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	_, changed, err = rotating.Rewrap(rewrapped)
+	assert.NoError(t, err)
+	assert.False(t, changed)
+
+	opened, err := NewKeyring([]byte("new-key")).Open(sealed, rewrapped)
+	assert.NoError(t, err)
+	assert.Equal(t, data, opened)
+}
+
+// TestSealValue 测试单值加解密
+func TestSealValue(t *testing.T) {
+	keyring := NewKeyring([]byte("current-key"))
+	sealed, err := keyring.SealValue("repo/password", "s3cret")
+	assert.NoError(t, err)
+	assert.True(t, IsSealedValue(sealed))
+	assert.NotContains(t, sealed, "s3cret")
+
+	opened, err := NewKeyring([]byte("new-key"), []byte("current-key")).OpenValue("repo/password", sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cret", opened)
+
+	_, err = keyring.OpenValue("other/password", sealed)
+	assert.Error(t, err)
+	_, err = keyring.OpenValue("repo/password", sealedValuePrefix+"broken")
+	assert.Error(t, err)
+
+	plain, err := keyring.OpenValue("repo/password", "s3cret")
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cret", plain)
+
+	_, err = NewKeyring(nil).SealValue("repo/password", "s3cret")
+	assert.Error(t, err)
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package httputil
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/emicklei/go-restful/v3"
+	"sigs.k8s.io/yaml"
+
+	"marketplace-service/pkg/utils/redact"
+)
+
+// MimeYAML content type of yaml entities, e.g. an exported repositories.yaml
+const MimeYAML = "application/x-yaml"
+
+// yamlAccessor yaml entity accessor for the error responses of routes producing yaml, entities are marshaled
+// as json first so that Scrubbed applies
+type yamlAccessor struct{}
+
+// NewYAMLAccessor yaml entity accessor
+func NewYAMLAccessor() restful.EntityReaderWriter {
+	return yamlAccessor{}
+}
+
+// Read the yaml body into v
+func (yamlAccessor) Read(req *restful.Request, v interface{}) error {
+	data, err := io.ReadAll(req.Request.Body)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(data, v)
+}
+
+// Write the yaml of v
+func (yamlAccessor) Write(resp *restful.Response, status int, v interface{}) error {
+	if v == nil {
+		resp.WriteHeader(status)
+		return nil
+	}
+	output, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if output, err = yaml.JSONToYAML(output); err != nil {
+		return err
+	}
+	resp.Header().Set(restful.HEADER_ContentType, MimeYAML)
+	resp.WriteHeader(status)
+	_, err = resp.Write(output)
+	return err
+}
+
+// Scrubbed entity written with the credentials it carries masked by Scrub, for responses of repositories and
+// audit records. Other responses, e.g. chart values with default passwords, are written as they are
+type Scrubbed struct {
+	Value any
+}
+
+// MarshalJSON scrubbed json of the wrapped value
+func (s Scrubbed) MarshalJSON() ([]byte, error) {
+	return Scrub(s.Value)
+}
+
+// Scrub json of v with values of sensitive keys masked and credentials in strings redacted
+func Scrub(v interface{}) ([]byte, error) {
+	output, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if !redact.MayContainSecret(string(output)) {
+		return output, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(output))
+	// numbers are kept as written instead of converted to float
+	decoder.UseNumber()
+	var decoded interface{}
+	if err = decoder.Decode(&decoded); err != nil {
+		return nil, err
+	}
+	return json.Marshal(redact.Value(decoded))
+}
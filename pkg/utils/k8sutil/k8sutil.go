@@ -37,137 +37,140 @@ const (
 )
 
 // GetSecret looks up secret by its name and namespace
-func GetSecret(clientset kubernetes.Interface, secretName, namespace string) (*v1.Secret, error) {
+func GetSecret(ctx context.Context, clientset kubernetes.Interface, secretName, namespace string) (*v1.Secret, error) {
 	secret, err := clientset.CoreV1().Secrets(namespace).
-		Get(context.Background(), secretName, metav1.GetOptions{})
+		Get(ctx, secretName, metav1.GetOptions{})
 	if err != nil {
-		zlog.Errorf("Secret %s lookup failed, err: %v", secretName, err)
+		zlog.FromContext(ctx).Errorf("Secret %s lookup failed, err: %v", secretName, err)
 		return nil, err
 	}
-	zlog.Debugf("Secret %s found in namespace %s", secret.Name, secret.Namespace)
+	zlog.FromContext(ctx).Debugf("Secret %s found in namespace %s", secret.Name, secret.Namespace)
 	return secret, nil
 }
 
 // CreateSecret create secret
-func CreateSecret(clientset kubernetes.Interface, secret *v1.Secret) (*v1.Secret, error) {
+func CreateSecret(ctx context.Context, clientset kubernetes.Interface, secret *v1.Secret) (*v1.Secret, error) {
 	if secret == nil {
-		zlog.Errorf("Secret object is nil when creating")
+		zlog.FromContext(ctx).Errorf("Secret object is nil when creating")
 		return nil, fmt.Errorf("secret object cannot be nil")
 	}
 	secretName := secret.Name
 	secret, err := clientset.CoreV1().Secrets(secret.Namespace).
-		Create(context.Background(), secret, metav1.CreateOptions{})
+		Create(ctx, secret, metav1.CreateOptions{})
 	if err != nil {
-		zlog.Debugf("Failed to create secret %s, err: %v", secretName, err)
+		zlog.FromContext(ctx).Debugf("Failed to create secret %s, err: %v", secretName, err)
 		return nil, err
 	}
-	zlog.Debugf("Secret %s created in namespace %s", secretName, secret.Namespace)
+	zlog.FromContext(ctx).Debugf("Secret %s created in namespace %s", secretName, secret.Namespace)
 	return secret, err
 }
 
 // UpdateSecret update secret
-func UpdateSecret(clientset kubernetes.Interface, secret *v1.Secret) (*v1.Secret, error) {
+func UpdateSecret(ctx context.Context, clientset kubernetes.Interface, secret *v1.Secret) (*v1.Secret, error) {
 	if secret == nil {
-		zlog.Errorf("Secret object is nil when updating")
+		zlog.FromContext(ctx).Errorf("Secret object is nil when updating")
 		return nil, fmt.Errorf("secret object cannot be nil")
 	}
 	if secret.Name == "" {
-		zlog.Errorf("Secret name is empty when updating")
+		zlog.FromContext(ctx).Errorf("Secret name is empty when updating")
 		return nil, fmt.Errorf("secret name cannot be empty")
 	}
 	if secret.Namespace == "" {
-		zlog.Errorf("Secret namespace is empty when updating")
+		zlog.FromContext(ctx).Errorf("Secret namespace is empty when updating")
 		return nil, fmt.Errorf("secret namespace cannot be empty")
 	}
 	secretName := secret.Name
 	secret, err := clientset.CoreV1().Secrets(secret.Namespace).
-		Update(context.Background(), secret, metav1.UpdateOptions{})
+		Update(ctx, secret, metav1.UpdateOptions{})
 	if err != nil {
-		zlog.Debugf("Failed to update secret %s, err: %v", secretName, err)
+		zlog.FromContext(ctx).Debugf("Failed to update secret %s, err: %v", secretName, err)
 		return nil, err
 	}
-	zlog.Debugf("Secret %s updated in namespace %s", secret.Name, secret.Namespace)
+	zlog.FromContext(ctx).Debugf("Secret %s updated in namespace %s", secret.Name, secret.Namespace)
 	return secret, err
 }
 
 // DeleteSecret delete secret
-func DeleteSecret(clientset kubernetes.Interface, secretName, namespace string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), requestLastTime*time.Second)
+func DeleteSecret(ctx context.Context, clientset kubernetes.Interface, secretName, namespace string) error {
+	ctx, cancel := context.WithTimeout(ctx, requestLastTime*time.Second)
 	defer cancel()
 
 	err := clientset.CoreV1().Secrets(namespace).Delete(ctx, secretName, metav1.DeleteOptions{})
 	if err != nil {
-		zlog.Debugf("Failed to delete secret %s, err: %v", secretName, err)
+		zlog.FromContext(ctx).Debugf("Failed to delete secret %s, err: %v", secretName, err)
 		return err
 	}
-	zlog.Debugf("Secret %s deleted in namespace %s\n", secretName, namespace)
+	zlog.FromContext(ctx).Debugf("Secret %s deleted in namespace %s\n", secretName, namespace)
 	return err
 }
 
 // GetConfigMap looks up configMap by its name and namespace
-func GetConfigMap(clientset kubernetes.Interface, configmapName, namespace string) (*v1.ConfigMap, error) {
+func GetConfigMap(ctx context.Context, clientset kubernetes.Interface,
+	configmapName, namespace string) (*v1.ConfigMap, error) {
 	configMap, err := clientset.CoreV1().ConfigMaps(namespace).
-		Get(context.Background(), configmapName, metav1.GetOptions{})
+		Get(ctx, configmapName, metav1.GetOptions{})
 	if err != nil {
-		zlog.Errorf("ConfigMap %s lookup failed, err: %v", configmapName, err)
+		zlog.FromContext(ctx).Errorf("ConfigMap %s lookup failed, err: %v", configmapName, err)
 		return nil, err
 	}
-	zlog.Debugf("ConfigMap %s found in namespace %s", configMap.Name, configMap.Namespace)
+	zlog.FromContext(ctx).Debugf("ConfigMap %s found in namespace %s", configMap.Name, configMap.Namespace)
 	return configMap, nil
 }
 
 // CreateConfigMap create secret
-func CreateConfigMap(clientset kubernetes.Interface, configMap *v1.ConfigMap) (*v1.ConfigMap, error) {
+func CreateConfigMap(ctx context.Context, clientset kubernetes.Interface,
+	configMap *v1.ConfigMap) (*v1.ConfigMap, error) {
 	configMapName := configMap.Name
 	configMap, err := clientset.CoreV1().ConfigMaps(configMap.Namespace).
-		Create(context.Background(), configMap, metav1.CreateOptions{})
+		Create(ctx, configMap, metav1.CreateOptions{})
 	if err != nil {
-		zlog.Debugf("Failed to create configMap %s, err: %v", configMapName, err)
+		zlog.FromContext(ctx).Debugf("Failed to create configMap %s, err: %v", configMapName, err)
 		return nil, err
 	}
-	zlog.Debugf("ConfigMap %s created in namespace %s", configMap.Name, configMap.Namespace)
+	zlog.FromContext(ctx).Debugf("ConfigMap %s created in namespace %s", configMap.Name, configMap.Namespace)
 	return configMap, nil
 }
 
 // UpdateConfigMap update config map
-func UpdateConfigMap(clientset kubernetes.Interface, configMap *v1.ConfigMap) (*v1.ConfigMap, error) {
+func UpdateConfigMap(ctx context.Context, clientset kubernetes.Interface,
+	configMap *v1.ConfigMap) (*v1.ConfigMap, error) {
 	configMapName := configMap.Name
 	configMap, err := clientset.CoreV1().ConfigMaps(configMap.Namespace).
-		Update(context.Background(), configMap, metav1.UpdateOptions{})
+		Update(ctx, configMap, metav1.UpdateOptions{})
 	if err != nil {
-		zlog.Debugf("Failed to update configMap %s, err: %v", configMapName, err)
+		zlog.FromContext(ctx).Debugf("Failed to update configMap %s, err: %v", configMapName, err)
 		return nil, err
 	}
 
-	zlog.Debugf("ConfigMap %s updated in namespace %s", configMap.Name, configMap.Namespace)
+	zlog.FromContext(ctx).Debugf("ConfigMap %s updated in namespace %s", configMap.Name, configMap.Namespace)
 	return configMap, nil
 }
 
 // DeleteConfigMap delete config map
-func DeleteConfigMap(clientset kubernetes.Interface, configmapName, namespace string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), requestLastTime*time.Second)
+func DeleteConfigMap(ctx context.Context, clientset kubernetes.Interface, configmapName, namespace string) error {
+	ctx, cancel := context.WithTimeout(ctx, requestLastTime*time.Second)
 	defer cancel()
 
 	err := clientset.CoreV1().ConfigMaps(namespace).Delete(ctx, configmapName, metav1.DeleteOptions{})
 	if err != nil {
-		zlog.Debugf("Failed to delete secret %s, err: %v", configmapName, err)
+		zlog.FromContext(ctx).Debugf("Failed to delete secret %s, err: %v", configmapName, err)
 		return err
 	}
-	zlog.Debugf("Configmap %s deleted in namespace %s\n", configmapName, namespace)
+	zlog.FromContext(ctx).Debugf("Configmap %s deleted in namespace %s\n", configmapName, namespace)
 	return err
 }
 
 // CrExists check cr existence by metadata.name
 // for cluster level resource, parameter namespace should be empty
-func CrExists(dynamicClient dynamic.Interface, resourceName, namespace string,
+func CrExists(ctx context.Context, dynamicClient dynamic.Interface, resourceName, namespace string,
 	gvr schema.GroupVersionResource) (bool, error) {
 	_, err := dynamicClient.Resource(gvr).Namespace(namespace).
-		Get(context.Background(), resourceName, metav1.GetOptions{})
+		Get(ctx, resourceName, metav1.GetOptions{})
 	if err != nil {
 		if errors.IsNotFound(err) {
 			return false, nil
 		}
-		zlog.Errorf("check CR existence failed: %v", err)
+		zlog.FromContext(ctx).Errorf("check CR existence failed: %v", err)
 		return false, err
 	}
 	return true, nil
@@ -32,7 +32,7 @@ func TestGetSecret(t *testing.T) {
 		},
 	})
 
-	secret, err := GetSecret(clientset, "test-secret", "default")
+	secret, err := GetSecret(context.Background(), clientset, "test-secret", "default")
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -51,7 +51,7 @@ func TestCreateSecret(t *testing.T) {
 	}
 
 	clientset := fake.NewSimpleClientset()
-	createdSecret, err := CreateSecret(clientset, newSecret)
+	createdSecret, err := CreateSecret(context.Background(), clientset, newSecret)
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -75,7 +75,7 @@ func TestUpdateSecret(t *testing.T) {
 
 	// Update the secret
 	initialSecret.Data["key"] = []byte("new-value")
-	updatedSecret, err := UpdateSecret(clientset, initialSecret)
+	updatedSecret, err := UpdateSecret(context.Background(), clientset, initialSecret)
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -95,7 +95,7 @@ func TestDeleteSecret(t *testing.T) {
 		},
 	})
 
-	err := DeleteSecret(clientset, secretName, namespace)
+	err := DeleteSecret(context.Background(), clientset, secretName, namespace)
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -116,7 +116,7 @@ func TestGetConfigMap(t *testing.T) {
 		},
 	})
 
-	configMap, err := GetConfigMap(clientset, "test-configmap", "default")
+	configMap, err := GetConfigMap(context.Background(), clientset, "test-configmap", "default")
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -135,7 +135,7 @@ func TestCreateConfigMap(t *testing.T) {
 		},
 	}
 
-	createdConfigMap, err := CreateConfigMap(clientset, configMap)
+	createdConfigMap, err := CreateConfigMap(context.Background(), clientset, configMap)
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -155,7 +155,7 @@ func TestUpdateConfigMap(t *testing.T) {
 	clientset := fake.NewSimpleClientset(configMap)
 
 	configMap.Data = map[string]string{"key": "new-value"}
-	updatedConfigMap, err := UpdateConfigMap(clientset, configMap)
+	updatedConfigMap, err := UpdateConfigMap(context.Background(), clientset, configMap)
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -173,7 +173,7 @@ func TestDeleteConfigMap(t *testing.T) {
 		},
 	})
 
-	err := DeleteConfigMap(clientset, "delete-configmap", "default")
+	err := DeleteConfigMap(context.Background(), clientset, "delete-configmap", "default")
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -21,6 +21,7 @@ import (
 	"archive/tar"
 	"bytes"
 	"compress/gzip"
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -117,7 +118,7 @@ const (
 
 // GetMarketplaceServiceConfig parse the configmap for marketplace-service configuration from the cluster
 func GetMarketplaceServiceConfig(c kubernetes.Interface) (*helm.MarketplaceServiceConfig, error) {
-	configMap, err := k8sutil.GetConfigMap(c, constant.MarketplaceServiceConfigmap,
+	configMap, err := k8sutil.GetConfigMap(context.Background(), c, constant.MarketplaceServiceConfigmap,
 		constant.MarketplaceServiceDefaultNamespace)
 	if err != nil {
 		zlog.Warnf("failed to read config map from k8s cluster  %v", err)
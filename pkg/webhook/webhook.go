@@ -0,0 +1,205 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+// Package webhook admission webhooks of the repository cr, so repositories written to the api server directly
+// follow the rules of the repository api
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"marketplace-service/pkg/helm"
+	helmModel "marketplace-service/pkg/models/helm"
+	"marketplace-service/pkg/zlog"
+)
+
+const (
+	// ValidatePath path of the validating webhook of repository crs
+	ValidatePath = "/validate-helmchartrepository"
+	// MutatePath path of the defaulting webhook of repository crs
+	MutatePath = "/mutate-helmchartrepository"
+
+	// reviewBodyLimit the api server rejects requests above 3MiB, reviews carry the object twice on update
+	reviewBodyLimit = 8 << 20
+)
+
+// Server admission webhooks of repository crs
+type Server struct {
+	operation helm.Operation
+	// exemptGroups users of these groups may modify the official and local repositories
+	exemptGroups map[string]bool
+}
+
+// NewServer webhooks validating repository crs with the rules of operation, users in exemptGroups bypass the
+// protection of the official and local repositories
+func NewServer(operation helm.Operation, exemptGroups []string) *Server {
+	server := &Server{operation: operation, exemptGroups: make(map[string]bool, len(exemptGroups))}
+	for _, group := range exemptGroups {
+		server.exemptGroups[group] = true
+	}
+	return server
+}
+
+// Handler http handler serving the validating and defaulting webhooks
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(ValidatePath, func(w http.ResponseWriter, r *http.Request) {
+		serve(w, r, s.validate)
+	})
+	mux.HandleFunc(MutatePath, func(w http.ResponseWriter, r *http.Request) {
+		serve(w, r, mutate)
+	})
+	return mux
+}
+
+type admitFunc func(ctx context.Context, request *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse
+
+// serve decode the admission review of the api server and answer it with the response of admit
+func serve(w http.ResponseWriter, r *http.Request, admit admitFunc) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, reviewBodyLimit+1))
+	if err != nil || len(body) > reviewBodyLimit {
+		http.Error(w, "admission review is too large or unreadable", http.StatusRequestEntityTooLarge)
+		return
+	}
+	review := &admissionv1.AdmissionReview{}
+	if err = json.Unmarshal(body, review); err != nil || review.Request == nil {
+		http.Error(w, "please provide an admission review", http.StatusBadRequest)
+		return
+	}
+	response := admit(r.Context(), review.Request)
+	response.UID = review.Request.UID
+	review.Request, review.Response = nil, response
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(review); err != nil {
+		zlog.FromContext(r.Context()).Errorf("write admission review failed, %v", err)
+	}
+}
+
+// validate reject repository crs CreateRepo, UpdateRepo or DeleteRepo would reject
+func (s *Server) validate(ctx context.Context, request *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	var repository, old *helmModel.HelmChartRepository
+	var err error
+	if request.Operation != admissionv1.Delete {
+		if repository, err = decodeRepository(request.Object.Raw); err != nil {
+			return denied(http.StatusBadRequest, metav1.StatusReasonBadRequest, err)
+		}
+	}
+	if request.Operation != admissionv1.Create && len(request.OldObject.Raw) != 0 {
+		if old, err = decodeRepository(request.OldObject.Raw); err != nil {
+			return denied(http.StatusBadRequest, metav1.StatusReasonBadRequest, err)
+		}
+	}
+
+	err = s.operation.WithContext(ctx).ValidateRepoCR(repository, old)
+	switch {
+	case err == nil:
+		return &admissionv1.AdmissionResponse{Allowed: true}
+	case errors.Is(err, helm.ErrRepoProtected) && s.exempt(request.UserInfo):
+		zlog.FromContext(ctx).Infof("%s of repository %s by exempt user %s", request.Operation, request.Name,
+			request.UserInfo.Username)
+		return &admissionv1.AdmissionResponse{Allowed: true}
+	case errors.Is(err, helm.ErrRepoProtected):
+		return denied(http.StatusForbidden, metav1.StatusReasonForbidden, err)
+	default:
+		zlog.FromContext(ctx).Warnf("%s of repository %s by %s rejected, %v", request.Operation, request.Name,
+			request.UserInfo.Username, err)
+		return denied(http.StatusUnprocessableEntity, metav1.StatusReasonInvalid, err)
+	}
+}
+
+func (s *Server) exempt(user authenticationv1.UserInfo) bool {
+	for _, group := range user.Groups {
+		if s.exemptGroups[group] {
+			return true
+		}
+	}
+	return false
+}
+
+// mutate default the display name of a repository cr to its name
+func mutate(_ context.Context, request *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	object := make(map[string]interface{})
+	if err := json.Unmarshal(request.Object.Raw, &object); err != nil {
+		return denied(http.StatusBadRequest, metav1.StatusReasonBadRequest,
+			fmt.Errorf("decode repository failed, %v", err))
+	}
+	response := &admissionv1.AdmissionResponse{Allowed: true}
+	patch := displayNamePatch(object)
+	if patch == nil {
+		return response
+	}
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return denied(http.StatusInternalServerError, metav1.StatusReasonInternalError, err)
+	}
+	patchType := admissionv1.PatchTypeJSONPatch
+	response.Patch, response.PatchType = data, &patchType
+	return response
+}
+
+// patchOperation json patch operation
+type patchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// displayNamePatch json patch setting a missing display name to the name of the object, nil if it has a display
+// name or no name yet
+func displayNamePatch(object map[string]interface{}) []patchOperation {
+	metadata, _ := object["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	if name == "" {
+		return nil
+	}
+	spec, exist := object["spec"].(map[string]interface{})
+	if !exist {
+		return []patchOperation{{Op: "add", Path: "/spec", Value: map[string]string{"displayName": name}}}
+	}
+	if displayName, _ := spec["displayName"].(string); displayName != "" {
+		return nil
+	}
+	return []patchOperation{{Op: "add", Path: "/spec/displayName", Value: name}}
+}
+
+func decodeRepository(raw []byte) (*helmModel.HelmChartRepository, error) {
+	repository := &helmModel.HelmChartRepository{}
+	if err := json.Unmarshal(raw, repository); err != nil {
+		return nil, fmt.Errorf("decode repository failed, %v", err)
+	}
+	return repository, nil
+}
+
+func denied(code int32, reason metav1.StatusReason, err error) *admissionv1.AdmissionResponse {
+	return &admissionv1.AdmissionResponse{
+		Allowed: false,
+		Result: &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Message: err.Error(),
+			Reason:  reason,
+			Code:    code,
+		},
+	}
+}
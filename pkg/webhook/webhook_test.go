@@ -0,0 +1,154 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"marketplace-service/pkg/helm"
+	helmModel "marketplace-service/pkg/models/helm"
+)
+
+// fakeOperation protects the repository named official and rejects repositories without url
+type fakeOperation struct {
+	helm.Operation
+}
+
+func (f *fakeOperation) WithContext(ctx context.Context) helm.Operation {
+	return f
+}
+
+func (f *fakeOperation) ValidateRepoCR(repository, old *helmModel.HelmChartRepository) error {
+	for _, checked := range []*helmModel.HelmChartRepository{repository, old} {
+		if checked != nil && checked.Name == "official" {
+			return fmt.Errorf("%w, official is managed by marketplace", helm.ErrRepoProtected)
+		}
+	}
+	if repository != nil && repository.Spec.URL == "" {
+		return errors.New("spec.url is required")
+	}
+	return nil
+}
+
+func review(t *testing.T, server http.Handler, path string,
+	request *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	body, err := json.Marshal(&admissionv1.AdmissionReview{Request: request})
+	assert.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body)))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	result := &admissionv1.AdmissionReview{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), result))
+	assert.Nil(t, result.Request)
+	assert.Equal(t, request.UID, result.Response.UID)
+	return result.Response
+}
+
+func repositoryObject(t *testing.T, name string, spec map[string]interface{}) runtime.RawExtension {
+	object := map[string]interface{}{"metadata": map[string]interface{}{"name": name}}
+	if spec != nil {
+		object["spec"] = spec
+	}
+	raw, err := json.Marshal(object)
+	assert.NoError(t, err)
+	return runtime.RawExtension{Raw: raw}
+}
+
+func TestServer_validate(t *testing.T) {
+	handler := NewServer(&fakeOperation{}, []string{"system:masters"}).Handler()
+	valid := repositoryObject(t, "team", map[string]interface{}{"displayName": "team", "url": "https://charts"})
+	official := repositoryObject(t, "official", map[string]interface{}{"displayName": "official", "url": "https://o"})
+	admin := authenticationv1.UserInfo{Username: "kubernetes-admin", Groups: []string{"system:masters"}}
+	user := authenticationv1.UserInfo{Username: "developer", Groups: []string{"system:authenticated"}}
+
+	tests := []struct {
+		name     string
+		request  *admissionv1.AdmissionRequest
+		wantCode int32
+	}{
+		{"valid create", &admissionv1.AdmissionRequest{Operation: admissionv1.Create, Object: valid}, 0},
+		{"invalid create", &admissionv1.AdmissionRequest{Operation: admissionv1.Create,
+			Object: repositoryObject(t, "team", map[string]interface{}{"displayName": "team"})},
+			http.StatusUnprocessableEntity},
+		{"protected update", &admissionv1.AdmissionRequest{Operation: admissionv1.Update, Object: official,
+			OldObject: official, UserInfo: user}, http.StatusForbidden},
+		{"protected delete", &admissionv1.AdmissionRequest{Operation: admissionv1.Delete, OldObject: official,
+			UserInfo: user}, http.StatusForbidden},
+		{"exempt delete", &admissionv1.AdmissionRequest{Operation: admissionv1.Delete, OldObject: official,
+			UserInfo: admin}, 0},
+		{"malformed object", &admissionv1.AdmissionRequest{Operation: admissionv1.Create,
+			Object: runtime.RawExtension{Raw: []byte(`{"spec":"team"}`)}}, http.StatusBadRequest},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.request.UID = types.UID(fmt.Sprintf("uid-%d", i))
+			response := review(t, handler, ValidatePath, tt.request)
+			assert.Equal(t, tt.wantCode == 0, response.Allowed)
+			if tt.wantCode != 0 {
+				assert.Equal(t, tt.wantCode, response.Result.Code)
+			}
+		})
+	}
+}
+
+func TestServer_mutate(t *testing.T) {
+	handler := NewServer(&fakeOperation{}, nil).Handler()
+	tests := []struct {
+		name      string
+		object    runtime.RawExtension
+		wantPatch string
+	}{
+		{"missing display name", repositoryObject(t, "team", map[string]interface{}{"url": "https://charts"}),
+			`[{"op":"add","path":"/spec/displayName","value":"team"}]`},
+		{"missing spec", repositoryObject(t, "team", nil),
+			`[{"op":"add","path":"/spec","value":{"displayName":"team"}}]`},
+		{"display name set", repositoryObject(t, "team", map[string]interface{}{"displayName": "Team"}), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := review(t, handler, MutatePath, &admissionv1.AdmissionRequest{UID: "uid",
+				Operation: admissionv1.Create, Object: tt.object})
+			assert.True(t, response.Allowed)
+			if tt.wantPatch == "" {
+				assert.Nil(t, response.Patch)
+				return
+			}
+			assert.JSONEq(t, tt.wantPatch, string(response.Patch))
+			assert.Equal(t, admissionv1.PatchTypeJSONPatch, *response.PatchType)
+		})
+	}
+}
+
+func TestServer_Handler_rejectsInvalidRequests(t *testing.T) {
+	handler := NewServer(&fakeOperation{}, nil).Handler()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, ValidatePath, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, MutatePath, bytes.NewReader([]byte("{}"))))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
@@ -0,0 +1,108 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package zlog
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// keys of request fields tagging log lines
+const (
+	RequestIDKey = "request_id"
+	RouteKey     = "route"
+	UserKey      = "user"
+
+	traceIDKey = "trace_id"
+	spanIDKey  = "span_id"
+)
+
+type fieldsContextKey struct{}
+
+// NewContext context whose loggers tag every line with the given key value pairs besides the fields
+// already in ctx
+func NewContext(ctx context.Context, keysAndValues ...interface{}) context.Context {
+	existing := fieldsFromContext(ctx)
+	fields := make([]interface{}, 0, len(existing)+len(keysAndValues))
+	fields = append(append(fields, existing...), keysAndValues...)
+	return context.WithValue(ctx, fieldsContextKey{}, fields)
+}
+
+func fieldsFromContext(ctx context.Context) []interface{} {
+	fields, _ := ctx.Value(fieldsContextKey{}).([]interface{})
+	return fields
+}
+
+// Logger logger bound to the fields of a request context, the underlying logger is resolved per call
+// so log config reloads are honored
+type Logger struct {
+	fields []interface{}
+}
+
+// FromContext logger tagging every line with the request fields and the trace and span id of the context
+func FromContext(ctx context.Context) *Logger {
+	fields := append(make([]interface{}, 0), fieldsFromContext(ctx)...)
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		fields = append(fields, traceIDKey, spanContext.TraceID().String(), spanIDKey, spanContext.SpanID().String())
+	}
+	return &Logger{fields: fields}
+}
+
+func (l *Logger) sugared() *zap.SugaredLogger {
+	if len(l.fields) == 0 {
+		return logger
+	}
+	return logger.With(l.fields...)
+}
+
+// Error 提供 Error级日志
+func (l *Logger) Error(args ...interface{}) {
+	l.sugared().Error(args...)
+}
+
+// Warn 提供 Warn级日志
+func (l *Logger) Warn(args ...interface{}) {
+	l.sugared().Warn(args...)
+}
+
+// Info 提供 Info级日志
+func (l *Logger) Info(args ...interface{}) {
+	l.sugared().Info(args...)
+}
+
+// Debug 提供 Debug级日志
+func (l *Logger) Debug(args ...interface{}) {
+	l.sugared().Debug(args...)
+}
+
+// Errorf 提供 Errorf级日志
+func (l *Logger) Errorf(template string, args ...interface{}) {
+	l.sugared().Errorf(template, args...)
+}
+
+// Warnf 提供Warnf级日志
+func (l *Logger) Warnf(template string, args ...interface{}) {
+	l.sugared().Warnf(template, cleanLogFields(args)...)
+}
+
+// Infof 提供Infof级日志
+func (l *Logger) Infof(template string, args ...interface{}) {
+	l.sugared().Infof(template, cleanLogFields(args)...)
+}
+
+// Debugf 提供Debugf级日志
+func (l *Logger) Debugf(template string, args ...interface{}) {
+	l.sugared().Debugf(template, cleanLogFields(args)...)
+}
@@ -0,0 +1,45 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package zlog
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TestFromContext 测试上下文日志携带trace id
+func TestFromContext(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+
+	assert.Equal(t, []interface{}{traceIDKey, traceID.String(), spanIDKey, spanID.String()},
+		FromContext(ctx).fields)
+	assert.Empty(t, FromContext(context.Background()).fields)
+	FromContext(ctx).Infof("traced %s", "line")
+}
+
+// TestNewContext 测试上下文字段累加
+func TestNewContext(t *testing.T) {
+	ctx := NewContext(context.Background(), RequestIDKey, "req-1", RouteKey, "/helm-repos")
+	ctx = NewContext(ctx, UserKey, "admin")
+
+	assert.Equal(t, []interface{}{RequestIDKey, "req-1", RouteKey, "/helm-repos", UserKey, "admin"},
+		FromContext(ctx).fields)
+}
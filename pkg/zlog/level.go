@@ -0,0 +1,161 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package zlog
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// DefaultPackage package name of the configured level, applying to packages without their own level
+const DefaultPackage = "default"
+
+// LevelSetting log level of a package
+type LevelSetting struct {
+	Package string `json:"package"`
+	Level   string `json:"level"`
+}
+
+// levels configured level and the package levels overriding it, package levels survive config reloads
+var levels = &levelRegistry{defaultLevel: zapcore.InfoLevel, packages: map[string]zapcore.Level{}}
+
+type levelRegistry struct {
+	sync.RWMutex
+	defaultLevel zapcore.Level
+	packages     map[string]zapcore.Level
+	// minimum lowest level of all, entries below are dropped before the caller is resolved
+	minimum zapcore.Level
+}
+
+func (r *levelRegistry) setDefault(level zapcore.Level) {
+	r.Lock()
+	defer r.Unlock()
+	r.defaultLevel = level
+	r.updateMinimum()
+}
+
+func (r *levelRegistry) set(pkg string, level zapcore.Level) {
+	r.Lock()
+	defer r.Unlock()
+	r.packages[pkg] = level
+	r.updateMinimum()
+}
+
+func (r *levelRegistry) reset(pkg string) {
+	r.Lock()
+	defer r.Unlock()
+	delete(r.packages, pkg)
+	r.updateMinimum()
+}
+
+func (r *levelRegistry) updateMinimum() {
+	r.minimum = r.defaultLevel
+	for _, level := range r.packages {
+		if level < r.minimum {
+			r.minimum = level
+		}
+	}
+}
+
+func (r *levelRegistry) enabled(level zapcore.Level) bool {
+	r.RLock()
+	defer r.RUnlock()
+	return level >= r.minimum
+}
+
+func (r *levelRegistry) enabledFor(pkg string, level zapcore.Level) bool {
+	r.RLock()
+	defer r.RUnlock()
+	if packageLevel, exist := r.packages[pkg]; exist {
+		return level >= packageLevel
+	}
+	return level >= r.defaultLevel
+}
+
+// SetLevel change the log level of a package at runtime, DefaultPackage changes the configured level
+// until the log config is reloaded
+func SetLevel(pkg, level string) error {
+	zapLevel, ok := logLevel[strings.ToLower(level)]
+	if !ok {
+		return fmt.Errorf("unsupported log level %s", level)
+	}
+	if pkg == "" {
+		return fmt.Errorf("package must not be empty")
+	}
+	if pkg == DefaultPackage {
+		levels.setDefault(zapLevel)
+		return nil
+	}
+	levels.set(pkg, zapLevel)
+	return nil
+}
+
+// ResetLevel drop the log level of a package, the configured level applies to it again
+func ResetLevel(pkg string) {
+	levels.reset(pkg)
+}
+
+// Levels configured level and package levels, sorted by package
+func Levels() []LevelSetting {
+	levels.RLock()
+	defer levels.RUnlock()
+	result := []LevelSetting{{Package: DefaultPackage, Level: levels.defaultLevel.String()}}
+	for pkg, level := range levels.packages {
+		result = append(result, LevelSetting{Package: pkg, Level: level.String()})
+	}
+	sort.Slice(result[1:], func(i, j int) bool {
+		return result[i+1].Package < result[j+1].Package
+	})
+	return result
+}
+
+// packageCore applies the level of the package an entry is logged from. The caller is only known when
+// the entry is written, so entries are checked against the lowest level and filtered on write
+type packageCore struct {
+	zapcore.Core
+}
+
+func (c *packageCore) Enabled(level zapcore.Level) bool {
+	return levels.enabled(level)
+}
+
+func (c *packageCore) With(fields []zapcore.Field) zapcore.Core {
+	return &packageCore{Core: c.Core.With(fields)}
+}
+
+func (c *packageCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *packageCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if !levels.enabledFor(packageOf(entry.Caller), entry.Level) {
+		return nil
+	}
+	return c.Core.Write(entry, fields)
+}
+
+// packageOf name of the package directory of the caller, e.g. helm for pkg/helm/repo.go
+func packageOf(caller zapcore.EntryCaller) string {
+	if !caller.Defined {
+		return DefaultPackage
+	}
+	return filepath.Base(filepath.Dir(caller.File))
+}
@@ -0,0 +1,68 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package zlog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestSetLevel 测试按包设置日志级别
+func TestSetLevel(t *testing.T) {
+	defer levels.setDefault(levels.defaultLevel)
+	defer ResetLevel("helm")
+
+	assert.Error(t, SetLevel("helm", "verbose"))
+	assert.Error(t, SetLevel("", "debug"))
+	assert.NoError(t, SetLevel("helm", "DEBUG"))
+	assert.NoError(t, SetLevel(DefaultPackage, "warn"))
+	assert.Equal(t, []LevelSetting{{Package: DefaultPackage, Level: "warn"}, {Package: "helm", Level: "debug"}},
+		Levels())
+
+	ResetLevel("helm")
+	assert.Equal(t, []LevelSetting{{Package: DefaultPackage, Level: "warn"}}, Levels())
+}
+
+// TestPackageCore 测试日志按调用方所在包过滤
+func TestPackageCore(t *testing.T) {
+	defer levels.setDefault(levels.defaultLevel)
+	defer ResetLevel("helm")
+	levels.setDefault(zapcore.InfoLevel)
+	observed, logs := observer.New(zapcore.DebugLevel)
+	core := &packageCore{Core: observed}
+
+	helmCaller := zapcore.NewEntryCaller(0, "/src/pkg/helm/repo.go", 1, true)
+	serverCaller := zapcore.NewEntryCaller(0, "/src/pkg/server/server.go", 1, true)
+	write := func(caller zapcore.EntryCaller, level zapcore.Level) {
+		entry := zapcore.Entry{Level: level, Caller: caller, Message: caller.File}
+		if checked := core.Check(entry, nil); checked != nil {
+			checked.Write()
+		}
+	}
+
+	assert.False(t, core.Enabled(zapcore.DebugLevel))
+	write(helmCaller, zapcore.DebugLevel)
+	assert.Equal(t, 0, logs.Len())
+
+	assert.NoError(t, SetLevel("helm", "debug"))
+	assert.True(t, core.Enabled(zapcore.DebugLevel))
+	write(helmCaller, zapcore.DebugLevel)
+	write(serverCaller, zapcore.DebugLevel)
+	write(serverCaller, zapcore.InfoLevel)
+	assert.Equal(t, 2, logs.Len())
+	assert.Equal(t, "/src/pkg/helm/repo.go", logs.All()[0].Message)
+	assert.Equal(t, zapcore.InfoLevel, logs.All()[1].Level)
+}
@@ -145,7 +145,9 @@ func getLogger(conf *logConfig) *zap.SugaredLogger {
 	if !ok {
 		level = logLevel["info"]
 	}
-	core := zapcore.NewCore(encoder, writeSyncer, level)
+	levels.setDefault(level)
+	// levels are applied per package by packageCore, credentials are masked by redactCore
+	core := &packageCore{Core: &redactCore{Core: zapcore.NewCore(encoder, writeSyncer, zapcore.DebugLevel)}}
 	logger := zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1))
 	return logger.Sugar()
 }
@@ -0,0 +1,62 @@
+/*
+ * Copyright (c) 2024 Huawei Technologies Co., Ltd.
+ * openFuyao is licensed under Mulan PSL v2.
+ * You can use this software according to the terms and conditions of the Mulan PSL v2.
+ * You may obtain a copy of Mulan PSL v2 at:
+ *          http://license.coscl.org.cn/MulanPSL2
+ * THIS SOFTWARE IS PROVIDED ON AN "AS IS" BASIS, WITHOUT WARRANTIES OF ANY KIND,
+ * EITHER EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO NON-INFRINGEMENT,
+ * MERCHANTABILITY OR FIT FOR A PARTICULAR PURPOSE.
+ * See the Mulan PSL v2 for more details.
+ */
+
+package zlog
+
+import (
+	"go.uber.org/zap/zapcore"
+
+	"marketplace-service/pkg/utils/redact"
+)
+
+// redactCore masks credentials in messages and fields before they are encoded, whatever logged them
+type redactCore struct {
+	zapcore.Core
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(redactFields(fields))}
+}
+
+func (c *redactCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *redactCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message = redact.String(entry.Message)
+	return c.Core.Write(entry, redactFields(fields))
+}
+
+func redactFields(fields []zapcore.Field) []zapcore.Field {
+	result := make([]zapcore.Field, len(fields))
+	for i, field := range fields {
+		switch {
+		case redact.IsSensitiveKey(field.Key):
+			result[i] = zapcore.Field{Key: field.Key, Type: zapcore.StringType, String: redact.Mask}
+		case field.Type == zapcore.StringType:
+			field.String = redact.String(field.String)
+			result[i] = field
+		case field.Type == zapcore.ErrorType:
+			if err, ok := field.Interface.(error); ok && err != nil {
+				result[i] = zapcore.Field{Key: field.Key, Type: zapcore.StringType, String: redact.String(err.Error())}
+				continue
+			}
+			result[i] = field
+		default:
+			result[i] = field
+		}
+	}
+	return result
+}